@@ -0,0 +1,161 @@
+// build-image-index builds the perceptual hash index of reference card images
+// that the server matches uploads against before calling the vision model.
+//
+// Usage: go run main.go [-out=<file>] [-game=all|pokemon|mtg] [-sets=<ids>] [-rebuild]
+//
+// Pokemon reference images come from the local pokemon-tcg-data (every card).
+// MTG has too many printings to index up front, so MTG images come from the
+// Scryfall cards cached in the database, i.e. cards the collection has seen.
+//
+// The build is incremental: cards already in the index are skipped, so it can
+// be re-run after new sets are released or more MTG cards are cached. Progress
+// is saved periodically, so an interrupted build resumes where it stopped.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+const saveInterval = 500 // Save progress every N indexed images
+
+func main() {
+	out := flag.String("out", "", "Index file (default: $IMAGE_INDEX_PATH or ./data/image_hash_index.json)")
+	game := flag.String("game", "all", "Which game to index: all, pokemon or mtg")
+	sets := flag.String("sets", "", "Comma-separated set codes to index (default: all sets)")
+	dbPath := flag.String("db", "", "SQLite database with cached MTG cards (default: $DB_PATH or ./tcg_tracker.db)")
+	dataDir := flag.String("pokemon-data", "", "Pokemon data directory (default: $POKEMON_DATA_DIR or ./data)")
+	concurrency := flag.Int("concurrency", 8, "Concurrent image downloads")
+	rebuild := flag.Bool("rebuild", false, "Re-hash cards that are already indexed")
+	flag.Parse()
+
+	if *game != "all" && *game != "pokemon" && *game != "mtg" {
+		fmt.Println("Usage: build-image-index [-out=<file>] [-game=all|pokemon|mtg] [-sets=<ids>] [-rebuild]")
+		fmt.Println("")
+		fmt.Println("Downloads reference card images and writes their perceptual hashes to the")
+		fmt.Println("index used to identify uploads before calling the vision model.")
+		fmt.Println("")
+		fmt.Println("Examples:")
+		fmt.Println("  build-image-index")
+		fmt.Println("  build-image-index -game=pokemon -sets=base1,base2,sv3pt5")
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		*out = services.ImageIndexPathFromEnv()
+	}
+	if *dbPath == "" {
+		*dbPath = envOr("DB_PATH", "./tcg_tracker.db")
+	}
+	if *dataDir == "" {
+		*dataDir = envOr("POKEMON_DATA_DIR", "./data")
+	}
+
+	index := services.NewImageHashIndex()
+	if existing, err := services.LoadImageHashIndex(*out); err == nil {
+		index = existing
+		log.Printf("Loaded %d existing entries from %s", index.Len(), *out)
+	} else if !os.IsNotExist(err) {
+		log.Fatalf("Failed to load %s: %v (delete it to start over)", *out, err)
+	}
+
+	var sources []services.ImageIndexSource
+	if *game == "all" || *game == "pokemon" {
+		pokemonService, err := services.NewPokemonHybridService(*dataDir)
+		if err != nil {
+			log.Fatalf("Failed to load Pokemon data: %v", err)
+		}
+		sources = append(sources, pokemonService.ImageIndexSources()...)
+	}
+	if *game == "all" || *game == "mtg" {
+		if err := database.Initialize(*dbPath); err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		mtgSources, err := services.CachedMTGImageIndexSources(database.GetDB())
+		if err != nil {
+			log.Fatalf("Failed to load cached MTG cards: %v", err)
+		}
+		sources = append(sources, mtgSources...)
+	}
+
+	sources = filterSources(sources, *sets, index, *rebuild)
+	log.Printf("Indexing %d reference images with %d workers", len(sources), *concurrency)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	work := make(chan services.ImageIndexSource)
+	var wg sync.WaitGroup
+	var indexed, failed atomic.Int64
+	var saveMu sync.Mutex
+
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range work {
+				if err := index.AddFromURL(context.Background(), client, src); err != nil {
+					failed.Add(1)
+					log.Printf("  %s: %v", src.CardID, err)
+					continue
+				}
+				if n := indexed.Add(1); n%saveInterval == 0 {
+					saveMu.Lock()
+					if err := index.Save(*out); err != nil {
+						log.Printf("Warning: failed to save progress: %v", err)
+					}
+					saveMu.Unlock()
+					log.Printf("  %d/%d indexed", n, len(sources))
+				}
+			}
+		}()
+	}
+	for _, src := range sources {
+		work <- src
+	}
+	close(work)
+	wg.Wait()
+
+	if err := index.Save(*out); err != nil {
+		log.Fatalf("Failed to save %s: %v", *out, err)
+	}
+	log.Printf("Done: %d indexed, %d failed, %d total entries in %s", indexed.Load(), failed.Load(), index.Len(), *out)
+}
+
+// filterSources keeps the requested sets and, unless rebuilding, the cards not yet indexed
+func filterSources(sources []services.ImageIndexSource, sets string, index *services.ImageHashIndex, rebuild bool) []services.ImageIndexSource {
+	wanted := make(map[string]bool)
+	for _, set := range strings.Split(sets, ",") {
+		if set = strings.TrimSpace(strings.ToLower(set)); set != "" {
+			wanted[set] = true
+		}
+	}
+
+	var filtered []services.ImageIndexSource
+	for _, src := range sources {
+		if len(wanted) > 0 && !wanted[strings.ToLower(src.SetCode)] {
+			continue
+		}
+		if !rebuild && index.Has(src.CardID) {
+			continue
+		}
+		filtered = append(filtered, src)
+	}
+	return filtered
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
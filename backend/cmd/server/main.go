@@ -42,8 +42,13 @@ func main() {
 	}
 	log.Printf("Loaded %d Pokemon cards from %d sets", pokemonService.GetCardCount(), pokemonService.GetSetCount())
 
-	// Initialize Gemini service for card identification
-	geminiService := services.NewGeminiService()
+	// Initialize the vision provider for card identification (Gemini unless VISION_PROVIDER says otherwise)
+	visionIdentifier := services.NewVisionIdentifierFromEnv()
+
+	// Match uploads against the reference image hash index first, when one has been built
+	if imageIndex := services.LoadImageHashIndexFromEnv(); imageIndex != nil {
+		visionIdentifier = services.NewImageIndexIdentifier(imageIndex, visionIdentifier)
+	}
 
 	// Initialize JustTCG service for condition-based pricing
 	justTCGAPIKey := os.Getenv("JUSTTCG_API_KEY")
@@ -55,23 +60,37 @@ func main() {
 	}
 	justTCGService := services.NewJustTCGService(justTCGAPIKey, justTCGDailyLimit)
 
-	// Initialize price service (JustTCG only, no fallbacks)
-	priceService := services.NewPriceService(justTCGService, database.GetDB())
+	// Initialize price service: JustTCG condition prices, Scryfall market prices as MTG fallback
+	// (provider order per game is configurable with PRICE_PROVIDERS_MTG / PRICE_PROVIDERS_POKEMON)
+	priceService := services.NewPriceService(justTCGService, database.GetDB(), justTCGService, scryfallService)
+
+	// Initialize snapshot service for daily value tracking
+	snapshotService := services.NewSnapshotService()
+	snapshotService.SetCardResolver(pokemonService.GetCard)
+
+	// Initialize price alerts (log, plus webhook/email when configured)
+	alertService := services.NewAlertService(database.GetDB(), snapshotService, services.NewNotifiersFromEnv())
+
+	// Initialize exchange rates for the display currency (Frankfurter API, or a static file offline)
+	exchangeRateService := services.NewExchangeRateService(database.GetDB(), services.NewRateSourceFromEnv(), services.ExchangeRateCurrenciesFromEnv())
 
 	// Initialize price worker with JustTCG batch support
-	priceWorker := services.NewPriceWorker(priceService, pokemonService, justTCGService)
+	priceWorker := services.NewPriceWorker(priceService, pokemonService, justTCGService, alertService)
 
 	// Initialize image storage service
 	imageStorageService := services.NewImageStorageService()
 
-	// Initialize snapshot service for daily value tracking
-	snapshotService := services.NewSnapshotService()
+	// Initialize backup service for archive export/restore
+	backupService := services.NewBackupService(database.GetDB(), imageStorageService.GetStorageDir(), snapshotService)
 
 	// Initialize TCGPlayer sync service for bulk prepopulating TCGPlayerIDs
 	tcgPlayerSync := services.NewTCGPlayerSyncService(justTCGService)
 
 	// Initialize bulk import worker
-	bulkImportWorker := services.NewBulkImportWorker(database.GetDB(), geminiService, pokemonService, scryfallService)
+	bulkImportWorker := services.NewBulkImportWorker(database.GetDB(), visionIdentifier, pokemonService, scryfallService)
+
+	// Initialize CSV/text import service (other apps' exports, staged for review)
+	csvImportService := services.NewCSVImportService(database.GetDB(), pokemonService, scryfallService)
 
 	// Create a cancellable context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -101,9 +120,15 @@ func main() {
 	// Start snapshot service in background
 	go snapshotService.Start(ctx)
 
+	// Start exchange rate refresh in background
+	go exchangeRateService.Start(ctx)
+
 	// Start bulk import worker in background
 	bulkImportWorker.Start()
 
+	// Finish any CSV imports interrupted by a restart
+	go csvImportService.ResumePendingJobs()
+
 	// Optionally sync missing TCGPlayerIDs on startup (if enabled)
 	if os.Getenv("SYNC_TCGPLAYER_IDS_ON_STARTUP") == "true" {
 		go func() {
@@ -120,7 +145,7 @@ func main() {
 	}
 
 	// Setup router
-	router := api.SetupRouter(scryfallService, pokemonService, geminiService, priceWorker, priceService, imageStorageService, snapshotService, tcgPlayerSync, justTCGService, bulkImportWorker)
+	router := api.SetupRouter(scryfallService, pokemonService, visionIdentifier, priceWorker, priceService, imageStorageService, snapshotService, tcgPlayerSync, justTCGService, bulkImportWorker, csvImportService, backupService, alertService, exchangeRateService)
 
 	// Get port from environment
 	port := os.Getenv("PORT")
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// maxAlertEvents caps the alert history returned in one request
+const maxAlertEvents = 500
+
+type AlertHandler struct {
+	alertService *services.AlertService
+}
+
+func NewAlertHandler(alertService *services.AlertService) *AlertHandler {
+	return &AlertHandler{alertService: alertService}
+}
+
+// GetAlertRules returns all price alert rules with their current state
+// GET /api/alerts
+func (h *AlertHandler) GetAlertRules(c *gin.Context) {
+	db := database.GetDB()
+
+	var rules []models.PriceAlertRule
+	if err := db.Order("created_at DESC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range rules {
+		if rules[i].CardID == "" {
+			continue
+		}
+		var card models.Card
+		if err := db.First(&card, "id = ?", rules[i].CardID).Error; err == nil {
+			rules[i].Card = &card
+		}
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetAlertEvents returns fired alerts, newest first
+// Query params: rule_id, limit (default 100)
+// GET /api/alerts/events
+func (h *AlertHandler) GetAlertEvents(c *gin.Context) {
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxAlertEvents)
+	}
+
+	query := database.GetDB().Order("fired_at DESC").Limit(limit)
+	if ruleID := c.Query("rule_id"); ruleID != "" {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+
+	var events []models.PriceAlertEvent
+	if err := query.Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// CreateAlertRule creates a price alert rule
+// POST /api/alerts
+func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
+	var req models.CreatePriceAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.PriceAlertRule{
+		Name:            strings.TrimSpace(req.Name),
+		Type:            req.Type,
+		Enabled:         true,
+		Direction:       req.Direction,
+		Threshold:       req.Threshold,
+		Days:            req.Days,
+		CooldownMinutes: models.DefaultAlertCooldownMinutes,
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.CooldownMinutes != nil {
+		rule.CooldownMinutes = *req.CooldownMinutes
+	}
+
+	db := database.GetDB()
+	if rule.Type.IsCardRule() {
+		var card models.Card
+		if err := db.First(&card, "id = ?", req.CardID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card not found, add it to the collection or want list first"})
+			return
+		}
+		rule.CardID = card.ID
+		rule.Card = &card
+
+		rule.Condition = req.Condition
+		if rule.Condition == "" {
+			rule.Condition = models.PriceConditionNM
+		}
+		rule.Printing = req.Printing
+		if rule.Printing == "" {
+			rule.Printing = models.PrintingNormal
+		}
+		rule.Language = models.NormalizeLanguage(string(req.Language))
+		if !isValidPriceCondition(rule.Condition) || !isValidPrinting(rule.Printing) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid condition or printing"})
+			return
+		}
+	}
+
+	if msg := validateAlertRule(&rule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := db.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAlertRule changes a rule's threshold, window, cooldown or enabled flag.
+// Changing the threshold, direction or window re-arms the rule.
+// PUT /api/alerts/:id
+func (h *AlertHandler) UpdateAlertRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdatePriceAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	var rule models.PriceAlertRule
+	if err := db.First(&rule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert rule not found"})
+		return
+	}
+
+	if req.Name != nil {
+		rule.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.CooldownMinutes != nil {
+		rule.CooldownMinutes = *req.CooldownMinutes
+	}
+	if req.Direction != nil || req.Threshold != nil || req.Days != nil {
+		if req.Direction != nil {
+			rule.Direction = *req.Direction
+		}
+		if req.Threshold != nil {
+			rule.Threshold = *req.Threshold
+		}
+		if req.Days != nil {
+			rule.Days = *req.Days
+		}
+		rule.Triggered = false
+	}
+
+	if msg := validateAlertRule(&rule); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if err := db.Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteAlertRule deletes a rule and its alert history
+// DELETE /api/alerts/:id
+func (h *AlertHandler) DeleteAlertRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	db := database.GetDB()
+	var rule models.PriceAlertRule
+	if err := db.First(&rule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert rule not found"})
+		return
+	}
+
+	if err := db.Where("rule_id = ?", rule.ID).Delete(&models.PriceAlertEvent{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// EvaluateAlerts checks every enabled rule against current prices immediately
+// POST /api/alerts/evaluate
+func (h *AlertHandler) EvaluateAlerts(c *gin.Context) {
+	if h.alertService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price alerts are not configured"})
+		return
+	}
+
+	fired, err := h.alertService.Evaluate(nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fired": fired})
+}
+
+// validateAlertRule checks the fields shared by create and update, returning an
+// error message or "" when the rule is valid
+func validateAlertRule(rule *models.PriceAlertRule) string {
+	if !rule.Type.IsValid() {
+		return "invalid alert type"
+	}
+	switch rule.Direction {
+	case models.AlertDirectionAbove, models.AlertDirectionBelow:
+	case models.AlertDirectionEither:
+		if rule.Type != models.AlertRulePercentChange {
+			return "direction 'either' is only valid for percent_change alerts"
+		}
+	default:
+		return "direction must be 'above' or 'below'"
+	}
+	if rule.Threshold < 0 {
+		return "threshold must not be negative"
+	}
+	if rule.Type == models.AlertRulePercentChange {
+		if rule.Days < 1 {
+			return "days must be at least 1 for percent_change alerts"
+		}
+		if rule.Threshold == 0 {
+			return "threshold must be greater than 0 for percent_change alerts"
+		}
+	}
+	if rule.CooldownMinutes < 0 {
+		return "cooldown_minutes must not be negative"
+	}
+	return ""
+}
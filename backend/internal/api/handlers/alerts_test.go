@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestAlertRuleEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 3})
+
+	h := NewAlertHandler(nil)
+	router := gin.New()
+	router.GET("/api/alerts", h.GetAlertRules)
+	router.POST("/api/alerts", h.CreateAlertRule)
+	router.PUT("/api/alerts/:id", h.UpdateAlertRule)
+	router.DELETE("/api/alerts/:id", h.DeleteAlertRule)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"type":"price_threshold","card_id":"mtg-1","direction":"above","threshold":5}`, http.StatusCreated},
+		{`{"type":"percent_change","card_id":"mtg-1","direction":"either","threshold":10,"days":7,"enabled":false}`, http.StatusCreated},
+		{`{"type":"collection_value","direction":"below","threshold":1000,"cooldown_minutes":0}`, http.StatusCreated},
+		{`{"type":"price_threshold","card_id":"missing","direction":"above","threshold":5}`, http.StatusBadRequest},
+		{`{"type":"price_threshold","card_id":"mtg-1","direction":"either","threshold":5}`, http.StatusBadRequest},
+		{`{"type":"percent_change","card_id":"mtg-1","direction":"above","threshold":10}`, http.StatusBadRequest},
+		{`{"type":"collection_value","direction":"sideways","threshold":1}`, http.StatusBadRequest},
+		{`{"type":"volume","direction":"above","threshold":1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			if w := do(http.MethodPost, "/api/alerts", tt.body); w.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var rules []models.PriceAlertRule
+	_ = json.Unmarshal(do(http.MethodGet, "/api/alerts", "").Body.Bytes(), &rules)
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+	var threshold, percent, value models.PriceAlertRule
+	for _, r := range rules {
+		switch r.Type {
+		case models.AlertRulePriceThreshold:
+			threshold = r
+		case models.AlertRulePercentChange:
+			percent = r
+		case models.AlertRuleCollectionValue:
+			value = r
+		}
+	}
+	if threshold.Card == nil || threshold.Condition != models.PriceConditionNM || threshold.CooldownMinutes != models.DefaultAlertCooldownMinutes || !threshold.Enabled {
+		t.Errorf("unexpected defaults: %+v", threshold)
+	}
+	if percent.Enabled || value.CooldownMinutes != 0 {
+		t.Errorf("explicit enabled/cooldown not kept: %+v %+v", percent, value)
+	}
+
+	// Changing the threshold re-arms a triggered rule
+	db.Model(&models.PriceAlertRule{}).Where("id = ?", threshold.ID).Update("triggered", true)
+	w := do(http.MethodPut, fmt.Sprintf("/api/alerts/%d", threshold.ID), `{"threshold":8}`)
+	var updated models.PriceAlertRule
+	_ = json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Triggered || updated.Threshold != 8 {
+		t.Errorf("expected re-armed rule with threshold 8, got %d %+v", w.Code, updated)
+	}
+
+	db.Create(&models.PriceAlertEvent{RuleID: threshold.ID, Message: "fired"})
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/alerts/%d", threshold.ID), ""); w.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d", w.Code)
+	}
+	var events int64
+	db.Model(&models.PriceAlertEvent{}).Count(&events)
+	if events != 0 {
+		t.Errorf("expected events removed with their rule, got %d", events)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// maxBackupUploadSize caps restore uploads; archives include scanned images
+const maxBackupUploadSize = 2 << 30 // 2GB
+
+type BackupHandler struct {
+	backupService *services.BackupService
+}
+
+func NewBackupHandler(backupService *services.BackupService) *BackupHandler {
+	return &BackupHandler{
+		backupService: backupService,
+	}
+}
+
+// DownloadBackup streams a backup archive of the collection, cached cards, prices,
+// value snapshots and scanned images
+// GET /api/admin/backup
+func (h *BackupHandler) DownloadBackup(c *gin.Context) {
+	// Write to a temp file first so a failure can still return a JSON error instead
+	// of a truncated download, without holding the scanned images in memory
+	tmp, err := os.CreateTemp("", "tcg-backup-*.zip")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create backup"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	manifest, err := h.backupService.WriteBackup(tmp)
+	if err != nil {
+		log.Printf("Backup: failed to write archive: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create backup"})
+		return
+	}
+
+	filename := fmt.Sprintf("tcg-tracker-backup-%s.zip", manifest.CreatedAt.Format("20060102-150405"))
+	c.FileAttachment(tmp.Name(), filename)
+}
+
+// RestoreBackup restores an uploaded backup archive into an empty database.
+// Returns 400 for an invalid archive, 409 if the database has rows, and 500 if the
+// restore failed or its stats did not match the backup (the tables are rolled back).
+// POST /api/admin/restore
+func (h *BackupHandler) RestoreBackup(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupUploadSize)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	start := time.Now()
+	result, err := h.backupService.Restore(file, header.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBackup):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRestoreNotEmpty):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case result != nil:
+			// Tables were restored but writing images failed
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "result": result})
+		default:
+			log.Printf("Backup: restore of %s failed: %v", header.Filename, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	log.Printf("Backup: restored %s (%d images) in %v, stats verified: %v",
+		header.Filename, result.Images, time.Since(start), result.StatsVerified)
+	c.JSON(http.StatusOK, result)
+}
@@ -41,6 +41,7 @@ func NewBulkImportHandler(worker *services.BulkImportWorker, pokemon *services.P
 }
 
 // CreateJob creates a new bulk import job and uploads images
+// Form fields: images (files), multi_card (optional, true to split each photo into its cards)
 // POST /api/bulk-import/jobs
 func (h *BulkImportHandler) CreateJob(c *gin.Context) {
 	// Check if there's already an active job
@@ -80,6 +81,8 @@ func (h *BulkImportHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
+	multiCard, _ := strconv.ParseBool(c.Request.FormValue("multi_card"))
+
 	// Create the job first
 	job, err := h.worker.CreateJob(len(files))
 	if err != nil {
@@ -120,6 +123,16 @@ func (h *BulkImportHandler) CreateJob(c *gin.Context) {
 			continue
 		}
 
+		// Multi-card photos become one item per detected card
+		if multiCard {
+			items, err := h.worker.AddPhotoToJob(c.Request.Context(), job.ID, imageData, fileHeader.Filename, maxBulkImportFiles-successCount)
+			successCount += len(items)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", fileHeader.Filename, err))
+			}
+			continue
+		}
+
 		// Save the image
 		imagePath, err := h.worker.SaveImage(imageData, fileHeader.Filename)
 		if err != nil {
@@ -334,7 +347,10 @@ func (h *BulkImportHandler) UpdateItem(c *gin.Context) {
 	c.JSON(http.StatusOK, item)
 }
 
-// ConfirmJob adds confirmed items to the collection
+// ConfirmJob adds confirmed items to the collection. Items whose photo looks like
+// one already uploaded for the same card are added with a warning, or skipped when
+// reject_duplicates is set.
+// Body (optional): {"item_ids": [1, 2], "reject_duplicates": true}
 // POST /api/bulk-import/jobs/:id/confirm
 func (h *BulkImportHandler) ConfirmJob(c *gin.Context) {
 	jobID := c.Param("id")
@@ -381,7 +397,7 @@ func (h *BulkImportHandler) ConfirmJob(c *gin.Context) {
 	db := database.GetDB()
 	added := 0
 	skipped := 0
-	var errors []string
+	var errors, warnings []string
 
 	for _, item := range itemsToConfirm {
 		// Ensure the card exists in the database
@@ -400,11 +416,23 @@ func (h *BulkImportHandler) ConfirmJob(c *gin.Context) {
 			}
 		}
 
-		// Copy the scanned image to the permanent scanned images directory
-		var scannedImagePath string
+		// Copy the scanned image to the permanent scanned images directory,
+		// checking first that the card wasn't already scanned into the collection
+		var scannedImagePath, fingerprint string
 		if item.ImagePath != "" && h.imageStorageService != nil {
 			srcPath := h.worker.GetImageStorageDir() + "/" + item.ImagePath
 			if imageBytes, err := os.ReadFile(srcPath); err == nil {
+				var duplicates []models.DuplicateUpload
+				fingerprint, duplicates = scanFingerprint(db, item.CardID, imageBytes)
+				if len(duplicates) > 0 {
+					msg := fmt.Sprintf("%s (%s) looks like a photo already uploaded (collection item %d)", card.Name, item.OriginalFilename, duplicates[0].ItemID)
+					if req.RejectDuplicates {
+						errors = append(errors, "Skipped "+msg)
+						skipped++
+						continue
+					}
+					warnings = append(warnings, msg)
+				}
 				scannedImagePath, _ = h.imageStorageService.SaveImage(imageBytes)
 			}
 		}
@@ -424,6 +452,7 @@ func (h *BulkImportHandler) ConfirmJob(c *gin.Context) {
 			Language:         language,
 			AddedAt:          time.Now(),
 			ScannedImagePath: scannedImagePath,
+			ScanFingerprint:  fingerprint,
 		}
 
 		if err := db.Create(&collectionItem).Error; err != nil {
@@ -441,13 +470,15 @@ func (h *BulkImportHandler) ConfirmJob(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.ConfirmBulkImportResponse{
-		Added:   added,
-		Skipped: skipped,
-		Errors:  errors,
+		Added:    added,
+		Skipped:  skipped,
+		Errors:   errors,
+		Warnings: warnings,
 	})
 }
 
 // AddImages adds more images to an existing job (for chunked uploads)
+// Form fields: images (files), multi_card (optional, true to split each photo into its cards)
 // POST /api/bulk-import/jobs/:id/images
 func (h *BulkImportHandler) AddImages(c *gin.Context) {
 	jobID := c.Param("id")
@@ -497,6 +528,8 @@ func (h *BulkImportHandler) AddImages(c *gin.Context) {
 		return
 	}
 
+	multiCard, _ := strconv.ParseBool(c.Request.FormValue("multi_card"))
+
 	// Process each file
 	successCount := 0
 	var errors []string
@@ -526,6 +559,15 @@ func (h *BulkImportHandler) AddImages(c *gin.Context) {
 			continue
 		}
 
+		if multiCard {
+			items, err := h.worker.AddPhotoToJob(c.Request.Context(), job.ID, imageData, fileHeader.Filename, maxBulkImportFiles-currentItems-successCount)
+			successCount += len(items)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", fileHeader.Filename, err))
+			}
+			continue
+		}
+
 		imagePath, err := h.worker.SaveImage(imageData, fileHeader.Filename)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("%s: failed to save image", fileHeader.Filename))
@@ -604,6 +646,12 @@ func (h *BulkImportHandler) SearchCards(c *gin.Context) {
 // Helper functions
 
 func (h *BulkImportHandler) loadCard(cardID string, game string) *models.Card {
+	return loadCardFromServices(h.pokemonService, h.scryfallService, cardID, game)
+}
+
+// loadCardFromServices loads a card from the database, falling back to the
+// pokemon and scryfall services for cards that have not been cached yet
+func loadCardFromServices(pokemon *services.PokemonHybridService, scryfall *services.ScryfallService, cardID string, game string) *models.Card {
 	// Try database first
 	db := database.GetDB()
 	var card models.Card
@@ -612,15 +660,15 @@ func (h *BulkImportHandler) loadCard(cardID string, game string) *models.Card {
 	}
 
 	// Try pokemon service
-	if game == "" || game == "pokemon" {
-		if card, err := h.pokemonService.GetCard(cardID); err == nil && card != nil {
+	if pokemon != nil && (game == "" || game == "pokemon") {
+		if card, err := pokemon.GetCard(cardID); err == nil && card != nil {
 			return card
 		}
 	}
 
 	// Try scryfall service
-	if game == "" || game == "mtg" {
-		if card, err := h.scryfallService.GetCard(cardID); err == nil && card != nil {
+	if scryfall != nil && (game == "" || game == "mtg") {
+		if card, err := scryfall.GetCard(cardID); err == nil && card != nil {
 			return card
 		}
 	}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/base64"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -62,7 +63,7 @@ func cardNameMatches(resolvedName, expectedName string) bool {
 type CardHandler struct {
 	scryfallService *services.ScryfallService
 	pokemonService  *services.PokemonHybridService
-	geminiService   *services.GeminiService
+	vision          services.VisionIdentifier
 }
 
 // cacheCardsAsync saves cards to the database asynchronously so they can be
@@ -83,11 +84,11 @@ func cacheCardsAsync(cards []models.Card) {
 	}(cardsToCache)
 }
 
-func NewCardHandler(scryfall *services.ScryfallService, pokemon *services.PokemonHybridService, gemini *services.GeminiService) *CardHandler {
+func NewCardHandler(scryfall *services.ScryfallService, pokemon *services.PokemonHybridService, vision services.VisionIdentifier) *CardHandler {
 	return &CardHandler{
 		scryfallService: scryfall,
 		pokemonService:  pokemon,
-		geminiService:   gemini,
+		vision:          vision,
 	}
 }
 
@@ -310,15 +311,19 @@ func (h *CardHandler) SearchCardsGrouped(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// IdentifyCardFromImage uses Gemini Vision with function calling to identify
-// a trading card from an uploaded image. Gemini can search for cards and
-// compare images to find the exact match.
+// IdentifyCardFromImage uses the configured vision provider (Gemini by default)
+// with function calling to identify a trading card from an uploaded image. The
+// model can search for cards and compare images to find the exact match.
 func (h *CardHandler) IdentifyCardFromImage(c *gin.Context) {
-	// Check if Gemini is available
-	if h.geminiService == nil || !h.geminiService.IsEnabled() {
+	// Check if the vision provider is available
+	if h.vision == nil || !h.vision.IsEnabled() {
+		message := "Vision provider not configured"
+		if h.vision != nil {
+			message = fmt.Sprintf("Vision provider %q not configured", h.vision.Name())
+		}
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error":   "Card identification is not available",
-			"message": "Gemini API key not configured",
+			"message": message,
 		})
 		return
 	}
@@ -367,15 +372,15 @@ func (h *CardHandler) IdentifyCardFromImage(c *gin.Context) {
 		}
 	}
 
-	// Use Gemini to identify the card
-	result, err := h.geminiService.IdentifyCard(
+	// Use the vision provider to identify the card
+	result, err := h.vision.IdentifyCard(
 		c.Request.Context(),
 		imageBytes,
 		h.pokemonService,  // implements CardSearcher
 		h.scryfallService, // implements CardSearcher
 	)
 	if err != nil {
-		log.Printf("Gemini identification failed: %v", err)
+		log.Printf("%s identification failed: %v", h.vision.Name(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Card identification failed",
 			"details": err.Error(),
@@ -2,17 +2,23 @@ package handlers
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/codyseavey/tcg-tracker/backend/internal/database"
 	"github.com/codyseavey/tcg-tracker/backend/internal/models"
 	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
 )
 
 type CollectionHandler struct {
@@ -36,40 +42,50 @@ func NewCollectionHandler(scryfall *services.ScryfallService, pokemon *services.
 func (h *CollectionHandler) GetCollection(c *gin.Context) {
 	db := database.GetDB()
 
+	conv, ok := loadDisplayConverter(c, db)
+	if !ok {
+		return
+	}
+
 	var items []models.CollectionItem
-	query := db.Preload("Card").Preload("Card.Prices").Order("added_at DESC")
+	query := db.Preload("Card").Preload("Card.Prices").Preload("Card.GradedPrices").Order("added_at DESC")
 
 	// Optional filters
 	if game := c.Query("game"); game != "" {
 		query = query.Joins("JOIN cards ON cards.id = collection_items.card_id").
 			Where("cards.game = ?", game)
 	}
+	query = applyTagFilters(query, c)
 
 	if err := query.Find(&items).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if locations, err := loadStorageLocationTree(db); err == nil {
+		annotateStorageLocations(locations, items)
+	}
+	annotateTags(db, items)
 
 	// For cards not in database (Japanese cards loaded from JSON), fetch from pokemon service
 	// Also calculate item values using condition-specific pricing
 	for i := range items {
-		if items[i].Card.Name == "" && items[i].Card.ImageURL == "" {
-			if card, err := h.pokemonService.GetCard(items[i].CardID); err == nil && card != nil {
-				items[i].Card = *card
-			}
-		}
-
-		// Calculate condition-specific item value
-		priceCondition := models.MapCollectionConditionToPriceCondition(items[i].Condition)
-		priceResult := items[i].Card.GetPriceWithSource(priceCondition, items[i].Printing, items[i].Language)
-		items[i].ItemValue = priceResult.Price * float64(items[i].Quantity)
-		items[i].PriceLanguage = priceResult.PriceLanguage
-		items[i].PriceFallback = priceResult.IsFallback
+		valuation.ResolveCard(&items[i], h.cardResolver())
+		valuation.Item(&items[i], &items[i].Card)
 	}
+	applyDisplayCurrency(items, conv, time.Now())
 
 	c.JSON(http.StatusOK, items)
 }
 
+// cardResolver loads collection cards that are missing from the cards table
+// (Japanese cards loaded from JSON) from the pokemon service
+func (h *CollectionHandler) cardResolver() valuation.CardResolver {
+	if h.pokemonService == nil {
+		return nil
+	}
+	return h.pokemonService.GetCard
+}
+
 // Maximum quantity allowed per collection item
 const maxQuantity = 9999
 
@@ -127,10 +143,41 @@ func (h *CollectionHandler) AddToCollection(c *gin.Context) {
 	if printing == "" {
 		printing = models.PrintingNormal
 	}
+	if msg := validateConditionAndPrinting(condition, printing); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 	language := models.NormalizeLanguage(string(req.Language))
+	if req.PurchasePrice != nil && *req.PurchasePrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "purchase price must not be negative"})
+		return
+	}
+	locationID := req.StorageLocationID
+	if locationID != nil && *locationID == 0 {
+		locationID = nil
+	}
+	if locationID != nil && !storageLocationExists(db, *locationID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "storage location not found"})
+		return
+	}
+	graded := req.GradingCompany != ""
+	if graded {
+		if msg := normalizeGrading(&req.GradingCompany, &req.Grade); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		if msg := duplicateCertNumber(db, req.GradingCompany, req.CertNumber, 0); msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return
+		}
+	} else if req.Grade != "" || req.CertNumber != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grade and cert_number require a grading_company"})
+		return
+	}
 
 	// Handle scanned image FIRST - if provided, we NEVER merge (each scan is a unique physical card)
-	var scannedImagePath string
+	var scannedImagePath, fingerprint string
+	var duplicateUploads []models.DuplicateUpload
 	hasScannedImage := false
 	if req.ScannedImageData != "" && h.imageStorageService != nil {
 		imageData, err := base64.StdEncoding.DecodeString(req.ScannedImageData)
@@ -138,6 +185,16 @@ func (h *CollectionHandler) AddToCollection(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image data"})
 			return
 		}
+
+		// Warn (or reject) when this photo looks like one already added for the card
+		if fingerprint, duplicateUploads = scanFingerprint(db, req.CardID, imageData); len(duplicateUploads) > 0 && req.RejectDuplicate {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":             "this photo looks like one already uploaded for this card",
+				"duplicate_uploads": duplicateUploads,
+			})
+			return
+		}
+
 		filename, err := h.imageStorageService.SaveImage(imageData)
 		if err != nil {
 			// Log but don't fail - image is optional
@@ -148,18 +205,27 @@ func (h *CollectionHandler) AddToCollection(c *gin.Context) {
 		}
 	}
 
-	// If we have a scanned image, ALWAYS create a new item (qty=1) - never merge
-	// Each scanned card represents a specific physical card that needs individual tracking
-	if hasScannedImage {
+	// If we have a scanned image or a graded slab, ALWAYS create a new item (qty=1) - never merge
+	// Each scanned card or slab represents a specific physical card that needs individual tracking
+	if hasScannedImage || graded {
 		item := models.CollectionItem{
-			CardID:           req.CardID,
-			Quantity:         1, // Always 1 for scanned cards
-			Condition:        condition,
-			Printing:         printing,
-			Language:         language,
-			Notes:            req.Notes,
-			AddedAt:          time.Now(),
-			ScannedImagePath: scannedImagePath,
+			CardID:            req.CardID,
+			Quantity:          1, // Always 1 for scanned cards and slabs
+			Condition:         condition,
+			Printing:          printing,
+			Language:          language,
+			Notes:             req.Notes,
+			AddedAt:           time.Now(),
+			ScannedImagePath:  scannedImagePath,
+			ScanFingerprint:   fingerprint,
+			PurchasePrice:     req.PurchasePrice,
+			PurchaseDate:      req.PurchaseDate,
+			AcquisitionSource: req.AcquisitionSource,
+			StorageLocationID: locationID,
+			GradingCompany:    req.GradingCompany,
+			Grade:             req.Grade,
+			CertNumber:        req.CertNumber,
+			SlabNotes:         req.SlabNotes,
 		}
 
 		if err := db.Create(&item).Error; err != nil {
@@ -168,18 +234,25 @@ func (h *CollectionHandler) AddToCollection(c *gin.Context) {
 		}
 
 		db.Preload("Card").First(&item, item.ID)
+		item.DuplicateUploads = duplicateUploads
 		c.JSON(http.StatusCreated, item)
 		return
 	}
 
-	// No scanned image - try to merge into existing NON-SCANNED stack with same language
+	// No scanned image - try to merge into existing NON-SCANNED, ungraded stack with same language and location
 	var existingItem models.CollectionItem
-	err := db.Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND (scanned_image_path IS NULL OR scanned_image_path = '')",
-		req.CardID, condition, printing, language).
+	err := whereSameCostBasis(whereSameStorageLocation(db, locationID), req.PurchasePrice).
+		Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND "+mergeableStackSQL,
+			req.CardID, condition, printing, language).
 		First(&existingItem).Error
 
 	if err == nil {
-		// Merge into existing non-scanned stack
+		// Merge into existing non-scanned stack, carrying the weighted cost basis
+		mergeCostBasisInto(&existingItem, &models.CollectionItem{
+			PurchasePrice:     req.PurchasePrice,
+			PurchaseDate:      req.PurchaseDate,
+			AcquisitionSource: req.AcquisitionSource,
+		}, quantity)
 		existingItem.Quantity += quantity
 		if err := db.Save(&existingItem).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -192,14 +265,18 @@ func (h *CollectionHandler) AddToCollection(c *gin.Context) {
 
 	// No existing stack to merge into - create new item
 	item := models.CollectionItem{
-		CardID:           req.CardID,
-		Quantity:         quantity,
-		Condition:        condition,
-		Printing:         printing,
-		Language:         language,
-		Notes:            req.Notes,
-		AddedAt:          time.Now(),
-		ScannedImagePath: "", // No scan
+		CardID:            req.CardID,
+		Quantity:          quantity,
+		Condition:         condition,
+		Printing:          printing,
+		Language:          language,
+		Notes:             req.Notes,
+		AddedAt:           time.Now(),
+		ScannedImagePath:  "", // No scan
+		PurchasePrice:     req.PurchasePrice,
+		PurchaseDate:      req.PurchaseDate,
+		AcquisitionSource: req.AcquisitionSource,
+		StorageLocationID: locationID,
 	}
 
 	if err := db.Create(&item).Error; err != nil {
@@ -244,6 +321,44 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 			return
 		}
 	}
+	if req.Condition != nil || req.Printing != nil {
+		condition, printing := item.Condition, item.Printing
+		if req.Condition != nil {
+			condition = *req.Condition
+		}
+		if req.Printing != nil {
+			printing = *req.Printing
+		}
+		if msg := validateConditionAndPrinting(condition, printing); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	// Resolve the target storage location (0 clears it)
+	newLocation := item.StorageLocationID
+	if req.StorageLocationID != nil {
+		if *req.StorageLocationID == 0 {
+			newLocation = nil
+		} else {
+			if !storageLocationExists(db, *req.StorageLocationID) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "storage location not found"})
+				return
+			}
+			locationID := *req.StorageLocationID
+			newLocation = &locationID
+		}
+	}
+
+	// Number of copies split off a stack when attributes change
+	splitQty := 1
+	if req.SplitQuantity != nil {
+		if *req.SplitQuantity < 1 || *req.SplitQuantity > item.Quantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("split quantity must be between 1 and %d", item.Quantity)})
+			return
+		}
+		splitQty = *req.SplitQuantity
+	}
 
 	// Handle card reassignment if CardID is provided and different
 	if req.CardID != nil && *req.CardID != item.CardID {
@@ -296,24 +411,31 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 			finalLanguage = models.NormalizeLanguage(string(*req.Language))
 		}
 
-		// Non-scanned items can merge into existing stacks with the new card_id
-		if item.ScannedImagePath == "" {
+		// Non-scanned, ungraded items can merge into existing stacks with the new card_id
+		if !item.IsIndividual() {
 			// Look for existing stack with new card_id + same attributes
 			var target models.CollectionItem
-			err := db.Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND (scanned_image_path IS NULL OR scanned_image_path = '') AND id != ?",
-				newCardID, finalCondition, finalPrinting, finalLanguage, item.ID).
+			err := whereSameCostBasis(whereSameStorageLocation(db, newLocation), item.PurchasePrice).
+				Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND "+mergeableStackSQL+" AND id != ?",
+					newCardID, finalCondition, finalPrinting, finalLanguage, item.ID).
 				First(&target).Error
 
 			if err == nil {
 				// Merge into existing stack using a transaction
 				tx := db.Begin()
+				mergeCostBasisInto(&target, &item, item.Quantity)
 				target.Quantity += item.Quantity
 				if err := tx.Save(&target).Error; err != nil {
 					tx.Rollback()
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
-				// Delete the source item
+				// Delete the source item, carrying its tags over to the target
+				if err := moveItemTags(tx, item.ID, target.ID); err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
 				if err := tx.Delete(&item).Error; err != nil {
 					tx.Rollback()
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -344,12 +466,14 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 		item.Condition = finalCondition
 		item.Printing = finalPrinting
 		item.Language = finalLanguage
+		item.StorageLocationID = newLocation
 		if req.Notes != nil {
 			item.Notes = *req.Notes
 		}
-		if req.Quantity != nil && item.ScannedImagePath == "" {
+		if req.Quantity != nil && !item.IsIndividual() {
 			item.Quantity = *req.Quantity
 		}
+		applyCostBasisUpdate(&item, &req)
 
 		if err := db.Save(&item).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -375,7 +499,8 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 	conditionChanging := req.Condition != nil && *req.Condition != item.Condition
 	printingChanging := req.Printing != nil && *req.Printing != item.Printing
 	languageChanging := req.Language != nil && models.NormalizeLanguage(string(*req.Language)) != item.Language
-	attributeChanging := conditionChanging || printingChanging || languageChanging
+	locationChanging := !sameStorageLocation(newLocation, item.StorageLocationID)
+	attributeChanging := conditionChanging || printingChanging || languageChanging || locationChanging
 
 	// Determine the new values
 	newCondition := item.Condition
@@ -391,10 +516,65 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 		newLanguage = models.NormalizeLanguage(string(*req.Language))
 	}
 
-	// Scanned items always stay individual - just update in place
+	// Grading fields: setting a company grades the card, an empty company cracks a slab
+	gradingRequested := req.GradingCompany != nil && *req.GradingCompany != ""
+	gradingChanging := req.GradingCompany != nil || req.Grade != nil || req.CertNumber != nil || req.SlabNotes != nil
+	if gradingChanging && !item.IsGraded() && !gradingRequested {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grade and cert_number require a grading_company"})
+		return
+	}
+
+	// Grading one copy of a stack splits it off as a slab
+	if gradingRequested && !item.IsIndividual() && item.Quantity > 1 {
+		slab := models.CollectionItem{
+			CardID:            item.CardID,
+			Quantity:          1,
+			Condition:         newCondition,
+			Printing:          newPrinting,
+			Language:          newLanguage,
+			AddedAt:           time.Now(),
+			PurchasePrice:     item.PurchasePrice,
+			PurchaseDate:      item.PurchaseDate,
+			AcquisitionSource: item.AcquisitionSource,
+			StorageLocationID: newLocation,
+		}
+		if status, msg := applyGradingUpdate(db, &slab, &req); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		if err := db.Create(&slab).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := copyItemTags(db, item.ID, slab.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		originalQty := item.Quantity
+		item.Quantity--
+		if err := db.Save(&item).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		db.Preload("Card").First(&slab, slab.ID)
+		c.JSON(http.StatusOK, models.CollectionUpdateResponse{
+			Item:      slab,
+			Operation: "split",
+			Message:   fmt.Sprintf("Split 1 graded copy from stack of %d", originalQty),
+		})
+		return
+	}
+
+	// Scanned and graded items always stay individual - just update in place
 	// They represent specific physical cards that have been visually assessed
-	// Quantity is always 1 for scanned items (one scan = one physical card)
-	if item.ScannedImagePath != "" {
+	// Quantity is always 1 for them (one scan or slab = one physical card)
+	if item.IsIndividual() || gradingRequested {
+		if gradingChanging {
+			if status, msg := applyGradingUpdate(db, &item, &req); msg != "" {
+				c.JSON(status, gin.H{"error": msg})
+				return
+			}
+		}
 		if req.Condition != nil {
 			item.Condition = *req.Condition
 		}
@@ -404,69 +584,89 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 		if req.Language != nil {
 			item.Language = models.NormalizeLanguage(string(*req.Language))
 		}
+		item.StorageLocationID = newLocation
 		// Quantity is intentionally not updated for scanned items
 		// Each scan represents exactly one physical card
 		if req.Notes != nil {
 			item.Notes = *req.Notes
 		}
+		applyCostBasisUpdate(&item, &req)
 
 		if err := db.Save(&item).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		message := "Updated scanned card"
+		if item.IsGraded() {
+			message = "Updated graded card"
+		}
 		db.Preload("Card").First(&item, item.ID)
 		c.JSON(http.StatusOK, models.CollectionUpdateResponse{
 			Item:      item,
 			Operation: "updated",
-			Message:   "Updated scanned card",
+			Message:   message,
 		})
 		return
 	}
 
 	// Non-scanned item below this point
 
-	// If condition, printing, or language is changing, we need smart split/merge logic
+	// If condition, printing, language or location is changing, we need smart split/merge logic
 	if attributeChanging {
-		if item.Quantity > 1 {
-			// Stack with qty > 1: split off 1 copy with new attributes
+		if item.Quantity > splitQty {
+			// Stack with more copies than are moving: split off splitQty copies with new attributes
 			originalQty := item.Quantity
 
-			// Look for existing non-scanned stack to merge the split copy into
+			// Look for existing non-scanned stack to merge the split copies into
 			var target models.CollectionItem
-			err := db.Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND (scanned_image_path IS NULL OR scanned_image_path = '') AND id != ?",
-				item.CardID, newCondition, newPrinting, newLanguage, item.ID).
+			err := whereSameCostBasis(whereSameStorageLocation(db, newLocation), item.PurchasePrice).
+				Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND "+mergeableStackSQL+" AND id != ?",
+					item.CardID, newCondition, newPrinting, newLanguage, item.ID).
 				First(&target).Error
 
 			var resultItem models.CollectionItem
 			if err == nil {
-				// Merge into existing stack
-				target.Quantity += 1
+				// Merge into existing stack (the split copies carry the stack's per-card cost)
+				mergeCostBasisInto(&target, &item, splitQty)
+				target.Quantity += splitQty
+				// The source's tags are not copied: they would apply to every copy
+				// already in the target stack
 				if err := db.Save(&target).Error; err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
 				resultItem = target
 			} else {
-				// Create new item for the split copy
+				// Create new item for the split copies
 				newItem := models.CollectionItem{
 					CardID:           item.CardID,
-					Quantity:         1,
+					Quantity:         splitQty,
 					Condition:        newCondition,
 					Printing:         newPrinting,
 					Language:         newLanguage,
 					Notes:            "", // Fresh item, no notes
 					AddedAt:          time.Now(),
 					ScannedImagePath: "",
+					// Per-card cost basis splits proportionally by keeping the same unit price
+					PurchasePrice:     item.PurchasePrice,
+					PurchaseDate:      item.PurchaseDate,
+					AcquisitionSource: item.AcquisitionSource,
+					StorageLocationID: newLocation,
 				}
 				if err := db.Create(&newItem).Error; err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
+				// Split copies keep the stack's tags
+				if err := copyItemTags(db, item.ID, newItem.ID); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
 				resultItem = newItem
 			}
 
 			// Decrement original stack
-			item.Quantity -= 1
+			item.Quantity -= splitQty
 			if err := db.Save(&item).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -476,24 +676,30 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 			c.JSON(http.StatusOK, models.CollectionUpdateResponse{
 				Item:      resultItem,
 				Operation: "split",
-				Message:   fmt.Sprintf("Split 1 card from stack of %d", originalQty),
+				Message:   fmt.Sprintf("Split %d from stack of %d", splitQty, originalQty),
 			})
 			return
 		}
 
-		// Single non-scanned item (qty=1): try to merge into existing stack
+		// Whole non-scanned item is moving (qty=1 or all copies): try to merge into existing stack
 		var target models.CollectionItem
-		err := db.Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND (scanned_image_path IS NULL OR scanned_image_path = '') AND id != ?",
-			item.CardID, newCondition, newPrinting, newLanguage, item.ID).
+		err := whereSameCostBasis(whereSameStorageLocation(db, newLocation), item.PurchasePrice).
+			Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND "+mergeableStackSQL+" AND id != ?",
+				item.CardID, newCondition, newPrinting, newLanguage, item.ID).
 			First(&target).Error
 
 		if err == nil {
 			// Merge into existing stack and delete this item
-			target.Quantity += 1
+			mergeCostBasisInto(&target, &item, item.Quantity)
+			target.Quantity += item.Quantity
 			if err := db.Save(&target).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
+			if err := moveItemTags(db, item.ID, target.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
 			if err := db.Delete(&item).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -511,9 +717,11 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 		item.Condition = newCondition
 		item.Printing = newPrinting
 		item.Language = newLanguage
+		item.StorageLocationID = newLocation
 		if req.Notes != nil {
 			item.Notes = *req.Notes
 		}
+		applyCostBasisUpdate(&item, &req)
 		if err := db.Save(&item).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -527,13 +735,14 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 		return
 	}
 
-	// No attribute change - just update quantity and/or notes in place
+	// No attribute change - just update quantity, notes and/or cost basis in place
 	if req.Quantity != nil {
 		item.Quantity = *req.Quantity
 	}
 	if req.Notes != nil {
 		item.Notes = *req.Notes
 	}
+	applyCostBasisUpdate(&item, &req)
 
 	if err := db.Save(&item).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -548,6 +757,150 @@ func (h *CollectionHandler) UpdateCollectionItem(c *gin.Context) {
 	})
 }
 
+// mergeableStackSQL matches items that can absorb more copies: stacks that are neither
+// scanned nor graded
+const mergeableStackSQL = "(scanned_image_path IS NULL OR scanned_image_path = '') AND (grading_company IS NULL OR grading_company = '')"
+
+// whereSameCostBasis limits a merge lookup to stacks whose purchase price is known
+// exactly when the incoming copies' price is. A stack has one per-card price, so
+// priced and unpriced copies are kept in separate stacks rather than losing a price.
+func whereSameCostBasis(query *gorm.DB, purchasePrice *float64) *gorm.DB {
+	if purchasePrice == nil {
+		return query.Where("purchase_price IS NULL")
+	}
+	return query.Where("purchase_price IS NOT NULL")
+}
+
+// scanFingerprint fingerprints a scan and finds existing scans of the card that look
+// like the same photo uploaded again. Fingerprinting is best-effort: an undecodable image is stored
+// without one and never reported as a duplicate.
+func scanFingerprint(db *gorm.DB, cardID string, imageData []byte) (string, []models.DuplicateUpload) {
+	fingerprint, err := services.ScanFingerprint(imageData)
+	if err != nil {
+		log.Printf("Warning: failed to fingerprint scan of %s: %v", cardID, err)
+		return "", nil
+	}
+	duplicates, err := services.FindDuplicateUploads(db, cardID, fingerprint)
+	if err != nil {
+		log.Printf("Warning: failed to check %s for duplicate uploads: %v", cardID, err)
+	}
+	return fingerprint, duplicates
+}
+
+// validateConditionAndPrinting returns an error message when the condition or
+// printing is not a known value, or "" when both are valid
+func validateConditionAndPrinting(condition models.Condition, printing models.PrintingType) string {
+	if !slices.Contains(models.AllConditions(), condition) {
+		return "condition must be one of M, NM, EX, GD, LP, PL or PR"
+	}
+	if !isValidPrinting(printing) {
+		return "invalid printing type"
+	}
+	return ""
+}
+
+// normalizeGrading canonicalizes a slab's company and grade in place, returning an
+// error message or "" when both are valid
+func normalizeGrading(company *models.GradingCompany, grade *string) string {
+	normalized, ok := models.NormalizeGradingCompany(string(*company))
+	if !ok {
+		return "grading_company must be one of PSA, BGS, CGC or SGC"
+	}
+	normalizedGrade, ok := models.NormalizeGrade(*grade)
+	if !ok {
+		return "grade must be 1 to 10 in half steps, or Authentic"
+	}
+	*company, *grade = normalized, normalizedGrade
+	return ""
+}
+
+// duplicateCertNumber returns an error message when another item already holds the
+// slab with this company and certificate number, or "" otherwise
+func duplicateCertNumber(db *gorm.DB, company models.GradingCompany, certNumber string, excludeID uint) string {
+	if certNumber == "" {
+		return ""
+	}
+	var count int64
+	db.Model(&models.CollectionItem{}).
+		Where("grading_company = ? AND cert_number = ? AND id != ?", company, certNumber, excludeID).
+		Count(&count)
+	if count > 0 {
+		return fmt.Sprintf("%s cert %s is already in the collection", company, certNumber)
+	}
+	return ""
+}
+
+// applyGradingUpdate applies any grading fields present in an update request and
+// validates the result. It returns an HTTP status and error message, or "" when valid.
+// Clearing the grading company turns the slab back into a raw card.
+func applyGradingUpdate(db *gorm.DB, item *models.CollectionItem, req *models.UpdateCollectionRequest) (int, string) {
+	if req.GradingCompany != nil {
+		item.GradingCompany = *req.GradingCompany
+	}
+	if req.Grade != nil {
+		item.Grade = *req.Grade
+	}
+	if req.CertNumber != nil {
+		item.CertNumber = *req.CertNumber
+	}
+	if req.SlabNotes != nil {
+		item.SlabNotes = *req.SlabNotes
+	}
+
+	if !item.IsGraded() {
+		item.Grade, item.CertNumber, item.SlabNotes = "", "", ""
+		return 0, ""
+	}
+	if msg := normalizeGrading(&item.GradingCompany, &item.Grade); msg != "" {
+		return http.StatusBadRequest, msg
+	}
+	if msg := duplicateCertNumber(db, item.GradingCompany, item.CertNumber, item.ID); msg != "" {
+		return http.StatusConflict, msg
+	}
+	return 0, ""
+}
+
+// sameStorageLocation reports whether two optional location IDs refer to the same location
+func sameStorageLocation(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// mergeCostBasisInto folds the cost basis of qty cards from src into dst.
+// Call before increasing dst.Quantity. The purchase price becomes the weighted
+// average; merge lookups use whereSameCostBasis, so both sides are priced or neither
+// is. Purchase date and acquisition source are only filled in when dst has none.
+func mergeCostBasisInto(dst *models.CollectionItem, src *models.CollectionItem, qty int) {
+	dst.PurchasePrice = models.MergePurchasePrice(dst.PurchasePrice, dst.Quantity, src.PurchasePrice, qty)
+	if dst.PurchaseDate == nil {
+		dst.PurchaseDate = src.PurchaseDate
+	}
+	if dst.AcquisitionSource == "" {
+		dst.AcquisitionSource = src.AcquisitionSource
+	}
+}
+
+// applyCostBasisUpdate applies any cost basis fields present in an update request.
+// A negative purchase price clears it.
+func applyCostBasisUpdate(item *models.CollectionItem, req *models.UpdateCollectionRequest) {
+	if req.PurchasePrice != nil {
+		if *req.PurchasePrice < 0 {
+			item.PurchasePrice = nil
+		} else {
+			price := *req.PurchasePrice
+			item.PurchasePrice = &price
+		}
+	}
+	if req.PurchaseDate != nil {
+		item.PurchaseDate = req.PurchaseDate
+	}
+	if req.AcquisitionSource != nil {
+		item.AcquisitionSource = *req.AcquisitionSource
+	}
+}
+
 func (h *CollectionHandler) DeleteCollectionItem(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -568,96 +921,38 @@ func (h *CollectionHandler) DeleteCollectionItem(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
 		return
 	}
+	if err := deleteItemTags(db, uint(id)); err != nil {
+		log.Printf("Warning: failed to remove tags of deleted item %d: %v", id, err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// GetStats returns collection totals by game, cost basis, value per tag and the
+// sealed bucket, valued the same way as the collection and grouped endpoints
+// GET /api/collection/stats
 func (h *CollectionHandler) GetStats(c *gin.Context) {
 	db := database.GetDB()
 
-	var stats models.CollectionStats
-
-	// Total and unique cards
-	db.Model(&models.CollectionItem{}).Select("COALESCE(SUM(quantity), 0)").Scan(&stats.TotalCards)
-	var uniqueCount int64
-	db.Model(&models.CollectionItem{}).Distinct("card_id").Count(&uniqueCount)
-	stats.UniqueCards = int(uniqueCount)
-
-	// Calculate values and counts by game using condition-appropriate prices
-	// First, try to use condition-specific prices from card_prices table
-	// Falls back to card base prices (NM) if no condition price exists
-	type gameStats struct {
-		Game       string
-		Count      int
-		TotalValue float64
-	}
-
-	var gameResults []gameStats
-	db.Table("collection_items").
-		Select(`
-			cards.game,
-			SUM(collection_items.quantity) as count,
-			SUM(
-				COALESCE(
-					(SELECT cp.price_usd FROM card_prices cp
-					 WHERE cp.card_id = cards.id
-					 AND cp.condition = (
-						CASE collection_items.condition
-							WHEN 'M' THEN 'NM'
-							WHEN 'NM' THEN 'NM'
-							WHEN 'EX' THEN 'LP'
-							WHEN 'LP' THEN 'LP'
-							WHEN 'GD' THEN 'MP'
-							WHEN 'PL' THEN 'HP'
-							WHEN 'PR' THEN 'DMG'
-							ELSE 'NM'
-						END
-					 )
-					 AND cp.printing = collection_items.printing
-					 AND cp.language = COALESCE(NULLIF(collection_items.language, ''), 'English')
-					 LIMIT 1),
-					(SELECT cp.price_usd FROM card_prices cp
-					 WHERE cp.card_id = cards.id
-					 AND cp.condition = (
-						CASE collection_items.condition
-							WHEN 'M' THEN 'NM'
-							WHEN 'NM' THEN 'NM'
-							WHEN 'EX' THEN 'LP'
-							WHEN 'LP' THEN 'LP'
-							WHEN 'GD' THEN 'MP'
-							WHEN 'PL' THEN 'HP'
-							WHEN 'PR' THEN 'DMG'
-							ELSE 'NM'
-						END
-					 )
-					 AND cp.printing = collection_items.printing
-					 AND cp.language = 'English'
-					 AND COALESCE(NULLIF(collection_items.language, ''), 'English') != 'English'
-					 LIMIT 1),
-					CASE 
-						WHEN collection_items.printing IN ('Foil', '1st Edition', 'Reverse Holofoil') 
-						THEN cards.price_foil_usd 
-						ELSE cards.price_usd 
-					END
-				) * collection_items.quantity
-			) as total_value
-		`).
-		Joins("JOIN cards ON cards.id = collection_items.card_id").
-		Group("cards.game").
-		Scan(&gameResults)
-
-	for _, gr := range gameResults {
-		switch gr.Game {
-		case "mtg":
-			stats.MTGCards = gr.Count
-			stats.MTGValue = gr.TotalValue
-		case "pokemon":
-			stats.PokemonCards = gr.Count
-			stats.PokemonValue = gr.TotalValue
-		}
-	}
-
-	stats.TotalValue = stats.MTGValue + stats.PokemonValue
+	conv, ok := loadDisplayConverter(c, db)
+	if !ok {
+		return
+	}
+
+	summary, err := valuation.Collection(db, h.cardResolver())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stats := summary.Stats()
+
+	now := time.Now()
+	stats.DisplayCurrency = conv.Currency
+	stats.ExchangeRate = conv.Rate(now)
+	stats.DisplayTotalValue = conv.Convert(stats.TotalValue, now)
+	stats.DisplayMTGValue = conv.Convert(stats.MTGValue, now)
+	stats.DisplayPokemonValue = conv.Convert(stats.PokemonValue, now)
+	stats.DisplaySealedValue = conv.Convert(stats.SealedValue, now)
 
 	c.JSON(http.StatusOK, stats)
 }
@@ -696,11 +991,25 @@ func (h *CollectionHandler) RefreshPrices(c *gin.Context) {
 // - game: filter by game ("pokemon" or "mtg")
 // - q: search by card name or set name (case-insensitive)
 // - sort: sort order ("added_at", "name", "value", "price_updated") - default "added_at"
+// - location: storage location ID (includes sub-locations), or "none" for unassigned items
+// - tag / -tag: only items with (or without) the tag; repeatable, names ignore case
+// - group_by: "location" to group cards by storage location first
 func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 	db := database.GetDB()
 
+	conv, ok := loadDisplayConverter(c, db)
+	if !ok {
+		return
+	}
+
+	locations, err := loadStorageLocationTree(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	var items []models.CollectionItem
-	query := db.Preload("Card").Preload("Card.Prices")
+	query := db.Preload("Card").Preload("Card.Prices").Preload("Card.GradedPrices")
 
 	// Always join cards table for filtering/sorting
 	needsJoin := false
@@ -725,6 +1034,23 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 			searchPattern, searchPattern, searchPattern)
 	}
 
+	// Storage location filter
+	if location := c.Query("location"); location != "" {
+		ids, unassigned, err := parseStorageLocationFilter(location, locations)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if unassigned {
+			query = query.Where("collection_items.storage_location_id IS NULL")
+		} else {
+			query = query.Where("collection_items.storage_location_id IN ?", ids)
+		}
+	}
+
+	// Tag filters (tag= requires every listed tag, -tag= excludes)
+	query = applyTagFilters(query, c)
+
 	// Sorting
 	sortBy := c.DefaultQuery("sort", "added_at")
 	switch sortBy {
@@ -748,7 +1074,76 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	annotateStorageLocations(locations, items)
+	annotateTags(db, items)
+
+	now := time.Now()
+	if c.Query("group_by") == "location" {
+		result := h.groupByStorageLocation(items, locations)
+		for i := range result {
+			result[i].DisplayCurrency = conv.Currency
+			result[i].DisplayTotalValue = conv.Convert(result[i].TotalValue, now)
+			applyDisplayCurrencyToGroups(result[i].Groups, conv, now)
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	groups := h.groupByCard(items)
+	applyDisplayCurrencyToGroups(groups, conv, now)
+	c.JSON(http.StatusOK, groups)
+}
 
+// groupByStorageLocation splits items by storage location and groups each location's
+// items by card. Locations are sorted by path; unassigned items come last.
+func (h *CollectionHandler) groupByStorageLocation(items []models.CollectionItem, locations storageLocationTree) []models.StorageLocationGroup {
+	byLocation := make(map[uint][]models.CollectionItem)
+	var unassigned []models.CollectionItem
+	for _, item := range items {
+		if item.StorageLocationID == nil {
+			unassigned = append(unassigned, item)
+			continue
+		}
+		byLocation[*item.StorageLocationID] = append(byLocation[*item.StorageLocationID], item)
+	}
+
+	result := make([]models.StorageLocationGroup, 0, len(byLocation)+1)
+	for id, locationItems := range byLocation {
+		var location *models.StorageLocation
+		if loc, ok := locations[id]; ok {
+			loc.Path = locations.Path(id)
+			location = &loc
+		}
+		result = append(result, newStorageLocationGroup(location, h.groupByCard(locationItems)))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return storageLocationGroupPath(result[i]) < storageLocationGroupPath(result[j])
+	})
+
+	if len(unassigned) > 0 {
+		result = append(result, newStorageLocationGroup(nil, h.groupByCard(unassigned)))
+	}
+	return result
+}
+
+func newStorageLocationGroup(location *models.StorageLocation, groups []models.GroupedCollectionItem) models.StorageLocationGroup {
+	group := models.StorageLocationGroup{Location: location, Groups: groups}
+	for _, g := range groups {
+		group.TotalQuantity += g.TotalQuantity
+		group.TotalValue += g.TotalValue
+	}
+	return group
+}
+
+func storageLocationGroupPath(g models.StorageLocationGroup) string {
+	if g.Location == nil {
+		return ""
+	}
+	return g.Location.Path
+}
+
+// groupByCard groups collection items by card_id, with value, variant and cost basis totals
+func (h *CollectionHandler) groupByCard(items []models.CollectionItem) []models.GroupedCollectionItem {
 	// Group items by card_id
 	cardGroups := make(map[string][]models.CollectionItem)
 	cardMap := make(map[string]models.Card)
@@ -758,15 +1153,8 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 		if _, exists := cardMap[item.CardID]; !exists {
 			// If card is empty (not in database), try to load from pokemon service
 			// This handles Japanese cards which are loaded from JSON files, not the DB
-			if item.Card.Name == "" && item.Card.ImageURL == "" {
-				if card, err := h.pokemonService.GetCard(item.CardID); err == nil && card != nil {
-					cardMap[item.CardID] = *card
-				} else {
-					cardMap[item.CardID] = item.Card
-				}
-			} else {
-				cardMap[item.CardID] = item.Card
-			}
+			valuation.ResolveCard(&item, h.cardResolver())
+			cardMap[item.CardID] = item.Card
 		}
 	}
 
@@ -780,6 +1168,9 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 		totalQty := 0
 		totalValue := 0.0
 		scannedCount := 0
+		costBasisQty := 0
+		totalCostBasis := 0.0
+		unrealizedGain := 0.0
 
 		// Track variants by printing+condition
 		variantMap := make(map[string]*models.CollectionVariant)
@@ -788,25 +1179,26 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 			item := &groupItems[i]
 			totalQty += item.Quantity
 
-			// Calculate item value using condition-specific pricing with language
-			// GetPriceWithSource returns metadata about whether a language fallback occurred
-			priceCondition := models.MapCollectionConditionToPriceCondition(item.Condition)
-			priceResult := card.GetPriceWithSource(priceCondition, item.Printing, item.Language)
-			itemValue := priceResult.Price * float64(item.Quantity)
+			// Value the item (condition-specific or graded pricing with language
+			// fallback) and set the calculated values on it for the API response
+			priceResult := valuation.Item(item, &card)
+			itemValue := item.ItemValue
 			totalValue += itemValue
 
-			// Set calculated values on the item itself for API response
-			item.ItemValue = itemValue
-			item.PriceLanguage = priceResult.PriceLanguage
-			item.PriceFallback = priceResult.IsFallback
+			// Gain/loss only covers items with a known purchase price
+			if item.CostBasis != nil {
+				costBasisQty += item.Quantity
+				totalCostBasis += *item.CostBasis
+				unrealizedGain += *item.UnrealizedGain
+			}
 
 			// Count scanned cards
 			if item.ScannedImagePath != "" {
 				scannedCount++
 			}
 
-			// Aggregate variants by printing+condition+language
-			variantKey := fmt.Sprintf("%s|%s|%s", item.Printing, item.Condition, item.Language)
+			// Aggregate variants by printing+condition+language (slabs also by company+grade)
+			variantKey := fmt.Sprintf("%s|%s|%s|%s|%s", item.Printing, item.Condition, item.Language, item.GradingCompany, item.Grade)
 			if v, exists := variantMap[variantKey]; exists {
 				v.Quantity += item.Quantity
 				v.Value += itemValue
@@ -821,15 +1213,17 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 					scannedQty = 1
 				}
 				variantMap[variantKey] = &models.CollectionVariant{
-					Printing:      item.Printing,
-					Condition:     item.Condition,
-					Language:      item.Language,
-					Quantity:      item.Quantity,
-					Value:         itemValue,
-					HasScans:      hasScans,
-					ScannedQty:    scannedQty,
-					PriceLanguage: priceResult.PriceLanguage,
-					PriceFallback: priceResult.IsFallback,
+					Printing:       item.Printing,
+					Condition:      item.Condition,
+					Language:       item.Language,
+					GradingCompany: item.GradingCompany,
+					Grade:          item.Grade,
+					Quantity:       item.Quantity,
+					Value:          itemValue,
+					HasScans:       hasScans,
+					ScannedQty:     scannedQty,
+					PriceLanguage:  priceResult.PriceLanguage,
+					PriceFallback:  priceResult.IsFallback,
 				}
 			}
 		}
@@ -841,25 +1235,41 @@ func (h *CollectionHandler) GetGroupedCollection(c *gin.Context) {
 		}
 
 		result = append(result, models.GroupedCollectionItem{
-			Card:          card,
-			TotalQuantity: totalQty,
-			TotalValue:    totalValue,
-			ScannedCount:  scannedCount,
-			Variants:      variants,
-			Items:         groupItems,
+			Card:              card,
+			TotalQuantity:     totalQty,
+			TotalValue:        totalValue,
+			ScannedCount:      scannedCount,
+			Variants:          variants,
+			Items:             groupItems,
+			CostBasisQuantity: costBasisQty,
+			TotalCostBasis:    totalCostBasis,
+			UnrealizedGain:    unrealizedGain,
 		})
 	}
 
-	c.JSON(http.StatusOK, result)
+	return result
 }
 
 // GetValueHistory returns collection value snapshots for charting
+// Query params: period (week|month|3month|year|all), group_by (set|rarity|language|location|tag)
+// GET /api/collection/stats/history
 func (h *CollectionHandler) GetValueHistory(c *gin.Context) {
 	if h.snapshotService == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "snapshot service not available"})
 		return
 	}
 
+	groupBy := c.Query("group_by")
+	if groupBy != "" && !models.IsValidBreakdownDimension(groupBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be one of: " + strings.Join(models.AllBreakdownDimensions(), ", ")})
+		return
+	}
+
+	conv, ok := loadDisplayConverter(c, database.GetDB())
+	if !ok {
+		return
+	}
+
 	period := c.DefaultQuery("period", "month")
 
 	snapshots, err := h.snapshotService.GetHistory(period)
@@ -868,8 +1278,138 @@ func (h *CollectionHandler) GetValueHistory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.ValueHistoryResponse{
+	// Each snapshot converts at the rate valid on its own date
+	for i := range snapshots {
+		date := snapshots[i].SnapshotDate
+		snapshots[i].DisplayCurrency = conv.Currency
+		snapshots[i].ExchangeRate = conv.Rate(date)
+		snapshots[i].DisplayTotalValue = conv.Convert(snapshots[i].TotalValue, date)
+		snapshots[i].DisplayMTGValue = conv.Convert(snapshots[i].MTGValue, date)
+		snapshots[i].DisplayPokemonValue = conv.Convert(snapshots[i].PokemonValue, date)
+		snapshots[i].DisplaySealedValue = conv.Convert(snapshots[i].SealedValue, date)
+	}
+
+	resp := models.ValueHistoryResponse{
 		Snapshots: snapshots,
 		Period:    period,
-	})
+		Currency:  conv.Currency,
+	}
+
+	if groupBy != "" {
+		rows, err := h.snapshotService.GetBreakdownHistory(groupBy, period)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var first, last time.Time
+		if len(snapshots) > 0 {
+			first, last = snapshots[0].SnapshotDate, snapshots[len(snapshots)-1].SnapshotDate
+		}
+		resp.GroupBy = groupBy
+		resp.Breakdown = services.BuildBreakdownSeries(rows, first, last)
+		for i := range resp.Breakdown {
+			series := &resp.Breakdown[i]
+			for j := range series.Points {
+				series.Points[j].DisplayValue = conv.Convert(series.Points[j].Value, series.Points[j].SnapshotDate)
+			}
+			series.DisplayChange = services.BreakdownValueAt(series.Points, last, true) - services.BreakdownValueAt(series.Points, first, true)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// BackfillSnapshots reconstructs missing daily value snapshots from AddedAt dates and
+// price history. Backfilled snapshots are marked derived; running it again only
+// recomputes derived snapshots.
+// Body (optional): {"from": "YYYY-MM-DD", "to": "YYYY-MM-DD"}
+// POST /api/admin/snapshots/backfill
+func (h *CollectionHandler) BackfillSnapshots(c *gin.Context) {
+	if h.snapshotService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "snapshot service not available"})
+		return
+	}
+
+	var req models.SnapshotBackfillRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var from, to time.Time
+	for _, d := range []struct {
+		name  string
+		value string
+		into  *time.Time
+	}{{"from", req.From, &from}, {"to", req.To, &to}} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation("2006-01-02", d.value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": d.name + " must be a YYYY-MM-DD date"})
+			return
+		}
+		*d.into = parsed
+	}
+
+	result, err := h.snapshotService.Backfill(from, to)
+	if errors.Is(err, services.ErrBackfillRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDuplicateUploads reports scanned items whose scans look like the same photo
+// added more than once. Scans saved before fingerprints were stored are counted
+// but left out until BackfillScanFingerprints fingerprints them.
+// GET /api/admin/duplicate-uploads
+func (h *CollectionHandler) GetDuplicateUploads(c *gin.Context) {
+	db := database.GetDB()
+
+	var report models.DuplicateUploadReport
+	unfingerprinted, err := services.CountUnfingerprintedScans(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report.Unfingerprinted = unfingerprinted
+
+	groups, err := services.FindDuplicateUploadGroups(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report.Groups = groups
+	if report.Groups == nil {
+		report.Groups = []models.DuplicateUploadGroup{}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// BackfillScanFingerprints fingerprints scans saved before fingerprints were
+// stored, so duplicate detection covers them
+// POST /api/admin/duplicate-uploads/backfill
+func (h *CollectionHandler) BackfillScanFingerprints(c *gin.Context) {
+	if h.imageStorageService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scanned image storage is not configured"})
+		return
+	}
+
+	backfilled, err := services.BackfillScanFingerprints(database.GetDB(), h.imageStorageService.GetStorageDir())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backfilled": backfilled})
 }
@@ -0,0 +1,610 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// setupTestDB points the global database at a fresh in-memory SQLite database
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	// Each connection to ":memory:" is a separate database, so pin the pool to one
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(
+		&models.Card{},
+		&models.CollectionItem{},
+		&models.StorageLocation{},
+		&models.Tag{},
+		&models.CollectionItemTag{},
+		&models.CardPrice{},
+		&models.GradedPrice{},
+		&models.CollectionSale{},
+		&models.SealedProduct{},
+		&models.SealedCollectionItem{},
+		&models.WantListEntry{},
+		&models.Deck{},
+		&models.DeckCard{},
+		&models.PriceAlertRule{},
+		&models.PriceAlertEvent{},
+		&models.ExchangeRate{},
+		&models.AppSetting{},
+		&models.CSVImportJob{},
+		&models.CSVImportItem{},
+	); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	original := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = original })
+
+	return db
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.0001
+}
+
+func TestGetStatsCostBasis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	db.Create(&models.Card{ID: "pkm-1", Name: "Pikachu", Game: models.GamePokemon, PriceUSD: 4})
+	db.Create(&models.CardPrice{CardID: "pkm-1", Condition: models.PriceConditionLP, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 3})
+
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(6)})
+	db.Create(&models.CollectionItem{CardID: "pkm-1", Quantity: 3, Condition: models.ConditionLightPlay, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(5)})
+	db.Create(&models.CollectionItem{CardID: "pkm-1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+
+	h := &CollectionHandler{}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/collection/stats", nil)
+	h.GetStats(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats models.CollectionStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+
+	// Value: 2*10 (MTG) + 3*3 (LP Pikachu) + 1*4 (NM Pikachu base price)
+	if !almostEqual(stats.TotalValue, 33) {
+		t.Errorf("TotalValue = %v, want 33", stats.TotalValue)
+	}
+	if stats.CostBasisCards != 5 {
+		t.Errorf("CostBasisCards = %d, want 5", stats.CostBasisCards)
+	}
+	// Cost: 2*6 + 3*5 = 27, current value of those cards: 20 + 9 = 29
+	if !almostEqual(stats.TotalCostBasis, 27) {
+		t.Errorf("TotalCostBasis = %v, want 27", stats.TotalCostBasis)
+	}
+	if !almostEqual(stats.CostBasisValue, 29) {
+		t.Errorf("CostBasisValue = %v, want 29", stats.CostBasisValue)
+	}
+	if !almostEqual(stats.UnrealizedGain, 2) {
+		t.Errorf("UnrealizedGain = %v, want 2", stats.UnrealizedGain)
+	}
+}
+
+// TestStatsMatchGroupedValues checks that stats, the grouped collection and value
+// snapshots agree for price lookups the old per-endpoint SQL resolved differently
+func TestStatsMatchGroupedValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	nm := func(cardID string, printing models.PrintingType, language models.CardLanguage, price float64) models.CardPrice {
+		return models.CardPrice{CardID: cardID, Condition: models.PriceConditionNM, Printing: printing, Language: language, PriceUSD: price}
+	}
+	item := func(cardID string, qty int, printing models.PrintingType, language models.CardLanguage) models.CollectionItem {
+		return models.CollectionItem{CardID: cardID, Quantity: qty, Condition: models.ConditionNearMint, Printing: printing, Language: language}
+	}
+
+	tests := []struct {
+		name      string
+		cards     []models.Card
+		prices    []models.CardPrice
+		graded    []models.GradedPrice
+		items     []models.CollectionItem
+		wantValue float64
+	}{
+		{
+			name:      "1st Edition priced from Unlimited, not the foil base price",
+			cards:     []models.Card{{ID: "base1-4", Name: "Charizard", Game: models.GamePokemon, PriceUSD: 30, PriceFoilUSD: 100}},
+			prices:    []models.CardPrice{nm("base1-4", models.PrintingUnlimited, models.LanguageEnglish, 50)},
+			items:     []models.CollectionItem{item("base1-4", 2, models.Printing1stEdition, models.LanguageEnglish)},
+			wantValue: 100,
+		},
+		{
+			name:      "Normal priced from Unlimited",
+			cards:     []models.Card{{ID: "base1-58", Name: "Pikachu", Game: models.GamePokemon, PriceUSD: 1}},
+			prices:    []models.CardPrice{nm("base1-58", models.PrintingUnlimited, models.LanguageEnglish, 5)},
+			items:     []models.CollectionItem{item("base1-58", 3, models.PrintingNormal, models.LanguageEnglish)},
+			wantValue: 15,
+		},
+		{
+			name:  "Japanese and English copies",
+			cards: []models.Card{{ID: "sv1-1", Name: "Sprigatito", Game: models.GamePokemon, PriceUSD: 0.5}},
+			prices: []models.CardPrice{
+				nm("sv1-1", models.PrintingNormal, models.LanguageEnglish, 2),
+				nm("sv1-1", models.PrintingNormal, models.LanguageJapanese, 3),
+			},
+			items: []models.CollectionItem{
+				item("sv1-1", 1, models.PrintingNormal, models.LanguageEnglish),
+				item("sv1-1", 1, models.PrintingNormal, models.LanguageJapanese),
+				item("sv1-1", 1, models.PrintingNormal, models.LanguageGerman),
+			},
+			wantValue: 2 + 3 + 2,
+		},
+		{
+			name:   "slabs with and without a graded price",
+			cards:  []models.Card{{ID: "mh3-1", Name: "Ragavan", Game: models.GameMTG, PriceUSD: 40, PriceFoilUSD: 60}},
+			graded: []models.GradedPrice{{CardID: "mh3-1", GradingCompany: models.GradingPSA, Grade: "10", PriceUSD: 300}},
+			items: []models.CollectionItem{
+				{CardID: "mh3-1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingFoil, Language: models.LanguageEnglish, GradingCompany: models.GradingPSA, Grade: "10", PurchasePrice: floatPtr(250)},
+				{CardID: "mh3-1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingFoil, Language: models.LanguageEnglish, GradingCompany: models.GradingCGC, Grade: "9"},
+				item("mh3-1", 2, models.PrintingNormal, models.LanguageEnglish),
+			},
+			wantValue: 300 + 60 + 80,
+		},
+		{
+			name:      "unpriced card",
+			cards:     []models.Card{{ID: "m1", Name: "Unpriced", Game: models.GameMTG}},
+			items:     []models.CollectionItem{item("m1", 4, models.PrintingFoil, models.LanguageEnglish)},
+			wantValue: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			if err := db.AutoMigrate(&models.CollectionValueSnapshot{}); err != nil {
+				t.Fatalf("failed to migrate snapshots: %v", err)
+			}
+			db.Create(&tt.cards)
+			if len(tt.prices) > 0 {
+				db.Create(&tt.prices)
+			}
+			if len(tt.graded) > 0 {
+				db.Create(&tt.graded)
+			}
+			db.Create(&tt.items)
+
+			h := &CollectionHandler{}
+			router := gin.New()
+			router.GET("/api/collection/stats", h.GetStats)
+			router.GET("/api/collection/grouped", h.GetGroupedCollection)
+			get := func(path string, into interface{}) {
+				t.Helper()
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				if w.Code != http.StatusOK {
+					t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), into); err != nil {
+					t.Fatalf("GET %s: %v", path, err)
+				}
+			}
+
+			var stats models.CollectionStats
+			get("/api/collection/stats", &stats)
+			var groups []models.GroupedCollectionItem
+			get("/api/collection/grouped", &groups)
+
+			var groupedValue, groupedCost float64
+			var groupedCards int
+			for _, g := range groups {
+				groupedValue += g.TotalValue
+				groupedCost += g.TotalCostBasis
+				groupedCards += g.TotalQuantity
+			}
+
+			if !almostEqual(stats.TotalValue, tt.wantValue) {
+				t.Errorf("stats value = %v, want %v", stats.TotalValue, tt.wantValue)
+			}
+			if !almostEqual(stats.MTGValue+stats.PokemonValue, groupedValue) || stats.TotalCards != groupedCards {
+				t.Errorf("stats $%.2f for %d cards, grouped $%.2f for %d cards", stats.MTGValue+stats.PokemonValue, stats.TotalCards, groupedValue, groupedCards)
+			}
+			if !almostEqual(stats.TotalCostBasis, groupedCost) {
+				t.Errorf("stats cost basis = %v, grouped = %v", stats.TotalCostBasis, groupedCost)
+			}
+
+			snapshot := services.NewSnapshotService().CurrentStats()
+			if !almostEqual(snapshot.TotalValue, stats.TotalValue) || snapshot.TotalCards != stats.TotalCards {
+				t.Errorf("snapshot $%.2f for %d cards, stats $%.2f for %d cards", snapshot.TotalValue, snapshot.TotalCards, stats.TotalValue, stats.TotalCards)
+			}
+		})
+	}
+}
+
+func TestUpdateCollectionItemCarriesCostBasis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+
+	// Stack of 3 bought at $4 each, plus an existing LP stack of 1 bought at $8
+	stack := models.CollectionItem{CardID: "mtg-1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(4)}
+	db.Create(&stack)
+	lpStack := models.CollectionItem{CardID: "mtg-1", Quantity: 1, Condition: models.ConditionLightPlay, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(8)}
+	db.Create(&lpStack)
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.PUT("/api/collection/:id", h.UpdateCollectionItem)
+
+	update := func(id uint, body string) models.CollectionUpdateResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/api/collection/"+strconv.FormatUint(uint64(id), 10), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.CollectionUpdateResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Split one copy off the $4 stack into the LP stack: weighted average (8*1 + 4*1) / 2 = 6
+	resp := update(stack.ID, `{"condition":"LP"}`)
+	if resp.Operation != "split" {
+		t.Fatalf("expected split, got %s", resp.Operation)
+	}
+	if resp.Item.ID != lpStack.ID || resp.Item.Quantity != 2 {
+		t.Fatalf("expected split copy merged into LP stack (qty 2), got id=%d qty=%d", resp.Item.ID, resp.Item.Quantity)
+	}
+	if resp.Item.PurchasePrice == nil || !almostEqual(*resp.Item.PurchasePrice, 6) {
+		t.Errorf("expected merged purchase price 6, got %v", resp.Item.PurchasePrice)
+	}
+
+	// The source stack keeps its per-card price (cost splits proportionally)
+	var source models.CollectionItem
+	db.First(&source, stack.ID)
+	if source.Quantity != 2 || source.PurchasePrice == nil || !almostEqual(*source.PurchasePrice, 4) {
+		t.Errorf("expected source stack qty 2 at $4, got qty %d price %v", source.Quantity, source.PurchasePrice)
+	}
+
+	// Split into a new stack: the new copy inherits the per-card price
+	resp = update(stack.ID, `{"condition":"GD"}`)
+	if resp.Item.Quantity != 1 || resp.Item.PurchasePrice == nil || !almostEqual(*resp.Item.PurchasePrice, 4) {
+		t.Errorf("expected new split copy qty 1 at $4, got qty %d price %v", resp.Item.Quantity, resp.Item.PurchasePrice)
+	}
+}
+
+func TestMergePurchasePrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		aPrice   *float64
+		aQty     int
+		bPrice   *float64
+		bQty     int
+		expected *float64
+	}{
+		{"both unknown", nil, 2, nil, 1, nil},
+		{"only existing known", floatPtr(5), 2, nil, 1, nil},
+		{"only incoming known", nil, 2, floatPtr(7), 1, nil},
+		{"unpriced side is empty", nil, 0, floatPtr(7), 1, floatPtr(7)},
+		{"weighted average", floatPtr(2), 3, floatPtr(10), 1, floatPtr(4)},
+		{"zero quantities keep first", floatPtr(3), 0, floatPtr(9), 0, floatPtr(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := models.MergePurchasePrice(tt.aPrice, tt.aQty, tt.bPrice, tt.bQty)
+			if (got == nil) != (tt.expected == nil) {
+				t.Fatalf("MergePurchasePrice() = %v, want %v", got, tt.expected)
+			}
+			if got != nil && !almostEqual(*got, *tt.expected) {
+				t.Errorf("MergePurchasePrice() = %v, want %v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestPricedAndUnpricedCopiesStaySeparate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.POST("/api/collection", h.AddToCollection)
+	router.PUT("/api/collection/:id", h.UpdateCollectionItem)
+	router.GET("/api/collection/stats", h.GetStats)
+
+	send := func(method, path, body string) []byte {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+			t.Fatalf("%s %s: expected success, got %d: %s", method, path, w.Code, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	add := func(body string) models.CollectionItem {
+		t.Helper()
+		var item models.CollectionItem
+		_ = json.Unmarshal(send(http.MethodPost, "/api/collection", body), &item)
+		return item
+	}
+
+	// One copy bought at $100 keeps its price next to 9 copies of unknown cost
+	unpriced := add(`{"card_id":"mtg-1","quantity":9}`)
+	priced := add(`{"card_id":"mtg-1","quantity":1,"purchase_price":100}`)
+	if priced.ID == unpriced.ID || priced.Quantity != 1 || priced.PurchasePrice == nil || *priced.PurchasePrice != 100 {
+		t.Fatalf("expected a separate stack of 1 at $100, got id %d qty %d price %v", priced.ID, priced.Quantity, priced.PurchasePrice)
+	}
+	// More priced copies merge with the priced stack, unpriced ones with the unpriced stack
+	if item := add(`{"card_id":"mtg-1","quantity":1,"purchase_price":50}`); item.ID != priced.ID || *item.PurchasePrice != 75 {
+		t.Errorf("expected the priced stack at $75, got id %d price %v", item.ID, item.PurchasePrice)
+	}
+	if item := add(`{"card_id":"mtg-1","quantity":1}`); item.ID != unpriced.ID || item.Quantity != 10 {
+		t.Errorf("expected the unpriced stack of 10, got id %d qty %d", item.ID, item.Quantity)
+	}
+
+	var stats models.CollectionStats
+	_ = json.Unmarshal(send(http.MethodGet, "/api/collection/stats", ""), &stats)
+	if stats.CostBasisCards != 2 || !almostEqual(stats.TotalCostBasis, 150) {
+		t.Errorf("expected 2 cards with a $150 cost basis, got %+v", stats)
+	}
+
+	// Condition and printing are validated like when adding
+	for _, body := range []string{`{"condition":"Mint-ish"}`, `{"printing":"Shiny"}`} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/api/collection/"+strconv.FormatUint(uint64(priced.ID), 10), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	// A negative price clears it, like sealed items
+	var resp models.CollectionUpdateResponse
+	_ = json.Unmarshal(send(http.MethodPut, "/api/collection/"+strconv.FormatUint(uint64(priced.ID), 10), `{"purchase_price":-1}`), &resp)
+	if resp.Item.PurchasePrice != nil {
+		t.Errorf("expected the purchase price cleared, got %v", *resp.Item.PurchasePrice)
+	}
+}
+
+func TestGradedItemsAreNeverMerged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "pkm-1", Name: "Charizard", Game: models.GamePokemon, PriceUSD: 100})
+	db.Create(&models.GradedPrice{CardID: "pkm-1", GradingCompany: models.GradingPSA, Grade: "10", PriceUSD: 2000, Source: models.GradedPriceSourceManual})
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.POST("/api/collection", h.AddToCollection)
+	router.PUT("/api/collection/:id", h.UpdateCollectionItem)
+	router.GET("/api/collection/stats", h.GetStats)
+	router.GET("/api/collection/grouped", h.GetGroupedCollection)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"slab forces quantity 1", `{"card_id":"pkm-1","quantity":3,"grading_company":"psa","grade":"10.0","cert_number":"111"}`, http.StatusCreated},
+		{"identical slab is a new item", `{"card_id":"pkm-1","grading_company":"PSA","grade":"10","cert_number":"222"}`, http.StatusCreated},
+		{"slab without a graded price", `{"card_id":"pkm-1","grading_company":"BGS","grade":"9.5"}`, http.StatusCreated},
+		{"duplicate cert", `{"card_id":"pkm-1","grading_company":"PSA","grade":"9","cert_number":"111"}`, http.StatusConflict},
+		{"unknown company", `{"card_id":"pkm-1","grading_company":"XYZ","grade":"9"}`, http.StatusBadRequest},
+		{"invalid grade", `{"card_id":"pkm-1","grading_company":"CGC","grade":"9.3"}`, http.StatusBadRequest},
+		{"grade without company", `{"card_id":"pkm-1","grade":"9"}`, http.StatusBadRequest},
+		{"raw stack", `{"card_id":"pkm-1","quantity":2}`, http.StatusCreated},
+		{"raw copies merge, not into slabs", `{"card_id":"pkm-1","quantity":2}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodPost, "/api/collection", tt.body); w.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var slabs []models.CollectionItem
+	db.Where("grading_company = ?", models.GradingPSA).Order("id").Find(&slabs)
+	if len(slabs) != 2 || slabs[0].Quantity != 1 || slabs[0].Grade != "10" {
+		t.Fatalf("expected two PSA 10 slabs of quantity 1, got %+v", slabs)
+	}
+	var raw models.CollectionItem
+	db.Where("grading_company = '' OR grading_company IS NULL").First(&raw)
+	if raw.Quantity != 4 {
+		t.Fatalf("expected the raw stack to hold 4 copies, got %d", raw.Quantity)
+	}
+
+	// Slabs are valued at their graded price; the BGS 9.5 has none and falls back to raw
+	var stats models.CollectionStats
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection/stats", "").Body.Bytes(), &stats)
+	if !almostEqual(stats.TotalValue, 2*2000+100+4*100) {
+		t.Errorf("TotalValue = %v, want 4500", stats.TotalValue)
+	}
+	var groups []models.GroupedCollectionItem
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection/grouped", "").Body.Bytes(), &groups)
+	if len(groups) != 1 || !almostEqual(groups[0].TotalValue, stats.TotalValue) || len(groups[0].Variants) != 3 {
+		t.Fatalf("expected grouped value to match stats with 3 variants, got %+v", groups)
+	}
+	for _, item := range groups[0].Items {
+		if item.GradedPriceFallback != (item.GradingCompany == models.GradingBGS) {
+			t.Errorf("item %d: GradedPriceFallback = %v", item.ID, item.GradedPriceFallback)
+		}
+	}
+
+	// Grading one copy of the raw stack splits it off as a slab
+	w := do(http.MethodPut, "/api/collection/"+strconv.FormatUint(uint64(raw.ID), 10), `{"grading_company":"CGC","grade":"8.5","cert_number":"333"}`)
+	var resp models.CollectionUpdateResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Operation != "split" || resp.Item.Quantity != 1 || resp.Item.GradingCompany != models.GradingCGC {
+		t.Fatalf("expected a split-off CGC slab, got %d %+v", w.Code, resp)
+	}
+	db.First(&raw, raw.ID)
+	if raw.Quantity != 3 {
+		t.Errorf("expected the raw stack to drop to 3, got %d", raw.Quantity)
+	}
+
+	// Slab quantity never changes, and cracking a slab keeps it as its own item
+	w = do(http.MethodPut, "/api/collection/"+strconv.FormatUint(uint64(slabs[0].ID), 10), `{"quantity":5,"grading_company":""}`)
+	var cracked models.CollectionUpdateResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &cracked)
+	if w.Code != http.StatusOK || cracked.Item.Quantity != 1 || cracked.Item.IsGraded() || cracked.Item.CertNumber != "" {
+		t.Errorf("expected a raw single card, got %d %+v", w.Code, cracked.Item)
+	}
+}
+
+// testScanImage draws a card-sized JPEG whose blocky pattern depends on seed,
+// standing in for a scanned card
+func testScanImage(t *testing.T, seed int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 252, 352))
+	for y := 0; y < 352; y++ {
+		for x := 0; x < 252; x++ {
+			v := uint8((x/36*seed + y/44*(seed+3)) * 37 % 256)
+			img.Set(x, y, color.RGBA{v, 255 - v, v / 2, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDuplicateUploads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	imageDir := t.TempDir()
+	t.Setenv("SCANNED_IMAGES_DIR", imageDir)
+
+	db.Create(&models.Card{ID: "pkm-1", Name: "Charizard", Game: models.GamePokemon})
+	db.Create(&models.Card{ID: "pkm-2", Name: "Blastoise", Game: models.GamePokemon})
+
+	h := &CollectionHandler{imageStorageService: services.NewImageStorageService()}
+	router := gin.New()
+	router.POST("/api/collection", h.AddToCollection)
+	router.GET("/api/admin/duplicate-uploads", h.GetDuplicateUploads)
+	router.POST("/api/admin/duplicate-uploads/backfill", h.BackfillScanFingerprints)
+
+	first, other := testScanImage(t, 1), testScanImage(t, 5)
+	tests := []struct {
+		name           string
+		body           string
+		code           int
+		wantDuplicates int
+	}{
+		{"first scan", fmt.Sprintf(`{"card_id":"pkm-1","scanned_image_data":%q}`, first), http.StatusCreated, 0},
+		{"same card scanned again", fmt.Sprintf(`{"card_id":"pkm-1","scanned_image_data":%q}`, first), http.StatusCreated, 1},
+		{"rejected when asked", fmt.Sprintf(`{"card_id":"pkm-1","scanned_image_data":%q,"reject_duplicate":true}`, first), http.StatusConflict, 2},
+		{"different card", fmt.Sprintf(`{"card_id":"pkm-1","scanned_image_data":%q,"reject_duplicate":true}`, other), http.StatusCreated, 0},
+		{"same image, other card ID", fmt.Sprintf(`{"card_id":"pkm-2","scanned_image_data":%q,"reject_duplicate":true}`, first), http.StatusCreated, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/collection", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			var resp struct {
+				ScanFingerprint  string                   `json:"scan_fingerprint"`
+				DuplicateUploads []models.DuplicateUpload `json:"duplicate_uploads"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.DuplicateUploads) != tt.wantDuplicates {
+				t.Errorf("expected %d duplicate uploads, got %+v", tt.wantDuplicates, resp.DuplicateUploads)
+			}
+			if tt.code == http.StatusCreated && resp.ScanFingerprint == "" {
+				t.Error("expected the scan to be fingerprinted")
+			}
+		})
+	}
+
+	var count int64
+	db.Model(&models.CollectionItem{}).Count(&count)
+	if count != 4 {
+		t.Errorf("expected the rejected scan not to be added, got %d items", count)
+	}
+
+	// A scan saved before fingerprinting is only counted by the report
+	data, _ := base64.StdEncoding.DecodeString(first)
+	if err := os.WriteFile(filepath.Join(imageDir, "old.jpg"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&models.CollectionItem{CardID: "pkm-1", Quantity: 1, ScannedImagePath: "old.jpg"})
+
+	report := func(wantUnfingerprinted int64, wantScans int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/duplicate-uploads", nil))
+		var report models.DuplicateUploadReport
+		_ = json.Unmarshal(w.Body.Bytes(), &report)
+		if w.Code != http.StatusOK || report.Unfingerprinted != wantUnfingerprinted || len(report.Groups) != 1 ||
+			report.Groups[0].CardID != "pkm-1" || len(report.Groups[0].Scans) != wantScans {
+			t.Fatalf("expected %d unfingerprinted and one pkm-1 group of %d scans, got %d %+v", wantUnfingerprinted, wantScans, w.Code, report)
+		}
+	}
+	report(1, 2)
+	report(1, 2) // Reading the report changes nothing
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/duplicate-uploads/backfill", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"backfilled":1`)) {
+		t.Fatalf("expected one scan backfilled, got %d %s", w.Code, w.Body.String())
+	}
+	report(0, 3)
+}
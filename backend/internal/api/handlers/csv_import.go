@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+const (
+	maxImportFileSize = 20 * 1024 * 1024 // 20MB, plenty for tens of thousands of rows
+	maxImportRows     = 20000
+)
+
+// CSVImportHandler handles CSV/text collection import endpoints
+type CSVImportHandler struct {
+	importService   *services.CSVImportService
+	pokemonService  *services.PokemonHybridService
+	scryfallService *services.ScryfallService
+}
+
+// NewCSVImportHandler creates a new CSV import handler
+func NewCSVImportHandler(importService *services.CSVImportService, pokemon *services.PokemonHybridService, scryfall *services.ScryfallService) *CSVImportHandler {
+	return &CSVImportHandler{
+		importService:   importService,
+		pokemonService:  pokemon,
+		scryfallService: scryfall,
+	}
+}
+
+// CreateJob parses an uploaded collection file and starts resolving its rows
+// POST /api/csv-import/jobs
+//
+// Multipart form fields:
+// - file: the CSV or text file
+// - format: "manabox", "dragonshield", "tcgplayer", "deckbox", "generic", "text" (detected if omitted)
+// - game: "pokemon" or "mtg", for formats that do not say which game a row is
+func (h *CSVImportHandler) CreateJob(c *gin.Context) {
+	format, ok := services.ParseImportFormat(c.PostForm("format"))
+	if !ok {
+		formats := make([]string, 0, len(services.AllImportFormats()))
+		for _, f := range services.AllImportFormats() {
+			formats = append(formats, string(f))
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, must be one of: " + strings.Join(formats, ", ")})
+		return
+	}
+
+	game := models.Game(strings.ToLower(c.PostForm("game")))
+	if game != "" && game != models.GameMTG && game != models.GamePokemon {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game, must be 'pokemon' or 'mtg'"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no file uploaded"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file too large (max %dMB)", maxImportFileSize/(1024*1024))})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer file.Close()
+
+	detected, rows, parseErrors, err := services.ParseImportFile(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse file: " + err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no rows found in file", "errors": parseErrors})
+		return
+	}
+	if len(rows) > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many rows: maximum is %d", maxImportRows)})
+		return
+	}
+
+	job, err := h.importService.CreateJob(detected, fileHeader.Filename, game, rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create job: " + err.Error()})
+		return
+	}
+
+	// Resolving can take minutes for large MTG files (Scryfall rate limit)
+	go h.importService.ProcessJob(job.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"job_id":      job.ID,
+		"format":      detected,
+		"total_items": job.TotalItems,
+		"status":      job.Status,
+		"errors":      parseErrors,
+	})
+}
+
+// GetJob retrieves job status and items
+// GET /api/csv-import/jobs/:id
+func (h *CSVImportHandler) GetJob(c *gin.Context) {
+	job, err := h.importService.GetJob(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	// Only parse candidates here; loading every card would be slow for large files
+	for i := range job.Items {
+		item := &job.Items[i]
+		if item.Candidates != "" && item.Candidates != "[]" {
+			var candidates []models.Card
+			if err := json.Unmarshal([]byte(item.Candidates), &candidates); err == nil {
+				item.CandidateList = candidates
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// UpdateItem updates an import item (card selection, quantity, condition, etc.)
+// PUT /api/csv-import/jobs/:id/items/:itemId
+func (h *CSVImportHandler) UpdateItem(c *gin.Context) {
+	jobID := c.Param("id")
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item ID"})
+		return
+	}
+
+	item, err := h.importService.GetItem(uint(itemID))
+	if err != nil || item.JobID != jobID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found in job"})
+		return
+	}
+
+	var req models.UpdateCSVImportItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.CardID != nil {
+		card := loadCardFromServices(h.pokemonService, h.scryfallService, *req.CardID, "")
+		if card == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card not found"})
+			return
+		}
+		updates["card_id"] = card.ID
+		updates["card_name"] = card.Name
+		updates["set_code"] = card.SetCode
+		updates["set_name"] = card.SetName
+		updates["card_number"] = card.CardNumber
+		updates["game"] = string(card.Game)
+		// Picking a card resolves an ambiguous or failed row
+		updates["status"] = models.CSVImportItemIdentified
+		updates["error_message"] = ""
+	}
+
+	if req.Quantity != nil {
+		if *req.Quantity < 1 || *req.Quantity > maxQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("quantity must be between 1 and %d", maxQuantity)})
+			return
+		}
+		updates["quantity"] = *req.Quantity
+	}
+
+	if req.Condition != nil || req.PrintingType != nil {
+		condition, printing := item.Condition, item.PrintingType
+		if req.Condition != nil {
+			condition = *req.Condition
+		}
+		if req.PrintingType != nil {
+			printing = *req.PrintingType
+		}
+		if condition == "" {
+			condition = models.ConditionNearMint
+		}
+		if printing == "" {
+			printing = models.PrintingNormal
+		}
+		if msg := validateConditionAndPrinting(condition, printing); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		updates["condition"] = condition
+		updates["printing_type"] = printing
+	}
+
+	if req.Language != nil {
+		updates["language"] = models.NormalizeLanguage(string(*req.Language))
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no updates provided"})
+		return
+	}
+
+	if err := h.importService.UpdateItem(uint(itemID), updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update item"})
+		return
+	}
+
+	item, err = h.importService.GetItem(uint(itemID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated item"})
+		return
+	}
+	if item.CardID != "" {
+		item.Card = loadCardFromServices(h.pokemonService, h.scryfallService, item.CardID, item.Game)
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ConfirmJob adds reviewed items to the collection once every row has been matched.
+// Rows merge into existing non-scanned stacks with the same card, condition,
+// printing and language.
+// POST /api/csv-import/jobs/:id/confirm
+func (h *CSVImportHandler) ConfirmJob(c *gin.Context) {
+	job, err := h.importService.GetJob(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if job.Status != models.BulkImportStatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("job is %s; rows can be confirmed once matching has completed", job.Status)})
+		return
+	}
+
+	var req models.ConfirmCSVImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Allow empty body (confirm all)
+		req = models.ConfirmCSVImportRequest{}
+	}
+
+	// Determine which items to confirm. Explicitly listed ambiguous items are
+	// accepted with their suggested card; "confirm all" only takes identified rows.
+	var itemsToConfirm []models.CSVImportItem
+	if len(req.ItemIDs) > 0 {
+		itemIDSet := make(map[uint]bool)
+		for _, id := range req.ItemIDs {
+			itemIDSet[id] = true
+		}
+		for _, item := range job.Items {
+			if !itemIDSet[item.ID] || item.CardID == "" {
+				continue
+			}
+			if item.Status == models.CSVImportItemIdentified || item.Status == models.CSVImportItemAmbiguous {
+				itemsToConfirm = append(itemsToConfirm, item)
+			}
+		}
+	} else {
+		for _, item := range job.Items {
+			if item.Status == models.CSVImportItemIdentified && item.CardID != "" {
+				itemsToConfirm = append(itemsToConfirm, item)
+			}
+		}
+	}
+
+	if len(itemsToConfirm) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no items to confirm"})
+		return
+	}
+
+	db := database.GetDB()
+	added := 0
+	skipped := 0
+	var errors []string
+	cachedCards := make(map[string]bool)
+
+	for _, item := range itemsToConfirm {
+		// Ensure the card exists in the database so prices can be tracked
+		if !cachedCards[item.CardID] {
+			var existingCard models.Card
+			if err := db.First(&existingCard, "id = ?", item.CardID).Error; err != nil {
+				card := loadCardFromServices(h.pokemonService, h.scryfallService, item.CardID, item.Game)
+				if card == nil {
+					errors = append(errors, fmt.Sprintf("Row %d: card %s not found", item.RowNumber, item.CardID))
+					skipped++
+					continue
+				}
+				if err := db.Save(card).Error; err != nil {
+					errors = append(errors, fmt.Sprintf("Row %d: failed to cache card %s: %v", item.RowNumber, item.CardID, err))
+					skipped++
+					continue
+				}
+			}
+			cachedCards[item.CardID] = true
+		}
+
+		condition := item.Condition
+		if condition == "" {
+			condition = models.ConditionNearMint
+		}
+		printing := item.PrintingType
+		if printing == "" {
+			printing = models.PrintingNormal
+		}
+		language := models.NormalizeLanguage(string(item.Language))
+		quantity := item.Quantity
+		if quantity < 1 {
+			quantity = 1
+		}
+		acquisitionSource := "import:" + job.Format
+
+		var existingItem models.CollectionItem
+		// Imported rows have no storage location, so only merge into unassigned stacks
+		err := whereSameCostBasis(whereSameStorageLocation(db, nil), item.PurchasePrice).
+			Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND "+mergeableStackSQL,
+				item.CardID, condition, printing, language).
+			First(&existingItem).Error
+
+		if err == nil && existingItem.Quantity+quantity <= maxQuantity {
+			mergeCostBasisInto(&existingItem, &models.CollectionItem{
+				PurchasePrice:     item.PurchasePrice,
+				PurchaseDate:      item.PurchaseDate,
+				AcquisitionSource: acquisitionSource,
+			}, quantity)
+			existingItem.Quantity += quantity
+			err = db.Save(&existingItem).Error
+		} else {
+			err = db.Create(&models.CollectionItem{
+				CardID:            item.CardID,
+				Quantity:          quantity,
+				Condition:         condition,
+				Printing:          printing,
+				Language:          language,
+				Notes:             item.Notes,
+				AddedAt:           time.Now(),
+				PurchasePrice:     item.PurchasePrice,
+				PurchaseDate:      item.PurchaseDate,
+				AcquisitionSource: acquisitionSource,
+			}).Error
+		}
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Row %d: failed to add %s: %v", item.RowNumber, item.CardName, err))
+			skipped++
+			continue
+		}
+
+		// Best-effort: the card is in the collection even if the status update fails
+		_ = h.importService.UpdateItem(item.ID, map[string]interface{}{
+			"status": models.CSVImportItemConfirmed,
+		})
+
+		added++
+	}
+
+	c.JSON(http.StatusOK, models.ConfirmBulkImportResponse{
+		Added:   added,
+		Skipped: skipped,
+		Errors:  errors,
+	})
+}
+
+// DeleteJob deletes an import job and its staged items
+// DELETE /api/csv-import/jobs/:id
+func (h *CSVImportHandler) DeleteJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	if _, err := h.importService.GetJob(jobID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if err := h.importService.DeleteJob(jobID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "job deleted"})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+func TestCSVImportConfirmJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	db.Create(&models.Card{ID: "mtg-2", Name: "Shock", Game: models.GameMTG, PriceUSD: 1})
+	existing := models.CollectionItem{CardID: "mtg-1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(2)}
+	db.Create(&existing)
+
+	importService := services.NewCSVImportService(db, nil, nil)
+	job, err := importService.CreateJob(services.ImportFormatManaBox, "export.csv", "", []services.ImportRow{
+		{RowNumber: 2, Name: "Bolt", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(4)},
+		{RowNumber: 3, Name: "Shock", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingFoil, Language: models.LanguageEnglish},
+		{RowNumber: 4, Name: "Shock?", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+	})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	// Simulate resolution: one identified row per card, one ambiguous row with a suggestion
+	staged, _ := importService.GetJob(job.ID)
+	statuses := []models.CSVImportItemStatus{models.CSVImportItemIdentified, models.CSVImportItemIdentified, models.CSVImportItemAmbiguous}
+	cardIDs := []string{"mtg-1", "mtg-2", "mtg-2"}
+	for i, item := range staged.Items {
+		_ = importService.UpdateItem(item.ID, map[string]interface{}{"status": statuses[i], "card_id": cardIDs[i]})
+	}
+
+	h := NewCSVImportHandler(importService, nil, nil)
+	router := gin.New()
+	router.POST("/api/csv-import/jobs/:id/confirm", h.ConfirmJob)
+
+	confirm := func(body string) models.ConfirmBulkImportResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/csv-import/jobs/"+job.ID+"/confirm", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.ConfirmBulkImportResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Rows can't be confirmed while matching is still running
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/csv-import/jobs/"+job.ID+"/confirm", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an unfinished job, got %d: %s", w.Code, w.Body.String())
+	}
+	db.Model(&models.CSVImportJob{}).Where("id = ?", job.ID).Update("status", models.BulkImportStatusCompleted)
+
+	// Confirm all only takes identified rows
+	if resp := confirm(""); resp.Added != 2 {
+		t.Fatalf("expected 2 rows added, got %+v", resp)
+	}
+
+	var merged models.CollectionItem
+	db.First(&merged, existing.ID)
+	if merged.Quantity != 4 || merged.PurchasePrice == nil || !almostEqual(*merged.PurchasePrice, 3) {
+		t.Errorf("expected merged stack qty 4 at $3, got qty %d price %v", merged.Quantity, merged.PurchasePrice)
+	}
+
+	// The ambiguous row is accepted when listed explicitly
+	ambiguousID := staged.Items[2].ID
+	if resp := confirm(fmt.Sprintf(`{"item_ids":[%d]}`, ambiguousID)); resp.Added != 1 {
+		t.Fatalf("expected ambiguous row to be added, got %+v", resp)
+	}
+
+	var shockCount int64
+	db.Model(&models.CollectionItem{}).Where("card_id = ?", "mtg-2").Count(&shockCount)
+	if shockCount != 2 {
+		t.Errorf("expected separate foil and normal Shock stacks, got %d items", shockCount)
+	}
+
+	final, _ := importService.GetJob(job.ID)
+	for _, item := range final.Items {
+		if item.Status != models.CSVImportItemConfirmed {
+			t.Errorf("row %d status = %s, want confirmed", item.RowNumber, item.Status)
+		}
+	}
+}
+
+func TestCSVImportUpdateItemValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	importService := services.NewCSVImportService(db, nil, nil)
+	job, err := importService.CreateJob(services.ImportFormatText, "cards.txt", "", []services.ImportRow{
+		{RowNumber: 1, Name: "Bolt", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+	})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	staged, _ := importService.GetJob(job.ID)
+	path := fmt.Sprintf("/api/csv-import/jobs/%s/items/%d", job.ID, staged.Items[0].ID)
+
+	h := NewCSVImportHandler(importService, nil, nil)
+	router := gin.New()
+	router.PUT("/api/csv-import/jobs/:id/items/:itemId", h.UpdateItem)
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"valid condition and printing", `{"condition":"LP","printing_type":"Foil"}`, http.StatusOK},
+		{"unknown condition", `{"condition":"Mint-ish"}`, http.StatusBadRequest},
+		{"unknown printing", `{"printing_type":"Holographic"}`, http.StatusBadRequest},
+		{"quantity over the maximum", `{"quantity":10000}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	item, _ := importService.GetItem(staged.Items[0].ID)
+	if item.Condition != models.ConditionLightPlay || item.PrintingType != models.PrintingFoil || item.Quantity != 1 {
+		t.Errorf("expected only the valid update to be stored, got %s/%s qty %d", item.Condition, item.PrintingType, item.Quantity)
+	}
+}
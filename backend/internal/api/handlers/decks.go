@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// maxDeckLines caps the distinct lines of a deck; a 100-card Commander deck with a
+// sideboard and maybeboard stays far below it
+const maxDeckLines = 500
+
+// DeckHandler handles deck and decklist endpoints
+type DeckHandler struct {
+	deckService *services.DeckService
+}
+
+// NewDeckHandler creates a new deck handler
+func NewDeckHandler(pokemonService *services.PokemonHybridService, scryfallService *services.ScryfallService) *DeckHandler {
+	return &DeckHandler{deckService: services.NewDeckService(pokemonService, scryfallService)}
+}
+
+// GetDecks returns all decks with their card counts, without their cards
+// Query params: game (mtg|pokemon)
+// GET /api/decks
+func (h *DeckHandler) GetDecks(c *gin.Context) {
+	db := database.GetDB()
+	query := db.Order("LOWER(name) ASC")
+	if game := c.Query("game"); game != "" {
+		query = query.Where("game = ?", game)
+	}
+
+	var decks []models.Deck
+	if err := query.Find(&decks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var counts []struct {
+		DeckID    uint
+		CardCount int
+	}
+	db.Model(&models.DeckCard{}).
+		Select("deck_id, SUM(quantity) as card_count").
+		Group("deck_id").
+		Scan(&counts)
+	byDeck := make(map[uint]int, len(counts))
+	for _, count := range counts {
+		byDeck[count.DeckID] = count.CardCount
+	}
+	for i := range decks {
+		decks[i].CardCount = byDeck[decks[i].ID]
+	}
+
+	c.JSON(http.StatusOK, decks)
+}
+
+// GetDeck returns a deck with its cards
+// GET /api/decks/:id
+func (h *DeckHandler) GetDeck(c *gin.Context) {
+	deck, ok := loadDeck(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, deck)
+}
+
+// CreateDeck creates a deck, importing its cards from an optional decklist
+// POST /api/decks
+func (h *DeckHandler) CreateDeck(c *gin.Context) {
+	var req models.CreateDeckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Game != models.GameMTG && req.Game != models.GamePokemon {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game, must be 'pokemon' or 'mtg'"})
+		return
+	}
+
+	deck := models.Deck{
+		Name:   strings.TrimSpace(req.Name),
+		Game:   req.Game,
+		Format: strings.TrimSpace(req.Format),
+		Notes:  req.Notes,
+	}
+	if strings.TrimSpace(req.Decklist) != "" {
+		list, ok := parseDecklist(c, deck.Game, req.Decklist)
+		if !ok {
+			return
+		}
+		if deck.Name == "" {
+			deck.Name = list.Name
+		}
+		deck.Cards = list.Cards
+		deck.ImportErrors = list.Errors
+	}
+	if deck.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	db := database.GetDB()
+	h.deckService.ResolveCards(db, deck.Game, deck.Cards)
+	if err := db.Create(&deck).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	deck.CardCount = deckCardCount(deck.Cards)
+	c.JSON(http.StatusCreated, deck)
+}
+
+// UpdateDeck renames a deck or changes its format or notes
+// PUT /api/decks/:id
+func (h *DeckHandler) UpdateDeck(c *gin.Context) {
+	var req models.UpdateDeckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deck, ok := loadDeck(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+			return
+		}
+		updates["name"] = name
+		deck.Name = name
+	}
+	if req.Format != nil {
+		updates["format"] = strings.TrimSpace(*req.Format)
+		deck.Format = strings.TrimSpace(*req.Format)
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+		deck.Notes = *req.Notes
+	}
+
+	if len(updates) > 0 {
+		if err := database.GetDB().Model(&models.Deck{}).Where("id = ?", deck.ID).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, deck)
+}
+
+// DeleteDeck deletes a deck and its cards. The collection is not touched.
+// DELETE /api/decks/:id
+func (h *DeckHandler) DeleteDeck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("deck_id = ?", id).Delete(&models.DeckCard{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Deck{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// ImportDecklist replaces a deck's cards with a decklist (MTG Arena/MTGO text or
+// Pokemon TCG Live export), or adds its cards to the deck with append
+// POST /api/decks/:id/import
+func (h *DeckHandler) ImportDecklist(c *gin.Context) {
+	var req models.ImportDecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deck, ok := loadDeck(c)
+	if !ok {
+		return
+	}
+	list, ok := parseDecklist(c, deck.Game, req.Decklist)
+	if !ok {
+		return
+	}
+
+	cards := list.Cards
+	if req.Append {
+		cards = mergeDeckCards(deck.Cards, list.Cards)
+		if len(cards) > maxDeckLines {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "deck has too many lines (max " + strconv.Itoa(maxDeckLines) + ")"})
+			return
+		}
+	}
+	for i := range cards {
+		cards[i].ID = 0
+		cards[i].DeckID = deck.ID
+	}
+
+	db := database.GetDB()
+	h.deckService.ResolveCards(db, deck.Game, cards)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("deck_id = ?", deck.ID).Delete(&models.DeckCard{}).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(cards, 100).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Deck{}).Where("id = ?", deck.ID).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	deck.Cards = cards
+	deck.CardCount = deckCardCount(cards)
+	deck.ImportErrors = list.Errors
+	c.JSON(http.StatusOK, deck)
+}
+
+// GetDeckOwnership compares a deck with the collection: how many copies of each card
+// are owned (any printing, condition or language), which are missing, and what the
+// missing cards cost at their cheapest cached NM price
+// GET /api/decks/:id/ownership
+func (h *DeckHandler) GetDeckOwnership(c *gin.Context) {
+	deck, ok := loadDeck(c)
+	if !ok {
+		return
+	}
+
+	resp, err := services.DeckOwnership(database.GetDB(), deck)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// loadDeck loads the deck of the :id parameter with its cards, writing the error
+// response if it can't
+func loadDeck(c *gin.Context) (*models.Deck, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	var deck models.Deck
+	err = database.GetDB().
+		Preload("Cards", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&deck, id).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "deck not found"})
+		return nil, false
+	}
+	deck.CardCount = deckCardCount(deck.Cards)
+	return &deck, true
+}
+
+// parseDecklist parses a request's decklist, writing the error response if it has
+// no cards or too many lines
+func parseDecklist(c *gin.Context, game models.Game, text string) (services.Decklist, bool) {
+	list := services.ParseDecklist(game, text)
+	if len(list.Cards) == 0 {
+		resp := gin.H{"error": "no cards found in decklist"}
+		if len(list.Errors) > 0 {
+			resp["import_errors"] = list.Errors
+		}
+		c.JSON(http.StatusBadRequest, resp)
+		return list, false
+	}
+	if len(list.Cards) > maxDeckLines {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "decklist has too many lines (max " + strconv.Itoa(maxDeckLines) + ")"})
+		return list, false
+	}
+	return list, true
+}
+
+// mergeDeckCards adds the added lines to a deck's existing ones, summing quantities
+// of the same card in the same section
+func mergeDeckCards(existing, added []models.DeckCard) []models.DeckCard {
+	merged := append([]models.DeckCard(nil), existing...)
+	for _, card := range added {
+		found := false
+		for i := range merged {
+			if merged[i].Section == card.Section && strings.EqualFold(merged[i].CardName, card.CardName) &&
+				strings.EqualFold(merged[i].SetCode, card.SetCode) && merged[i].CollectorNumber == card.CollectorNumber {
+				merged[i].Quantity += card.Quantity
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, card)
+		}
+	}
+	return merged
+}
+
+func deckCardCount(cards []models.DeckCard) int {
+	count := 0
+	for _, card := range cards {
+		count += card.Quantity
+	}
+	return count
+}
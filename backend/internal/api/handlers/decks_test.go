@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestDeckEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Lightning Bolt", Game: models.GameMTG, SetCode: "sta", CardNumber: "42", PriceUSD: 3})
+	db.Create(&models.Card{ID: "mtg-2", Name: "Counterspell", Game: models.GameMTG, PriceUSD: 1})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+
+	h := NewDeckHandler(nil, nil)
+	router := gin.New()
+	router.GET("/api/decks", h.GetDecks)
+	router.GET("/api/decks/:id", h.GetDeck)
+	router.GET("/api/decks/:id/ownership", h.GetDeckOwnership)
+	router.POST("/api/decks", h.CreateDeck)
+	router.PUT("/api/decks/:id", h.UpdateDeck)
+	router.DELETE("/api/decks/:id", h.DeleteDeck)
+	router.POST("/api/decks/:id/import", h.ImportDecklist)
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, into interface{}) {
+		t.Helper()
+		if err := json.Unmarshal(w.Body.Bytes(), into); err != nil {
+			t.Fatalf("failed to decode %s: %v", w.Body.String(), err)
+		}
+	}
+
+	// The deck is named by the Arena "About" section
+	w := do(http.MethodPost, "/api/decks", gin.H{
+		"game":     "mtg",
+		"decklist": "About\nName Izzet\n\nDeck\n4 Lightning Bolt (STA) 42\n2 Counterspell\nnot a card\n",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var deck models.Deck
+	decode(w, &deck)
+	if deck.Name != "Izzet" || deck.CardCount != 6 || len(deck.Cards) != 2 || len(deck.ImportErrors) != 1 {
+		t.Errorf("unexpected deck: %+v", deck)
+	}
+	if deck.Cards[0].CardID != "mtg-1" || deck.Cards[1].CardID != "mtg-2" {
+		t.Errorf("cards not resolved to cached cards: %+v", deck.Cards)
+	}
+
+	for _, body := range []gin.H{
+		{"game": "mtg"}, // No name
+		{"name": "X", "game": "yugioh"},
+		{"name": "X", "game": "mtg", "decklist": "Deck\nnothing here\n"},
+	} {
+		if w := do(http.MethodPost, "/api/decks", body); w.Code != http.StatusBadRequest {
+			t.Errorf("create %v: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	ownership := func() models.DeckOwnership {
+		t.Helper()
+		w := do(http.MethodGet, fmt.Sprintf("/api/decks/%d/ownership", deck.ID), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ownership: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.DeckOwnership
+		decode(w, &resp)
+		return resp
+	}
+	owned := ownership()
+	if owned.OwnedCards != 3 || owned.MissingCards != 3 || len(owned.Missing) != 2 || !almostEqual(owned.CostToComplete, 3+2) {
+		t.Errorf("unexpected ownership: %+v", owned)
+	}
+
+	// Appending merges the same card; a sideboard copy competes for the same owned copies
+	w = do(http.MethodPost, fmt.Sprintf("/api/decks/%d/import", deck.ID), gin.H{
+		"decklist": "Deck\n1 Counterspell\n\nSideboard\n1 Lightning Bolt\n",
+		"append":   true,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("append: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	owned = ownership()
+	if owned.TotalCards != 8 || owned.OwnedCards != 3 || len(owned.Cards) != 3 || owned.Cards[1].Quantity != 3 {
+		t.Errorf("unexpected ownership after append: %+v", owned)
+	}
+
+	// Replacing the list
+	w = do(http.MethodPost, fmt.Sprintf("/api/decks/%d/import", deck.ID), gin.H{"decklist": "3 Lightning Bolt"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("replace: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if owned = ownership(); !owned.Complete || owned.TotalCards != 3 {
+		t.Errorf("expected a complete deck, got %+v", owned)
+	}
+
+	if w := do(http.MethodPut, fmt.Sprintf("/api/decks/%d", deck.ID), gin.H{"name": "Burn", "format": "modern"}); w.Code != http.StatusOK {
+		t.Errorf("update: expected 200, got %d", w.Code)
+	}
+	w = do(http.MethodGet, "/api/decks?game=mtg", nil)
+	var decks []models.Deck
+	decode(w, &decks)
+	if len(decks) != 1 || decks[0].Name != "Burn" || decks[0].Format != "modern" || decks[0].CardCount != 3 || len(decks[0].Cards) != 0 {
+		t.Errorf("unexpected deck list: %+v", decks)
+	}
+
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/decks/%d", deck.ID), nil); w.Code != http.StatusOK {
+		t.Errorf("delete: expected 200, got %d", w.Code)
+	}
+	var cards int64
+	db.Model(&models.DeckCard{}).Count(&cards)
+	if cards != 0 {
+		t.Errorf("expected deck cards to be deleted, %d left", cards)
+	}
+	if w := do(http.MethodGet, fmt.Sprintf("/api/decks/%d", deck.ID), nil); w.Code != http.StatusNotFound {
+		t.Errorf("get deleted deck: expected 404, got %d", w.Code)
+	}
+}
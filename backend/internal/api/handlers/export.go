@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// exportBatchSize is how many collection items are loaded per query while streaming an export
+const exportBatchSize = 500
+
+// ExportCollection streams the collection as CSV
+// GET /api/collection/export
+//
+// Query parameters:
+// - format: "generic" (default), "tcgplayer", "cardmarket", "moxfield", "deckbox"
+// - game: filter by game ("pokemon" or "mtg")
+func (h *CollectionHandler) ExportCollection(c *gin.Context) {
+	format, ok := services.ParseExportFormat(c.Query("format"))
+	if !ok {
+		formats := make([]string, 0, len(services.AllExportFormats()))
+		for _, f := range services.AllExportFormats() {
+			formats = append(formats, string(f))
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, must be one of: " + strings.Join(formats, ", ")})
+		return
+	}
+
+	db := database.GetDB()
+	query := db.Model(&models.CollectionItem{}).Preload("Card").Preload("Card.Prices").Preload("Card.GradedPrices")
+	if game := c.Query("game"); game != "" {
+		query = query.Joins("JOIN cards ON cards.id = collection_items.card_id").
+			Where("cards.game = ?", game)
+	}
+
+	filename := fmt.Sprintf("collection-%s-%s.csv", format, time.Now().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	writer, err := services.NewCollectionCSVWriter(c.Writer, format)
+	if err != nil {
+		log.Printf("Export: failed to write header: %v", err)
+		return
+	}
+
+	// Headers are already sent, so errors past this point can only be logged
+	var items []models.CollectionItem
+	result := query.FindInBatches(&items, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range items {
+			// Japanese cards are not in the database; load them from the pokemon service
+			if items[i].Card.Name == "" && h.pokemonService != nil {
+				if card, err := h.pokemonService.GetCard(items[i].CardID); err == nil && card != nil {
+					items[i].Card = *card
+				}
+			}
+			if err := writer.Write(&items[i]); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+	if result.Error != nil {
+		log.Printf("Export: failed to stream %s export: %v", format, result.Error)
+		return
+	}
+	if err := writer.Flush(); err != nil {
+		log.Printf("Export: failed to flush %s export: %v", format, err)
+		return
+	}
+	if skipped := writer.Skipped(); skipped > 0 {
+		log.Printf("Export: %s export skipped %d items from unsupported games", format, skipped)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestExportCollection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	db.Create(&models.Card{ID: "pkm-1", Name: "Pikachu", Game: models.GamePokemon, PriceUSD: 4})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+	db.Create(&models.CollectionItem{CardID: "pkm-1", Quantity: 1, Condition: models.ConditionLightPlay, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.GET("/api/collection/export", h.ExportCollection)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantRows   int
+	}{
+		{"default generic", "", http.StatusOK, 2},
+		{"game filter", "?format=tcgplayer&game=pokemon", http.StatusOK, 1},
+		{"mtg only format", "?format=moxfield", http.StatusOK, 1},
+		{"unknown format", "?format=xml", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/collection/export"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+				t.Errorf("Content-Type = %q, want text/csv", ct)
+			}
+			records, err := csv.NewReader(w.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("failed to parse CSV: %v", err)
+			}
+			if len(records)-1 != tt.wantRows {
+				t.Errorf("got %d data rows, want %d", len(records)-1, tt.wantRows)
+			}
+		})
+	}
+}
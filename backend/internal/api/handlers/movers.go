@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// maxMovers caps each top movers ranking
+const maxMovers = 100
+
+// GetMovers returns the collection's biggest gainers and losers over a period, by game
+// Query params: period (7d|30d, default 7d), limit (default 10)
+// GET /api/collection/movers
+func (h *CollectionHandler) GetMovers(c *gin.Context) {
+	period := c.DefaultQuery("period", "7d")
+	since, ok := services.MoversPeriodStart(period, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be '7d' or '30d'"})
+		return
+	}
+
+	limit := 10
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxMovers)
+	}
+
+	resp, err := services.CalculateMovers(database.GetDB(), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp.Period = period
+
+	c.JSON(http.StatusOK, resp)
+}
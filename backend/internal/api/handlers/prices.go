@@ -93,3 +93,132 @@ func (h *PriceHandler) GetCardPrices(c *gin.Context) {
 		"refresh_queued": needsRefresh,
 	})
 }
+
+// GetCardPriceHistory returns the recorded price time series for a card
+// GET /api/cards/:id/prices/history?condition=&printing=&language=&period=
+func (h *PriceHandler) GetCardPriceHistory(c *gin.Context) {
+	cardID := c.Param("id")
+
+	if cardID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "card id is required"})
+		return
+	}
+
+	db := database.GetDB()
+
+	var card models.Card
+	if err := db.First(&card, "id = ?", cardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "card not found"})
+		return
+	}
+
+	var filter services.PriceHistoryFilter
+	if condition := c.Query("condition"); condition != "" {
+		filter.Condition = models.PriceCondition(condition)
+		if !isValidPriceCondition(filter.Condition) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid condition"})
+			return
+		}
+	}
+	if printing := c.Query("printing"); printing != "" {
+		filter.Printing = models.PrintingType(printing)
+		if !isValidPrinting(filter.Printing) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid printing"})
+			return
+		}
+	}
+	if language := c.Query("language"); language != "" {
+		filter.Language = models.NormalizeLanguage(language)
+	}
+
+	period := c.DefaultQuery("period", "month")
+
+	series, err := h.priceService.GetPriceHistory(cardID, filter, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PriceHistoryResponse{
+		CardID: cardID,
+		Period: period,
+		Series: series,
+	})
+}
+
+// GetCardGradedPrices returns the stored slab prices for a card, by company and grade
+// GET /api/cards/:id/graded-prices
+func (h *PriceHandler) GetCardGradedPrices(c *gin.Context) {
+	prices, err := h.priceService.GetGradedPrices(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, prices)
+}
+
+// SetCardGradedPrice records the price of a slab of a card at one company and grade.
+// No provider supplies graded prices, so they are entered by hand; a price of 0 removes one.
+// PUT /api/cards/:id/graded-prices
+func (h *PriceHandler) SetCardGradedPrice(c *gin.Context) {
+	var req models.SetGradedPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := normalizeGrading(&req.GradingCompany, &req.Grade); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.PriceUSD < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price must not be negative"})
+		return
+	}
+
+	cardID := c.Param("id")
+	var card models.Card
+	if err := database.GetDB().First(&card, "id = ?", cardID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "card not found"})
+		return
+	}
+
+	if req.PriceUSD == 0 {
+		if err := h.priceService.DeleteGradedPrice(card.ID, req.GradingCompany, req.Grade); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+		return
+	}
+
+	price := models.GradedPrice{
+		CardID:         card.ID,
+		GradingCompany: req.GradingCompany,
+		Grade:          req.Grade,
+		PriceUSD:       req.PriceUSD,
+		Source:         models.GradedPriceSourceManual,
+	}
+	if err := h.priceService.SaveGradedPrice(&price); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+func isValidPriceCondition(condition models.PriceCondition) bool {
+	for _, c := range models.AllPriceConditions() {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidPrinting(printing models.PrintingType) bool {
+	for _, p := range models.AllPrintingTypes() {
+		if p == printing {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// SellCollectionItem moves some or all of a collection item into the sales ledger
+// POST /api/collection/:id/sell
+func (h *CollectionHandler) SellCollectionItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.SellCollectionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.SalePrice == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sale_price is required"})
+		return
+	}
+	if *req.SalePrice < 0 || req.Fees < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sale_price and fees cannot be negative"})
+		return
+	}
+
+	db := database.GetDB()
+
+	var item models.CollectionItem
+	if err := db.Preload("Card").First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+
+	// Japanese cards may not be in the database; fall back to the pokemon service for name/game
+	if item.Card.Name == "" && h.pokemonService != nil {
+		if card, err := h.pokemonService.GetCard(item.CardID); err == nil && card != nil {
+			item.Card = *card
+		}
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = item.Quantity
+	}
+	if quantity < 1 || quantity > item.Quantity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("quantity must be between 1 and %d", item.Quantity)})
+		return
+	}
+
+	soldAt := time.Now()
+	if req.SoldAt != nil {
+		soldAt = *req.SoldAt
+	}
+
+	sale := models.CollectionSale{
+		CollectionItemID:  item.ID,
+		CardID:            item.CardID,
+		CardName:          item.Card.Name,
+		Game:              item.Card.Game,
+		Quantity:          quantity,
+		Condition:         item.Condition,
+		Printing:          item.Printing,
+		Language:          item.Language,
+		ScannedImagePath:  item.ScannedImagePath,
+		SalePrice:         *req.SalePrice,
+		Fees:              req.Fees,
+		Channel:           req.Channel,
+		SoldAt:            soldAt,
+		Notes:             req.Notes,
+		PurchasePrice:     item.PurchasePrice,
+		PurchaseDate:      item.PurchaseDate,
+		AcquisitionSource: item.AcquisitionSource,
+	}
+
+	fullySold := quantity == item.Quantity
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sale).Error; err != nil {
+			return err
+		}
+		if fullySold {
+			if err := deleteItemTags(tx, item.ID); err != nil {
+				return err
+			}
+			return tx.Delete(&models.CollectionItem{}, item.ID).Error
+		}
+		return tx.Model(&item).Update("quantity", item.Quantity-quantity).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sale.CalculateRealized()
+
+	if fullySold {
+		c.JSON(http.StatusOK, models.SellCollectionItemResponse{
+			Sale:      sale,
+			Operation: "sold",
+		})
+		return
+	}
+
+	item.Quantity -= quantity
+	c.JSON(http.StatusOK, models.SellCollectionItemResponse{
+		Sale:      sale,
+		Item:      &item,
+		Operation: "partial",
+	})
+}
+
+// GetSales returns the sales ledger, most recent first
+// GET /api/collection/sales
+//
+// Query parameters:
+// - game: filter by game ("pokemon" or "mtg")
+// - channel: filter by sales channel
+func (h *CollectionHandler) GetSales(c *gin.Context) {
+	db := database.GetDB()
+
+	query := db.Order("sold_at DESC, id DESC")
+	if game := c.Query("game"); game != "" {
+		query = query.Where("game = ?", game)
+	}
+	if channel := c.Query("channel"); channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	var sales []models.CollectionSale
+	if err := query.Find(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range sales {
+		sales[i].CalculateRealized()
+	}
+
+	c.JSON(http.StatusOK, sales)
+}
+
+// GetSalesReport returns realized profit and loss grouped by month and by game
+// GET /api/collection/sales/report
+//
+// Query parameters:
+// - year: only include sales from this calendar year
+func (h *CollectionHandler) GetSalesReport(c *gin.Context) {
+	db := database.GetDB()
+
+	query := db.Order("sold_at ASC")
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+			return
+		}
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+		query = query.Where("sold_at >= ? AND sold_at < ?", start, start.AddDate(1, 0, 0))
+	}
+
+	var sales []models.CollectionSale
+	if err := query.Find(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildRealizedPnLReport(sales))
+}
+
+// buildRealizedPnLReport aggregates sales into month and game buckets plus overall totals
+func buildRealizedPnLReport(sales []models.CollectionSale) models.RealizedPnLReport {
+	byMonth := make(map[string]*models.RealizedPnLRow)
+	byGame := make(map[string]*models.RealizedPnLRow)
+	report := models.RealizedPnLReport{
+		ByMonth: []models.RealizedPnLRow{},
+		ByGame:  []models.RealizedPnLRow{},
+		Totals:  models.RealizedPnLRow{Key: "total"},
+	}
+
+	for i := range sales {
+		sale := &sales[i]
+		sale.CalculateRealized()
+
+		month := sale.SoldAt.Local().Format("2006-01")
+		if byMonth[month] == nil {
+			byMonth[month] = &models.RealizedPnLRow{Key: month}
+		}
+		byMonth[month].Add(sale)
+
+		game := string(sale.Game)
+		if game == "" {
+			game = "unknown"
+		}
+		if byGame[game] == nil {
+			byGame[game] = &models.RealizedPnLRow{Key: game}
+		}
+		byGame[game].Add(sale)
+
+		report.Totals.Add(sale)
+	}
+
+	for _, row := range byMonth {
+		report.ByMonth = append(report.ByMonth, *row)
+	}
+	for _, row := range byGame {
+		report.ByGame = append(report.ByGame, *row)
+	}
+	sort.Slice(report.ByMonth, func(i, j int) bool { return report.ByMonth[i].Key < report.ByMonth[j].Key })
+	sort.Slice(report.ByGame, func(i, j int) bool { return report.ByGame[i].Key < report.ByGame[j].Key })
+
+	return report
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestSellCollectionItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	item := models.CollectionItem{CardID: "mtg-1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(4)}
+	db.Create(&item)
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.POST("/api/collection/:id/sell", h.SellCollectionItem)
+
+	sell := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/collection/"+strconv.FormatUint(uint64(item.ID), 10)+"/sell", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantOp     string
+		wantLeft   int64 // remaining quantity, 0 if the item is gone
+		wantGain   float64
+	}{
+		{"missing sale price", `{"quantity":1}`, http.StatusBadRequest, "", 3, 0},
+		{"too many", `{"quantity":4,"sale_price":9}`, http.StatusBadRequest, "", 3, 0},
+		// 2 * 9 - 1 fees = 17 net, cost 2 * 4 = 8
+		{"partial sale", `{"quantity":2,"sale_price":9,"fees":1,"channel":"TCGplayer"}`, http.StatusOK, "partial", 1, 9},
+		// Defaults to the rest of the item: 1 * 3 = 3 net, cost 4
+		{"sell remainder", `{"sale_price":3,"channel":"trade"}`, http.StatusOK, "sold", 0, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := sell(tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			var left int64
+			db.Model(&models.CollectionItem{}).Select("COALESCE(SUM(quantity), 0)").Scan(&left)
+			if left != tt.wantLeft {
+				t.Errorf("remaining quantity = %d, want %d", left, tt.wantLeft)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp models.SellCollectionItemResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Operation != tt.wantOp {
+				t.Errorf("operation = %s, want %s", resp.Operation, tt.wantOp)
+			}
+			if resp.Sale.Game != models.GameMTG || resp.Sale.CardName != "Bolt" {
+				t.Errorf("sale not denormalized: game=%q name=%q", resp.Sale.Game, resp.Sale.CardName)
+			}
+			if resp.Sale.RealizedGain == nil || !almostEqual(*resp.Sale.RealizedGain, tt.wantGain) {
+				t.Errorf("realized gain = %v, want %v", resp.Sale.RealizedGain, tt.wantGain)
+			}
+		})
+	}
+
+	var sales int64
+	db.Model(&models.CollectionSale{}).Count(&sales)
+	if sales != 2 {
+		t.Errorf("expected 2 ledger entries, got %d", sales)
+	}
+}
+
+func TestBuildRealizedPnLReport(t *testing.T) {
+	jan := time.Date(2026, time.January, 10, 12, 0, 0, 0, time.Local)
+	feb := time.Date(2026, time.February, 3, 12, 0, 0, 0, time.Local)
+
+	sales := []models.CollectionSale{
+		{Game: models.GameMTG, Quantity: 2, SalePrice: 10, Fees: 2, SoldAt: jan, PurchasePrice: floatPtr(5)},
+		{Game: models.GamePokemon, Quantity: 1, SalePrice: 20, Fees: 0, SoldAt: jan},
+		{Game: models.GamePokemon, Quantity: 1, SalePrice: 8, Fees: 1, SoldAt: feb, PurchasePrice: floatPtr(10)},
+	}
+
+	report := buildRealizedPnLReport(sales)
+
+	if len(report.ByMonth) != 2 || report.ByMonth[0].Key != "2026-01" || report.ByMonth[1].Key != "2026-02" {
+		t.Fatalf("unexpected months: %+v", report.ByMonth)
+	}
+	if len(report.ByGame) != 2 || report.ByGame[0].Key != "mtg" || report.ByGame[1].Key != "pokemon" {
+		t.Fatalf("unexpected games: %+v", report.ByGame)
+	}
+
+	tests := []struct {
+		name        string
+		row         models.RealizedPnLRow
+		cardsSold   int
+		netProceeds float64
+		costBasis   float64
+		gain        float64
+	}{
+		// January: 18 + 20 net; only the MTG sale has a cost basis (18 - 10)
+		{"january", report.ByMonth[0], 3, 38, 10, 8},
+		// February: 7 net against 10 cost
+		{"february", report.ByMonth[1], 1, 7, 10, -3},
+		{"mtg", report.ByGame[0], 2, 18, 10, 8},
+		{"pokemon", report.ByGame[1], 2, 27, 10, -3},
+		{"totals", report.Totals, 4, 45, 20, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.row.CardsSold != tt.cardsSold {
+				t.Errorf("CardsSold = %d, want %d", tt.row.CardsSold, tt.cardsSold)
+			}
+			if !almostEqual(tt.row.NetProceeds, tt.netProceeds) {
+				t.Errorf("NetProceeds = %v, want %v", tt.row.NetProceeds, tt.netProceeds)
+			}
+			if !almostEqual(tt.row.TotalCostBasis, tt.costBasis) {
+				t.Errorf("TotalCostBasis = %v, want %v", tt.row.TotalCostBasis, tt.costBasis)
+			}
+			if !almostEqual(tt.row.RealizedGain, tt.gain) {
+				t.Errorf("RealizedGain = %v, want %v", tt.row.RealizedGain, tt.gain)
+			}
+		})
+	}
+}
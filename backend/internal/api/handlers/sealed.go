@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+type SealedHandler struct {
+	justTCG *services.JustTCGService
+}
+
+func NewSealedHandler(justTCG *services.JustTCGService) *SealedHandler {
+	return &SealedHandler{justTCG: justTCG}
+}
+
+// GetSealedProducts returns the sealed product catalog
+// Query params: game (mtg|pokemon), q (name contains)
+// GET /api/sealed/products
+func (h *SealedHandler) GetSealedProducts(c *gin.Context) {
+	query := database.GetDB().Order("LOWER(name)")
+	if game := c.Query("game"); game != "" {
+		query = query.Where("game = ?", game)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(q)+"%")
+	}
+
+	products := []models.SealedProduct{}
+	if err := query.Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, products)
+}
+
+// SearchSealedProducts searches JustTCG for sealed products to add to the catalog
+// Query params: q (required), game (mtg|pokemon, default pokemon)
+// GET /api/sealed/products/search
+func (h *SealedHandler) SearchSealedProducts(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'q' is required"})
+		return
+	}
+	game := models.GamePokemon
+	if c.Query("game") == string(models.GameMTG) {
+		game = models.GameMTG
+	}
+
+	products, err := h.justTCG.SearchSealedProducts(q, game)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if products == nil {
+		products = []models.SealedProduct{}
+	}
+	c.JSON(http.StatusOK, products)
+}
+
+// CreateSealedProduct adds a product to the sealed catalog. Without a price_usd the
+// price is fetched from JustTCG by tcgplayer_id.
+// POST /api/sealed/products
+func (h *SealedHandler) CreateSealedProduct(c *gin.Context) {
+	var req models.CreateSealedProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product := models.SealedProduct{
+		Name:        strings.TrimSpace(req.Name),
+		Game:        req.Game,
+		ProductType: req.ProductType,
+		SetCode:     req.SetCode,
+		SetName:     req.SetName,
+		TCGPlayerID: strings.TrimSpace(req.TCGPlayerID),
+		ImageURL:    req.ImageURL,
+	}
+	if product.Game != models.GameMTG && product.Game != models.GamePokemon {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game"})
+		return
+	}
+	if product.ProductType == "" {
+		product.ProductType = models.InferSealedProductType(product.Name)
+	}
+	if !models.IsValidSealedProductType(product.ProductType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product_type"})
+		return
+	}
+	if req.PriceUSD != nil {
+		if *req.PriceUSD < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price must not be negative"})
+			return
+		}
+		now := time.Now()
+		product.PriceUSD = *req.PriceUSD
+		product.PriceSource = models.SealedPriceSourceManual
+		product.PriceUpdatedAt = &now
+	}
+
+	db := database.GetDB()
+	if product.TCGPlayerID != "" {
+		var count int64
+		db.Model(&models.SealedProduct{}).Where("tcg_player_id = ?", product.TCGPlayerID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "a sealed product with this tcgplayer_id already exists"})
+			return
+		}
+	}
+
+	if err := db.Create(&product).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if product.PriceSource == "" && product.TCGPlayerID != "" {
+		if _, err := services.RefreshSealedPrices(db, h.justTCG, []models.SealedProduct{product}); err != nil {
+			log.Printf("Warning: failed to price sealed product %d: %v", product.ID, err)
+		}
+		db.First(&product, product.ID)
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// UpdateSealedProduct edits a catalog product. Setting price_usd records a manual
+// price that the price worker leaves alone. Changing tcgplayer_id clears a price
+// fetched for the old ID so the worker prices the new one.
+// PUT /api/sealed/products/:id
+func (h *SealedHandler) UpdateSealedProduct(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateSealedProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	var product models.SealedProduct
+	if err := db.First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sealed product not found"})
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+			return
+		}
+		product.Name = name
+	}
+	if req.ProductType != nil {
+		if !models.IsValidSealedProductType(*req.ProductType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product_type"})
+			return
+		}
+		product.ProductType = *req.ProductType
+	}
+	if req.SetCode != nil {
+		product.SetCode = *req.SetCode
+	}
+	if req.SetName != nil {
+		product.SetName = *req.SetName
+	}
+	if req.TCGPlayerID != nil {
+		tcgPlayerID := strings.TrimSpace(*req.TCGPlayerID)
+		if tcgPlayerID != product.TCGPlayerID {
+			if tcgPlayerID != "" {
+				var count int64
+				db.Model(&models.SealedProduct{}).Where("tcg_player_id = ? AND id != ?", tcgPlayerID, product.ID).Count(&count)
+				if count > 0 {
+					c.JSON(http.StatusConflict, gin.H{"error": "a sealed product with this tcgplayer_id already exists"})
+					return
+				}
+			}
+			product.TCGPlayerID = tcgPlayerID
+			if product.PriceSource != models.SealedPriceSourceManual {
+				product.PriceUSD = 0
+				product.PriceSource = ""
+				product.PriceUpdatedAt = nil
+				product.PriceCheckedAt = nil
+			}
+		}
+	}
+	if req.ImageURL != nil {
+		product.ImageURL = *req.ImageURL
+	}
+	if req.PriceUSD != nil {
+		if *req.PriceUSD < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price must not be negative"})
+			return
+		}
+		now := time.Now()
+		product.PriceUSD = *req.PriceUSD
+		product.PriceSource = models.SealedPriceSourceManual
+		product.PriceUpdatedAt = &now
+	}
+
+	if err := db.Save(&product).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// DeleteSealedProduct removes a product from the catalog. Products still in the
+// collection cannot be deleted.
+// DELETE /api/sealed/products/:id
+func (h *SealedHandler) DeleteSealedProduct(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	db := database.GetDB()
+	var count int64
+	db.Model(&models.SealedCollectionItem{}).Where("sealed_product_id = ?", id).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "sealed product is still in the collection"})
+		return
+	}
+
+	result := db.Delete(&models.SealedProduct{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sealed product not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// RefreshSealedProductPrice fetches the product's price from JustTCG now. This also
+// replaces a manual price, handing the product back to the price worker.
+// POST /api/sealed/products/:id/refresh-price
+func (h *SealedHandler) RefreshSealedProductPrice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	db := database.GetDB()
+	var product models.SealedProduct
+	if err := db.First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sealed product not found"})
+		return
+	}
+	if product.TCGPlayerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sealed product has no tcgplayer_id to look up"})
+		return
+	}
+
+	updated, err := services.RefreshSealedPrices(db, h.justTCG, []models.SealedProduct{product})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if updated == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "JustTCG has no sealed price for this product"})
+		return
+	}
+
+	db.First(&product, product.ID)
+	c.JSON(http.StatusOK, product)
+}
+
+// GetSealedCollection returns the sealed products in the collection with their values
+// Query params: game (mtg|pokemon), currency (display currency override)
+// GET /api/sealed/collection
+func (h *SealedHandler) GetSealedCollection(c *gin.Context) {
+	db := database.GetDB()
+
+	conv, ok := loadDisplayConverter(c, db)
+	if !ok {
+		return
+	}
+
+	query := db.Preload("SealedProduct").Order("added_at DESC")
+	if game := c.Query("game"); game != "" {
+		query = query.Where("sealed_product_id IN (SELECT id FROM sealed_products WHERE game = ?)", game)
+	}
+
+	items := []models.SealedCollectionItem{}
+	if err := query.Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	for i := range items {
+		annotateSealedItem(&items[i])
+		items[i].DisplayCurrency = conv.Currency
+		items[i].DisplayItemValue = conv.Convert(items[i].ItemValue, now)
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// AddSealedItem adds copies of a catalog product to the collection
+// POST /api/sealed/collection
+func (h *SealedHandler) AddSealedItem(c *gin.Context) {
+	var req models.AddSealedItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 || req.Quantity > maxQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 9999"})
+		return
+	}
+	if req.PurchasePrice != nil && *req.PurchasePrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "purchase price must not be negative"})
+		return
+	}
+
+	db := database.GetDB()
+	var product models.SealedProduct
+	if err := db.First(&product, req.SealedProductID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sealed product not found"})
+		return
+	}
+
+	item := models.SealedCollectionItem{
+		SealedProductID:   product.ID,
+		Quantity:          req.Quantity,
+		Notes:             req.Notes,
+		AddedAt:           time.Now(),
+		PurchasePrice:     req.PurchasePrice,
+		PurchaseDate:      req.PurchaseDate,
+		AcquisitionSource: req.AcquisitionSource,
+	}
+	if err := db.Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	item.SealedProduct = product
+	annotateSealedItem(&item)
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateSealedItem updates the quantity, notes or cost basis of a sealed collection item
+// PUT /api/sealed/collection/:id
+func (h *SealedHandler) UpdateSealedItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateSealedItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	var item models.SealedCollectionItem
+	if err := db.Preload("SealedProduct").First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sealed collection item not found"})
+		return
+	}
+
+	if req.Quantity != nil {
+		if *req.Quantity < 1 || *req.Quantity > maxQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 9999"})
+			return
+		}
+		item.Quantity = *req.Quantity
+	}
+	if req.Notes != nil {
+		item.Notes = *req.Notes
+	}
+	if req.PurchasePrice != nil {
+		if *req.PurchasePrice < 0 {
+			item.PurchasePrice = nil
+		} else {
+			price := *req.PurchasePrice
+			item.PurchasePrice = &price
+		}
+	}
+	if req.PurchaseDate != nil {
+		item.PurchaseDate = req.PurchaseDate
+	}
+	if req.AcquisitionSource != nil {
+		item.AcquisitionSource = *req.AcquisitionSource
+	}
+
+	if err := db.Omit("SealedProduct").Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotateSealedItem(&item)
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteSealedItem removes a sealed item from the collection. The catalog product stays.
+// DELETE /api/sealed/collection/:id
+func (h *SealedHandler) DeleteSealedItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	result := database.GetDB().Delete(&models.SealedCollectionItem{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sealed collection item not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// annotateSealedItem fills in the calculated value and gain of a sealed item.
+// SealedProduct must be loaded.
+func annotateSealedItem(item *models.SealedCollectionItem) {
+	item.ItemValue = item.SealedProduct.PriceUSD * float64(item.Quantity)
+	item.CostBasis, item.UnrealizedGain = nil, nil
+	if item.PurchasePrice != nil {
+		costBasis := *item.PurchasePrice * float64(item.Quantity)
+		gain := item.ItemValue - costBasis
+		item.CostBasis = &costBasis
+		item.UnrealizedGain = &gain
+	}
+}
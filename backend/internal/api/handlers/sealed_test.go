@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+func TestSealedCollectionIsASeparateBucket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+
+	sealed := NewSealedHandler(services.NewJustTCGService("", 100))
+	collection := &CollectionHandler{snapshotService: services.NewSnapshotService()}
+	router := gin.New()
+	router.POST("/api/sealed/products", sealed.CreateSealedProduct)
+	router.DELETE("/api/sealed/products/:id", sealed.DeleteSealedProduct)
+	router.GET("/api/sealed/collection", sealed.GetSealedCollection)
+	router.POST("/api/sealed/collection", sealed.AddSealedItem)
+	router.PUT("/api/sealed/collection/:id", sealed.UpdateSealedItem)
+	router.GET("/api/collection/stats", collection.GetStats)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/sealed/products", `{"name":"Scarlet & Violet 151 Elite Trainer Box","game":"pokemon","price_usd":60}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create product: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var product models.SealedProduct
+	_ = json.Unmarshal(w.Body.Bytes(), &product)
+	if product.ProductType != models.SealedEliteTrainer || product.PriceSource != models.SealedPriceSourceManual {
+		t.Errorf("expected an inferred ETB with a manual price, got %+v", product)
+	}
+
+	if w := do(http.MethodPost, "/api/sealed/products", `{"name":"Box","game":"yugioh"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown game, got %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/api/sealed/collection", fmt.Sprintf(`{"sealed_product_id":%d,"quantity":2,"purchase_price":45}`, product.ID))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add item: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var item models.SealedCollectionItem
+	_ = json.Unmarshal(w.Body.Bytes(), &item)
+	if item.ItemValue != 120 || item.UnrealizedGain == nil || *item.UnrealizedGain != 30 {
+		t.Errorf("unexpected item value %+v", item)
+	}
+
+	if w := do(http.MethodPut, fmt.Sprintf("/api/sealed/collection/%d", item.ID), `{"quantity":0}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for quantity 0, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/sealed/products/%d", product.ID), ""); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 deleting a product in the collection, got %d", w.Code)
+	}
+
+	var items []models.SealedCollectionItem
+	_ = json.Unmarshal(do(http.MethodGet, "/api/sealed/collection?game=pokemon", "").Body.Bytes(), &items)
+	if len(items) != 1 || items[0].SealedProduct.Name != product.Name {
+		t.Errorf("unexpected sealed collection %+v", items)
+	}
+
+	// Singles stay in the game buckets; sealed value is added to the total only
+	var stats models.CollectionStats
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection/stats", "").Body.Bytes(), &stats)
+	if stats.TotalCards != 3 || stats.PokemonValue != 0 || !almostEqual(stats.MTGValue, 30) {
+		t.Errorf("sealed items leaked into the card buckets: %+v", stats)
+	}
+	if stats.SealedItems != 2 || !almostEqual(stats.SealedValue, 120) || !almostEqual(stats.TotalValue, 150) {
+		t.Errorf("unexpected sealed bucket: %+v", stats)
+	}
+	if stats.CostBasisCards != 2 || !almostEqual(stats.TotalCostBasis, 90) || !almostEqual(stats.UnrealizedGain, 30) {
+		t.Errorf("expected sealed cost basis in the totals: %+v", stats)
+	}
+
+	snapshotStats := services.NewSnapshotService().CurrentStats()
+	if snapshotStats.SealedItems != 2 || !almostEqual(snapshotStats.SealedValue, 120) || !almostEqual(snapshotStats.TotalValue, 150) {
+		t.Errorf("snapshot stats disagree with GetStats: %+v", snapshotStats)
+	}
+}
+
+func TestUpdateSealedProductTCGPlayerID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	checked := time.Now()
+	db.Create(&models.SealedProduct{Name: "Booster Box", Game: models.GameMTG, ProductType: models.SealedBoosterBox, TCGPlayerID: "111"})
+	fetched := models.SealedProduct{
+		Name: "Bundle", Game: models.GameMTG, ProductType: models.SealedBundle, TCGPlayerID: "222",
+		PriceUSD: 50, PriceSource: services.PriceProviderJustTCG, PriceUpdatedAt: &checked, PriceCheckedAt: &checked,
+	}
+	db.Create(&fetched)
+	manual := models.SealedProduct{
+		Name: "Tin", Game: models.GameMTG, ProductType: models.SealedCollectionBox, TCGPlayerID: "333",
+		PriceUSD: 20, PriceSource: models.SealedPriceSourceManual, PriceUpdatedAt: &checked,
+	}
+	db.Create(&manual)
+
+	h := NewSealedHandler(services.NewJustTCGService("", 100))
+	router := gin.New()
+	router.PUT("/api/sealed/products/:id", h.UpdateSealedProduct)
+	update := func(id uint, body string) (int, models.SealedProduct) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/sealed/products/%d", id), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		var product models.SealedProduct
+		_ = json.Unmarshal(w.Body.Bytes(), &product)
+		return w.Code, product
+	}
+
+	if code, _ := update(fetched.ID, `{"tcgplayer_id":"111"}`); code != http.StatusConflict {
+		t.Errorf("expected 409 for a duplicate tcgplayer_id, got %d", code)
+	}
+
+	// Keeping the same ID leaves the fetched price alone
+	if code, product := update(fetched.ID, `{"tcgplayer_id":"222","name":"Gift Bundle"}`); code != http.StatusOK || product.PriceUSD != 50 {
+		t.Errorf("expected the price kept, got %d %+v", code, product)
+	}
+
+	// A new ID drops the price fetched for the old one
+	code, product := update(fetched.ID, `{"tcgplayer_id":"444"}`)
+	if code != http.StatusOK || product.PriceUSD != 0 || product.PriceSource != "" || product.PriceUpdatedAt != nil || product.PriceCheckedAt != nil {
+		t.Errorf("expected the fetched price reset, got %d %+v", code, product)
+	}
+
+	// Manual prices don't depend on the ID
+	code, product = update(manual.ID, `{"tcgplayer_id":"555"}`)
+	if code != http.StatusOK || product.PriceUSD != 20 || product.PriceSource != models.SealedPriceSourceManual {
+		t.Errorf("expected the manual price kept, got %d %+v", code, product)
+	}
+}
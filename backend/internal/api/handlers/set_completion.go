@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// GetSetCompletion returns owned/total for every set the collection has cards from
+// Query params: game (mtg|pokemon), printings (true to count each printing separately)
+// GET /api/collection/sets
+func (h *CollectionHandler) GetSetCompletion(c *gin.Context) {
+	game := c.Query("game")
+	if game != "" && game != string(models.GameMTG) && game != string(models.GamePokemon) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "game parameter must be 'mtg' or 'pokemon'"})
+		return
+	}
+	countPrintings := c.Query("printings") == "true"
+
+	db := database.GetDB()
+	sets, err := services.CollectionSets(db, game)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	completions := make([]models.SetCompletion, 0, len(sets))
+	for _, set := range sets {
+		resp, err := h.setCompletion(c.Request.Context(), db, set.Game, set.SetCode, countPrintings)
+		if err != nil {
+			log.Printf("Set completion: failed to load set %s/%s: %v", set.Game, set.SetCode, err)
+			completions = append(completions, models.SetCompletion{
+				Game:           set.Game,
+				SetCode:        set.SetCode,
+				SetName:        set.SetName,
+				CountPrintings: countPrintings,
+				Error:          err.Error(),
+			})
+			continue
+		}
+		completions = append(completions, resp.SetCompletion)
+	}
+
+	// Closest to complete first
+	sort.SliceStable(completions, func(i, j int) bool {
+		if completions[i].Percent != completions[j].Percent {
+			return completions[i].Percent > completions[j].Percent
+		}
+		return strings.ToLower(completions[i].SetName) < strings.ToLower(completions[j].SetName)
+	})
+
+	c.JSON(http.StatusOK, completions)
+}
+
+// GetMissingSetCards returns a set's completion with the cards (or printings) not in
+// the collection and the estimated cost to complete it
+// Query params: game (mtg|pokemon, required), printings (true to count each printing separately)
+// GET /api/collection/sets/:setCode/missing
+func (h *CollectionHandler) GetMissingSetCards(c *gin.Context) {
+	game := models.Game(c.Query("game"))
+	if game != models.GameMTG && game != models.GamePokemon {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "game parameter must be 'mtg' or 'pokemon'"})
+		return
+	}
+
+	resp, err := h.setCompletion(c.Request.Context(), database.GetDB(), game, c.Param("setCode"), c.Query("printings") == "true")
+	if err != nil {
+		status := http.StatusInternalServerError
+		var upstream *setListError
+		switch {
+		case errors.Is(err, services.ErrSetNotFound):
+			status = http.StatusNotFound
+		case errors.As(err, &upstream):
+			status = http.StatusBadGateway
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// setListError is a failure to load a set's card list from its card service, as
+// opposed to a failure reading the collection
+type setListError struct {
+	err error
+}
+
+func (e *setListError) Error() string { return e.err.Error() }
+func (e *setListError) Unwrap() error { return e.err }
+
+// setCompletion loads a set's card list and compares it with the collection. MTG
+// lists come from Scryfall, every page, cached by the Scryfall service.
+func (h *CollectionHandler) setCompletion(ctx context.Context, db *gorm.DB, game models.Game, setCode string, countPrintings bool) (models.SetMissingResponse, error) {
+	var result *models.CardSearchResult
+	var err error
+	switch {
+	case game == models.GameMTG && h.scryfallService != nil:
+		var cards []models.Card
+		cards, err = h.scryfallService.GetAllSetCards(ctx, setCode)
+		result = &models.CardSearchResult{Cards: cards, TotalCount: len(cards)}
+	case game == models.GamePokemon && h.pokemonService != nil:
+		result, err = h.pokemonService.GetSetCards(setCode, "")
+	default:
+		err = fmt.Errorf("no card service for %s", game)
+	}
+	if err != nil {
+		return models.SetMissingResponse{}, &setListError{err: err}
+	}
+
+	owned, err := services.LoadOwnedSetCards(db, game, setCode)
+	if err != nil {
+		return models.SetMissingResponse{}, err
+	}
+
+	services.AttachCachedPrices(db, result.Cards)
+	return services.BuildSetCompletion(game, setCode, result.Cards, result.TotalCount, result.HasMore, owned, countPrintings), nil
+}
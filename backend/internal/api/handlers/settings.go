@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+// rateFetchTimeout bounds the rate fetch when switching to a currency without stored rates
+const rateFetchTimeout = 30 * time.Second
+
+type SettingsHandler struct {
+	exchangeRates *services.ExchangeRateService
+}
+
+func NewSettingsHandler(exchangeRates *services.ExchangeRateService) *SettingsHandler {
+	return &SettingsHandler{exchangeRates: exchangeRates}
+}
+
+// GetSettings returns the display currency, today's rate and the currencies available
+// GET /api/settings
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	db := database.GetDB()
+
+	currencies, err := services.AvailableCurrencies(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := models.SettingsResponse{
+		DisplayCurrency:     services.DisplayCurrency(db),
+		ExchangeRate:        1,
+		AvailableCurrencies: currencies,
+	}
+	if conv, err := services.LoadCurrencyConverter(db, resp.DisplayCurrency); err == nil {
+		now := time.Now()
+		resp.ExchangeRate = conv.Rate(now)
+		resp.ExchangeRateDate = conv.RateDate(now)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateSettings changes the display currency. Rates for a currency that has none
+// stored yet are fetched first; the change is rejected if none can be found.
+// PUT /api/settings
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	var req models.UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	if req.DisplayCurrency != nil {
+		currency, ok := services.NormalizeCurrency(*req.DisplayCurrency)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "display_currency must be a 3-letter currency code"})
+			return
+		}
+
+		_, err := services.LoadCurrencyConverter(db, currency)
+		if errors.Is(err, services.ErrNoExchangeRates) && h.exchangeRates != nil {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), rateFetchTimeout)
+			fetchErr := h.exchangeRates.RefreshCurrency(ctx, currency)
+			cancel()
+			if fetchErr != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch exchange rates: " + fetchErr.Error()})
+				return
+			}
+			_, err = services.LoadCurrencyConverter(db, currency)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		setting := models.AppSetting{Key: models.SettingDisplayCurrency, Value: currency}
+		if err := db.Save(&setting).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	h.GetSettings(c)
+}
+
+// loadDisplayConverter returns the converter for the ?currency= query parameter, or
+// for the saved display currency. An unknown requested currency is a 400 (and false
+// is returned); a saved currency whose rates are missing falls back to USD.
+func loadDisplayConverter(c *gin.Context, db *gorm.DB) (*services.CurrencyConverter, bool) {
+	if requested := c.Query("currency"); requested != "" {
+		currency, ok := services.NormalizeCurrency(requested)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a 3-letter currency code"})
+			return nil, false
+		}
+		conv, err := services.LoadCurrencyConverter(db, currency)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrNoExchangeRates) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		return conv, true
+	}
+
+	conv, err := services.LoadCurrencyConverter(db, services.DisplayCurrency(db))
+	if err != nil {
+		conv, _ = services.LoadCurrencyConverter(db, models.CurrencyUSD)
+	}
+	return conv, true
+}
+
+// applyDisplayCurrency sets the converted value of each item at today's rate
+func applyDisplayCurrency(items []models.CollectionItem, conv *services.CurrencyConverter, now time.Time) {
+	for i := range items {
+		items[i].DisplayCurrency = conv.Currency
+		items[i].DisplayItemValue = conv.Convert(items[i].ItemValue, now)
+	}
+}
+
+// applyDisplayCurrencyToGroups sets the converted totals of each card group and its items
+func applyDisplayCurrencyToGroups(groups []models.GroupedCollectionItem, conv *services.CurrencyConverter, now time.Time) {
+	for i := range groups {
+		groups[i].DisplayCurrency = conv.Currency
+		groups[i].DisplayTotalValue = conv.Convert(groups[i].TotalValue, now)
+		applyDisplayCurrency(groups[i].Items, conv, now)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+func TestDisplayCurrencyConversion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.CollectionValueSnapshot{}); err != nil {
+		t.Fatalf("failed to migrate snapshots: %v", err)
+	}
+
+	jan := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	db.Create(&models.ExchangeRate{Currency: "EUR", Date: jan, Rate: 0.8})
+	db.Create(&models.ExchangeRate{Currency: "EUR", Date: feb, Rate: 0.9})
+	db.Create(&models.CollectionValueSnapshot{SnapshotDate: jan.AddDate(0, 0, 5), TotalValue: 100, MTGValue: 100})
+	db.Create(&models.CollectionValueSnapshot{SnapshotDate: feb.AddDate(0, 0, 5), TotalValue: 200, MTGValue: 200})
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+
+	settings := NewSettingsHandler(nil)
+	collection := &CollectionHandler{snapshotService: services.NewSnapshotService()}
+	router := gin.New()
+	router.GET("/api/settings", settings.GetSettings)
+	router.PUT("/api/settings", settings.UpdateSettings)
+	router.GET("/api/collection", collection.GetCollection)
+	router.GET("/api/collection/stats", collection.GetStats)
+	router.GET("/api/collection/stats/history", collection.GetValueHistory)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"display_currency":"euro"}`, http.StatusBadRequest},
+		{`{"display_currency":"CHF"}`, http.StatusBadRequest}, // No rates and no source to fetch them
+		{`{"display_currency":"eur"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := do(http.MethodPut, "/api/settings", tt.body); w.Code != tt.code {
+			t.Errorf("PUT %s: expected %d, got %d: %s", tt.body, tt.code, w.Code, w.Body.String())
+		}
+	}
+
+	var resp models.SettingsResponse
+	_ = json.Unmarshal(do(http.MethodGet, "/api/settings", "").Body.Bytes(), &resp)
+	if resp.DisplayCurrency != "EUR" || resp.ExchangeRate != 0.9 || len(resp.AvailableCurrencies) != 2 {
+		t.Errorf("unexpected settings: %+v", resp)
+	}
+
+	var stats models.CollectionStats
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection/stats", "").Body.Bytes(), &stats)
+	if stats.DisplayCurrency != "EUR" || !almostEqual(stats.TotalValue, 20) || !almostEqual(stats.DisplayTotalValue, 18) || !almostEqual(stats.DisplayMTGValue, 18) {
+		t.Errorf("unexpected converted stats: %+v", stats)
+	}
+
+	var items []models.CollectionItem
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection", "").Body.Bytes(), &items)
+	if len(items) != 1 || items[0].DisplayCurrency != "EUR" || !almostEqual(items[0].DisplayItemValue, 18) {
+		t.Errorf("unexpected converted items: %+v", items)
+	}
+
+	// Each snapshot converts at the rate of its own date
+	var history models.ValueHistoryResponse
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection/stats/history?period=all", "").Body.Bytes(), &history)
+	if history.Currency != "EUR" || len(history.Snapshots) != 2 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if !almostEqual(history.Snapshots[0].DisplayTotalValue, 80) || !almostEqual(history.Snapshots[1].DisplayTotalValue, 180) {
+		t.Errorf("expected 80 and 180 EUR, got %v and %v", history.Snapshots[0].DisplayTotalValue, history.Snapshots[1].DisplayTotalValue)
+	}
+
+	// A currency query parameter overrides the setting
+	_ = json.Unmarshal(do(http.MethodGet, "/api/collection/stats?currency=usd", "").Body.Bytes(), &stats)
+	if stats.DisplayCurrency != "USD" || !almostEqual(stats.DisplayTotalValue, 20) {
+		t.Errorf("expected USD override, got %+v", stats)
+	}
+	if w := do(http.MethodGet, "/api/collection/stats?currency=CHF", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a currency without rates, got %d", w.Code)
+	}
+}
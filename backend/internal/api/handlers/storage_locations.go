@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// storageLocationPathSeparator joins location names into a path
+const storageLocationPathSeparator = " / "
+
+type StorageLocationHandler struct{}
+
+func NewStorageLocationHandler() *StorageLocationHandler {
+	return &StorageLocationHandler{}
+}
+
+// storageLocationTree is every location keyed by ID, used to resolve paths and
+// sub-locations without a query per level. The table is small (one row per box/binder/page).
+type storageLocationTree map[uint]models.StorageLocation
+
+func loadStorageLocationTree(db *gorm.DB) (storageLocationTree, error) {
+	var locations []models.StorageLocation
+	if err := db.Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	tree := make(storageLocationTree, len(locations))
+	for _, loc := range locations {
+		tree[loc.ID] = loc
+	}
+	return tree, nil
+}
+
+// Path returns the names from the top-level location down to id
+func (t storageLocationTree) Path(id uint) string {
+	var names []string
+	seen := make(map[uint]bool)
+	for loc, ok := t[id]; ok && !seen[loc.ID]; {
+		seen[loc.ID] = true
+		names = append([]string{loc.Name}, names...)
+		if loc.ParentID == nil {
+			break
+		}
+		loc, ok = t[*loc.ParentID]
+	}
+	return strings.Join(names, storageLocationPathSeparator)
+}
+
+// Descendants returns id and the IDs of all locations nested under it
+func (t storageLocationTree) Descendants(id uint) []uint {
+	children := make(map[uint][]uint)
+	for _, loc := range t {
+		if loc.ParentID != nil {
+			children[*loc.ParentID] = append(children[*loc.ParentID], loc.ID)
+		}
+	}
+
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// IsDescendant reports whether candidate is id or nested under it
+func (t storageLocationTree) IsDescendant(candidate, id uint) bool {
+	for _, d := range t.Descendants(id) {
+		if d == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateStorageLocations fills StorageLocationPath on items that have a location
+func annotateStorageLocations(tree storageLocationTree, items []models.CollectionItem) {
+	for i := range items {
+		if items[i].StorageLocationID != nil {
+			items[i].StorageLocationPath = tree.Path(*items[i].StorageLocationID)
+		}
+	}
+}
+
+// whereSameStorageLocation narrows a stack lookup to items in the same location
+// (or without a location when locationID is nil)
+func whereSameStorageLocation(query *gorm.DB, locationID *uint) *gorm.DB {
+	if locationID == nil {
+		return query.Where("storage_location_id IS NULL")
+	}
+	return query.Where("storage_location_id = ?", *locationID)
+}
+
+// storageLocationExists reports whether a location with the given ID exists
+func storageLocationExists(db *gorm.DB, id uint) bool {
+	var count int64
+	db.Model(&models.StorageLocation{}).Where("id = ?", id).Count(&count)
+	return count > 0
+}
+
+// GetStorageLocations returns all storage locations with their full path and the
+// number of cards stored in each (including sub-locations), sorted by path
+// GET /api/storage-locations
+func (h *StorageLocationHandler) GetStorageLocations(c *gin.Context) {
+	db := database.GetDB()
+
+	tree, err := loadStorageLocationTree(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	counts, err := storageLocationCardCounts(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	locations := make([]models.StorageLocation, 0, len(tree))
+	for id, loc := range tree {
+		loc.Path = tree.Path(id)
+		for _, d := range tree.Descendants(id) {
+			loc.CardCount += counts[d]
+		}
+		locations = append(locations, loc)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Path < locations[j].Path
+	})
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetStorageLocation returns a single location with its direct sub-locations
+// GET /api/storage-locations/:id
+func (h *StorageLocationHandler) GetStorageLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	db := database.GetDB()
+	tree, err := loadStorageLocationTree(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	loc, ok := tree[uint(id)]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "storage location not found"})
+		return
+	}
+	counts, err := storageLocationCardCounts(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	loc.Path = tree.Path(loc.ID)
+	for _, d := range tree.Descendants(loc.ID) {
+		loc.CardCount += counts[d]
+	}
+	for _, child := range tree {
+		if child.ParentID != nil && *child.ParentID == loc.ID {
+			child.Path = tree.Path(child.ID)
+			for _, d := range tree.Descendants(child.ID) {
+				child.CardCount += counts[d]
+			}
+			loc.Children = append(loc.Children, child)
+		}
+	}
+	sort.Slice(loc.Children, func(i, j int) bool {
+		return loc.Children[i].Name < loc.Children[j].Name
+	})
+
+	c.JSON(http.StatusOK, loc)
+}
+
+// CreateStorageLocation creates a new storage location
+// POST /api/storage-locations
+func (h *StorageLocationHandler) CreateStorageLocation(c *gin.Context) {
+	var req models.CreateStorageLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	locType := req.Type
+	if locType == "" {
+		locType = models.StorageLocationOther
+	}
+	if !locType.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location type"})
+		return
+	}
+
+	db := database.GetDB()
+	if req.ParentID != nil && !storageLocationExists(db, *req.ParentID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parent location not found"})
+		return
+	}
+
+	loc := models.StorageLocation{
+		ParentID: req.ParentID,
+		Name:     name,
+		Type:     locType,
+		Notes:    req.Notes,
+	}
+	if err := db.Create(&loc).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if tree, err := loadStorageLocationTree(db); err == nil {
+		loc.Path = tree.Path(loc.ID)
+	}
+	c.JSON(http.StatusCreated, loc)
+}
+
+// UpdateStorageLocation renames, retypes or moves a storage location
+// PUT /api/storage-locations/:id
+func (h *StorageLocationHandler) UpdateStorageLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateStorageLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	tree, err := loadStorageLocationTree(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	loc, ok := tree[uint(id)]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "storage location not found"})
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+			return
+		}
+		loc.Name = name
+	}
+	if req.Type != nil {
+		if !req.Type.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location type"})
+			return
+		}
+		loc.Type = *req.Type
+	}
+	if req.Notes != nil {
+		loc.Notes = *req.Notes
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			loc.ParentID = nil
+		} else {
+			if _, ok := tree[*req.ParentID]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent location not found"})
+				return
+			}
+			// A location cannot be moved into itself or one of its own sub-locations
+			if tree.IsDescendant(*req.ParentID, loc.ID) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "cannot move a location inside itself"})
+				return
+			}
+			parentID := *req.ParentID
+			loc.ParentID = &parentID
+		}
+	}
+
+	if err := db.Save(&loc).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tree[loc.ID] = loc
+	loc.Path = tree.Path(loc.ID)
+	c.JSON(http.StatusOK, loc)
+}
+
+// DeleteStorageLocation deletes a location that has no sub-locations.
+// Items stored there are kept and become unassigned; stacks merge into matching
+// unassigned stacks so no duplicates are left behind.
+// DELETE /api/storage-locations/:id
+func (h *StorageLocationHandler) DeleteStorageLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	db := database.GetDB()
+	var loc models.StorageLocation
+	if err := db.First(&loc, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "storage location not found"})
+		return
+	}
+
+	var children int64
+	db.Model(&models.StorageLocation{}).Where("parent_id = ?", loc.ID).Count(&children)
+	if children > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "location has sub-locations; move or delete them first"})
+		return
+	}
+
+	var unassigned, merged int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var items []models.CollectionItem
+		if err := tx.Where("storage_location_id = ?", loc.ID).Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			item := &items[i]
+			unassigned++
+
+			if !item.IsIndividual() {
+				var target models.CollectionItem
+				err := whereSameCostBasis(whereSameStorageLocation(tx, nil), item.PurchasePrice).
+					Where("card_id = ? AND condition = ? AND printing = ? AND language = ? AND "+mergeableStackSQL,
+						item.CardID, item.Condition, item.Printing, item.Language).
+					First(&target).Error
+				if err == nil {
+					mergeCostBasisInto(&target, item, item.Quantity)
+					target.Quantity += item.Quantity
+					if err := tx.Save(&target).Error; err != nil {
+						return err
+					}
+					if err := moveItemTags(tx, item.ID, target.ID); err != nil {
+						return err
+					}
+					if err := tx.Delete(item).Error; err != nil {
+						return err
+					}
+					merged++
+					continue
+				}
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+			}
+
+			if err := tx.Model(item).Update("storage_location_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&loc).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted", "unassigned_items": unassigned, "merged_items": merged})
+}
+
+// storageLocationCardCounts returns the number of cards stored directly in each location
+func storageLocationCardCounts(db *gorm.DB) (map[uint]int, error) {
+	var rows []struct {
+		StorageLocationID uint
+		Count             int
+	}
+	err := db.Model(&models.CollectionItem{}).
+		Select("storage_location_id, SUM(quantity) as count").
+		Where("storage_location_id IS NOT NULL").
+		Group("storage_location_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		counts[r.StorageLocationID] = r.Count
+	}
+	return counts, nil
+}
+
+// parseStorageLocationFilter parses the "location" query parameter. "none" selects
+// items without a location; an ID selects that location and its sub-locations.
+func parseStorageLocationFilter(value string, tree storageLocationTree) (ids []uint, unassigned bool, err error) {
+	if value == "none" {
+		return nil, true, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, false, errors.New("invalid location")
+	}
+	if _, ok := tree[uint(id)]; !ok {
+		return nil, false, errors.New("storage location not found")
+	}
+	return tree.Descendants(uint(id)), false, nil
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestStorageLocationTree(t *testing.T) {
+	tree := storageLocationTree{
+		1: {ID: 1, Name: "Binder A", Type: models.StorageLocationBinder},
+		2: {ID: 2, ParentID: uintPtr(1), Name: "Page 3", Type: models.StorageLocationPage},
+		3: {ID: 3, ParentID: uintPtr(2), Name: "Pocket 5", Type: models.StorageLocationPocket},
+		4: {ID: 4, Name: "Bulk Box", Type: models.StorageLocationBox},
+	}
+
+	tests := []struct {
+		id          uint
+		path        string
+		descendants int
+	}{
+		{1, "Binder A", 3},
+		{2, "Binder A / Page 3", 2},
+		{3, "Binder A / Page 3 / Pocket 5", 1},
+		{4, "Bulk Box", 1},
+		{99, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("location %d", tt.id), func(t *testing.T) {
+			if got := tree.Path(tt.id); got != tt.path {
+				t.Errorf("Path() = %q, want %q", got, tt.path)
+			}
+			if got := tree.Descendants(tt.id); len(got) != tt.descendants {
+				t.Errorf("Descendants() = %v, want %d IDs", got, tt.descendants)
+			}
+		})
+	}
+
+	if !tree.IsDescendant(3, 1) || tree.IsDescendant(1, 3) || tree.IsDescendant(4, 1) {
+		t.Error("IsDescendant() returned wrong result")
+	}
+}
+
+func TestStorageLocationCRUD(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	h := NewStorageLocationHandler()
+	router := gin.New()
+	router.GET("/api/storage-locations", h.GetStorageLocations)
+	router.POST("/api/storage-locations", h.CreateStorageLocation)
+	router.PUT("/api/storage-locations/:id", h.UpdateStorageLocation)
+	router.DELETE("/api/storage-locations/:id", h.DeleteStorageLocation)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	create := func(body string) models.StorageLocation {
+		t.Helper()
+		w := do(http.MethodPost, "/api/storage-locations", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var loc models.StorageLocation
+		if err := json.Unmarshal(w.Body.Bytes(), &loc); err != nil {
+			t.Fatalf("failed to decode location: %v", err)
+		}
+		return loc
+	}
+
+	binder := create(`{"name":"Binder A","type":"binder"}`)
+	page := create(fmt.Sprintf(`{"name":"Page 3","type":"page","parent_id":%d}`, binder.ID))
+	if page.Path != "Binder A / Page 3" {
+		t.Errorf("page path = %q, want %q", page.Path, "Binder A / Page 3")
+	}
+
+	// Validation errors
+	for _, body := range []string{
+		`{"name":"X","type":"shoebox"}`,
+		`{"name":"X","parent_id":999}`,
+		`{"name":"  "}`,
+	} {
+		if w := do(http.MethodPost, "/api/storage-locations", body); w.Code != http.StatusBadRequest {
+			t.Errorf("create %s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	// A location cannot be moved under its own sub-location
+	w := do(http.MethodPut, fmt.Sprintf("/api/storage-locations/%d", binder.ID), fmt.Sprintf(`{"parent_id":%d}`, page.ID))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("cyclic move: expected 400, got %d", w.Code)
+	}
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 1})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 4, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, StorageLocationID: &page.ID})
+
+	// Card counts roll up into parent locations
+	w = do(http.MethodGet, "/api/storage-locations", "")
+	var locations []models.StorageLocation
+	if err := json.Unmarshal(w.Body.Bytes(), &locations); err != nil {
+		t.Fatalf("failed to decode locations: %v", err)
+	}
+	if len(locations) != 2 || locations[0].CardCount != 4 || locations[1].CardCount != 4 {
+		t.Errorf("expected both locations to count 4 cards, got %+v", locations)
+	}
+
+	// Locations with sub-locations cannot be deleted
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/storage-locations/%d", binder.ID), ""); w.Code != http.StatusConflict {
+		t.Errorf("delete parent: expected 409, got %d", w.Code)
+	}
+
+	// Deleting a leaf keeps its items, unassigned
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/storage-locations/%d", page.ID), ""); w.Code != http.StatusOK {
+		t.Fatalf("delete page: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var item models.CollectionItem
+	db.First(&item)
+	if item.StorageLocationID != nil || item.Quantity != 4 {
+		t.Errorf("expected item kept with no location, got location %v qty %d", item.StorageLocationID, item.Quantity)
+	}
+
+	// Stacks merge into matching unassigned stacks instead of duplicating them
+	box := create(`{"name":"Box","type":"box"}`)
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, StorageLocationID: &box.ID})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 1, Condition: models.ConditionPlayed, Printing: models.PrintingNormal, Language: models.LanguageEnglish, StorageLocationID: &box.ID})
+	w = do(http.MethodDelete, fmt.Sprintf("/api/storage-locations/%d", box.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete box: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		UnassignedItems int `json:"unassigned_items"`
+		MergedItems     int `json:"merged_items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.UnassignedItems != 2 || resp.MergedItems != 1 {
+		t.Errorf("expected 2 unassigned and 1 merged, got %+v", resp)
+	}
+	var items []models.CollectionItem
+	db.Order("id").Find(&items)
+	if len(items) != 2 || items[0].Quantity != 6 || items[1].Condition != models.ConditionPlayed || items[1].StorageLocationID != nil {
+		t.Errorf("expected a merged NM stack of 6 and an unassigned played copy, got %+v", items)
+	}
+}
+
+func TestMoveCollectionItemToStorageLocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	binder := models.StorageLocation{Name: "Binder A", Type: models.StorageLocationBinder}
+	db.Create(&binder)
+	box := models.StorageLocation{Name: "Bulk Box", Type: models.StorageLocationBox}
+	db.Create(&box)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 2})
+	stack := models.CollectionItem{CardID: "mtg-1", Quantity: 5, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchasePrice: floatPtr(1)}
+	db.Create(&stack)
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.PUT("/api/collection/:id", h.UpdateCollectionItem)
+	router.GET("/api/collection/grouped", h.GetGroupedCollection)
+
+	update := func(id uint, body string) (int, models.CollectionUpdateResponse) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/collection/%d", id), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		var resp models.CollectionUpdateResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// Move 2 of 5 into the binder: splits into a new stack
+	code, resp := update(stack.ID, fmt.Sprintf(`{"storage_location_id":%d,"split_quantity":2}`, binder.ID))
+	if code != http.StatusOK || resp.Operation != "split" {
+		t.Fatalf("expected split, got %d %s", code, resp.Operation)
+	}
+	if resp.Item.Quantity != 2 || resp.Item.StorageLocationID == nil || *resp.Item.StorageLocationID != binder.ID {
+		t.Errorf("expected 2 copies in binder, got qty %d location %v", resp.Item.Quantity, resp.Item.StorageLocationID)
+	}
+	if resp.Item.PurchasePrice == nil || !almostEqual(*resp.Item.PurchasePrice, 1) {
+		t.Errorf("expected split stack to keep per-card price 1, got %v", resp.Item.PurchasePrice)
+	}
+	binderStackID := resp.Item.ID
+
+	// Move 1 more: merges into the binder stack
+	_, resp = update(stack.ID, fmt.Sprintf(`{"storage_location_id":%d}`, binder.ID))
+	if resp.Item.ID != binderStackID || resp.Item.Quantity != 3 {
+		t.Errorf("expected merge into binder stack (qty 3), got id %d qty %d", resp.Item.ID, resp.Item.Quantity)
+	}
+
+	// Splitting more than the stack holds is rejected
+	if code, _ := update(stack.ID, fmt.Sprintf(`{"storage_location_id":%d,"split_quantity":3}`, box.ID)); code != http.StatusBadRequest {
+		t.Errorf("oversized split: expected 400, got %d", code)
+	}
+
+	// Moving the whole remaining stack updates it in place
+	_, resp = update(stack.ID, fmt.Sprintf(`{"storage_location_id":%d,"split_quantity":2}`, box.ID))
+	if resp.Operation != "updated" || resp.Item.ID != stack.ID || resp.Item.Quantity != 2 {
+		t.Errorf("expected whole stack moved in place, got %s id %d qty %d", resp.Operation, resp.Item.ID, resp.Item.Quantity)
+	}
+
+	if code, _ := update(stack.ID, `{"storage_location_id":999}`); code != http.StatusBadRequest {
+		t.Errorf("unknown location: expected 400, got %d", code)
+	}
+
+	grouped := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/collection/grouped?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("grouped %s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	var byCard []models.GroupedCollectionItem
+	_ = json.Unmarshal(grouped(fmt.Sprintf("location=%d", binder.ID)).Body.Bytes(), &byCard)
+	if len(byCard) != 1 || byCard[0].TotalQuantity != 3 {
+		t.Fatalf("expected 3 cards in binder, got %+v", byCard)
+	}
+	if byCard[0].Items[0].StorageLocationPath != "Binder A" {
+		t.Errorf("StorageLocationPath = %q, want %q", byCard[0].Items[0].StorageLocationPath, "Binder A")
+	}
+
+	var byLocation []models.StorageLocationGroup
+	_ = json.Unmarshal(grouped("group_by=location").Body.Bytes(), &byLocation)
+	if len(byLocation) != 2 {
+		t.Fatalf("expected 2 location groups, got %d", len(byLocation))
+	}
+	if byLocation[0].Location.Name != "Binder A" || byLocation[0].TotalQuantity != 3 || !almostEqual(byLocation[0].TotalValue, 6) {
+		t.Errorf("unexpected binder group: %+v", byLocation[0])
+	}
+	if byLocation[1].Location.Name != "Bulk Box" || byLocation[1].TotalQuantity != 2 {
+		t.Errorf("unexpected box group: %+v", byLocation[1])
+	}
+}
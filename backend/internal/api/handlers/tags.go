@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// tagQueryChunk bounds IN (...) lists to stay under SQLite's bound parameter limit
+const tagQueryChunk = 500
+
+type TagHandler struct{}
+
+func NewTagHandler() *TagHandler {
+	return &TagHandler{}
+}
+
+// GetTags returns all tags with the number of items and cards carrying each
+// GET /api/tags
+func (h *TagHandler) GetTags(c *gin.Context) {
+	db := database.GetDB()
+
+	var tags []models.Tag
+	if err := db.Order("LOWER(name) ASC").Find(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var counts []struct {
+		TagID     uint
+		ItemCount int
+		CardCount int
+	}
+	db.Table("collection_item_tags").
+		Select("collection_item_tags.tag_id, COUNT(*) as item_count, COALESCE(SUM(collection_items.quantity), 0) as card_count").
+		Joins("JOIN collection_items ON collection_items.id = collection_item_tags.collection_item_id").
+		Group("collection_item_tags.tag_id").
+		Scan(&counts)
+
+	byTag := make(map[uint]int, len(counts))
+	for i, count := range counts {
+		byTag[count.TagID] = i
+	}
+	for i := range tags {
+		if idx, ok := byTag[tags[i].ID]; ok {
+			tags[i].ItemCount = counts[idx].ItemCount
+			tags[i].CardCount = counts[idx].CardCount
+		}
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag creates a new tag
+// POST /api/tags
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name, errMsg := validateTagName(req.Name)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	db := database.GetDB()
+	if existing, err := findTagByName(db, name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "tag already exists", "tag": existing})
+		return
+	}
+
+	tag := models.Tag{Name: name, Color: req.Color}
+	if err := db.Create(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// UpdateTag renames or recolors a tag
+// PUT /api/tags/:id
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	var tag models.Tag
+	if err := db.First(&tag, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+
+	if req.Name != nil {
+		name, errMsg := validateTagName(*req.Name)
+		if errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+		if existing, err := findTagByName(db, name); err == nil && existing.ID != tag.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "tag already exists", "tag": existing})
+			return
+		}
+		tag.Name = name
+	}
+	if req.Color != nil {
+		tag.Color = *req.Color
+	}
+
+	if err := db.Save(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag deletes a tag and removes it from all items
+// DELETE /api/tags/:id
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	db := database.GetDB()
+	var untagged int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("tag_id = ?", id).Delete(&models.CollectionItemTag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		untagged = result.RowsAffected
+
+		result = tx.Delete(&models.Tag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted", "untagged_items": untagged})
+}
+
+// TagItems adds tags to many collection items at once, creating missing tags
+// POST /api/collection/tag
+func (h *CollectionHandler) TagItems(c *gin.Context) {
+	var req models.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	names, errMsg := validateBulkTagRequest(&req)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	db := database.GetDB()
+	resp := models.BulkTagResponse{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		itemIDs, err := existingItemIDs(tx, req.ItemIDs)
+		if err != nil {
+			return err
+		}
+		resp.Items = len(itemIDs)
+
+		for _, name := range names {
+			tag, err := findTagByName(tx, name)
+			if err == gorm.ErrRecordNotFound {
+				tag = models.Tag{Name: name}
+				err = tx.Create(&tag).Error
+			}
+			if err != nil {
+				return err
+			}
+			resp.Tags = append(resp.Tags, tag)
+
+			links := make([]models.CollectionItemTag, 0, len(itemIDs))
+			for _, itemID := range itemIDs {
+				links = append(links, models.CollectionItemTag{CollectionItemID: itemID, TagID: tag.ID})
+			}
+			if len(links) == 0 {
+				continue
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, tagQueryChunk)
+			if result.Error != nil {
+				return result.Error
+			}
+			resp.Changed += int(result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UntagItems removes tags from many collection items at once. Tags themselves are kept.
+// POST /api/collection/untag
+func (h *CollectionHandler) UntagItems(c *gin.Context) {
+	var req models.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	names, errMsg := validateBulkTagRequest(&req)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	db := database.GetDB()
+	resp := models.BulkTagResponse{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		itemIDs, err := existingItemIDs(tx, req.ItemIDs)
+		if err != nil {
+			return err
+		}
+		resp.Items = len(itemIDs)
+
+		for _, name := range names {
+			tag, err := findTagByName(tx, name)
+			if err == gorm.ErrRecordNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			resp.Tags = append(resp.Tags, tag)
+
+			for start := 0; start < len(itemIDs); start += tagQueryChunk {
+				end := min(start+tagQueryChunk, len(itemIDs))
+				result := tx.Where("tag_id = ? AND collection_item_id IN ?", tag.ID, itemIDs[start:end]).
+					Delete(&models.CollectionItemTag{})
+				if result.Error != nil {
+					return result.Error
+				}
+				resp.Changed += int(result.RowsAffected)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// validateTagName normalizes a tag name, returning an error message if it is unusable
+func validateTagName(name string) (string, string) {
+	name = models.NormalizeTagName(name)
+	if name == "" {
+		return "", "tag name is required"
+	}
+	if len(name) > models.MaxTagNameLength {
+		return "", "tag name is too long (max 50 characters)"
+	}
+	return name, ""
+}
+
+// validateBulkTagRequest checks a bulk request and returns its distinct, normalized tag names
+func validateBulkTagRequest(req *models.BulkTagRequest) ([]string, string) {
+	if len(req.ItemIDs) == 0 {
+		return nil, "item_ids must not be empty"
+	}
+	if len(req.Tags) == 0 {
+		return nil, "tags must not be empty"
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, raw := range req.Tags {
+		name, errMsg := validateTagName(raw)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		key := normalizeTagKey(name)
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names, ""
+}
+
+func normalizeTagKey(name string) string {
+	return lowerASCII(models.NormalizeTagName(name))
+}
+
+// lowerASCII lowercases like SQLite's LOWER(), which only folds ASCII letters
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, ch := range b {
+		if ch >= 'A' && ch <= 'Z' {
+			b[i] = ch + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// findTagByName looks up a tag ignoring case
+func findTagByName(db *gorm.DB, name string) (models.Tag, error) {
+	var tag models.Tag
+	err := db.Where("LOWER(name) = ?", normalizeTagKey(name)).First(&tag).Error
+	return tag, err
+}
+
+// existingItemIDs returns the distinct IDs from ids that are collection items
+func existingItemIDs(db *gorm.DB, ids []uint) ([]uint, error) {
+	var existing []uint
+	for start := 0; start < len(ids); start += tagQueryChunk {
+		end := min(start+tagQueryChunk, len(ids))
+		var chunk []uint
+		if err := db.Model(&models.CollectionItem{}).Where("id IN ?", ids[start:end]).Pluck("id", &chunk).Error; err != nil {
+			return nil, err
+		}
+		existing = append(existing, chunk...)
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i] < existing[j] })
+	// Duplicate IDs across chunks
+	unique := existing[:0]
+	for i, id := range existing {
+		if i == 0 || id != existing[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// applyTagFilters adds the tag= (must have every listed tag) and -tag= (must have
+// none of the listed tags) query filters to a collection_items query
+func applyTagFilters(query *gorm.DB, c *gin.Context) *gorm.DB {
+	const hasTag = `collection_items.id IN (
+		SELECT collection_item_tags.collection_item_id FROM collection_item_tags
+		JOIN tags ON tags.id = collection_item_tags.tag_id
+		WHERE LOWER(tags.name) = ?)`
+
+	for _, name := range c.QueryArray("tag") {
+		if key := normalizeTagKey(name); key != "" {
+			query = query.Where(hasTag, key)
+		}
+	}
+	for _, name := range c.QueryArray("-tag") {
+		if key := normalizeTagKey(name); key != "" {
+			query = query.Where("NOT "+hasTag, key)
+		}
+	}
+	return query
+}
+
+// annotateTags fills the Tags field of each item
+func annotateTags(db *gorm.DB, items []models.CollectionItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	index := make(map[uint][]int, len(items))
+	ids := make([]uint, 0, len(items))
+	for i := range items {
+		if _, ok := index[items[i].ID]; !ok {
+			ids = append(ids, items[i].ID)
+		}
+		index[items[i].ID] = append(index[items[i].ID], i)
+	}
+
+	for start := 0; start < len(ids); start += tagQueryChunk {
+		end := min(start+tagQueryChunk, len(ids))
+		var rows []struct {
+			CollectionItemID uint
+			Name             string
+		}
+		db.Table("collection_item_tags").
+			Select("collection_item_tags.collection_item_id, tags.name").
+			Joins("JOIN tags ON tags.id = collection_item_tags.tag_id").
+			Where("collection_item_tags.collection_item_id IN ?", ids[start:end]).
+			Order("LOWER(tags.name) ASC").
+			Scan(&rows)
+		for _, row := range rows {
+			for _, i := range index[row.CollectionItemID] {
+				items[i].Tags = append(items[i].Tags, row.Name)
+			}
+		}
+	}
+}
+
+// copyItemTags gives the item toID every tag of the item fromID (used when a stack splits)
+func copyItemTags(db *gorm.DB, fromID, toID uint) error {
+	return db.Exec(`INSERT OR IGNORE INTO collection_item_tags (collection_item_id, tag_id, created_at)
+		SELECT ?, tag_id, CURRENT_TIMESTAMP FROM collection_item_tags WHERE collection_item_id = ?`, toID, fromID).Error
+}
+
+// moveItemTags merges the tags of fromID into toID and removes fromID's links
+// (used when a stack is merged into another and deleted)
+func moveItemTags(db *gorm.DB, fromID, toID uint) error {
+	if err := copyItemTags(db, fromID, toID); err != nil {
+		return err
+	}
+	return deleteItemTags(db, fromID)
+}
+
+// deleteItemTags removes all tag links of a deleted item
+func deleteItemTags(db *gorm.DB, itemID uint) error {
+	return db.Where("collection_item_id = ?", itemID).Delete(&models.CollectionItemTag{}).Error
+}
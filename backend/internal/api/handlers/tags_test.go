@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestBulkTagAndFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	db.Create(&models.Card{ID: "mtg-2", Name: "Sol Ring", Game: models.GameMTG, PriceUSD: 2})
+	db.Create(&models.Card{ID: "pkm-1", Name: "Pikachu", Game: models.GamePokemon, PriceUSD: 5})
+
+	bolt := models.CollectionItem{CardID: "mtg-1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish}
+	ring := models.CollectionItem{CardID: "mtg-2", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish}
+	pika := models.CollectionItem{CardID: "pkm-1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish}
+	for _, item := range []*models.CollectionItem{&bolt, &ring, &pika} {
+		db.Create(item)
+	}
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.GET("/api/collection", h.GetCollection)
+	router.GET("/api/collection/grouped", h.GetGroupedCollection)
+	router.GET("/api/collection/stats", h.GetStats)
+	router.POST("/api/collection/tag", h.TagItems)
+	router.POST("/api/collection/untag", h.UntagItems)
+	router.GET("/api/tags", NewTagHandler().GetTags)
+
+	post := func(path, body string) models.BulkTagResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var resp models.BulkTagResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+	get := func(path string, out interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+	}
+
+	// Tags are created on first use; unknown item IDs are ignored
+	resp := post("/api/collection/tag", fmt.Sprintf(`{"item_ids":[%d,%d,999],"tags":["For Trade","  commander   deck "]}`, bolt.ID, ring.ID))
+	if resp.Items != 2 || resp.Changed != 4 || len(resp.Tags) != 2 {
+		t.Fatalf("expected 2 items, 4 links and 2 tags, got %+v", resp)
+	}
+	if resp.Tags[1].Name != "commander deck" {
+		t.Errorf("expected normalized tag name, got %q", resp.Tags[1].Name)
+	}
+
+	// Tagging again matches existing tags ignoring case and adds no duplicate links
+	resp = post("/api/collection/tag", fmt.Sprintf(`{"item_ids":[%d,%d],"tags":["for trade"]}`, bolt.ID, pika.ID))
+	if resp.Changed != 1 || resp.Tags[0].ID == 0 {
+		t.Errorf("expected 1 new link on the existing tag, got %+v", resp)
+	}
+	var tagCount int64
+	db.Model(&models.Tag{}).Count(&tagCount)
+	if tagCount != 2 {
+		t.Errorf("expected 2 tags, got %d", tagCount)
+	}
+
+	// Sol Ring is no longer in the commander deck
+	resp = post("/api/collection/untag", fmt.Sprintf(`{"item_ids":[%d],"tags":["Commander Deck"]}`, ring.ID))
+	if resp.Changed != 1 {
+		t.Errorf("expected 1 link removed, got %+v", resp)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"tag=for+trade", []string{"mtg-1", "mtg-2", "pkm-1"}},
+		{"tag=for+trade&tag=commander+deck", []string{"mtg-1"}},
+		{"tag=for+trade&-tag=commander+deck", []string{"mtg-2", "pkm-1"}},
+		{"-tag=FOR+TRADE", nil},
+		{"tag=unknown", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var items []models.CollectionItem
+			get("/api/collection?"+tt.query, &items)
+			got := make(map[string]bool)
+			for _, item := range items {
+				got[item.CardID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got cards %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("missing %s in %v", id, got)
+				}
+			}
+
+			var groups []models.GroupedCollectionItem
+			get("/api/collection/grouped?"+tt.query, &groups)
+			if len(groups) != len(tt.want) {
+				t.Errorf("grouped returned %d cards, want %d", len(groups), len(tt.want))
+			}
+		})
+	}
+
+	var items []models.CollectionItem
+	get("/api/collection?tag=commander+deck", &items)
+	if len(items) != 1 || len(items[0].Tags) != 2 || items[0].Tags[0] != "commander deck" || items[0].Tags[1] != "For Trade" {
+		t.Errorf("expected Bolt with both tags sorted, got %+v", items)
+	}
+
+	// Value per tag: For Trade = 2*10 + 3*2 + 1*5, commander deck = 2*10
+	var stats models.CollectionStats
+	get("/api/collection/stats", &stats)
+	if len(stats.ByTag) != 2 {
+		t.Fatalf("expected 2 tag stats, got %+v", stats.ByTag)
+	}
+	if stats.ByTag[0].Name != "commander deck" || stats.ByTag[0].Cards != 2 || !almostEqual(stats.ByTag[0].TotalValue, 20) {
+		t.Errorf("unexpected commander deck stats: %+v", stats.ByTag[0])
+	}
+	if stats.ByTag[1].Name != "For Trade" || stats.ByTag[1].Cards != 6 || !almostEqual(stats.ByTag[1].TotalValue, 31) {
+		t.Errorf("unexpected For Trade stats: %+v", stats.ByTag[1])
+	}
+
+	var tags []models.Tag
+	get("/api/tags", &tags)
+	if len(tags) != 2 || tags[1].ItemCount != 3 || tags[1].CardCount != 6 {
+		t.Errorf("unexpected tag counts: %+v", tags)
+	}
+}
+
+func TestTagsFollowSplitAndMerge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 10})
+	stack := models.CollectionItem{CardID: "mtg-1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish}
+	db.Create(&stack)
+	lpStack := models.CollectionItem{CardID: "mtg-1", Quantity: 1, Condition: models.ConditionLightPlay, Printing: models.PrintingNormal, Language: models.LanguageEnglish}
+	db.Create(&lpStack)
+
+	tag := models.Tag{Name: "for trade"}
+	db.Create(&tag)
+	db.Create(&models.CollectionItemTag{CollectionItemID: stack.ID, TagID: tag.ID})
+
+	h := &CollectionHandler{}
+	router := gin.New()
+	router.PUT("/api/collection/:id", h.UpdateCollectionItem)
+	router.DELETE("/api/collection/:id", h.DeleteCollectionItem)
+
+	do := func(method string, id uint, body string) models.CollectionUpdateResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/collection/%d", id), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.CollectionUpdateResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+	tagged := func(itemID uint) bool {
+		var count int64
+		db.Model(&models.CollectionItemTag{}).Where("collection_item_id = ? AND tag_id = ?", itemID, tag.ID).Count(&count)
+		return count > 0
+	}
+
+	// Split into a new stack: the copy keeps the tag
+	resp := do(http.MethodPut, stack.ID, `{"condition":"GD"}`)
+	if !tagged(resp.Item.ID) {
+		t.Error("expected split copy to keep the tag")
+	}
+	gdID := resp.Item.ID
+
+	// Splitting into an existing stack doesn't tag the copies already there
+	resp = do(http.MethodPut, stack.ID, `{"condition":"LP"}`)
+	if resp.Item.ID != lpStack.ID || resp.Item.Quantity != 2 || tagged(lpStack.ID) {
+		t.Errorf("expected split merged into untagged LP stack, got item %d qty %d", resp.Item.ID, resp.Item.Quantity)
+	}
+
+	// Merging the whole GD item into the LP stack moves the tag over
+	resp = do(http.MethodPut, gdID, `{"condition":"LP"}`)
+	if resp.Operation != "merged" || !tagged(lpStack.ID) || tagged(gdID) {
+		t.Errorf("expected tag moved to LP stack on merge (op %s)", resp.Operation)
+	}
+
+	// Deleting an item removes its tag links
+	do(http.MethodDelete, stack.ID, "")
+	if tagged(stack.ID) {
+		t.Error("expected tag links removed with the item")
+	}
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/services"
+)
+
+type WantListHandler struct {
+	pokemonService  *services.PokemonHybridService
+	scryfallService *services.ScryfallService
+}
+
+func NewWantListHandler(pokemonService *services.PokemonHybridService, scryfallService *services.ScryfallService) *WantListHandler {
+	return &WantListHandler{
+		pokemonService:  pokemonService,
+		scryfallService: scryfallService,
+	}
+}
+
+// GetWantList returns all want list entries with their current best price
+// Query params: game (mtg|pokemon), under_target (true to only return entries at or below max price)
+// GET /api/wantlist
+func (h *WantListHandler) GetWantList(c *gin.Context) {
+	entries, err := loadWantList(database.GetDB(), c.Query("game"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("under_target") == "true" {
+		entries = filterUnderTarget(entries)
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetUnderTarget returns the want list entries whose current price is at or below
+// their max price, cheapest relative to target first
+// GET /api/wantlist/under-target
+func (h *WantListHandler) GetUnderTarget(c *gin.Context) {
+	entries, err := loadWantList(database.GetDB(), c.Query("game"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries = filterUnderTarget(entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return *entries[i].CurrentPrice / *entries[i].MaxPrice < *entries[j].CurrentPrice / *entries[j].MaxPrice
+	})
+	c.JSON(http.StatusOK, entries)
+}
+
+// CreateWantListEntry adds a card to the want list. A card_id targets one printing;
+// a card_name on its own (or any_printing) matches every printing with that name.
+// POST /api/wantlist
+func (h *WantListHandler) CreateWantListEntry(c *gin.Context) {
+	var req models.CreateWantListEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := models.WantListEntry{
+		Game:        req.Game,
+		AnyPrinting: req.AnyPrinting,
+		Condition:   req.Condition,
+		Printing:    req.Printing,
+		Language:    models.NormalizeLanguage(string(req.Language)),
+		Quantity:    req.Quantity,
+		MaxPrice:    req.MaxPrice,
+		Notes:       req.Notes,
+	}
+	if entry.Game != "" && entry.Game != models.GameMTG && entry.Game != models.GamePokemon {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game"})
+		return
+	}
+	if entry.Condition == "" {
+		entry.Condition = models.ConditionNearMint
+	}
+	if entry.Printing != "" && !isValidPrinting(entry.Printing) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid printing"})
+		return
+	}
+	if entry.Quantity == 0 {
+		entry.Quantity = 1
+	}
+	if entry.Quantity < 0 || entry.Quantity > maxQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 9999"})
+		return
+	}
+	if entry.MaxPrice != nil && *entry.MaxPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max price must not be negative"})
+		return
+	}
+
+	db := database.GetDB()
+	cardID := strings.TrimSpace(req.CardID)
+	if cardID != "" {
+		card := loadCardFromServices(h.pokemonService, h.scryfallService, cardID, string(req.Game))
+		if card == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card not found, please search for it first"})
+			return
+		}
+		// Cache the card in the database so the price worker can update it
+		if err := db.Save(card).Error; err != nil {
+			log.Printf("Warning: failed to cache card %s: %v", card.ID, err)
+		}
+		entry.CardID = card.ID
+		entry.CardName = card.Name
+		entry.Game = card.Game
+	} else {
+		entry.CardName = strings.TrimSpace(req.CardName)
+		if entry.CardName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card_id or card_name is required"})
+			return
+		}
+		// Without a card ID, every printing with the name is a match
+		entry.AnyPrinting = true
+	}
+
+	if err := db.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entry.AnyPrinting {
+		h.cachePrintings(db, &entry)
+	}
+
+	entries := []models.WantListEntry{entry}
+	if err := annotateWantList(db, entries); err != nil {
+		log.Printf("Warning: failed to price want list entry %d: %v", entry.ID, err)
+	}
+	c.JSON(http.StatusCreated, entries[0])
+}
+
+// UpdateWantListEntry updates the desired condition, printing, quantity or price target
+// PUT /api/wantlist/:id
+func (h *WantListHandler) UpdateWantListEntry(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateWantListEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	var entry models.WantListEntry
+	if err := db.First(&entry, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "want list entry not found"})
+		return
+	}
+
+	if req.AnyPrinting != nil {
+		if !*req.AnyPrinting && entry.CardID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entries without a card_id always match any printing"})
+			return
+		}
+		entry.AnyPrinting = *req.AnyPrinting
+	}
+	if req.Condition != nil && *req.Condition != "" {
+		entry.Condition = *req.Condition
+	}
+	if req.Printing != nil {
+		if *req.Printing != "" && !isValidPrinting(*req.Printing) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid printing"})
+			return
+		}
+		entry.Printing = *req.Printing
+	}
+	if req.Language != nil {
+		entry.Language = models.NormalizeLanguage(string(*req.Language))
+	}
+	if req.Quantity != nil {
+		if *req.Quantity < 1 || *req.Quantity > maxQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 9999"})
+			return
+		}
+		entry.Quantity = *req.Quantity
+	}
+	if req.MaxPrice != nil {
+		if *req.MaxPrice < 0 {
+			entry.MaxPrice = nil
+		} else {
+			maxPrice := *req.MaxPrice
+			entry.MaxPrice = &maxPrice
+		}
+	}
+	if req.Notes != nil {
+		entry.Notes = *req.Notes
+	}
+
+	if err := db.Save(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AnyPrinting != nil && *req.AnyPrinting {
+		h.cachePrintings(db, &entry)
+	}
+
+	entries := []models.WantListEntry{entry}
+	if err := annotateWantList(db, entries); err != nil {
+		log.Printf("Warning: failed to price want list entry %d: %v", entry.ID, err)
+	}
+	c.JSON(http.StatusOK, entries[0])
+}
+
+// DeleteWantListEntry removes an entry from the want list
+// DELETE /api/wantlist/:id
+func (h *WantListHandler) DeleteWantListEntry(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	result := database.GetDB().Delete(&models.WantListEntry{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "want list entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// cachePrintings caches the printings an any-printing entry matches, so a card that
+// was never searched still gets prices and price worker refreshes. A failed lookup
+// only leaves the entry unpriced until its printings are cached another way.
+func (h *WantListHandler) cachePrintings(db *gorm.DB, entry *models.WantListEntry) {
+	if _, err := services.CachePrintingsByName(db, h.pokemonService, h.scryfallService, entry.Game, entry.CardName); err != nil {
+		log.Printf("Warning: failed to look up printings of %q: %v", entry.CardName, err)
+	}
+}
+
+// loadWantList returns every want list entry (optionally for one game) with prices
+// and owned quantities filled in, newest first
+func loadWantList(db *gorm.DB, game string) ([]models.WantListEntry, error) {
+	query := db.Order("created_at DESC")
+	if game != "" {
+		// Name-only entries without a game match both games
+		query = query.Where("game = ? OR game = ''", game)
+	}
+
+	var entries []models.WantListEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if err := annotateWantList(db, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func filterUnderTarget(entries []models.WantListEntry) []models.WantListEntry {
+	filtered := make([]models.WantListEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.UnderTarget {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// annotateWantList fills the calculated price and ownership fields on entries from
+// the cached cards and their condition prices
+func annotateWantList(db *gorm.DB, entries []models.WantListEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var ids, names []string
+	for _, entry := range entries {
+		if entry.CardID != "" {
+			ids = append(ids, entry.CardID)
+		}
+		if entry.AnyPrinting {
+			names = append(names, strings.ToLower(entry.CardName))
+		}
+	}
+
+	query := db.Preload("Prices")
+	switch {
+	case len(ids) > 0 && len(names) > 0:
+		query = query.Where("id IN ? OR LOWER(name) IN ?", ids, names)
+	case len(ids) > 0:
+		query = query.Where("id IN ?", ids)
+	default:
+		query = query.Where("LOWER(name) IN ?", names)
+	}
+	var cards []models.Card
+	if err := query.Find(&cards).Error; err != nil {
+		return err
+	}
+
+	cardIDs := make([]string, len(cards))
+	for i, card := range cards {
+		cardIDs[i] = card.ID
+	}
+	var owned []struct {
+		CardID   string
+		Quantity int
+	}
+	if len(cardIDs) > 0 {
+		err := db.Model(&models.CollectionItem{}).
+			Select("card_id, SUM(quantity) as quantity").
+			Where("card_id IN ?", cardIDs).
+			Group("card_id").
+			Scan(&owned).Error
+		if err != nil {
+			return err
+		}
+	}
+	ownedByCard := make(map[string]int, len(owned))
+	for _, o := range owned {
+		ownedByCard[o.CardID] = o.Quantity
+	}
+
+	for i := range entries {
+		matches := wantListMatches(&entries[i], cards)
+		for _, card := range matches {
+			entries[i].OwnedQuantity += ownedByCard[card.ID]
+		}
+		applyBestWantListPrice(&entries[i], matches)
+	}
+	return nil
+}
+
+// wantListMatches returns the cached cards that satisfy an entry: the exact printing,
+// or every printing with the same name (and game, if set) for any-printing entries
+func wantListMatches(entry *models.WantListEntry, cards []models.Card) []models.Card {
+	var matches []models.Card
+	for _, card := range cards {
+		if card.ID == entry.CardID {
+			matches = append(matches, card)
+			continue
+		}
+		if entry.AnyPrinting && strings.EqualFold(card.Name, entry.CardName) &&
+			(entry.Game == "" || entry.Game == card.Game) {
+			matches = append(matches, card)
+		}
+	}
+	return matches
+}
+
+// applyBestWantListPrice sets the lowest per-card price among the matching cards for the
+// entry's condition and language. Without a desired printing every printing the card has
+// prices for is considered.
+func applyBestWantListPrice(entry *models.WantListEntry, cards []models.Card) {
+	condition := models.MapCollectionConditionToPriceCondition(entry.Condition)
+
+	for i := range cards {
+		card := &cards[i]
+		for _, printing := range wantListPrintings(entry, card) {
+			price := card.GetPriceWithSource(condition, printing, entry.Language).Price
+			if price <= 0 || (entry.CurrentPrice != nil && price >= *entry.CurrentPrice) {
+				continue
+			}
+			entry.CurrentPrice = &price
+			entry.CurrentCardID = card.ID
+			entry.CurrentPrinting = printing
+			entry.PriceUpdatedAt = card.PriceUpdatedAt
+			entry.Card = card
+		}
+	}
+
+	entry.UnderTarget = entry.CurrentPrice != nil && entry.MaxPrice != nil && *entry.CurrentPrice <= *entry.MaxPrice
+}
+
+func wantListPrintings(entry *models.WantListEntry, card *models.Card) []models.PrintingType {
+	if entry.Printing != "" {
+		return []models.PrintingType{entry.Printing}
+	}
+
+	printings := []models.PrintingType{models.PrintingNormal}
+	seen := map[models.PrintingType]bool{models.PrintingNormal: true}
+	for _, p := range card.Prices {
+		if !seen[p.Printing] {
+			seen[p.Printing] = true
+			printings = append(printings, p.Printing)
+		}
+	}
+	if card.PriceFoilUSD > 0 && !seen[models.PrintingFoil] {
+		printings = append(printings, models.PrintingFoil)
+	}
+	return printings
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestApplyBestWantListPrice(t *testing.T) {
+	nm := models.PriceConditionNM
+	lp := models.PriceConditionLP
+	alpha := models.Card{ID: "a", Name: "Bolt", PriceUSD: 8, PriceFoilUSD: 20, Prices: []models.CardPrice{
+		{Condition: nm, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 8},
+		{Condition: lp, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 5},
+		{Condition: nm, Printing: models.PrintingFoil, Language: models.LanguageEnglish, PriceUSD: 20},
+	}}
+	reprint := models.Card{ID: "b", Name: "Bolt", PriceUSD: 2}
+	unpriced := models.Card{ID: "c", Name: "Bolt"}
+
+	tests := []struct {
+		name      string
+		entry     models.WantListEntry
+		cards     []models.Card
+		wantPrice float64
+		wantCard  string
+		under     bool
+	}{
+		{"cheapest printing", models.WantListEntry{Condition: models.ConditionNearMint, MaxPrice: floatPtr(10)}, []models.Card{alpha}, 8, "a", true},
+		{"condition price", models.WantListEntry{Condition: models.ConditionLightPlay, MaxPrice: floatPtr(5)}, []models.Card{alpha}, 5, "a", true},
+		{"desired printing", models.WantListEntry{Condition: models.ConditionNearMint, Printing: models.PrintingFoil, MaxPrice: floatPtr(10)}, []models.Card{alpha}, 20, "a", false},
+		{"cheapest reprint", models.WantListEntry{Condition: models.ConditionNearMint, MaxPrice: floatPtr(2)}, []models.Card{unpriced, alpha, reprint}, 2, "b", true},
+		{"no target", models.WantListEntry{Condition: models.ConditionNearMint}, []models.Card{reprint}, 2, "b", false},
+		{"no price", models.WantListEntry{Condition: models.ConditionNearMint, MaxPrice: floatPtr(10)}, []models.Card{unpriced}, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			applyBestWantListPrice(&entry, tt.cards)
+			if tt.wantCard == "" {
+				if entry.CurrentPrice != nil {
+					t.Errorf("expected no price, got %v", *entry.CurrentPrice)
+				}
+			} else if entry.CurrentPrice == nil || !almostEqual(*entry.CurrentPrice, tt.wantPrice) || entry.CurrentCardID != tt.wantCard {
+				t.Errorf("got price %v from %q, want %v from %q", entry.CurrentPrice, entry.CurrentCardID, tt.wantPrice, tt.wantCard)
+			}
+			if entry.UnderTarget != tt.under {
+				t.Errorf("UnderTarget = %v, want %v", entry.UnderTarget, tt.under)
+			}
+		})
+	}
+}
+
+func TestWantListEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Lightning Bolt", Game: models.GameMTG, PriceUSD: 3})
+	db.Create(&models.Card{ID: "mtg-2", Name: "Lightning Bolt", Game: models.GameMTG, PriceUSD: 1.5})
+	db.Create(&models.Card{ID: "mtg-3", Name: "Sol Ring", Game: models.GameMTG, PriceUSD: 4})
+	db.Create(&models.CollectionItem{CardID: "mtg-2", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+
+	h := NewWantListHandler(nil, nil)
+	router := gin.New()
+	router.GET("/api/wantlist", h.GetWantList)
+	router.GET("/api/wantlist/under-target", h.GetUnderTarget)
+	router.POST("/api/wantlist", h.CreateWantListEntry)
+	router.PUT("/api/wantlist/:id", h.UpdateWantListEntry)
+	router.DELETE("/api/wantlist/:id", h.DeleteWantListEntry)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	create := func(body string) models.WantListEntry {
+		t.Helper()
+		w := do(http.MethodPost, "/api/wantlist", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var entry models.WantListEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to decode entry: %v", err)
+		}
+		return entry
+	}
+
+	// Name-only entries match every printing; the cheapest is reported
+	bolt := create(`{"card_name":"lightning bolt","game":"mtg","quantity":4,"max_price":2}`)
+	if !bolt.AnyPrinting || bolt.CurrentCardID != "mtg-2" || !bolt.UnderTarget || bolt.OwnedQuantity != 2 {
+		t.Errorf("unexpected bolt entry: %+v", bolt)
+	}
+
+	ring := create(`{"card_id":"mtg-3","max_price":3}`)
+	if ring.CardName != "Sol Ring" || ring.Game != models.GameMTG || ring.UnderTarget {
+		t.Errorf("unexpected ring entry: %+v", ring)
+	}
+
+	for _, body := range []string{
+		`{}`,
+		`{"card_id":"missing"}`,
+		`{"card_name":"Bolt","printing":"Shiny"}`,
+		`{"card_name":"Bolt","max_price":-1}`,
+		`{"card_name":"Bolt","game":"yugioh"}`,
+	} {
+		if w := do(http.MethodPost, "/api/wantlist", body); w.Code != http.StatusBadRequest {
+			t.Errorf("create %s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	list := func(path string) []models.WantListEntry {
+		t.Helper()
+		w := do(http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var entries []models.WantListEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("failed to decode entries: %v", err)
+		}
+		return entries
+	}
+
+	if entries := list("/api/wantlist"); len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+	if entries := list("/api/wantlist/under-target"); len(entries) != 1 || entries[0].ID != bolt.ID {
+		t.Errorf("expected only bolt under target, got %+v", entries)
+	}
+
+	// Raising the target puts Sol Ring under it; a negative max price clears the target
+	if w := do(http.MethodPut, fmt.Sprintf("/api/wantlist/%d", ring.ID), `{"max_price":4}`); w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if entries := list("/api/wantlist?under_target=true"); len(entries) != 2 {
+		t.Errorf("expected 2 entries under target, got %d", len(entries))
+	}
+	do(http.MethodPut, fmt.Sprintf("/api/wantlist/%d", bolt.ID), `{"max_price":-1}`)
+	if entries := list("/api/wantlist/under-target"); len(entries) != 1 || entries[0].ID != ring.ID {
+		t.Errorf("expected only ring under target, got %+v", entries)
+	}
+
+	if w := do(http.MethodPut, fmt.Sprintf("/api/wantlist/%d", bolt.ID), `{"any_printing":false}`); w.Code != http.StatusBadRequest {
+		t.Errorf("name-only entry without any_printing: expected 400, got %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/wantlist/%d", ring.ID), ""); w.Code != http.StatusOK {
+		t.Errorf("delete: expected 200, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, fmt.Sprintf("/api/wantlist/%d", ring.ID), ""); w.Code != http.StatusNotFound {
+		t.Errorf("second delete: expected 404, got %d", w.Code)
+	}
+}
@@ -16,7 +16,7 @@ import (
 	"github.com/codyseavey/tcg-tracker/backend/internal/services"
 )
 
-func SetupRouter(scryfallService *services.ScryfallService, pokemonService *services.PokemonHybridService, geminiService *services.GeminiService, priceWorker *services.PriceWorker, priceService *services.PriceService, imageStorageService *services.ImageStorageService, snapshotService *services.SnapshotService, tcgPlayerSync *services.TCGPlayerSyncService, justTCG *services.JustTCGService, bulkImportWorker *services.BulkImportWorker) *gin.Engine {
+func SetupRouter(scryfallService *services.ScryfallService, pokemonService *services.PokemonHybridService, visionIdentifier services.VisionIdentifier, priceWorker *services.PriceWorker, priceService *services.PriceService, imageStorageService *services.ImageStorageService, snapshotService *services.SnapshotService, tcgPlayerSync *services.TCGPlayerSyncService, justTCG *services.JustTCGService, bulkImportWorker *services.BulkImportWorker, csvImportService *services.CSVImportService, backupService *services.BackupService, alertService *services.AlertService, exchangeRateService *services.ExchangeRateService) *gin.Engine {
 	router := gin.Default()
 
 	// Get frontend dist path from env
@@ -39,11 +39,20 @@ func SetupRouter(scryfallService *services.ScryfallService, pokemonService *serv
 	router.Use(metrics.HTTPMetrics())
 
 	// Initialize handlers
-	cardHandler := handlers.NewCardHandler(scryfallService, pokemonService, geminiService)
+	cardHandler := handlers.NewCardHandler(scryfallService, pokemonService, visionIdentifier)
 	collectionHandler := handlers.NewCollectionHandler(scryfallService, pokemonService, imageStorageService, snapshotService, priceWorker)
 	priceHandler := handlers.NewPriceHandler(priceWorker, priceService)
 	adminHandler := handlers.NewAdminHandler(tcgPlayerSync, justTCG)
 	bulkImportHandler := handlers.NewBulkImportHandler(bulkImportWorker, pokemonService, scryfallService, imageStorageService)
+	csvImportHandler := handlers.NewCSVImportHandler(csvImportService, pokemonService, scryfallService)
+	backupHandler := handlers.NewBackupHandler(backupService)
+	storageLocationHandler := handlers.NewStorageLocationHandler()
+	tagHandler := handlers.NewTagHandler()
+	wantListHandler := handlers.NewWantListHandler(pokemonService, scryfallService)
+	deckHandler := handlers.NewDeckHandler(pokemonService, scryfallService)
+	alertHandler := handlers.NewAlertHandler(alertService)
+	settingsHandler := handlers.NewSettingsHandler(exchangeRateService)
+	sealedHandler := handlers.NewSealedHandler(justTCG)
 
 	// Serve scanned images
 	if imageStorageService != nil {
@@ -75,13 +84,16 @@ func SetupRouter(scryfallService *services.ScryfallService, pokemonService *serv
 			sets.GET("/:setCode/cards", cardHandler.GetSetCards)
 		}
 
-		// Card routes (all public)
+		// Card routes (public, except setting graded prices)
 		cards := api.Group("/cards")
 		{
 			cards.GET("/search", cardHandler.SearchCards)
 			cards.GET("/search/grouped", cardHandler.SearchCardsGrouped)
 			cards.GET("/:id", cardHandler.GetCard)
 			cards.GET("/:id/prices", priceHandler.GetCardPrices)
+			cards.GET("/:id/prices/history", priceHandler.GetCardPriceHistory)
+			cards.GET("/:id/graded-prices", priceHandler.GetCardGradedPrices)
+			cards.PUT("/:id/graded-prices", adminAuth, priceHandler.SetCardGradedPrice)
 			cards.POST("/identify-image", cardHandler.IdentifyCardFromImage)
 			cards.POST("/:id/refresh-price", priceHandler.RefreshCardPrice)
 		}
@@ -94,12 +106,116 @@ func SetupRouter(scryfallService *services.ScryfallService, pokemonService *serv
 			collection.GET("/grouped", collectionHandler.GetGroupedCollection)
 			collection.GET("/stats", collectionHandler.GetStats)
 			collection.GET("/stats/history", collectionHandler.GetValueHistory)
+			collection.GET("/movers", collectionHandler.GetMovers)
+			collection.GET("/sets", collectionHandler.GetSetCompletion)
+			collection.GET("/sets/:setCode/missing", collectionHandler.GetMissingSetCards)
+			collection.GET("/export", collectionHandler.ExportCollection)
+			collection.GET("/sales", collectionHandler.GetSales)
+			collection.GET("/sales/report", collectionHandler.GetSalesReport)
 
 			// Protected routes (require admin key)
 			collection.POST("", adminAuth, collectionHandler.AddToCollection)
 			collection.PUT("/:id", adminAuth, collectionHandler.UpdateCollectionItem)
 			collection.DELETE("/:id", adminAuth, collectionHandler.DeleteCollectionItem)
+			collection.POST("/:id/sell", adminAuth, collectionHandler.SellCollectionItem)
 			collection.POST("/refresh-prices", adminAuth, collectionHandler.RefreshPrices)
+			collection.POST("/tag", adminAuth, collectionHandler.TagItems)
+			collection.POST("/untag", adminAuth, collectionHandler.UntagItems)
+		}
+
+		// Sealed product routes (catalog and sealed collection)
+		sealed := api.Group("/sealed")
+		{
+			// Public routes (read-only)
+			sealed.GET("/products", sealedHandler.GetSealedProducts)
+			sealed.GET("/products/search", sealedHandler.SearchSealedProducts)
+			sealed.GET("/collection", sealedHandler.GetSealedCollection)
+
+			// Protected routes (require admin key)
+			sealed.POST("/products", adminAuth, sealedHandler.CreateSealedProduct)
+			sealed.PUT("/products/:id", adminAuth, sealedHandler.UpdateSealedProduct)
+			sealed.DELETE("/products/:id", adminAuth, sealedHandler.DeleteSealedProduct)
+			sealed.POST("/products/:id/refresh-price", adminAuth, sealedHandler.RefreshSealedProductPrice)
+			sealed.POST("/collection", adminAuth, sealedHandler.AddSealedItem)
+			sealed.PUT("/collection/:id", adminAuth, sealedHandler.UpdateSealedItem)
+			sealed.DELETE("/collection/:id", adminAuth, sealedHandler.DeleteSealedItem)
+		}
+
+		// Storage location routes (binders, boxes, deck boxes)
+		storageLocations := api.Group("/storage-locations")
+		{
+			// Public routes (read-only)
+			storageLocations.GET("", storageLocationHandler.GetStorageLocations)
+			storageLocations.GET("/:id", storageLocationHandler.GetStorageLocation)
+
+			// Protected routes (require admin key)
+			storageLocations.POST("", adminAuth, storageLocationHandler.CreateStorageLocation)
+			storageLocations.PUT("/:id", adminAuth, storageLocationHandler.UpdateStorageLocation)
+			storageLocations.DELETE("/:id", adminAuth, storageLocationHandler.DeleteStorageLocation)
+		}
+
+		// Tag routes
+		tags := api.Group("/tags")
+		{
+			// Public routes (read-only)
+			tags.GET("", tagHandler.GetTags)
+
+			// Protected routes (require admin key)
+			tags.POST("", adminAuth, tagHandler.CreateTag)
+			tags.PUT("/:id", adminAuth, tagHandler.UpdateTag)
+			tags.DELETE("/:id", adminAuth, tagHandler.DeleteTag)
+		}
+
+		// Want list routes
+		wantList := api.Group("/wantlist")
+		{
+			// Public routes (read-only)
+			wantList.GET("", wantListHandler.GetWantList)
+			wantList.GET("/under-target", wantListHandler.GetUnderTarget)
+
+			// Protected routes (require admin key)
+			wantList.POST("", adminAuth, wantListHandler.CreateWantListEntry)
+			wantList.PUT("/:id", adminAuth, wantListHandler.UpdateWantListEntry)
+			wantList.DELETE("/:id", adminAuth, wantListHandler.DeleteWantListEntry)
+		}
+
+		// Deck routes
+		decks := api.Group("/decks")
+		{
+			// Public routes (read-only)
+			decks.GET("", deckHandler.GetDecks)
+			decks.GET("/:id", deckHandler.GetDeck)
+			decks.GET("/:id/ownership", deckHandler.GetDeckOwnership)
+
+			// Protected routes (require admin key)
+			decks.POST("", adminAuth, deckHandler.CreateDeck)
+			decks.PUT("/:id", adminAuth, deckHandler.UpdateDeck)
+			decks.DELETE("/:id", adminAuth, deckHandler.DeleteDeck)
+			decks.POST("/:id/import", adminAuth, deckHandler.ImportDecklist)
+		}
+
+		// Price alert routes
+		alerts := api.Group("/alerts")
+		{
+			// Public routes (read-only)
+			alerts.GET("", alertHandler.GetAlertRules)
+			alerts.GET("/events", alertHandler.GetAlertEvents)
+
+			// Protected routes (require admin key)
+			alerts.POST("", adminAuth, alertHandler.CreateAlertRule)
+			alerts.PUT("/:id", adminAuth, alertHandler.UpdateAlertRule)
+			alerts.DELETE("/:id", adminAuth, alertHandler.DeleteAlertRule)
+			alerts.POST("/evaluate", adminAuth, alertHandler.EvaluateAlerts)
+		}
+
+		// Settings routes
+		settings := api.Group("/settings")
+		{
+			// Public routes (read-only)
+			settings.GET("", settingsHandler.GetSettings)
+
+			// Protected routes (require admin key)
+			settings.PUT("", adminAuth, settingsHandler.UpdateSettings)
 		}
 
 		// Price routes (public)
@@ -117,6 +233,17 @@ func SetupRouter(scryfallService *services.ScryfallService, pokemonService *serv
 			admin.POST("/sync-tcgplayer-ids/blocking", adminHandler.SyncTCGPlayerIDsBlocking)
 			admin.POST("/sync-tcgplayer-ids/set/:setName", adminHandler.SyncSetTCGPlayerIDs)
 			admin.GET("/sync-tcgplayer-ids/status", adminHandler.GetSyncStatus)
+
+			// Value snapshots
+			admin.POST("/snapshots/backfill", collectionHandler.BackfillSnapshots)
+
+			// Scans of a card that look like the same photo added twice
+			admin.GET("/duplicate-uploads", collectionHandler.GetDuplicateUploads)
+			admin.POST("/duplicate-uploads/backfill", collectionHandler.BackfillScanFingerprints)
+
+			// Backup and restore
+			admin.GET("/backup", backupHandler.DownloadBackup)
+			admin.POST("/restore", backupHandler.RestoreBackup)
 		}
 
 		// Bulk import routes (protected)
@@ -132,6 +259,17 @@ func SetupRouter(scryfallService *services.ScryfallService, pokemonService *serv
 			bulkImport.DELETE("/jobs/:id", bulkImportHandler.DeleteJob)
 			bulkImport.GET("/search", bulkImportHandler.SearchCards)
 		}
+
+		// CSV/text import routes (protected)
+		csvImport := api.Group("/csv-import")
+		csvImport.Use(adminAuth)
+		{
+			csvImport.POST("/jobs", csvImportHandler.CreateJob)
+			csvImport.GET("/jobs/:id", csvImportHandler.GetJob)
+			csvImport.PUT("/jobs/:id/items/:itemId", csvImportHandler.UpdateItem)
+			csvImport.POST("/jobs/:id/confirm", csvImportHandler.ConfirmJob)
+			csvImport.DELETE("/jobs/:id", csvImportHandler.DeleteJob)
+		}
 	}
 
 	// Health check
@@ -50,10 +50,28 @@ func Initialize(dbPath string) error {
 	err = DB.AutoMigrate(
 		&models.Card{},
 		&models.CollectionItem{},
+		&models.StorageLocation{},
+		&models.Tag{},
+		&models.CollectionItemTag{},
 		&models.CardPrice{},
+		&models.GradedPrice{},
+		&models.CardPriceHistory{},
+		&models.CollectionSale{},
+		&models.SealedProduct{},
+		&models.SealedCollectionItem{},
+		&models.WantListEntry{},
+		&models.Deck{},
+		&models.DeckCard{},
+		&models.PriceAlertRule{},
+		&models.PriceAlertEvent{},
 		&models.CollectionValueSnapshot{},
+		&models.CollectionValueBreakdown{},
+		&models.ExchangeRate{},
+		&models.AppSetting{},
 		&models.BulkImportJob{},
 		&models.BulkImportItem{},
+		&models.CSVImportJob{},
+		&models.CSVImportItem{},
 	)
 	if err != nil {
 		return err
@@ -205,6 +205,15 @@ var (
 		},
 	)
 
+	// Image Index Metrics
+	ImageIndexLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tcg_image_index_lookups_total",
+			Help: "Image hash index lookups before card identification, by outcome",
+		},
+		[]string{"result"}, // "match", "candidates", "miss", "error"
+	)
+
 	// Translation Decision Metrics
 	TranslationDecisions = promauto.NewCounterVec(
 		prometheus.CounterOpts{
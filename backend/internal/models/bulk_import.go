@@ -89,8 +89,18 @@ type BulkImportItem struct {
 	Language         CardLanguage         `json:"language,omitempty"`
 	ErrorCode        BulkImportErrorCode  `json:"error_code,omitempty"`    // Categorized error code for frontend display
 	ErrorMessage     string               `json:"error_message,omitempty"` // Detailed error message for debugging
-	CreatedAt        time.Time            `json:"created_at"`
-	UpdatedAt        time.Time            `json:"updated_at"`
+
+	// Set when the item was cut out of a multi-card photo (binder page, table spread):
+	// ImagePath is the crop, SourceImagePath the whole photo, and Box* the crop's
+	// bounding box in the photo's pixel coordinates
+	SourceImagePath string `json:"source_image_path,omitempty"`
+	BoxX            int    `json:"box_x"`
+	BoxY            int    `json:"box_y"`
+	BoxWidth        int    `json:"box_width"`
+	BoxHeight       int    `json:"box_height"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	// Transient fields (not persisted, populated at runtime)
 	Card          *Card  `json:"card,omitempty" gorm:"-"`
@@ -118,6 +128,9 @@ type UpdateBulkImportItemRequest struct {
 // ConfirmBulkImportRequest is the request to add items to collection
 type ConfirmBulkImportRequest struct {
 	ItemIDs []uint `json:"item_ids,omitempty"` // If empty, confirm all identified items
+
+	// Skip items whose photo was already uploaded for the card instead of warning
+	RejectDuplicates bool `json:"reject_duplicates,omitempty"`
 }
 
 // ConfirmBulkImportResponse is the response after confirming items
@@ -125,4 +138,7 @@ type ConfirmBulkImportResponse struct {
 	Added   int      `json:"added"`
 	Skipped int      `json:"skipped"`
 	Errors  []string `json:"errors,omitempty"`
+
+	// Warnings lists added items that look like cards already in the collection
+	Warnings []string `json:"warnings,omitempty"`
 }
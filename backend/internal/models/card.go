@@ -12,24 +12,25 @@ const (
 )
 
 type Card struct {
-	PriceUpdatedAt *time.Time  `json:"price_updated_at"`
-	LastPriceCheck *time.Time  `json:"last_price_check"` // When we last attempted to fetch price
-	CreatedAt      time.Time   `json:"created_at"`
-	UpdatedAt      time.Time   `json:"updated_at"`
-	ID             string      `json:"id" gorm:"primaryKey"`
-	Name           string      `json:"name" gorm:"not null;index"`
-	SetName        string      `json:"set_name"`
-	SetCode        string      `json:"set_code"`
-	CardNumber     string      `json:"card_number"`
-	Rarity         string      `json:"rarity"`
-	ImageURL       string      `json:"image_url"`
-	ImageURLLarge  string      `json:"image_url_large"`
-	PriceSource    string      `json:"price_source"` // "api", "cached", or "pending"
-	TCGPlayerID    string      `json:"tcgplayer_id"` // Cached from JustTCG for batch lookups
-	Game           Game        `json:"game" gorm:"not null;index"`
-	PriceUSD       float64     `json:"price_usd"`      // Backward compat: NM non-foil price
-	PriceFoilUSD   float64     `json:"price_foil_usd"` // Backward compat: NM foil price
-	Prices         []CardPrice `json:"prices,omitempty" gorm:"foreignKey:CardID;references:ID"`
+	PriceUpdatedAt *time.Time    `json:"price_updated_at"`
+	LastPriceCheck *time.Time    `json:"last_price_check"` // When we last attempted to fetch price
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	ID             string        `json:"id" gorm:"primaryKey"`
+	Name           string        `json:"name" gorm:"not null;index"`
+	SetName        string        `json:"set_name"`
+	SetCode        string        `json:"set_code"`
+	CardNumber     string        `json:"card_number"`
+	Rarity         string        `json:"rarity"`
+	ImageURL       string        `json:"image_url"`
+	ImageURLLarge  string        `json:"image_url_large"`
+	PriceSource    string        `json:"price_source"` // "api", "cached", or "pending"
+	TCGPlayerID    string        `json:"tcgplayer_id"` // Cached from JustTCG for batch lookups
+	Game           Game          `json:"game" gorm:"not null;index"`
+	PriceUSD       float64       `json:"price_usd"`      // Backward compat: NM non-foil price
+	PriceFoilUSD   float64       `json:"price_foil_usd"` // Backward compat: NM foil price
+	Prices         []CardPrice   `json:"prices,omitempty" gorm:"foreignKey:CardID;references:ID"`
+	GradedPrices   []GradedPrice `json:"graded_prices,omitempty" gorm:"foreignKey:CardID;references:ID"`
 
 	// MTG variant info (from Scryfall, not persisted)
 	Finishes     []string `json:"finishes,omitempty" gorm:"-"`      // nonfoil, foil, etched
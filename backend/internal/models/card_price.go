@@ -75,7 +75,7 @@ type CardPrice struct {
 	Printing       PrintingType   `json:"printing" gorm:"not null;uniqueIndex:idx_card_cond_print_lang;default:'Normal'"`
 	Language       CardLanguage   `json:"language" gorm:"not null;uniqueIndex:idx_card_cond_print_lang;default:'English'"`
 	PriceUSD       float64        `json:"price_usd"`
-	Source         string         `json:"source"` // "justtcg" (sole price source)
+	Source         string         `json:"source"` // Price provider: "justtcg" or "scryfall"
 	PriceUpdatedAt *time.Time     `json:"price_updated_at"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// CardPriceHistory is an append-only record of a single price observation.
+// CardPrice only holds the latest value per (card, condition, printing, language);
+// every refresh also writes a row here so the trend is preserved.
+type CardPriceHistory struct {
+	ID         uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	CardID     string         `json:"card_id" gorm:"not null;index:idx_price_history_lookup,priority:1"`
+	Condition  PriceCondition `json:"condition" gorm:"not null;index:idx_price_history_lookup,priority:2"`
+	Printing   PrintingType   `json:"printing" gorm:"not null;default:'Normal';index:idx_price_history_lookup,priority:3"`
+	Language   CardLanguage   `json:"language" gorm:"not null;default:'English';index:idx_price_history_lookup,priority:4"`
+	PriceUSD   float64        `json:"price_usd"`
+	Source     string         `json:"source"`
+	RecordedAt time.Time      `json:"recorded_at" gorm:"not null;index:idx_price_history_lookup,priority:5"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// TableName keeps the table name singular to match the "history" naming used in the API
+func (CardPriceHistory) TableName() string {
+	return "card_price_history"
+}
+
+// PriceHistoryPoint is a single point in a price time series
+type PriceHistoryPoint struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	PriceUSD   float64   `json:"price_usd"`
+	Source     string    `json:"source,omitempty"`
+}
+
+// PriceHistorySeries is the time series for one condition/printing/language combination
+type PriceHistorySeries struct {
+	Condition PriceCondition      `json:"condition"`
+	Printing  PrintingType        `json:"printing"`
+	Language  CardLanguage        `json:"language"`
+	Points    []PriceHistoryPoint `json:"points"`
+}
+
+// PriceHistoryResponse is the API response for a card's price history
+type PriceHistoryResponse struct {
+	CardID string               `json:"card_id"`
+	Period string               `json:"period"` // "week", "month", "3month", "year", "all"
+	Series []PriceHistorySeries `json:"series"`
+}
@@ -16,6 +16,19 @@ const (
 	ConditionPoor      Condition = "PR"
 )
 
+// AllConditions returns all card conditions, best first
+func AllConditions() []Condition {
+	return []Condition{
+		ConditionMint,
+		ConditionNearMint,
+		ConditionExcellent,
+		ConditionGood,
+		ConditionLightPlay,
+		ConditionPlayed,
+		ConditionPoor,
+	}
+}
+
 type CollectionItem struct {
 	ID               uint         `json:"id" gorm:"primaryKey;autoIncrement"`
 	CardID           string       `json:"card_id" gorm:"not null;index"`
@@ -27,11 +40,112 @@ type CollectionItem struct {
 	Notes            string       `json:"notes"`
 	AddedAt          time.Time    `json:"added_at"`
 	ScannedImagePath string       `json:"scanned_image_path" gorm:"default:null"`
+	ScanFingerprint  string       `json:"scan_fingerprint,omitempty"` // Perceptual hash of the scan, for duplicate upload detection
+
+	// Cost basis (optional). PurchasePrice is per card, so it survives splits unchanged.
+	PurchasePrice     *float64   `json:"purchase_price,omitempty"`
+	PurchaseDate      *time.Time `json:"purchase_date,omitempty"`
+	AcquisitionSource string     `json:"acquisition_source,omitempty"` // e.g. "LGS", "TCGplayer", "trade", "pack"
+
+	// Physical storage (optional). Stacks in different locations are never merged.
+	StorageLocationID *uint `json:"storage_location_id,omitempty" gorm:"index"`
+
+	// Grading (optional). A graded slab is one physical card, so like a scanned card it
+	// is never merged and always has quantity 1.
+	GradingCompany GradingCompany `json:"grading_company,omitempty" gorm:"index"`
+	Grade          string         `json:"grade,omitempty"`       // "10", "9.5", ... or "Authentic"
+	CertNumber     string         `json:"cert_number,omitempty"` // Certificate number printed on the slab label
+	SlabNotes      string         `json:"slab_notes,omitempty"`  // e.g. subgrades, label type, case damage
 
 	// Calculated fields (not persisted to database)
-	ItemValue     float64      `json:"item_value" gorm:"-"`               // Condition-specific value for this item
-	PriceLanguage CardLanguage `json:"price_language,omitempty" gorm:"-"` // Language of price used (may differ if fallback)
-	PriceFallback bool         `json:"price_fallback,omitempty" gorm:"-"` // True if price is from different language than card
+	StorageLocationPath string       `json:"storage_location_path,omitempty" gorm:"-"` // e.g. "Binder A / Page 3"
+	Tags                []string     `json:"tags,omitempty" gorm:"-"`                  // Tag names, sorted ignoring case
+	ItemValue           float64      `json:"item_value" gorm:"-"`                      // Condition-specific value for this item
+	PriceLanguage       CardLanguage `json:"price_language,omitempty" gorm:"-"`        // Language of price used (may differ if fallback)
+	PriceFallback       bool         `json:"price_fallback,omitempty" gorm:"-"`        // True if price is from different language than card
+	GradedPriceFallback bool         `json:"graded_price_fallback,omitempty" gorm:"-"` // True if a slab is valued at its raw price (no graded price known)
+	CostBasis           *float64     `json:"cost_basis,omitempty" gorm:"-"`            // PurchasePrice * Quantity (nil if unknown)
+	UnrealizedGain      *float64     `json:"unrealized_gain,omitempty" gorm:"-"`       // ItemValue - CostBasis (nil if unknown)
+
+	// Existing scans of the same card that look like the same photo uploaded again (set when adding a scan)
+	DuplicateUploads []DuplicateUpload `json:"duplicate_uploads,omitempty" gorm:"-"`
+
+	// ItemValue converted to the display currency at today's exchange rate
+	DisplayCurrency  string  `json:"display_currency,omitempty" gorm:"-"`
+	DisplayItemValue float64 `json:"display_item_value,omitempty" gorm:"-"`
+}
+
+// IsGraded reports whether the item is a graded slab
+func (i *CollectionItem) IsGraded() bool {
+	return i.GradingCompany != ""
+}
+
+// IsIndividual reports whether the item is a single tracked physical card (scanned or
+// graded) that is never merged into or split from a stack
+func (i *CollectionItem) IsIndividual() bool {
+	return i.ScannedImagePath != "" || i.IsGraded()
+}
+
+// UnitPrice returns the per-card price the item is valued at. Slabs use the card's
+// graded price for their company and grade; when none is known they fall back to the
+// raw condition price and gradedFallback is true. Card.Prices and Card.GradedPrices
+// must be loaded.
+func (i *CollectionItem) UnitPrice(card *Card) (result PriceResult, gradedFallback bool) {
+	if i.IsGraded() {
+		if price, ok := card.GetGradedPrice(i.GradingCompany, i.Grade); ok {
+			language := i.Language
+			if language == "" {
+				language = LanguageEnglish
+			}
+			return PriceResult{Price: price, PriceLanguage: language}, false
+		}
+	}
+	priceCondition := MapCollectionConditionToPriceCondition(i.Condition)
+	return card.GetPriceWithSource(priceCondition, i.Printing, i.Language), i.IsGraded()
+}
+
+// CalculateGainLoss fills CostBasis and UnrealizedGain from PurchasePrice and ItemValue.
+// Must be called after ItemValue is set. Items without a purchase price are left nil.
+func (i *CollectionItem) CalculateGainLoss() {
+	if i.PurchasePrice == nil {
+		i.CostBasis = nil
+		i.UnrealizedGain = nil
+		return
+	}
+	costBasis := *i.PurchasePrice * float64(i.Quantity)
+	gain := i.ItemValue - costBasis
+	i.CostBasis = &costBasis
+	i.UnrealizedGain = &gain
+}
+
+// MergePurchasePrice returns the per-card purchase price after merging two stacks.
+// Known prices are combined as a quantity-weighted average. Priced and unpriced
+// copies are kept in separate stacks; should they still be merged, the result is nil
+// (unknown) rather than applying one side's price to the unpriced copies.
+func MergePurchasePrice(aPrice *float64, aQty int, bPrice *float64, bQty int) *float64 {
+	switch {
+	case aPrice == nil && bPrice == nil:
+		return nil
+	case aPrice == nil:
+		if aQty > 0 {
+			return nil
+		}
+		price := *bPrice
+		return &price
+	case bPrice == nil:
+		if bQty > 0 {
+			return nil
+		}
+		price := *aPrice
+		return &price
+	}
+	totalQty := aQty + bQty
+	if totalQty <= 0 {
+		price := *aPrice
+		return &price
+	}
+	price := (*aPrice*float64(aQty) + *bPrice*float64(bQty)) / float64(totalQty)
+	return &price
 }
 
 type CollectionStats struct {
@@ -42,6 +156,27 @@ type CollectionStats struct {
 	PokemonCards int     `json:"pokemon_cards"`
 	MTGValue     float64 `json:"mtg_value"`
 	PokemonValue float64 `json:"pokemon_value"`
+
+	// Sealed products are valued separately from singles; SealedValue is part of
+	// TotalValue, but SealedItems is not part of TotalCards
+	SealedItems int     `json:"sealed_items"`
+	SealedValue float64 `json:"sealed_value"`
+
+	// Cost basis totals only cover items (singles and sealed) with a purchase price
+	CostBasisCards int     `json:"cost_basis_cards"` // Cards and sealed items with a known purchase price
+	TotalCostBasis float64 `json:"total_cost_basis"` // Sum of purchase price * quantity
+	CostBasisValue float64 `json:"cost_basis_value"` // Current value of the cards with a known purchase price
+	UnrealizedGain float64 `json:"unrealized_gain"`  // CostBasisValue - TotalCostBasis
+
+	ByTag []TagStats `json:"by_tag,omitempty"` // Value per tag, sorted by name ignoring case
+
+	// Values converted to the display currency at today's exchange rate
+	DisplayCurrency     string  `json:"display_currency"`
+	ExchangeRate        float64 `json:"exchange_rate"` // Units of DisplayCurrency per USD
+	DisplayTotalValue   float64 `json:"display_total_value"`
+	DisplayMTGValue     float64 `json:"display_mtg_value"`
+	DisplayPokemonValue float64 `json:"display_pokemon_value"`
+	DisplaySealedValue  float64 `json:"display_sealed_value"`
 }
 
 type AddToCollectionRequest struct {
@@ -52,7 +187,20 @@ type AddToCollectionRequest struct {
 	Language         CardLanguage `json:"language"`
 	Notes            string       `json:"notes"`
 	ScannedImageData string       `json:"scanned_image_data,omitempty"` // base64 encoded
+	RejectDuplicate  bool         `json:"reject_duplicate,omitempty"`   // Reject a photo already uploaded for the card instead of warning
 	OCRText          string       `json:"ocr_text,omitempty"`           // For caching Japanese card translations
+
+	PurchasePrice     *float64   `json:"purchase_price,omitempty"` // Per card, USD
+	PurchaseDate      *time.Time `json:"purchase_date,omitempty"`
+	AcquisitionSource string     `json:"acquisition_source,omitempty"`
+
+	StorageLocationID *uint `json:"storage_location_id,omitempty"`
+
+	// Graded slabs are always added as a new item with quantity 1
+	GradingCompany GradingCompany `json:"grading_company,omitempty"`
+	Grade          string         `json:"grade,omitempty"`
+	CertNumber     string         `json:"cert_number,omitempty"`
+	SlabNotes      string         `json:"slab_notes,omitempty"`
 }
 
 type UpdateCollectionRequest struct {
@@ -62,6 +210,42 @@ type UpdateCollectionRequest struct {
 	Printing  *PrintingType `json:"printing"`
 	Language  *CardLanguage `json:"language"`
 	Notes     *string       `json:"notes"`
+
+	PurchasePrice     *float64   `json:"purchase_price"` // Per card, USD; negative clears it
+	PurchaseDate      *time.Time `json:"purchase_date"`
+	AcquisitionSource *string    `json:"acquisition_source"`
+
+	// Moving to another location is an attribute change like condition: a stack is split
+	// and only SplitQuantity copies move. A StorageLocationID of 0 clears the location.
+	StorageLocationID *uint `json:"storage_location_id"`
+	SplitQuantity     *int  `json:"split_quantity"` // Copies to split off on an attribute change (default 1)
+
+	// Setting a grading company on a stack splits one copy off as a slab; an empty
+	// company turns a slab back into a raw card
+	GradingCompany *GradingCompany `json:"grading_company"`
+	Grade          *string         `json:"grade"`
+	CertNumber     *string         `json:"cert_number"`
+	SlabNotes      *string         `json:"slab_notes"`
+}
+
+// DuplicateUpload is a collection item whose scan looks like the same photo as another scan
+type DuplicateUpload struct {
+	ItemID           uint   `json:"item_id"`
+	ScannedImagePath string `json:"scanned_image_path"`
+	Distance         int    `json:"distance"` // Fingerprint distance (0-128, lower is closer)
+}
+
+// DuplicateUploadGroup is a card with scans that look like the same photo
+type DuplicateUploadGroup struct {
+	CardID   string            `json:"card_id"`
+	CardName string            `json:"card_name"`
+	Scans    []DuplicateUpload `json:"scans"` // Distance is to the group's first scan
+}
+
+// DuplicateUploadReport lists photos that look uploaded more than once across the collection
+type DuplicateUploadReport struct {
+	Unfingerprinted int64                  `json:"unfingerprinted"` // Older scans left out until backfilled
+	Groups          []DuplicateUploadGroup `json:"groups"`
 }
 
 // CollectionUpdateResponse includes the updated item plus operation info
@@ -73,15 +257,17 @@ type CollectionUpdateResponse struct {
 
 // CollectionVariant summarizes items with same printing+condition+language
 type CollectionVariant struct {
-	Printing      PrintingType `json:"printing"`
-	Condition     Condition    `json:"condition"`
-	Language      CardLanguage `json:"language"`
-	Quantity      int          `json:"quantity"`
-	Value         float64      `json:"value"`
-	HasScans      bool         `json:"has_scans"`
-	ScannedQty    int          `json:"scanned_qty"`
-	PriceLanguage CardLanguage `json:"price_language,omitempty"` // Language of price used (may differ if fallback)
-	PriceFallback bool         `json:"price_fallback,omitempty"` // True if price is from different language than card
+	Printing       PrintingType   `json:"printing"`
+	Condition      Condition      `json:"condition"`
+	Language       CardLanguage   `json:"language"`
+	Quantity       int            `json:"quantity"`
+	Value          float64        `json:"value"`
+	GradingCompany GradingCompany `json:"grading_company,omitempty"` // Set for slabs, which are grouped by grade
+	Grade          string         `json:"grade,omitempty"`
+	HasScans       bool           `json:"has_scans"`
+	ScannedQty     int            `json:"scanned_qty"`
+	PriceLanguage  CardLanguage   `json:"price_language,omitempty"` // Language of price used (may differ if fallback)
+	PriceFallback  bool           `json:"price_fallback,omitempty"` // True if price is from different language than card
 }
 
 // GroupedCollectionItem represents a card with all its collection entries grouped
@@ -92,4 +278,13 @@ type GroupedCollectionItem struct {
 	ScannedCount  int                 `json:"scanned_count"`
 	Variants      []CollectionVariant `json:"variants"`
 	Items         []CollectionItem    `json:"items"`
+
+	// Cost basis totals only cover items with a purchase price
+	CostBasisQuantity int     `json:"cost_basis_quantity"`
+	TotalCostBasis    float64 `json:"total_cost_basis"`
+	UnrealizedGain    float64 `json:"unrealized_gain"`
+
+	// TotalValue converted to the display currency
+	DisplayCurrency   string  `json:"display_currency,omitempty"`
+	DisplayTotalValue float64 `json:"display_total_value,omitempty"`
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// CSVImportItemStatus represents the match status of a single imported row
+type CSVImportItemStatus string
+
+const (
+	CSVImportItemPending    CSVImportItemStatus = "pending"
+	CSVImportItemIdentified CSVImportItemStatus = "identified" // Resolved to exactly one card
+	CSVImportItemAmbiguous  CSVImportItemStatus = "ambiguous"  // Several candidates, needs review
+	CSVImportItemFailed     CSVImportItemStatus = "failed"     // No card found
+	CSVImportItemConfirmed  CSVImportItemStatus = "confirmed"  // Successfully added to collection
+)
+
+// CSVImportJob is a staged import of a CSV or text export from another app.
+// Rows are resolved to cards in the background and reviewed before confirming.
+type CSVImportJob struct {
+	ID             string              `json:"id" gorm:"primaryKey"`
+	Format         string              `json:"format"` // "manabox", "dragonshield", "tcgplayer", "deckbox", "generic", "text"
+	Filename       string              `json:"filename"`
+	Game           Game                `json:"game,omitempty"` // Game hint from the request; empty means try both
+	Status         BulkImportJobStatus `json:"status" gorm:"not null;default:'pending'"`
+	TotalItems     int                 `json:"total_items" gorm:"not null"`
+	ProcessedItems int                 `json:"processed_items" gorm:"default:0"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Items          []CSVImportItem     `json:"items,omitempty" gorm:"foreignKey:JobID;constraint:OnDelete:CASCADE"`
+}
+
+// CSVImportItem is a single row of an import, with the values parsed from the
+// file and the card it was matched to
+type CSVImportItem struct {
+	ID        uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	JobID     string `json:"job_id" gorm:"not null;index"`
+	RowNumber int    `json:"row_number"` // 1-based line in the source file
+
+	// Values as read from the file
+	RawName       string       `json:"raw_name"`
+	RawSetCode    string       `json:"raw_set_code,omitempty"`
+	RawSetName    string       `json:"raw_set_name,omitempty"`
+	RawNumber     string       `json:"raw_number,omitempty"`
+	SourceCardID  string       `json:"source_card_id,omitempty"` // Card/Scryfall ID if the file has one
+	Quantity      int          `json:"quantity" gorm:"default:1"`
+	Condition     Condition    `json:"condition" gorm:"default:'NM'"`
+	PrintingType  PrintingType `json:"printing_type" gorm:"default:'Normal'"`
+	Language      CardLanguage `json:"language" gorm:"default:'English'"`
+	PurchasePrice *float64     `json:"purchase_price,omitempty"`
+	PurchaseDate  *time.Time   `json:"purchase_date,omitempty"`
+	Notes         string       `json:"notes,omitempty"`
+
+	// Match result
+	Status       CSVImportItemStatus `json:"status" gorm:"not null;default:'pending'"`
+	MatchMethod  string              `json:"match_method,omitempty"` // "id", "set_number", "name"
+	CardID       string              `json:"card_id,omitempty"`      // Suggested card for ambiguous rows
+	CardName     string              `json:"card_name,omitempty"`
+	SetCode      string              `json:"set_code,omitempty"`
+	SetName      string              `json:"set_name,omitempty"`
+	CardNumber   string              `json:"card_number,omitempty"`
+	Game         string              `json:"game,omitempty"`
+	Candidates   string              `json:"candidates,omitempty" gorm:"type:text"` // JSON array of candidate cards
+	ErrorMessage string              `json:"error_message,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// Transient fields (not persisted, populated at runtime)
+	Card          *Card  `json:"card,omitempty" gorm:"-"`
+	CandidateList []Card `json:"candidate_list,omitempty" gorm:"-"`
+}
+
+// UpdateCSVImportItemRequest is the request to change an item's card selection or attributes
+type UpdateCSVImportItemRequest struct {
+	CardID       *string       `json:"card_id"`
+	Quantity     *int          `json:"quantity"`
+	Condition    *Condition    `json:"condition"`
+	PrintingType *PrintingType `json:"printing_type"`
+	Language     *CardLanguage `json:"language"`
+}
+
+// ConfirmCSVImportRequest is the request to add items to the collection.
+// Listed items are confirmed if identified, or if ambiguous with a suggested card.
+type ConfirmCSVImportRequest struct {
+	ItemIDs []uint `json:"item_ids,omitempty"` // If empty, confirm all identified items
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// CurrencyUSD is the currency all prices are stored in
+const CurrencyUSD = "USD"
+
+// SettingDisplayCurrency is the AppSetting key for the currency values are shown in
+const SettingDisplayCurrency = "display_currency"
+
+// ExchangeRate is the number of units of Currency that one USD bought on Date.
+// Rates are kept per day so historical values convert at the rate of their own date.
+type ExchangeRate struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Currency  string    `json:"currency" gorm:"not null;uniqueIndex:idx_exchange_rate_currency_date"` // ISO 4217 code, e.g. "EUR"
+	Date      time.Time `json:"date" gorm:"not null;uniqueIndex:idx_exchange_rate_currency_date"`     // Day the rate applies from (UTC midnight)
+	Rate      float64   `json:"rate" gorm:"not null"`
+	Source    string    `json:"source"` // Rate source that supplied it, e.g. "frankfurter", "file"
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// AppSetting is a user-changeable application setting stored as a key/value pair
+type AppSetting struct {
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SettingsResponse is the API response for the application settings
+type SettingsResponse struct {
+	DisplayCurrency     string     `json:"display_currency"`
+	ExchangeRate        float64    `json:"exchange_rate"`                // Units of DisplayCurrency per USD today
+	ExchangeRateDate    *time.Time `json:"exchange_rate_date,omitempty"` // Date of that rate (nil for USD)
+	AvailableCurrencies []string   `json:"available_currencies"`         // Currencies with stored rates, plus USD
+}
+
+// UpdateSettingsRequest changes application settings
+type UpdateSettingsRequest struct {
+	DisplayCurrency *string `json:"display_currency"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+)
+
+// DeckSection is the part of a deck a card is in
+type DeckSection string
+
+const (
+	DeckSectionMain      DeckSection = "main"
+	DeckSectionSideboard DeckSection = "sideboard"
+	DeckSectionCommander DeckSection = "commander"
+	DeckSectionCompanion DeckSection = "companion"
+)
+
+// AllDeckSections returns the deck sections in display order
+func AllDeckSections() []DeckSection {
+	return []DeckSection{
+		DeckSectionCommander,
+		DeckSectionCompanion,
+		DeckSectionMain,
+		DeckSectionSideboard,
+	}
+}
+
+// Deck is a decklist the user is building. Its cards are matched against the
+// collection by name, so any printing of a card counts toward it.
+type Deck struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string     `json:"name" gorm:"not null"`
+	Game      Game       `json:"game" gorm:"not null;index"`
+	Format    string     `json:"format,omitempty"` // Free text, e.g. "standard", "commander"
+	Notes     string     `json:"notes,omitempty"`
+	Cards     []DeckCard `json:"cards,omitempty" gorm:"foreignKey:DeckID"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Calculated fields (not persisted to database)
+	CardCount    int      `json:"card_count" gorm:"-"`              // Sum of quantities across sections
+	ImportErrors []string `json:"import_errors,omitempty" gorm:"-"` // Decklist lines that could not be parsed
+}
+
+// DeckCard is one line of a decklist. Rows are removed explicitly with their deck.
+type DeckCard struct {
+	ID              uint        `json:"id" gorm:"primaryKey;autoIncrement"`
+	DeckID          uint        `json:"deck_id" gorm:"not null;index"`
+	Section         DeckSection `json:"section" gorm:"not null;default:'main'"`
+	Quantity        int         `json:"quantity" gorm:"not null;default:1"`
+	CardName        string      `json:"card_name" gorm:"not null"`
+	SetCode         string      `json:"set_code,omitempty"`             // As written in the decklist
+	CollectorNumber string      `json:"collector_number,omitempty"`     // As written in the decklist
+	CardID          string      `json:"card_id,omitempty" gorm:"index"` // Resolved printing; empty if the card was not found
+}
+
+// CreateDeckRequest creates a deck, optionally importing a decklist. Name may be
+// omitted when the decklist names the deck (MTG Arena "About" section).
+type CreateDeckRequest struct {
+	Name     string `json:"name"`
+	Game     Game   `json:"game" binding:"required"`
+	Format   string `json:"format"`
+	Notes    string `json:"notes"`
+	Decklist string `json:"decklist"`
+}
+
+type UpdateDeckRequest struct {
+	Name   *string `json:"name"`
+	Format *string `json:"format"`
+	Notes  *string `json:"notes"`
+}
+
+// ImportDecklistRequest replaces a deck's cards with a decklist, or adds the
+// decklist's cards to them with Append
+type ImportDecklistRequest struct {
+	Decklist string `json:"decklist" binding:"required"`
+	Append   bool   `json:"append"`
+}
+
+// DeckCardOwnership is a decklist line compared with the collection
+type DeckCardOwnership struct {
+	DeckCard
+	Owned   int `json:"owned"`   // Copies in the collection counted toward this line
+	Missing int `json:"missing"` // Quantity - Owned
+
+	// Cheapest NM English price of any cached printing, 0 if unknown
+	PriceUSD      float64      `json:"price_usd"`
+	PriceCardID   string       `json:"price_card_id,omitempty"`
+	PricePrinting PrintingType `json:"price_printing,omitempty"`
+	Card          *Card        `json:"card,omitempty"` // Printing the price is for, or the resolved printing
+}
+
+// DeckOwnership compares a deck with the collection. A card owned fewer times than
+// the deck needs is listed in Missing, with the cost to buy the rest.
+type DeckOwnership struct {
+	DeckID       uint   `json:"deck_id"`
+	Name         string `json:"name"`
+	Game         Game   `json:"game"`
+	TotalCards   int    `json:"total_cards"`
+	OwnedCards   int    `json:"owned_cards"`
+	MissingCards int    `json:"missing_cards"`
+	Complete     bool   `json:"complete"`
+
+	// Sum of price * missing over the missing cards with a cached price. Missing cards
+	// without one are counted in UnpricedMissing.
+	CostToComplete  float64 `json:"cost_to_complete"`
+	UnpricedMissing int     `json:"unpriced_missing"`
+
+	Cards   []DeckCardOwnership `json:"cards"`
+	Missing []DeckCardOwnership `json:"missing"`
+}
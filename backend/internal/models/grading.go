@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GradingCompany is the service that graded and slabbed a card
+type GradingCompany string
+
+const (
+	GradingPSA GradingCompany = "PSA" // Professional Sports Authenticator
+	GradingBGS GradingCompany = "BGS" // Beckett Grading Services
+	GradingCGC GradingCompany = "CGC" // Certified Guaranty Company
+	GradingSGC GradingCompany = "SGC" // Sportscard Guaranty
+)
+
+// GradeAuthentic is the grade of a slab that is certified genuine but not numerically graded
+const GradeAuthentic = "Authentic"
+
+// AllGradingCompanies returns all supported grading companies
+func AllGradingCompanies() []GradingCompany {
+	return []GradingCompany{GradingPSA, GradingBGS, GradingCGC, GradingSGC}
+}
+
+// NormalizeGradingCompany maps a company name in any case to a GradingCompany.
+// It reports false for unknown companies.
+func NormalizeGradingCompany(company string) (GradingCompany, bool) {
+	normalized := GradingCompany(strings.ToUpper(strings.TrimSpace(company)))
+	if normalized == "BECKETT" {
+		normalized = GradingBGS
+	}
+	for _, c := range AllGradingCompanies() {
+		if c == normalized {
+			return c, true
+		}
+	}
+	return normalized, false
+}
+
+// NormalizeGrade canonicalizes a grade so "10", "10.0" and " 10 " are stored alike.
+// Numeric grades run from 1 to 10 in half steps; "Authentic" (or "A") is also
+// accepted. It reports false for anything else.
+func NormalizeGrade(grade string) (string, bool) {
+	grade = strings.TrimSpace(grade)
+	if strings.EqualFold(grade, GradeAuthentic) || strings.EqualFold(grade, "A") {
+		return GradeAuthentic, true
+	}
+	value, err := strconv.ParseFloat(grade, 64)
+	if err != nil || value < 1 || value > 10 || value*2 != float64(int(value*2)) {
+		return grade, false
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), true
+}
+
+// GradedPriceSourceManual marks graded prices entered by hand, which never go stale
+const GradedPriceSourceManual = "manual"
+
+// GradedPrice is the market price of a slab of a card at one company and grade.
+// Graded prices replace condition prices, so they have no condition of their own.
+type GradedPrice struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	CardID         string         `json:"card_id" gorm:"not null;uniqueIndex:idx_graded_card_company_grade"`
+	GradingCompany GradingCompany `json:"grading_company" gorm:"not null;uniqueIndex:idx_graded_card_company_grade"`
+	Grade          string         `json:"grade" gorm:"not null;uniqueIndex:idx_graded_card_company_grade"`
+	PriceUSD       float64        `json:"price_usd"`
+	Source         string         `json:"source"` // "manual" for prices entered by hand
+	PriceUpdatedAt *time.Time     `json:"price_updated_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// GetGradedPrice returns the card's price for a slab of the company and grade, or
+// false when no graded price is known. GradedPrices must be loaded.
+func (c *Card) GetGradedPrice(company GradingCompany, grade string) (float64, bool) {
+	for _, p := range c.GradedPrices {
+		if p.GradingCompany == company && p.Grade == grade && p.PriceUSD > 0 {
+			return p.PriceUSD, true
+		}
+	}
+	return 0, false
+}
+
+// SetGradedPriceRequest records the price of a slab of a card. A price of 0 removes it.
+type SetGradedPriceRequest struct {
+	GradingCompany GradingCompany `json:"grading_company" binding:"required"`
+	Grade          string         `json:"grade" binding:"required"`
+	PriceUSD       float64        `json:"price_usd"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNormalizeGrade(t *testing.T) {
+	tests := []struct {
+		grade string
+		want  string
+		ok    bool
+	}{
+		{"10", "10", true},
+		{" 10.0 ", "10", true},
+		{"9.50", "9.5", true},
+		{"1", "1", true},
+		{"authentic", GradeAuthentic, true},
+		{"A", GradeAuthentic, true},
+		{"9.3", "", false},
+		{"0.5", "", false},
+		{"11", "", false},
+		{"gem mint", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.grade, func(t *testing.T) {
+			got, ok := NormalizeGrade(tt.grade)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Errorf("NormalizeGrade(%q) = %q, %v; want %q, %v", tt.grade, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeGradingCompany(t *testing.T) {
+	tests := []struct {
+		company string
+		want    GradingCompany
+		ok      bool
+	}{
+		{"PSA", GradingPSA, true},
+		{"psa", GradingPSA, true},
+		{"Beckett", GradingBGS, true},
+		{" cgc ", GradingCGC, true},
+		{"SGC", GradingSGC, true},
+		{"ACE", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.company, func(t *testing.T) {
+			got, ok := NormalizeGradingCompany(tt.company)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Errorf("NormalizeGradingCompany(%q) = %q, %v; want %q, %v", tt.company, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCollectionItemUnitPrice(t *testing.T) {
+	card := &Card{
+		PriceUSD: 100,
+		Prices: []CardPrice{
+			{Condition: PriceConditionLP, Printing: PrintingNormal, Language: LanguageEnglish, PriceUSD: 80},
+		},
+		GradedPrices: []GradedPrice{
+			{GradingCompany: GradingPSA, Grade: "10", PriceUSD: 1500},
+			{GradingCompany: GradingPSA, Grade: "9", PriceUSD: 0}, // Unknown price
+		},
+	}
+
+	tests := []struct {
+		name         string
+		item         CollectionItem
+		wantPrice    float64
+		wantFallback bool
+	}{
+		{"raw LP", CollectionItem{Condition: ConditionLightPlay, Printing: PrintingNormal}, 80, false},
+		{"graded price", CollectionItem{Condition: ConditionLightPlay, Printing: PrintingNormal, GradingCompany: GradingPSA, Grade: "10"}, 1500, false},
+		{"other company falls back to raw", CollectionItem{Condition: ConditionNearMint, Printing: PrintingNormal, GradingCompany: GradingBGS, Grade: "10"}, 100, true},
+		{"zero graded price falls back to raw", CollectionItem{Condition: ConditionNearMint, Printing: PrintingNormal, GradingCompany: GradingPSA, Grade: "9"}, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, fallback := tt.item.UnitPrice(card)
+			if result.Price != tt.wantPrice || fallback != tt.wantFallback {
+				t.Errorf("UnitPrice() = %v, %v; want %v, %v", result.Price, fallback, tt.wantPrice, tt.wantFallback)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// PriceMover is the change in value of the copies of one card printing in the collection.
+// Values are condition-appropriate unit prices times the quantity held, as in GetStats.
+type PriceMover struct {
+	CardID        string       `json:"card_id"`
+	CardName      string       `json:"card_name"`
+	SetCode       string       `json:"set_code"`
+	SetName       string       `json:"set_name"`
+	ImageURL      string       `json:"image_url,omitempty"`
+	Game          Game         `json:"game"`
+	Condition     Condition    `json:"condition"`
+	Printing      PrintingType `json:"printing"`
+	Language      CardLanguage `json:"language"`
+	Quantity      int          `json:"quantity"`
+	UnitPriceThen float64      `json:"unit_price_then"`
+	UnitPriceNow  float64      `json:"unit_price_now"`
+	ValueThen     float64      `json:"value_then"`
+	ValueNow      float64      `json:"value_now"`
+	Change        float64      `json:"change"`         // ValueNow - ValueThen
+	ChangePercent float64      `json:"change_percent"` // Change relative to ValueThen; 0 when ValueThen is 0
+}
+
+// GameMovers ranks the movers of one game by absolute and by percent change
+type GameMovers struct {
+	TrackedCards   int          `json:"tracked_cards"` // Card printings with a price observation to compare against
+	ValueThen      float64      `json:"value_then"`
+	ValueNow       float64      `json:"value_now"`
+	Change         float64      `json:"change"`
+	ChangePercent  float64      `json:"change_percent"`
+	Gainers        []PriceMover `json:"gainers"`         // Largest increase in value first
+	Losers         []PriceMover `json:"losers"`          // Largest decrease in value first
+	PercentGainers []PriceMover `json:"percent_gainers"` // Largest percent increase first
+	PercentLosers  []PriceMover `json:"percent_losers"`  // Largest percent decrease first
+}
+
+// MoversResponse is the API response for the collection's top movers
+type MoversResponse struct {
+	Period  string     `json:"period"` // "7d" or "30d"
+	Since   time.Time  `json:"since"`
+	MTG     GameMovers `json:"mtg"`
+	Pokemon GameMovers `json:"pokemon"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertRuleType is the kind of condition a price alert watches
+type AlertRuleType string
+
+const (
+	AlertRulePriceThreshold  AlertRuleType = "price_threshold"  // A card's price is above/below Threshold USD
+	AlertRulePercentChange   AlertRuleType = "percent_change"   // A card's price moved Threshold percent over Days
+	AlertRuleCollectionValue AlertRuleType = "collection_value" // Total collection value is above/below Threshold USD
+)
+
+// IsValid reports whether t is one of the known rule types
+func (t AlertRuleType) IsValid() bool {
+	switch t {
+	case AlertRulePriceThreshold, AlertRulePercentChange, AlertRuleCollectionValue:
+		return true
+	}
+	return false
+}
+
+// IsCardRule reports whether rules of this type watch a single card
+func (t AlertRuleType) IsCardRule() bool {
+	return t == AlertRulePriceThreshold || t == AlertRulePercentChange
+}
+
+// AlertDirection is which side of the threshold triggers an alert
+type AlertDirection string
+
+const (
+	AlertDirectionAbove  AlertDirection = "above"  // Value at or above threshold (percent: rose by at least threshold)
+	AlertDirectionBelow  AlertDirection = "below"  // Value at or below threshold (percent: fell by at least threshold)
+	AlertDirectionEither AlertDirection = "either" // Percent change rules only: moved by at least threshold either way
+)
+
+// DefaultAlertCooldownMinutes is how long a rule stays quiet after firing (one day)
+const DefaultAlertCooldownMinutes = 24 * 60
+
+// PriceAlertRule is a user-defined condition checked after every price update batch.
+// A rule fires when its condition becomes true; it re-arms once the condition is
+// false again and never fires more than once per cooldown.
+type PriceAlertRule struct {
+	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string         `json:"name"`
+	Type      AlertRuleType  `json:"type" gorm:"not null;index"`
+	Enabled   bool           `json:"enabled" gorm:"not null"`
+	CardID    string         `json:"card_id,omitempty" gorm:"index"` // Card rules only
+	Condition PriceCondition `json:"condition,omitempty"`            // Card rules only; defaults to NM
+	Printing  PrintingType   `json:"printing,omitempty"`             // Card rules only; defaults to Normal
+	Language  CardLanguage   `json:"language,omitempty"`             // Card rules only; defaults to English
+	Direction AlertDirection `json:"direction" gorm:"not null"`
+	Threshold float64        `json:"threshold"`      // USD, or percent for percent_change rules
+	Days      int            `json:"days,omitempty"` // Percent change window
+
+	CooldownMinutes int `json:"cooldown_minutes" gorm:"not null"`
+
+	// Persisted alert state so restarts don't re-send the same alert
+	Triggered     bool       `json:"triggered"`                // Condition held when last evaluated
+	LastValue     *float64   `json:"last_value,omitempty"`     // Value seen at the last evaluation
+	LastEvaluated *time.Time `json:"last_evaluated,omitempty"` // When the rule was last checked
+	LastFiredAt   *time.Time `json:"last_fired_at,omitempty"`  // When a notification was last sent
+	LastError     string     `json:"last_error,omitempty"`     // Delivery error from the last firing
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Card *Card `json:"card,omitempty" gorm:"-"`
+}
+
+// PriceAlertEvent records each time a rule fired
+type PriceAlertEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	RuleID    uint      `json:"rule_id" gorm:"not null;index"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Delivered bool      `json:"delivered"`       // At least one notifier succeeded
+	Error     string    `json:"error,omitempty"` // Errors from notifiers that failed
+	FiredAt   time.Time `json:"fired_at" gorm:"not null;index"`
+}
+
+// CreatePriceAlertRuleRequest defines a new alert rule
+type CreatePriceAlertRuleRequest struct {
+	Name            string         `json:"name"`
+	Type            AlertRuleType  `json:"type" binding:"required"`
+	CardID          string         `json:"card_id"`
+	Condition       PriceCondition `json:"condition"`
+	Printing        PrintingType   `json:"printing"`
+	Language        CardLanguage   `json:"language"`
+	Direction       AlertDirection `json:"direction" binding:"required"`
+	Threshold       float64        `json:"threshold"`
+	Days            int            `json:"days"`
+	CooldownMinutes *int           `json:"cooldown_minutes"`
+	Enabled         *bool          `json:"enabled"`
+}
+
+// UpdatePriceAlertRuleRequest changes a rule. Changing what a rule watches resets its state.
+type UpdatePriceAlertRuleRequest struct {
+	Name            *string         `json:"name"`
+	Enabled         *bool           `json:"enabled"`
+	Direction       *AlertDirection `json:"direction"`
+	Threshold       *float64        `json:"threshold"`
+	Days            *int            `json:"days"`
+	CooldownMinutes *int            `json:"cooldown_minutes"`
+}
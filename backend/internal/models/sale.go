@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+)
+
+// CollectionSale is a ledger entry for cards that left the collection (sold or traded away).
+// Selling moves quantity out of collection_items, so value snapshots no longer count the
+// cards, while the sale keeps enough of the original item to report realized profit.
+type CollectionSale struct {
+	ID               uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	CollectionItemID uint         `json:"collection_item_id"` // Item the cards came from (may no longer exist)
+	CardID           string       `json:"card_id" gorm:"not null;index"`
+	CardName         string       `json:"card_name"`                 // Snapshot of the card name at sale time
+	Game             Game         `json:"game" gorm:"index"`         // Denormalized so reports work for cards not in the cards table
+	Quantity         int          `json:"quantity" gorm:"default:1"` // Number of cards sold
+	Condition        Condition    `json:"condition"`
+	Printing         PrintingType `json:"printing"`
+	Language         CardLanguage `json:"language"`
+	ScannedImagePath string       `json:"scanned_image_path,omitempty"`
+
+	SalePrice float64   `json:"sale_price"` // Per card, USD
+	Fees      float64   `json:"fees"`       // Total for the sale (platform, payment, shipping)
+	Channel   string    `json:"channel"`    // e.g. "TCGplayer", "eBay", "LGS", "trade"
+	SoldAt    time.Time `json:"sold_at" gorm:"not null;index"`
+	Notes     string    `json:"notes"`
+
+	// Cost basis carried over from the collection item (per card, nil if unknown)
+	PurchasePrice     *float64   `json:"purchase_price,omitempty"`
+	PurchaseDate      *time.Time `json:"purchase_date,omitempty"`
+	AcquisitionSource string     `json:"acquisition_source,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Calculated fields (not persisted to database)
+	NetProceeds  float64  `json:"net_proceeds" gorm:"-"`            // SalePrice * Quantity - Fees
+	CostBasis    *float64 `json:"cost_basis,omitempty" gorm:"-"`    // PurchasePrice * Quantity (nil if unknown)
+	RealizedGain *float64 `json:"realized_gain,omitempty" gorm:"-"` // NetProceeds - CostBasis (nil if unknown)
+}
+
+// CalculateRealized fills NetProceeds, CostBasis and RealizedGain
+func (s *CollectionSale) CalculateRealized() {
+	s.NetProceeds = s.SalePrice*float64(s.Quantity) - s.Fees
+	if s.PurchasePrice == nil {
+		s.CostBasis = nil
+		s.RealizedGain = nil
+		return
+	}
+	costBasis := *s.PurchasePrice * float64(s.Quantity)
+	gain := s.NetProceeds - costBasis
+	s.CostBasis = &costBasis
+	s.RealizedGain = &gain
+}
+
+// SellCollectionItemRequest records a sale of some or all of a collection item
+type SellCollectionItemRequest struct {
+	Quantity  int        `json:"quantity"`   // Defaults to the full item quantity
+	SalePrice *float64   `json:"sale_price"` // Per card, USD (required, 0 for trades with no cash value)
+	Fees      float64    `json:"fees"`
+	Channel   string     `json:"channel"`
+	SoldAt    *time.Time `json:"sold_at"` // Defaults to now
+	Notes     string     `json:"notes"`
+}
+
+// SellCollectionItemResponse includes the ledger entry plus what is left of the item
+type SellCollectionItemResponse struct {
+	Sale      CollectionSale  `json:"sale"`
+	Item      *CollectionItem `json:"item,omitempty"` // Remaining item, nil if fully sold
+	Operation string          `json:"operation"`      // "sold" (item removed) or "partial"
+}
+
+// RealizedPnLRow summarizes sales for one month or one game
+type RealizedPnLRow struct {
+	Key         string  `json:"key"` // "2006-01" for months, game name for games
+	Sales       int     `json:"sales"`
+	CardsSold   int     `json:"cards_sold"`
+	Gross       float64 `json:"gross"`        // Sum of sale price * quantity
+	Fees        float64 `json:"fees"`         // Sum of fees
+	NetProceeds float64 `json:"net_proceeds"` // Gross - Fees
+
+	// Realized gain only covers sales with a known purchase price
+	CostBasisCards    int     `json:"cost_basis_cards"`
+	TotalCostBasis    float64 `json:"total_cost_basis"`
+	CostBasisProceeds float64 `json:"cost_basis_proceeds"` // Net proceeds of the sales with a known purchase price
+	RealizedGain      float64 `json:"realized_gain"`       // CostBasisProceeds - TotalCostBasis
+}
+
+// Add folds a sale into the row. The sale must have CalculateRealized applied.
+func (r *RealizedPnLRow) Add(sale *CollectionSale) {
+	r.Sales++
+	r.CardsSold += sale.Quantity
+	r.Gross += sale.SalePrice * float64(sale.Quantity)
+	r.Fees += sale.Fees
+	r.NetProceeds += sale.NetProceeds
+	if sale.CostBasis != nil {
+		r.CostBasisCards += sale.Quantity
+		r.TotalCostBasis += *sale.CostBasis
+		r.CostBasisProceeds += sale.NetProceeds
+		r.RealizedGain += *sale.RealizedGain
+	}
+}
+
+// RealizedPnLReport is the API response for realized profit and loss
+type RealizedPnLReport struct {
+	ByMonth []RealizedPnLRow `json:"by_month"`
+	ByGame  []RealizedPnLRow `json:"by_game"`
+	Totals  RealizedPnLRow   `json:"totals"`
+}
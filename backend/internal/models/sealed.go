@@ -0,0 +1,147 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// SealedProductType is the kind of sealed product
+type SealedProductType string
+
+const (
+	SealedBoosterBox    SealedProductType = "booster_box"
+	SealedBoosterPack   SealedProductType = "booster_pack"
+	SealedEliteTrainer  SealedProductType = "elite_trainer_box"
+	SealedBundle        SealedProductType = "bundle"
+	SealedPrecon        SealedProductType = "precon" // Commander decks, theme decks, starter decks
+	SealedCollectionBox SealedProductType = "collection_box"
+	SealedOtherProduct  SealedProductType = "other"
+)
+
+// SealedPriceSourceManual marks sealed prices entered by hand, which the price worker keeps
+const SealedPriceSourceManual = "manual"
+
+// AllSealedProductTypes returns all sealed product types
+func AllSealedProductTypes() []SealedProductType {
+	return []SealedProductType{
+		SealedBoosterBox, SealedBoosterPack, SealedEliteTrainer, SealedBundle,
+		SealedPrecon, SealedCollectionBox, SealedOtherProduct,
+	}
+}
+
+// IsValidSealedProductType reports whether t is a known sealed product type
+func IsValidSealedProductType(t SealedProductType) bool {
+	for _, known := range AllSealedProductTypes() {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// InferSealedProductType guesses the product type from a product name such as
+// "Scarlet & Violet 151 Elite Trainer Box". Unrecognized names are "other".
+func InferSealedProductType(name string) SealedProductType {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "elite trainer box") || strings.Contains(lower, " etb"):
+		return SealedEliteTrainer
+	case strings.Contains(lower, "booster box") || strings.Contains(lower, "booster display"):
+		return SealedBoosterBox
+	case strings.Contains(lower, "booster bundle") || strings.Contains(lower, "bundle"):
+		return SealedBundle
+	case strings.Contains(lower, "commander deck") || strings.Contains(lower, "precon") ||
+		strings.Contains(lower, "theme deck") || strings.Contains(lower, "starter deck") ||
+		strings.Contains(lower, "battle deck") || strings.Contains(lower, "challenger deck"):
+		return SealedPrecon
+	case strings.Contains(lower, "booster pack") || strings.HasSuffix(lower, " booster"):
+		return SealedBoosterPack
+	case strings.Contains(lower, "collection") || strings.Contains(lower, "premium") || strings.Contains(lower, " tin"):
+		return SealedCollectionBox
+	default:
+		return SealedOtherProduct
+	}
+}
+
+// SealedProduct is a catalog entry for an unopened product (booster box, ETB, precon).
+// Sealed products are priced as a whole through JustTCG, which lists them by TCGPlayerID.
+type SealedProduct struct {
+	ID             uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name           string            `json:"name" gorm:"not null;index"`
+	Game           Game              `json:"game" gorm:"not null;index"`
+	ProductType    SealedProductType `json:"product_type" gorm:"default:'other'"`
+	SetCode        string            `json:"set_code,omitempty"`
+	SetName        string            `json:"set_name,omitempty"`
+	TCGPlayerID    string            `json:"tcgplayer_id,omitempty" gorm:"index"` // Needed for JustTCG pricing
+	ImageURL       string            `json:"image_url,omitempty"`
+	PriceUSD       float64           `json:"price_usd"`
+	PriceSource    string            `json:"price_source,omitempty"` // "justtcg" or "manual"
+	PriceUpdatedAt *time.Time        `json:"price_updated_at,omitempty"`
+	PriceCheckedAt *time.Time        `json:"price_checked_at,omitempty"` // Last JustTCG lookup, found or not
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// SealedCollectionItem is a number of copies of a sealed product in the collection
+type SealedCollectionItem struct {
+	ID              uint          `json:"id" gorm:"primaryKey;autoIncrement"`
+	SealedProductID uint          `json:"sealed_product_id" gorm:"not null;index"`
+	SealedProduct   SealedProduct `json:"sealed_product" gorm:"foreignKey:SealedProductID"`
+	Quantity        int           `json:"quantity" gorm:"default:1"`
+	Notes           string        `json:"notes,omitempty"`
+	AddedAt         time.Time     `json:"added_at"`
+
+	// Cost basis (optional), per unit like CollectionItem.PurchasePrice
+	PurchasePrice     *float64   `json:"purchase_price,omitempty"`
+	PurchaseDate      *time.Time `json:"purchase_date,omitempty"`
+	AcquisitionSource string     `json:"acquisition_source,omitempty"`
+
+	// Calculated fields (not persisted to database)
+	ItemValue      float64  `json:"item_value" gorm:"-"`                // SealedProduct.PriceUSD * Quantity
+	CostBasis      *float64 `json:"cost_basis,omitempty" gorm:"-"`      // PurchasePrice * Quantity (nil if unknown)
+	UnrealizedGain *float64 `json:"unrealized_gain,omitempty" gorm:"-"` // ItemValue - CostBasis (nil if unknown)
+
+	// ItemValue converted to the display currency at today's exchange rate
+	DisplayCurrency  string  `json:"display_currency,omitempty" gorm:"-"`
+	DisplayItemValue float64 `json:"display_item_value,omitempty" gorm:"-"`
+}
+
+// CreateSealedProductRequest adds a product to the sealed catalog
+type CreateSealedProductRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	Game        Game              `json:"game" binding:"required"`
+	ProductType SealedProductType `json:"product_type"` // Inferred from the name when empty
+	SetCode     string            `json:"set_code"`
+	SetName     string            `json:"set_name"`
+	TCGPlayerID string            `json:"tcgplayer_id"`
+	ImageURL    string            `json:"image_url"`
+	PriceUSD    *float64          `json:"price_usd"` // Manual price; otherwise fetched from JustTCG
+}
+
+// UpdateSealedProductRequest edits a catalog product. Setting price_usd records a manual price.
+type UpdateSealedProductRequest struct {
+	Name        *string            `json:"name"`
+	ProductType *SealedProductType `json:"product_type"`
+	SetCode     *string            `json:"set_code"`
+	SetName     *string            `json:"set_name"`
+	TCGPlayerID *string            `json:"tcgplayer_id"`
+	ImageURL    *string            `json:"image_url"`
+	PriceUSD    *float64           `json:"price_usd"`
+}
+
+type AddSealedItemRequest struct {
+	SealedProductID   uint       `json:"sealed_product_id" binding:"required"`
+	Quantity          int        `json:"quantity"`
+	Notes             string     `json:"notes"`
+	PurchasePrice     *float64   `json:"purchase_price"` // Per unit, USD
+	PurchaseDate      *time.Time `json:"purchase_date"`
+	AcquisitionSource string     `json:"acquisition_source"`
+}
+
+type UpdateSealedItemRequest struct {
+	Quantity          *int       `json:"quantity"`
+	Notes             *string    `json:"notes"`
+	PurchasePrice     *float64   `json:"purchase_price"` // Per unit, USD; negative clears it
+	PurchaseDate      *time.Time `json:"purchase_date"`
+	AcquisitionSource *string    `json:"acquisition_source"`
+}
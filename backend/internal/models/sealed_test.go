@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestInferSealedProductType(t *testing.T) {
+	tests := []struct {
+		name string
+		want SealedProductType
+	}{
+		{"Scarlet & Violet 151 Elite Trainer Box", SealedEliteTrainer},
+		{"Evolving Skies Booster Box", SealedBoosterBox},
+		{"Paldea Evolved Booster Bundle", SealedBundle},
+		{"Murders at Karlov Manor Commander Deck - Deep Clue Sea", SealedPrecon},
+		{"Silver Tempest Booster Pack", SealedBoosterPack},
+		{"Wilds of Eldraine Set Booster", SealedBoosterPack},
+		{"Charizard ex Premium Collection", SealedCollectionBox},
+		{"Prerelease Kit", SealedOtherProduct},
+	}
+	for _, tt := range tests {
+		if got := InferSealedProductType(tt.name); got != tt.want {
+			t.Errorf("InferSealedProductType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+// SetCompletion is how much of one set the collection holds
+type SetCompletion struct {
+	Game           Game    `json:"game"`
+	SetCode        string  `json:"set_code"`
+	SetName        string  `json:"set_name"`
+	CountPrintings bool    `json:"count_printings"` // Owned and Total count card printings (normal, reverse holo, ...) instead of cards
+	Owned          int     `json:"owned"`
+	Total          int     `json:"total"`
+	Percent        float64 `json:"percent"`
+	OwnedCopies    int     `json:"owned_copies"` // Copies of the set's cards in the collection, duplicates included
+
+	// Estimated cost of one NM English copy of every missing card (or printing) from
+	// cached prices. Missing cards without a cached price are counted in UnpricedMissing.
+	CostToComplete  float64 `json:"cost_to_complete"`
+	UnpricedMissing int     `json:"unpriced_missing"`
+
+	Partial bool   `json:"partial,omitempty"` // The card list was truncated, so some missing cards are not listed or priced
+	Error   string `json:"error,omitempty"`   // Set list could not be loaded
+}
+
+// MissingSetCard is a card (or printing) of a set that is not in the collection
+type MissingSetCard struct {
+	Card     Card         `json:"card"`
+	Printing PrintingType `json:"printing"`
+	PriceUSD float64      `json:"price_usd"` // NM English price, 0 if unknown
+}
+
+// SetMissingResponse is the API response for a set's missing cards
+type SetMissingResponse struct {
+	SetCompletion
+	Missing []MissingSetCard `json:"missing"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+// StorageLocationType describes what kind of physical container a location is
+type StorageLocationType string
+
+const (
+	StorageLocationBox     StorageLocationType = "box"
+	StorageLocationRow     StorageLocationType = "row"
+	StorageLocationSlot    StorageLocationType = "slot"
+	StorageLocationBinder  StorageLocationType = "binder"
+	StorageLocationPage    StorageLocationType = "page"
+	StorageLocationPocket  StorageLocationType = "pocket"
+	StorageLocationDeckBox StorageLocationType = "deck_box"
+	StorageLocationOther   StorageLocationType = "other"
+)
+
+// IsValid reports whether t is one of the known location types
+func (t StorageLocationType) IsValid() bool {
+	switch t {
+	case StorageLocationBox, StorageLocationRow, StorageLocationSlot,
+		StorageLocationBinder, StorageLocationPage, StorageLocationPocket,
+		StorageLocationDeckBox, StorageLocationOther:
+		return true
+	}
+	return false
+}
+
+// StorageLocation is a physical place cards are kept. Locations nest through
+// ParentID, e.g. box → row → slot or binder → page → pocket.
+type StorageLocation struct {
+	ID        uint                `json:"id" gorm:"primaryKey;autoIncrement"`
+	ParentID  *uint               `json:"parent_id,omitempty" gorm:"index"` // nil for top-level locations
+	Name      string              `json:"name" gorm:"not null"`
+	Type      StorageLocationType `json:"type" gorm:"not null;default:'other'"`
+	Notes     string              `json:"notes,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+
+	// Calculated fields (not persisted to database)
+	Path      string            `json:"path" gorm:"-"`               // e.g. "Binder A / Page 3 / Pocket 5"
+	CardCount int               `json:"card_count" gorm:"-"`         // Cards stored here or in sub-locations
+	Children  []StorageLocation `json:"children,omitempty" gorm:"-"` // Direct sub-locations
+}
+
+type CreateStorageLocationRequest struct {
+	Name     string              `json:"name" binding:"required"`
+	Type     StorageLocationType `json:"type"` // Defaults to "other"
+	ParentID *uint               `json:"parent_id"`
+	Notes    string              `json:"notes"`
+}
+
+// UpdateStorageLocationRequest changes a location. A ParentID of 0 moves it to the top level.
+type UpdateStorageLocationRequest struct {
+	Name     *string              `json:"name"`
+	Type     *StorageLocationType `json:"type"`
+	ParentID *uint                `json:"parent_id"`
+	Notes    *string              `json:"notes"`
+}
+
+// StorageLocationGroup is the collection grouped by card within one storage location
+type StorageLocationGroup struct {
+	Location      *StorageLocation        `json:"location"` // nil for items without a location
+	TotalQuantity int                     `json:"total_quantity"`
+	TotalValue    float64                 `json:"total_value"`
+	Groups        []GroupedCollectionItem `json:"groups"`
+
+	// TotalValue converted to the display currency
+	DisplayCurrency   string  `json:"display_currency,omitempty"`
+	DisplayTotalValue float64 `json:"display_total_value,omitempty"`
+}
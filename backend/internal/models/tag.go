@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxTagNameLength limits tag names so they stay usable as filter chips
+const MaxTagNameLength = 50
+
+// Tag is a user-defined label on collection items, e.g. "for trade" or "PSA candidate".
+// Names are unique ignoring case.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex"`
+	Color     string    `json:"color,omitempty"` // Optional display color, e.g. "#ff8800"
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Calculated fields (not persisted to database)
+	ItemCount int `json:"item_count" gorm:"-"` // Collection items with this tag
+	CardCount int `json:"card_count" gorm:"-"` // Sum of quantity of those items
+}
+
+// CollectionItemTag links a collection item to a tag (many-to-many join table).
+// Rows are removed explicitly when an item is deleted or merged into another stack.
+type CollectionItemTag struct {
+	CollectionItemID uint      `json:"collection_item_id" gorm:"primaryKey"`
+	TagID            uint      `json:"tag_id" gorm:"primaryKey;index"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// NormalizeTagName trims a tag name and collapses internal whitespace
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+// BulkTagRequest adds or removes tags on many collection items at once.
+// Tags are referenced by name; adding creates tags that do not exist yet.
+type BulkTagRequest struct {
+	ItemIDs []uint   `json:"item_ids" binding:"required"`
+	Tags    []string `json:"tags" binding:"required"`
+}
+
+// BulkTagResponse reports how many item/tag links were added or removed
+type BulkTagResponse struct {
+	Changed int   `json:"changed"`
+	Items   int   `json:"items"` // Items the request matched
+	Tags    []Tag `json:"tags"`
+}
+
+// TagStats is the value of the cards carrying one tag. Items with several
+// tags count toward each of them.
+type TagStats struct {
+	TagID      uint    `json:"tag_id"`
+	Name       string  `json:"name"`
+	Cards      int     `json:"cards"`
+	TotalValue float64 `json:"total_value"`
+}
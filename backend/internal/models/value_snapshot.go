@@ -15,11 +15,103 @@ type CollectionValueSnapshot struct {
 	PokemonCards int       `json:"pokemon_cards"`
 	MTGValue     float64   `json:"mtg_value"`
 	PokemonValue float64   `json:"pokemon_value"`
+	SealedItems  int       `json:"sealed_items"`
+	SealedValue  float64   `json:"sealed_value"`                          // Included in TotalValue
+	Derived      bool      `json:"derived" gorm:"not null;default:false"` // Reconstructed by a backfill rather than recorded on the day
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Values converted to the display currency at the rate valid on SnapshotDate
+	DisplayCurrency     string  `json:"display_currency,omitempty" gorm:"-"`
+	ExchangeRate        float64 `json:"exchange_rate,omitempty" gorm:"-"`
+	DisplayTotalValue   float64 `json:"display_total_value,omitempty" gorm:"-"`
+	DisplayMTGValue     float64 `json:"display_mtg_value,omitempty" gorm:"-"`
+	DisplayPokemonValue float64 `json:"display_pokemon_value,omitempty" gorm:"-"`
+	DisplaySealedValue  float64 `json:"display_sealed_value,omitempty" gorm:"-"`
+}
+
+// SnapshotBackfillRequest limits a snapshot backfill to a date range (YYYY-MM-DD, both
+// optional and inclusive). By default it covers the first AddedAt date to yesterday.
+type SnapshotBackfillRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SnapshotBackfillResult reports what a snapshot backfill did for each day in its range
+type SnapshotBackfillResult struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Created  int    `json:"created"`  // Days that had no snapshot
+	Updated  int    `json:"updated"`  // Derived snapshots recomputed
+	Recorded int    `json:"recorded"` // Days with a snapshot recorded on the day, left untouched
+	Empty    int    `json:"empty"`    // Days with nothing in the collection
+}
+
+// Breakdown dimensions recorded with each value snapshot
+const (
+	BreakdownSet      = "set"
+	BreakdownRarity   = "rarity"
+	BreakdownLanguage = "language"
+	BreakdownLocation = "location" // Top-level storage location, e.g. a box or binder
+	BreakdownTag      = "tag"      // Items with several tags count toward each of them
+
+	// BreakdownSealedKey is the key of the sealed product row of each dimension but tag
+	BreakdownSealedKey = "sealed"
+)
+
+// AllBreakdownDimensions returns every breakdown dimension in display order
+func AllBreakdownDimensions() []string {
+	return []string{BreakdownSet, BreakdownRarity, BreakdownLanguage, BreakdownLocation, BreakdownTag}
+}
+
+// IsValidBreakdownDimension reports whether d is a known breakdown dimension
+func IsValidBreakdownDimension(d string) bool {
+	for _, known := range AllBreakdownDimensions() {
+		if d == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectionValueBreakdown is the value of one part of the collection (a set, a rarity,
+// ...) on a snapshot date. Sealed product is one row per dimension (BreakdownSealedKey),
+// its Cards counting sealed items; it is not split by set or tag.
+type CollectionValueBreakdown struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	SnapshotDate time.Time `json:"snapshot_date" gorm:"not null;uniqueIndex:idx_value_breakdown,priority:1"`
+	Dimension    string    `json:"dimension" gorm:"not null;uniqueIndex:idx_value_breakdown,priority:2"`
+	Key          string    `json:"key" gorm:"not null;uniqueIndex:idx_value_breakdown,priority:3"` // e.g. "mtg/mh3" for a set, "" for unknown
+	Label        string    `json:"label"`                                                          // e.g. the set name
+	Cards        int       `json:"cards"`
+	Value        float64   `json:"value"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ValueBreakdownPoint is one snapshot's value for a breakdown series
+type ValueBreakdownPoint struct {
+	SnapshotDate time.Time `json:"snapshot_date"`
+	Cards        int       `json:"cards"`
+	Value        float64   `json:"value"`
+	DisplayValue float64   `json:"display_value,omitempty"`
+}
+
+// ValueBreakdownSeries is the value history of one part of the collection. Snapshots
+// where the part held no cards have no point.
+type ValueBreakdownSeries struct {
+	Key           string                `json:"key"`
+	Label         string                `json:"label"`
+	Change        float64               `json:"change"` // Value at the last snapshot minus the first, a missing point counting as 0
+	DisplayChange float64               `json:"display_change,omitempty"`
+	Points        []ValueBreakdownPoint `json:"points"`
 }
 
 // ValueHistoryResponse is the API response for value history
 type ValueHistoryResponse struct {
 	Snapshots []CollectionValueSnapshot `json:"snapshots"`
-	Period    string                    `json:"period"` // "week", "month", "year", "all"
+	Period    string                    `json:"period"`   // "week", "month", "year", "all"
+	Currency  string                    `json:"currency"` // Display currency of the snapshots' display values
+
+	// With group_by, the history of each part of the collection, largest change first
+	GroupBy   string                 `json:"group_by,omitempty"`
+	Breakdown []ValueBreakdownSeries `json:"breakdown,omitempty"`
 }
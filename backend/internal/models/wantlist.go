@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// WantListEntry is a card the user wants to acquire, optionally with a price target.
+// An entry either targets one printing (CardID) or, with AnyPrinting, every cached
+// printing that shares the card name.
+type WantListEntry struct {
+	ID          uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	CardID      string       `json:"card_id,omitempty" gorm:"index"` // Specific printing; empty for name-only entries
+	CardName    string       `json:"card_name" gorm:"not null;index"`
+	Game        Game         `json:"game,omitempty" gorm:"index"` // Empty matches both games (name-only entries)
+	AnyPrinting bool         `json:"any_printing"`                // Match every printing with this name
+	Condition   Condition    `json:"condition" gorm:"default:'NM'"`
+	Printing    PrintingType `json:"printing,omitempty"` // Empty means the cheapest available printing
+	Language    CardLanguage `json:"language" gorm:"default:'English'"`
+	Quantity    int          `json:"quantity" gorm:"default:1"`
+	MaxPrice    *float64     `json:"max_price,omitempty"` // Per card, USD; nil means no target
+	Notes       string       `json:"notes,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// Calculated fields (not persisted to database)
+	CurrentPrice    *float64     `json:"current_price,omitempty" gorm:"-"`    // Lowest matching price per card
+	CurrentCardID   string       `json:"current_card_id,omitempty" gorm:"-"`  // Printing with the lowest price
+	CurrentPrinting PrintingType `json:"current_printing,omitempty" gorm:"-"` // Printing type of that price
+	PriceUpdatedAt  *time.Time   `json:"price_updated_at,omitempty" gorm:"-"` // When that card's price was refreshed
+	UnderTarget     bool         `json:"under_target" gorm:"-"`               // CurrentPrice <= MaxPrice
+	OwnedQuantity   int          `json:"owned_quantity" gorm:"-"`             // Matching copies already in the collection
+	Card            *Card        `json:"card,omitempty" gorm:"-"`             // Card for CurrentCardID (or CardID)
+}
+
+// CreateWantListEntryRequest adds a card to the want list. Either CardID or CardName is required.
+type CreateWantListEntryRequest struct {
+	CardID      string       `json:"card_id"`
+	CardName    string       `json:"card_name"`
+	Game        Game         `json:"game"`
+	AnyPrinting bool         `json:"any_printing"`
+	Condition   Condition    `json:"condition"`
+	Printing    PrintingType `json:"printing"`
+	Language    CardLanguage `json:"language"`
+	Quantity    int          `json:"quantity"`
+	MaxPrice    *float64     `json:"max_price"`
+	Notes       string       `json:"notes"`
+}
+
+type UpdateWantListEntryRequest struct {
+	AnyPrinting *bool         `json:"any_printing"`
+	Condition   *Condition    `json:"condition"`
+	Printing    *PrintingType `json:"printing"` // Empty string clears it (any printing type)
+	Language    *CardLanguage `json:"language"`
+	Quantity    *int          `json:"quantity"`
+	MaxPrice    *float64      `json:"max_price"` // Negative clears the target
+	Notes       *string       `json:"notes"`
+}
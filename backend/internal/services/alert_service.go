@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// notifyTimeout bounds how long a single notifier may take to deliver an alert
+const notifyTimeout = 15 * time.Second
+
+// AlertService evaluates price alert rules and fires notifications when they trigger.
+// Rule state lives on the rule rows so a restart doesn't re-send alerts.
+type AlertService struct {
+	db        *gorm.DB
+	snapshots *SnapshotService
+	notifiers []Notifier
+	now       func() time.Time
+}
+
+func NewAlertService(db *gorm.DB, snapshots *SnapshotService, notifiers []Notifier) *AlertService {
+	return &AlertService{
+		db:        db,
+		snapshots: snapshots,
+		notifiers: notifiers,
+		now:       time.Now,
+	}
+}
+
+// Evaluate checks the enabled rules affected by a price update: card rules for the
+// given cards (all card rules when cardIDs is nil) and every collection value rule.
+// It returns the number of alerts fired.
+func (s *AlertService) Evaluate(cardIDs []string) (int, error) {
+	query := s.db.Where("enabled = ?", true)
+	if cardIDs != nil {
+		cardTypes := []models.AlertRuleType{models.AlertRulePriceThreshold, models.AlertRulePercentChange}
+		if len(cardIDs) > 0 {
+			query = query.Where("type = ? OR (type IN ? AND card_id IN ?)", models.AlertRuleCollectionValue, cardTypes, cardIDs)
+		} else {
+			query = query.Where("type = ?", models.AlertRuleCollectionValue)
+		}
+	}
+
+	var rules []models.PriceAlertRule
+	if err := query.Find(&rules).Error; err != nil {
+		return 0, err
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	cards, err := s.loadRuleCards(rules)
+	if err != nil {
+		return 0, err
+	}
+
+	var collectionValue *float64
+	fired := 0
+	for i := range rules {
+		rule := &rules[i]
+
+		var value float64
+		var ok bool
+		if rule.Type == models.AlertRuleCollectionValue {
+			if collectionValue == nil && s.snapshots != nil {
+				v := s.snapshots.CurrentStats().TotalValue
+				collectionValue = &v
+			}
+			if collectionValue != nil {
+				value, ok = *collectionValue, true
+			}
+		} else {
+			value, ok = s.cardRuleValue(rule, cards[rule.CardID])
+		}
+
+		if s.apply(rule, value, ok) {
+			fired++
+		}
+		if err := s.saveState(rule); err != nil {
+			log.Printf("Alerts: failed to save state for rule %d: %v", rule.ID, err)
+		}
+	}
+	return fired, nil
+}
+
+// loadRuleCards returns the cards (with condition prices) watched by card rules
+func (s *AlertService) loadRuleCards(rules []models.PriceAlertRule) (map[string]*models.Card, error) {
+	var ids []string
+	for _, rule := range rules {
+		if rule.Type.IsCardRule() && rule.CardID != "" {
+			ids = append(ids, rule.CardID)
+		}
+	}
+	cards := make(map[string]*models.Card, len(ids))
+	if len(ids) == 0 {
+		return cards, nil
+	}
+
+	var loaded []models.Card
+	if err := s.db.Preload("Prices").Where("id IN ?", ids).Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+	for i := range loaded {
+		cards[loaded[i].ID] = &loaded[i]
+	}
+	return cards, nil
+}
+
+// cardRuleValue returns the value a card rule compares against its threshold: the
+// current price, or the percent change over the rule's window
+func (s *AlertService) cardRuleValue(rule *models.PriceAlertRule, card *models.Card) (float64, bool) {
+	if card == nil {
+		return 0, false
+	}
+	rule.Card = card
+
+	price := card.GetPriceWithSource(ruleCondition(rule), rulePrinting(rule), ruleLanguage(rule)).Price
+	if price <= 0 {
+		return 0, false
+	}
+	if rule.Type == models.AlertRulePriceThreshold {
+		return price, true
+	}
+
+	// The base goes through the same fallback as the current price (e.g. to the holo
+	// price of a holo-only card), applied to the prices recorded at the window start
+	since := s.now().AddDate(0, 0, -rule.Days)
+	var history []models.CardPrice
+	if err := s.db.Raw(fmt.Sprintf(historyPricesSQL, "MAX", "<="), []string{card.ID}, since).Scan(&history).Error; err != nil {
+		log.Printf("Alerts: failed to load price history of %s: %v", card.ID, err)
+		return 0, false
+	}
+	then := historicalCard(*card, history)
+	base := then.GetPriceWithSource(ruleCondition(rule), rulePrinting(rule), ruleLanguage(rule)).Price
+	if base <= 0 {
+		// Not enough history to cover the window yet
+		return 0, false
+	}
+	return (price - base) / base * 100, true
+}
+
+// apply updates the rule's state for the latest value and fires it when the condition
+// has just become true and the rule is out of cooldown. It reports whether it fired.
+func (s *AlertService) apply(rule *models.PriceAlertRule, value float64, ok bool) bool {
+	now := s.now()
+	rule.LastEvaluated = &now
+	if !ok {
+		return false
+	}
+	rule.LastValue = &value
+
+	if !ruleHolds(rule, value) {
+		// Re-arm so the next crossing fires again
+		rule.Triggered = false
+		return false
+	}
+	if rule.Triggered {
+		return false
+	}
+	cooldown := time.Duration(rule.CooldownMinutes) * time.Minute
+	if rule.LastFiredAt != nil && now.Sub(*rule.LastFiredAt) < cooldown {
+		// Leave the rule armed; it fires once the cooldown is over if the condition still holds
+		return false
+	}
+
+	rule.Triggered = true
+	rule.LastFiredAt = &now
+	s.fire(rule, value, now)
+	return true
+}
+
+// ruleHolds reports whether value satisfies the rule's direction and threshold
+func ruleHolds(rule *models.PriceAlertRule, value float64) bool {
+	switch rule.Direction {
+	case models.AlertDirectionAbove:
+		return value >= rule.Threshold
+	case models.AlertDirectionBelow:
+		if rule.Type == models.AlertRulePercentChange {
+			return value <= -rule.Threshold
+		}
+		return value <= rule.Threshold
+	case models.AlertDirectionEither:
+		return math.Abs(value) >= rule.Threshold
+	}
+	return false
+}
+
+// fire sends the alert through every notifier and records the event
+func (s *AlertService) fire(rule *models.PriceAlertRule, value float64, now time.Time) {
+	alert := Alert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Type:      rule.Type,
+		CardID:    rule.CardID,
+		Value:     value,
+		Threshold: rule.Threshold,
+		Message:   alertMessage(rule, value),
+		FiredAt:   now,
+	}
+	if rule.Card != nil {
+		alert.CardName = rule.Card.Name
+	}
+
+	var errs []string
+	delivered := false
+	for _, n := range s.notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		err := n.Notify(ctx, alert)
+		cancel()
+		if err != nil {
+			log.Printf("Alerts: %s notifier failed for rule %d: %v", n.Name(), rule.ID, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", n.Name(), err))
+			continue
+		}
+		delivered = true
+	}
+	rule.LastError = strings.Join(errs, "; ")
+
+	event := models.PriceAlertEvent{
+		RuleID:    rule.ID,
+		Message:   alert.Message,
+		Value:     value,
+		Delivered: delivered,
+		Error:     rule.LastError,
+		FiredAt:   now,
+	}
+	if err := s.db.Create(&event).Error; err != nil {
+		log.Printf("Alerts: failed to record event for rule %d: %v", rule.ID, err)
+	}
+}
+
+func (s *AlertService) saveState(rule *models.PriceAlertRule) error {
+	return s.db.Model(rule).
+		Select("triggered", "last_value", "last_evaluated", "last_fired_at", "last_error").
+		Updates(rule).Error
+}
+
+// alertMessage describes why a rule fired
+func alertMessage(rule *models.PriceAlertRule, value float64) string {
+	subject := "Collection value"
+	if rule.Type.IsCardRule() {
+		subject = rule.CardID
+		if rule.Card != nil {
+			subject = rule.Card.Name
+		}
+		subject = fmt.Sprintf("%s (%s %s)", subject, ruleCondition(rule), rulePrinting(rule))
+	}
+
+	if rule.Type == models.AlertRulePercentChange {
+		verb := "rose"
+		if value < 0 {
+			verb = "fell"
+		}
+		price := ""
+		if rule.Card != nil {
+			price = fmt.Sprintf(" to $%.2f", rule.Card.GetPriceWithSource(ruleCondition(rule), rulePrinting(rule), ruleLanguage(rule)).Price)
+		}
+		return fmt.Sprintf("%s %s %.1f%% over %d days%s", subject, verb, math.Abs(value), rule.Days, price)
+	}
+	return fmt.Sprintf("%s is $%.2f, %s $%.2f", subject, value, rule.Direction, rule.Threshold)
+}
+
+func ruleCondition(rule *models.PriceAlertRule) models.PriceCondition {
+	if rule.Condition == "" {
+		return models.PriceConditionNM
+	}
+	return rule.Condition
+}
+
+func rulePrinting(rule *models.PriceAlertRule) models.PrintingType {
+	if rule.Printing == "" {
+		return models.PrintingNormal
+	}
+	return rule.Printing
+}
+
+func ruleLanguage(rule *models.PriceAlertRule) models.CardLanguage {
+	if rule.Language == "" {
+		return models.LanguageEnglish
+	}
+	return rule.Language
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// webhookReceiver collects alerts POSTed to a local test server
+type webhookReceiver struct {
+	mu     sync.Mutex
+	alerts []Alert
+	status int
+}
+
+func newWebhookReceiver(t *testing.T) (*webhookReceiver, *httptest.Server) {
+	t.Helper()
+	r := &webhookReceiver{status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var alert Alert
+		if err := json.NewDecoder(req.Body).Decode(&alert); err != nil {
+			t.Errorf("webhook received invalid JSON: %v", err)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.alerts = append(r.alerts, alert)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.alerts)
+}
+
+func newTestAlertService(t *testing.T, url string) (*AlertService, *time.Time) {
+	t.Helper()
+	db := newTestDB(t,
+		&models.Card{},
+		&models.CardPrice{},
+		&models.GradedPrice{},
+		&models.CardPriceHistory{},
+		&models.CollectionItem{},
+		&models.SealedProduct{},
+		&models.SealedCollectionItem{},
+		&models.PriceAlertRule{},
+		&models.PriceAlertEvent{},
+	)
+	useTestDB(t, db)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewAlertService(db, NewSnapshotService(), []Notifier{NewLogNotifier(), NewWebhookNotifier(url)})
+	svc.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func TestAlertThresholdFiresOncePerCrossing(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t)
+	svc, now := newTestAlertService(t, srv.URL)
+	db := svc.db
+
+	card := models.Card{ID: "mtg-1", Name: "Lightning Bolt", Game: models.GameMTG, PriceUSD: 3}
+	db.Create(&card)
+	rule := models.PriceAlertRule{
+		Name: "Bolt spike", Type: models.AlertRulePriceThreshold, Enabled: true, CardID: card.ID,
+		Direction: models.AlertDirectionAbove, Threshold: 5, CooldownMinutes: 60,
+	}
+	db.Create(&rule)
+
+	setPrice := func(price float64) {
+		db.Model(&models.Card{}).Where("id = ?", card.ID).Update("price_usd", price)
+	}
+	evaluate := func() int {
+		t.Helper()
+		fired, err := svc.Evaluate([]string{card.ID})
+		if err != nil {
+			t.Fatalf("Evaluate() error: %v", err)
+		}
+		return fired
+	}
+
+	steps := []struct {
+		name    string
+		price   float64
+		advance time.Duration
+		fired   int
+	}{
+		{"below threshold", 3, 0, 0},
+		{"crosses threshold", 6, 0, 1},
+		{"still above", 7, 0, 0},
+		{"drops and re-arms", 4, 0, 0},
+		{"crosses again within cooldown", 6, 10 * time.Minute, 0},
+		{"cooldown over", 6, time.Hour, 1},
+	}
+	for _, step := range steps {
+		*now = now.Add(step.advance)
+		setPrice(step.price)
+		if got := evaluate(); got != step.fired {
+			t.Errorf("%s: fired %d, want %d", step.name, got, step.fired)
+		}
+	}
+
+	if receiver.count() != 2 {
+		t.Fatalf("webhook received %d alerts, want 2", receiver.count())
+	}
+	first := receiver.alerts[0]
+	if first.RuleID != rule.ID || first.CardName != "Lightning Bolt" || first.Value != 6 {
+		t.Errorf("unexpected alert payload: %+v", first)
+	}
+	if !strings.Contains(first.Message, "above $5.00") {
+		t.Errorf("unexpected message %q", first.Message)
+	}
+
+	// State survives a "restart": a fresh service sees the rule as already triggered
+	restarted := NewAlertService(db, nil, []Notifier{NewWebhookNotifier(srv.URL)})
+	restarted.now = svc.now
+	if fired, _ := restarted.Evaluate([]string{card.ID}); fired != 0 {
+		t.Errorf("restarted service re-fired %d alerts", fired)
+	}
+
+	var events []models.PriceAlertEvent
+	db.Find(&events)
+	if len(events) != 2 || !events[0].Delivered {
+		t.Errorf("expected 2 delivered events, got %+v", events)
+	}
+}
+
+func TestAlertPercentChange(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t)
+	svc, now := newTestAlertService(t, srv.URL)
+	db := svc.db
+
+	db.Create(&models.Card{ID: "pkm-1", Name: "Charizard", Game: models.GamePokemon})
+	db.Create(&models.CardPrice{CardID: "pkm-1", Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 80})
+	for _, h := range []struct {
+		daysAgo int
+		price   float64
+	}{{10, 100}, {6, 95}, {1, 85}} {
+		db.Create(&models.CardPriceHistory{
+			CardID: "pkm-1", Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish,
+			PriceUSD: h.price, RecordedAt: now.AddDate(0, 0, -h.daysAgo),
+		})
+	}
+
+	tests := []struct {
+		name      string
+		direction models.AlertDirection
+		threshold float64
+		days      int
+		fired     int
+	}{
+		{"fell 20% over 7 days", models.AlertDirectionBelow, 15, 7, 1},
+		{"fall smaller than threshold", models.AlertDirectionBelow, 25, 7, 0},
+		{"either direction", models.AlertDirectionEither, 15, 7, 1},
+		{"did not rise", models.AlertDirectionAbove, 5, 7, 0},
+		{"window before history", models.AlertDirectionBelow, 1, 30, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.Where("1 = 1").Delete(&models.PriceAlertRule{})
+			db.Create(&models.PriceAlertRule{
+				Type: models.AlertRulePercentChange, Enabled: true, CardID: "pkm-1",
+				Direction: tt.direction, Threshold: tt.threshold, Days: tt.days,
+			})
+			fired, err := svc.Evaluate(nil)
+			if err != nil {
+				t.Fatalf("Evaluate() error: %v", err)
+			}
+			if fired != tt.fired {
+				t.Errorf("fired %d, want %d", fired, tt.fired)
+			}
+		})
+	}
+
+	if receiver.count() == 0 || !strings.Contains(receiver.alerts[0].Message, "fell 20.0% over 7 days to $80.00") {
+		t.Errorf("unexpected alerts: %+v", receiver.alerts)
+	}
+}
+
+func TestAlertPercentChangeHoloOnlyCard(t *testing.T) {
+	_, srv := newWebhookReceiver(t)
+	svc, now := newTestAlertService(t, srv.URL)
+	db := svc.db
+
+	// Only a foil (holo) price exists, so the default (normal) printing falls back to it
+	db.Create(&models.Card{ID: "pkm-holo", Name: "Mewtwo", Game: models.GamePokemon, PriceFoilUSD: 50})
+	db.Create(&models.CardPrice{CardID: "pkm-holo", Condition: models.PriceConditionNM, Printing: models.PrintingFoil, Language: models.LanguageEnglish, PriceUSD: 50})
+	db.Create(&models.CardPriceHistory{
+		CardID: "pkm-holo", Condition: models.PriceConditionNM, Printing: models.PrintingFoil, Language: models.LanguageEnglish,
+		PriceUSD: 40, RecordedAt: now.AddDate(0, 0, -10),
+	})
+	rule := models.PriceAlertRule{
+		Type: models.AlertRulePercentChange, Enabled: true, CardID: "pkm-holo",
+		Direction: models.AlertDirectionAbove, Threshold: 20, Days: 7,
+	}
+	db.Create(&rule)
+
+	fired, err := svc.Evaluate(nil)
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if fired != 1 {
+		t.Errorf("fired %d, want 1", fired)
+	}
+	db.First(&rule, rule.ID)
+	if rule.LastValue == nil || !almostEqualFloat(*rule.LastValue, 25) {
+		t.Errorf("LastValue = %v, want 25", rule.LastValue)
+	}
+}
+
+func TestAlertCollectionValueAndFailedDelivery(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t)
+	receiver.status = http.StatusInternalServerError
+	svc, _ := newTestAlertService(t, srv.URL)
+	svc.notifiers = []Notifier{NewWebhookNotifier(srv.URL)}
+	db := svc.db
+
+	db.Create(&models.Card{ID: "mtg-1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 400})
+	db.Create(&models.CollectionItem{CardID: "mtg-1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish})
+	rule := models.PriceAlertRule{Type: models.AlertRuleCollectionValue, Enabled: true, Direction: models.AlertDirectionAbove, Threshold: 1000}
+	db.Create(&rule)
+	db.Create(&models.PriceAlertRule{Type: models.AlertRuleCollectionValue, Enabled: false, Direction: models.AlertDirectionAbove, Threshold: 1})
+
+	// Collection rules are checked after any batch, even for unrelated cards
+	fired, err := svc.Evaluate([]string{"other"})
+	if err != nil || fired != 1 {
+		t.Fatalf("expected 1 alert, got %d (%v)", fired, err)
+	}
+
+	db.First(&rule, rule.ID)
+	if !rule.Triggered || rule.LastValue == nil || *rule.LastValue != 1200 || !strings.Contains(rule.LastError, "status 500") {
+		t.Errorf("unexpected rule state: %+v", rule)
+	}
+	var event models.PriceAlertEvent
+	db.First(&event)
+	if event.Delivered || event.Message != "Collection value is $1200.00, above $1000.00" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	n := NewSMTPNotifier("mail.example.com:587", nil, "tracker@example.com", []string{"a@example.com", " b@example.com ", ""})
+	var gotAddr string
+	var gotTo []string
+	var gotMsg string
+	n.sendMail = func(_ context.Context, addr string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+
+	err := n.Notify(context.Background(), Alert{RuleName: "Bolt spike", Message: "Lightning Bolt is $6.00, above $5.00", FiredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	if gotAddr != "mail.example.com:587" || len(gotTo) != 2 || gotTo[1] != "b@example.com" {
+		t.Errorf("unexpected envelope: %s %v", gotAddr, gotTo)
+	}
+	if !strings.Contains(gotMsg, "Subject: TCG Tracker price alert: Bolt spike\r\n") || !strings.HasSuffix(gotMsg, "above $5.00\r\n") {
+		t.Errorf("unexpected message:\n%s", gotMsg)
+	}
+
+	// A rule name cannot add headers of its own
+	err = n.Notify(context.Background(), Alert{RuleName: "Bolt\r\nBcc: evil@example.com\nX: y", Message: "m", FiredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	if !strings.Contains(gotMsg, "Subject: TCG Tracker price alert: Bolt Bcc: evil@example.com X: y\r\n") || strings.Contains(gotMsg, "\r\nBcc:") {
+		t.Errorf("rule name injected headers:\n%s", gotMsg)
+	}
+}
+
+func TestSendMailContextHonorsCancel(t *testing.T) {
+	// A server that accepts but never greets would hang smtp.SendMail
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = sendMailContext(ctx, ln.Addr().String(), nil, "a@example.com", []string{"b@example.com"}, []byte("hi"))
+	if err == nil {
+		t.Fatal("sendMailContext() succeeded against a silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("sendMailContext() took %v, want it bounded by the context", elapsed)
+	}
+}
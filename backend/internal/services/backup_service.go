@@ -0,0 +1,442 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+const (
+	// BackupFormatVersion is bumped whenever the archive layout or table set changes
+	// in a way older restores cannot read
+	BackupFormatVersion = 1
+
+	backupManifestFile = "manifest.json"
+	backupDataDir      = "data/"
+	backupImagesDir    = "images/"
+	backupBatchSize    = 500
+)
+
+var (
+	// ErrInvalidBackup wraps restore errors caused by the archive itself: not a zip,
+	// a bad manifest or table dump, or an unsupported format version
+	ErrInvalidBackup = errors.New("invalid backup")
+	// ErrRestoreNotEmpty is returned when the database already has rows
+	ErrRestoreNotEmpty = errors.New("database is not empty")
+	// ErrStatsMismatch is returned when the restored rows do not reproduce the stats
+	// recorded in the manifest. The restore is rolled back.
+	ErrStatsMismatch = errors.New("restored stats do not match the backup")
+)
+
+// BackupManifest describes the contents of a backup archive. It is stored as
+// manifest.json at the root of the zip so an archive can be inspected by hand.
+type BackupManifest struct {
+	FormatVersion int                    `json:"format_version"`
+	App           string                 `json:"app"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Tables        []BackupTableInfo      `json:"tables"`
+	Images        int                    `json:"images"`
+	MissingImages []string               `json:"missing_images,omitempty"` // Referenced but not found on disk
+	Stats         models.CollectionStats `json:"stats"`                    // Stats at backup time, checked after restore
+}
+
+// BackupTableInfo describes one table dump inside the archive
+type BackupTableInfo struct {
+	Name string `json:"name"`
+	File string `json:"file"` // JSON array of rows, in primary key order
+	Rows int    `json:"rows"`
+}
+
+// RestoreResult reports what a restore wrote and whether the stats match the backup
+type RestoreResult struct {
+	Manifest      BackupManifest         `json:"manifest"`
+	Tables        []BackupTableInfo      `json:"tables"`
+	Images        int                    `json:"images"`
+	Stats         models.CollectionStats `json:"stats"`
+	StatsVerified bool                   `json:"stats_verified"`
+}
+
+// backupTable dumps and loads one table as a JSON array of model rows
+type backupTable struct {
+	name    string
+	dump    func(db *gorm.DB, w io.Writer) (int, error)
+	load    func(tx *gorm.DB, r io.Reader) (int, error)
+	isEmpty func(db *gorm.DB) (bool, error)
+}
+
+// newBackupTable builds the dump/load functions for a model type
+func newBackupTable[T any](name string) backupTable {
+	return backupTable{
+		name: name,
+		dump: func(db *gorm.DB, w io.Writer) (int, error) {
+			if _, err := io.WriteString(w, "["); err != nil {
+				return 0, err
+			}
+			enc := json.NewEncoder(w)
+			count := 0
+			var batch []T
+			result := db.Model(new(T)).FindInBatches(&batch, backupBatchSize, func(tx *gorm.DB, _ int) error {
+				for i := range batch {
+					if count > 0 {
+						if _, err := io.WriteString(w, ","); err != nil {
+							return err
+						}
+					}
+					if err := enc.Encode(&batch[i]); err != nil {
+						return err
+					}
+					count++
+				}
+				return nil
+			})
+			if result.Error != nil {
+				return count, result.Error
+			}
+			_, err := io.WriteString(w, "]\n")
+			return count, err
+		},
+		load: func(tx *gorm.DB, r io.Reader) (int, error) {
+			dec := json.NewDecoder(r)
+			if _, err := dec.Token(); err != nil { // opening [
+				return 0, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+			}
+			count := 0
+			for dec.More() {
+				var row T
+				if err := dec.Decode(&row); err != nil {
+					return count, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+				}
+				// Rows carry their own IDs; never touch associations (e.g. CollectionItem.Card).
+				// Inserted one at a time: batch inserts emit DEFAULT for columns like
+				// scanned_image_path, which SQLite does not accept in VALUES.
+				if err := tx.Omit(clause.Associations).Create(&row).Error; err != nil {
+					return count, err
+				}
+				count++
+			}
+			return count, nil
+		},
+		isEmpty: func(db *gorm.DB) (bool, error) {
+			var n int64
+			err := db.Model(new(T)).Count(&n).Error
+			return n == 0, err
+		},
+	}
+}
+
+// backupTables lists the tables in the archive, in restore order (cards before
+// the rows that reference them)
+var backupTables = []backupTable{
+	newBackupTable[models.Card]("cards"),
+	newBackupTable[models.CardPrice]("card_prices"),
+	newBackupTable[models.GradedPrice]("graded_prices"),
+	newBackupTable[models.CardPriceHistory]("card_price_history"),
+	newBackupTable[models.StorageLocation]("storage_locations"),
+	newBackupTable[models.CollectionItem]("collection_items"),
+	newBackupTable[models.Tag]("tags"),
+	newBackupTable[models.CollectionItemTag]("collection_item_tags"),
+	newBackupTable[models.CollectionSale]("collection_sales"),
+	newBackupTable[models.SealedProduct]("sealed_products"),
+	newBackupTable[models.SealedCollectionItem]("sealed_collection_items"),
+	newBackupTable[models.WantListEntry]("want_list_entries"),
+	newBackupTable[models.Deck]("decks"),
+	newBackupTable[models.DeckCard]("deck_cards"),
+	newBackupTable[models.PriceAlertRule]("price_alert_rules"),
+	newBackupTable[models.PriceAlertEvent]("price_alert_events"),
+	newBackupTable[models.CollectionValueSnapshot]("collection_value_snapshots"),
+	newBackupTable[models.CollectionValueBreakdown]("collection_value_breakdowns"),
+	newBackupTable[models.ExchangeRate]("exchange_rates"),
+	newBackupTable[models.AppSetting]("app_settings"),
+}
+
+// BackupService writes and restores self-describing backup archives. Unlike a
+// copy of the SQLite file, an archive is consistent even while WAL mode is active.
+type BackupService struct {
+	db        *gorm.DB
+	imageDir  string
+	snapshots *SnapshotService
+}
+
+// NewBackupService creates a backup service. imageDir is the scanned images
+// directory; snapshots is used to compute the stats recorded in the manifest.
+func NewBackupService(db *gorm.DB, imageDir string, snapshots *SnapshotService) *BackupService {
+	return &BackupService{
+		db:        db,
+		imageDir:  imageDir,
+		snapshots: snapshots,
+	}
+}
+
+// WriteBackup writes a zip archive with the manifest, one JSON file per table and
+// the scanned images. All table reads happen in one transaction for a consistent view.
+func (s *BackupService) WriteBackup(w io.Writer) (*BackupManifest, error) {
+	manifest := &BackupManifest{
+		FormatVersion: BackupFormatVersion,
+		App:           "tcg-tracker",
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	zw := zip.NewWriter(w)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range backupTables {
+			file := backupDataDir + table.name + ".json"
+			entry, err := zw.Create(file)
+			if err != nil {
+				return err
+			}
+			rows, err := table.dump(tx, entry)
+			if err != nil {
+				return fmt.Errorf("failed to dump %s: %w", table.name, err)
+			}
+			manifest.Tables = append(manifest.Tables, BackupTableInfo{Name: table.name, File: file, Rows: rows})
+		}
+
+		var paths []string
+		if err := tx.Model(&models.CollectionItem{}).
+			Where("scanned_image_path IS NOT NULL AND scanned_image_path != ''").
+			Distinct().Pluck("scanned_image_path", &paths).Error; err != nil {
+			return err
+		}
+		var salePaths []string
+		if err := tx.Model(&models.CollectionSale{}).
+			Where("scanned_image_path IS NOT NULL AND scanned_image_path != ''").
+			Distinct().Pluck("scanned_image_path", &salePaths).Error; err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool)
+		for _, path := range append(paths, salePaths...) {
+			name := filepath.Base(path)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if err := s.addImage(zw, name); err != nil {
+				manifest.MissingImages = append(manifest.MissingImages, name)
+				continue
+			}
+			manifest.Images++
+		}
+
+		// Computed in the dump transaction, so the stats describe the archived rows
+		if s.snapshots != nil {
+			manifest.Stats = s.snapshots.StatsFrom(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := zw.Create(backupManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(entry)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (s *BackupService) addImage(zw *zip.Writer, name string) error {
+	f, err := os.Open(filepath.Join(s.imageDir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Images are already compressed, so store them as-is
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: backupImagesDir + name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+// Restore loads an archive into an empty database. It refuses to run if any of
+// the backed-up tables already has rows, so an existing collection is never merged
+// or overwritten. Tables are restored in one transaction, which is rolled back if
+// the restored stats differ from the manifest; images are written after.
+// Errors caused by the archive wrap ErrInvalidBackup.
+func (s *BackupService) Restore(r io.ReaderAt, size int64) (*RestoreResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a valid backup archive: %v", ErrInvalidBackup, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifestFile, ok := files[backupManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%w: archive has no %s", ErrInvalidBackup, backupManifestFile)
+	}
+	var manifest BackupManifest
+	if err := readZipJSON(manifestFile, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %v", ErrInvalidBackup, err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > BackupFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported backup format version %d (this build reads up to %d)", ErrInvalidBackup, manifest.FormatVersion, BackupFormatVersion)
+	}
+
+	for _, table := range backupTables {
+		empty, err := table.isEmpty(s.db)
+		if err != nil {
+			return nil, err
+		}
+		if !empty {
+			return nil, fmt.Errorf("%w: table %s has rows", ErrRestoreNotEmpty, table.name)
+		}
+	}
+
+	result := &RestoreResult{Manifest: manifest}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range backupTables {
+			info, ok := findBackupTable(manifest.Tables, table.name)
+			if !ok {
+				continue // Table added after this archive was written
+			}
+			f, ok := files[info.File]
+			if !ok {
+				return fmt.Errorf("%w: archive is missing %s", ErrInvalidBackup, info.File)
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			rows, err := table.load(tx, rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("failed to restore %s: %w", table.name, err)
+			}
+			if rows != info.Rows {
+				return fmt.Errorf("%w: table %s: manifest lists %d rows but archive has %d", ErrInvalidBackup, table.name, info.Rows, rows)
+			}
+			result.Tables = append(result.Tables, BackupTableInfo{Name: table.name, File: info.File, Rows: rows})
+		}
+
+		// Checked before commit, so a restore that doesn't reproduce the backup leaves
+		// the database empty
+		if s.snapshots != nil {
+			result.Stats = s.snapshots.StatsFrom(tx)
+			if !statsMatch(manifest.Stats, result.Stats) {
+				return fmt.Errorf("%w: backup had %d cards / $%.2f, restored rows have %d cards / $%.2f", ErrStatsMismatch,
+					manifest.Stats.TotalCards, manifest.Stats.TotalValue, result.Stats.TotalCards, result.Stats.TotalValue)
+			}
+			result.StatsVerified = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.imageDir, 0755); err != nil {
+		return result, fmt.Errorf("failed to create image directory: %w", err)
+	}
+	for name, f := range files {
+		if len(name) <= len(backupImagesDir) || name[:len(backupImagesDir)] != backupImagesDir {
+			continue
+		}
+		// Base() guards against path traversal in crafted archives
+		if err := extractZipFile(f, filepath.Join(s.imageDir, filepath.Base(name))); err != nil {
+			return result, fmt.Errorf("failed to restore image %s: %w", name, err)
+		}
+		result.Images++
+	}
+	return result, nil
+}
+
+// ReadBackupManifest returns the manifest of an archive without restoring it
+func ReadBackupManifest(r io.ReaderAt, size int64) (*BackupManifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid backup archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name == backupManifestFile {
+			var manifest BackupManifest
+			if err := readZipJSON(f, &manifest); err != nil {
+				return nil, err
+			}
+			return &manifest, nil
+		}
+	}
+	return nil, fmt.Errorf("archive has no %s", backupManifestFile)
+}
+
+func findBackupTable(tables []BackupTableInfo, name string) (BackupTableInfo, bool) {
+	for _, t := range tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return BackupTableInfo{}, false
+}
+
+func readZipJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func extractZipFile(f *zip.File, dest string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// statsMatch compares the stats fields that a restore must reproduce.
+// Values are compared to the cent to ignore float summation order.
+func statsMatch(a, b models.CollectionStats) bool {
+	cents := func(v float64) int64 { return int64(math.Round(v * 100)) }
+	return a.TotalCards == b.TotalCards &&
+		a.UniqueCards == b.UniqueCards &&
+		a.MTGCards == b.MTGCards &&
+		a.PokemonCards == b.PokemonCards &&
+		cents(a.TotalValue) == cents(b.TotalValue) &&
+		cents(a.MTGValue) == cents(b.MTGValue) &&
+		cents(a.PokemonValue) == cents(b.PokemonValue) &&
+		a.SealedItems == b.SealedItems &&
+		cents(a.SealedValue) == cents(b.SealedValue) &&
+		a.CostBasisCards == b.CostBasisCards &&
+		cents(a.TotalCostBasis) == cents(b.TotalCostBasis) &&
+		cents(a.CostBasisValue) == cents(b.CostBasisValue) &&
+		cents(a.UnrealizedGain) == cents(b.UnrealizedGain)
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func newTestBackupDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return newTestDB(t,
+		&models.Card{},
+		&models.CardPrice{},
+		&models.GradedPrice{},
+		&models.CardPriceHistory{},
+		&models.StorageLocation{},
+		&models.CollectionItem{},
+		&models.Tag{},
+		&models.CollectionItemTag{},
+		&models.CollectionSale{},
+		&models.SealedProduct{},
+		&models.SealedCollectionItem{},
+		&models.WantListEntry{},
+		&models.Deck{},
+		&models.DeckCard{},
+		&models.PriceAlertRule{},
+		&models.PriceAlertEvent{},
+		&models.CollectionValueSnapshot{},
+		&models.CollectionValueBreakdown{},
+		&models.ExchangeRate{},
+		&models.AppSetting{},
+	)
+}
+
+// useTestDB points database.DB at db for code that uses the global (SnapshotService)
+func useTestDB(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	original := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = original })
+}
+
+func seedBackupDB(t *testing.T, db *gorm.DB, imageDir string) {
+	t.Helper()
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	purchase := 2.5
+
+	cards := []models.Card{
+		{ID: "mtg-1", Name: "Lightning Bolt", SetCode: "lea", Game: models.GameMTG, PriceUSD: 300, PriceFoilUSD: 0},
+		{ID: "mtg-2", Name: "Sol Ring", SetCode: "cmr", Game: models.GameMTG, PriceUSD: 1.25, PriceFoilUSD: 4},
+		{ID: "pk-1", Name: "Pikachu", SetCode: "base1", Game: models.GamePokemon, PriceUSD: 10, PriceFoilUSD: 20},
+	}
+	prices := []models.CardPrice{
+		{CardID: "pk-1", Condition: models.PriceConditionLP, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 7.5, Source: "justtcg"},
+		{CardID: "pk-1", Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageJapanese, PriceUSD: 14, Source: "justtcg"},
+	}
+	history := []models.CardPriceHistory{
+		{CardID: "pk-1", Condition: models.PriceConditionLP, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 7, Source: "justtcg", RecordedAt: now.AddDate(0, 0, -1)},
+	}
+	items := []models.CollectionItem{
+		{CardID: "mtg-1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, AddedAt: now, ScannedImagePath: "scan-1.jpg"},
+		{CardID: "mtg-2", Quantity: 4, Condition: models.ConditionNearMint, Printing: models.PrintingFoil, Language: models.LanguageEnglish, AddedAt: now, PurchasePrice: &purchase, AcquisitionSource: "LGS"},
+		{CardID: "pk-1", Quantity: 2, Condition: models.ConditionExcellent, Printing: models.PrintingNormal, Language: models.LanguageEnglish, AddedAt: now},
+		{CardID: "pk-1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageJapanese, AddedAt: now, ScannedImagePath: "missing.jpg"},
+	}
+	sales := []models.CollectionSale{
+		{CardID: "mtg-2", CardName: "Sol Ring", Game: "mtg", Quantity: 1, SalePrice: 3, Fees: 0.3, SoldAt: now},
+	}
+	snapshots := []models.CollectionValueSnapshot{
+		{SnapshotDate: now.Truncate(24 * time.Hour), TotalCards: 8, UniqueCards: 3, TotalValue: 350},
+	}
+
+	for _, rows := range []interface{}{&cards, &prices, &history, &sales, &snapshots} {
+		if err := db.Create(rows).Error; err != nil {
+			t.Fatalf("failed to seed: %v", err)
+		}
+	}
+	// Items one at a time so an empty ScannedImagePath is stored as NULL
+	for i := range items {
+		if err := db.Create(&items[i]).Error; err != nil {
+			t.Fatalf("failed to seed item: %v", err)
+		}
+	}
+
+	tag := models.Tag{Name: "for trade"}
+	if err := db.Create(&tag).Error; err != nil {
+		t.Fatalf("failed to seed tag: %v", err)
+	}
+	if err := db.Create(&models.CollectionItemTag{CollectionItemID: items[1].ID, TagID: tag.ID}).Error; err != nil {
+		t.Fatalf("failed to seed item tag: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(imageDir, "scan-1.jpg"), []byte("fake jpeg"), 0644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	srcDB := newTestBackupDB(t)
+	srcImages := t.TempDir()
+	seedBackupDB(t, srcDB, srcImages)
+
+	useTestDB(t, srcDB)
+	snapshots := NewSnapshotService()
+	sourceStats := snapshots.CurrentStats()
+
+	var archive bytes.Buffer
+	manifest, err := NewBackupService(srcDB, srcImages, snapshots).WriteBackup(&archive)
+	if err != nil {
+		t.Fatalf("WriteBackup: %v", err)
+	}
+
+	if manifest.FormatVersion != BackupFormatVersion {
+		t.Errorf("FormatVersion = %d, want %d", manifest.FormatVersion, BackupFormatVersion)
+	}
+	if manifest.Images != 1 {
+		t.Errorf("Images = %d, want 1", manifest.Images)
+	}
+	if len(manifest.MissingImages) != 1 || manifest.MissingImages[0] != "missing.jpg" {
+		t.Errorf("MissingImages = %v, want [missing.jpg]", manifest.MissingImages)
+	}
+	if !statsMatch(manifest.Stats, sourceStats) {
+		t.Errorf("manifest stats = %+v, want %+v", manifest.Stats, sourceStats)
+	}
+
+	// The manifest is readable on its own
+	read, err := ReadBackupManifest(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("ReadBackupManifest: %v", err)
+	}
+	if len(read.Tables) != len(backupTables) {
+		t.Errorf("manifest lists %d tables, want %d", len(read.Tables), len(backupTables))
+	}
+
+	dstDB := newTestBackupDB(t)
+	dstImages := t.TempDir()
+	useTestDB(t, dstDB)
+
+	result, err := NewBackupService(dstDB, dstImages, snapshots).Restore(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if !result.StatsVerified {
+		t.Errorf("StatsVerified = false: backup %+v, restored %+v", manifest.Stats, result.Stats)
+	}
+	if result.Stats.TotalCards != sourceStats.TotalCards || !almostEqualFloat(result.Stats.TotalValue, sourceStats.TotalValue) {
+		t.Errorf("restored stats = %+v, want %+v", result.Stats, sourceStats)
+	}
+
+	wantRows := map[string]int{
+		"cards": 3, "card_prices": 2, "card_price_history": 1,
+		"collection_items": 4, "collection_sales": 1, "collection_value_snapshots": 1,
+		"tags": 1, "collection_item_tags": 1,
+	}
+	for _, table := range result.Tables {
+		if table.Rows != wantRows[table.Name] {
+			t.Errorf("table %s restored %d rows, want %d", table.Name, table.Rows, wantRows[table.Name])
+		}
+	}
+
+	// IDs and optional fields survive the round trip
+	var item models.CollectionItem
+	if err := dstDB.Where("card_id = ?", "mtg-2").First(&item).Error; err != nil {
+		t.Fatalf("restored item not found: %v", err)
+	}
+	if item.PurchasePrice == nil || *item.PurchasePrice != 2.5 || item.AcquisitionSource != "LGS" {
+		t.Errorf("cost basis not restored: %+v", item)
+	}
+	var srcItem models.CollectionItem
+	srcDB.Where("card_id = ?", "mtg-2").First(&srcItem)
+	if item.ID != srcItem.ID {
+		t.Errorf("item ID = %d, want %d", item.ID, srcItem.ID)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dstImages, "scan-1.jpg"))
+	if err != nil || string(data) != "fake jpeg" {
+		t.Errorf("scanned image not restored: %v", err)
+	}
+}
+
+func TestStatsMatch(t *testing.T) {
+	base := models.CollectionStats{
+		TotalCards: 4, UniqueCards: 3, TotalValue: 12.5, MTGCards: 3, MTGValue: 10.5, PokemonCards: 1, PokemonValue: 2,
+		SealedItems: 1, SealedValue: 90, CostBasisCards: 2, TotalCostBasis: 5, CostBasisValue: 8, UnrealizedGain: 3,
+	}
+	tests := []struct {
+		name   string
+		change func(s *models.CollectionStats)
+		want   bool
+	}{
+		{"identical", func(s *models.CollectionStats) {}, true},
+		{"float noise", func(s *models.CollectionStats) { s.TotalValue += 0.0001 }, true},
+		{"card count", func(s *models.CollectionStats) { s.TotalCards++ }, false},
+		{"sealed items", func(s *models.CollectionStats) { s.SealedItems++ }, false},
+		{"sealed value", func(s *models.CollectionStats) { s.SealedValue += 1 }, false},
+		{"cost basis cards", func(s *models.CollectionStats) { s.CostBasisCards++ }, false},
+		{"total cost basis", func(s *models.CollectionStats) { s.TotalCostBasis += 1 }, false},
+		{"cost basis value", func(s *models.CollectionStats) { s.CostBasisValue += 1 }, false},
+		{"unrealized gain", func(s *models.CollectionStats) { s.UnrealizedGain -= 1 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base
+			tt.change(&other)
+			if got := statsMatch(base, other); got != tt.want {
+				t.Errorf("statsMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreRejects(t *testing.T) {
+	srcDB := newTestBackupDB(t)
+	srcImages := t.TempDir()
+	seedBackupDB(t, srcDB, srcImages)
+	useTestDB(t, srcDB)
+
+	var archive bytes.Buffer
+	if _, err := NewBackupService(srcDB, srcImages, NewSnapshotService()).WriteBackup(&archive); err != nil {
+		t.Fatalf("WriteBackup: %v", err)
+	}
+
+	futureVersion := rewriteManifest(t, archive.Bytes(), func(m *BackupManifest) { m.FormatVersion = BackupFormatVersion + 1 })
+	wrongRows := rewriteManifest(t, archive.Bytes(), func(m *BackupManifest) { m.Tables[0].Rows++ })
+
+	tests := []struct {
+		name     string
+		db       *gorm.DB
+		data     []byte
+		wantErr  string
+		sentinel error
+	}{
+		{
+			name:     "non-empty database",
+			db:       srcDB,
+			data:     archive.Bytes(),
+			wantErr:  "not empty",
+			sentinel: ErrRestoreNotEmpty,
+		},
+		{
+			name:     "not a zip",
+			db:       newTestBackupDB(t),
+			data:     []byte("hello"),
+			wantErr:  "not a valid backup archive",
+			sentinel: ErrInvalidBackup,
+		},
+		{
+			name:     "future format version",
+			db:       newTestBackupDB(t),
+			data:     futureVersion,
+			wantErr:  "unsupported backup format version",
+			sentinel: ErrInvalidBackup,
+		},
+		{
+			name:     "row count mismatch",
+			db:       newTestBackupDB(t),
+			data:     wrongRows,
+			wantErr:  "manifest lists",
+			sentinel: ErrInvalidBackup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewBackupService(tt.db, t.TempDir(), nil)
+			_, err := svc.Restore(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Restore error = %v, want containing %q", err, tt.wantErr)
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("Restore error = %v, want wrapping %v", err, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestRestoreStatsMismatchRollsBack(t *testing.T) {
+	srcDB := newTestBackupDB(t)
+	srcImages := t.TempDir()
+	seedBackupDB(t, srcDB, srcImages)
+	useTestDB(t, srcDB)
+
+	snapshots := NewSnapshotService()
+	var archive bytes.Buffer
+	if _, err := NewBackupService(srcDB, srcImages, snapshots).WriteBackup(&archive); err != nil {
+		t.Fatalf("WriteBackup: %v", err)
+	}
+	data := rewriteManifest(t, archive.Bytes(), func(m *BackupManifest) { m.Stats.TotalCards++ })
+
+	dstDB := newTestBackupDB(t)
+	useTestDB(t, dstDB)
+	_, err := NewBackupService(dstDB, t.TempDir(), snapshots).Restore(bytes.NewReader(data), int64(len(data)))
+	if !errors.Is(err, ErrStatsMismatch) {
+		t.Fatalf("Restore error = %v, want %v", err, ErrStatsMismatch)
+	}
+
+	var cards, items int64
+	dstDB.Model(&models.Card{}).Count(&cards)
+	dstDB.Model(&models.CollectionItem{}).Count(&items)
+	if cards != 0 || items != 0 {
+		t.Errorf("restore was not rolled back: %d cards, %d items", cards, items)
+	}
+}
+
+// rewriteManifest returns a copy of archive with its manifest changed by edit
+func rewriteManifest(t *testing.T, archive []byte, edit func(m *BackupManifest)) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		if f.Name == backupManifestFile {
+			var manifest BackupManifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				t.Fatalf("failed to decode manifest: %v", err)
+			}
+			edit(&manifest)
+			if data, err = json.Marshal(manifest); err != nil {
+				t.Fatalf("failed to encode manifest: %v", err)
+			}
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatalf("failed to write %s: %v", f.Name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("failed to write %s: %v", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return out.Bytes()
+}
+
+func almostEqualFloat(a, b float64) bool {
+	d := a - b
+	return d < 0.005 && d > -0.005
+}
@@ -1,9 +1,11 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"image"
 	"log"
 	"os"
 	"path/filepath"
@@ -28,9 +30,10 @@ const (
 // BulkImportWorker handles background processing of bulk import jobs
 type BulkImportWorker struct {
 	db              *gorm.DB
-	geminiService   *GeminiService
+	vision          VisionIdentifier
 	pokemonService  *PokemonHybridService
 	scryfallService *ScryfallService
+	segmenter       *CardSegmenter
 	imageStorageDir string
 	concurrency     int
 	stopCh          chan struct{}
@@ -40,7 +43,7 @@ type BulkImportWorker struct {
 }
 
 // NewBulkImportWorker creates a new bulk import worker
-func NewBulkImportWorker(db *gorm.DB, gemini *GeminiService, pokemon *PokemonHybridService, scryfall *ScryfallService) *BulkImportWorker {
+func NewBulkImportWorker(db *gorm.DB, vision VisionIdentifier, pokemon *PokemonHybridService, scryfall *ScryfallService) *BulkImportWorker {
 	storageDir := os.Getenv("BULK_IMPORT_IMAGES_DIR")
 	if storageDir == "" {
 		storageDir = "./data/bulk_import_images"
@@ -58,11 +61,18 @@ func NewBulkImportWorker(db *gorm.DB, gemini *GeminiService, pokemon *PokemonHyb
 		}
 	}
 
+	// Multi-card photos fall back to the vision provider when edge detection fails
+	var locator CardLocator
+	if l, ok := vision.(CardLocator); ok && vision.IsEnabled() {
+		locator = l
+	}
+
 	return &BulkImportWorker{
 		db:              db,
-		geminiService:   gemini,
+		vision:          vision,
 		pokemonService:  pokemon,
 		scryfallService: scryfall,
+		segmenter:       NewCardSegmenter(locator),
 		imageStorageDir: storageDir,
 		concurrency:     concurrency,
 		stopCh:          make(chan struct{}),
@@ -218,15 +228,15 @@ func (w *BulkImportWorker) processItem(item *models.BulkImportItem) {
 		return
 	}
 
-	// Check if Gemini is available
-	if !w.geminiService.IsEnabled() {
-		w.markItemFailed(item, models.ErrorCodeServiceUnavailable, "Card identification service is not configured. Please set GOOGLE_API_KEY.")
+	// Check if the vision provider is available
+	if w.vision == nil || !w.vision.IsEnabled() {
+		w.markItemFailed(item, models.ErrorCodeServiceUnavailable, "Card identification service is not configured. Please set GOOGLE_API_KEY or configure VISION_PROVIDER.")
 		return
 	}
 
-	// Identify the card using Gemini with thorough mode for better accuracy
+	// Identify the card with thorough mode for better accuracy
 	// (bulk import runs in the background, so accuracy > speed)
-	result, err := w.geminiService.IdentifyCardWithOptions(ctx, imageData, w.pokemonService, w.scryfallService, IdentifyOptions{Thorough: true})
+	result, err := w.vision.IdentifyCardWithOptions(ctx, imageData, w.pokemonService, w.scryfallService, IdentifyOptions{Thorough: true})
 	if err != nil {
 		errorCode := categorizeGeminiError(err, "")
 		w.markItemFailed(item, errorCode, err.Error())
@@ -457,6 +467,78 @@ func (w *BulkImportWorker) AddItemToJob(jobID string, imagePath string, original
 	return item, nil
 }
 
+// AddPhotoToJob adds a photo of several cards (a binder page or a table spread)
+// to a job. Each detected card is cropped and stored as its own item, keeping the
+// source photo and bounding box for review. Photos with fewer than two cards
+// found are added whole as a single item, since a lone region is as likely to be
+// part of a close-up card as a card. maxItems caps how many items the photo may add.
+func (w *BulkImportWorker) AddPhotoToJob(ctx context.Context, jobID string, imageData []byte, originalFilename string, maxItems int) ([]*models.BulkImportItem, error) {
+	regions, err := w.segmenter.Segment(ctx, imageData)
+	if err != nil || len(regions) < 2 {
+		if err != nil {
+			log.Printf("Bulk import: could not segment %s, adding it as one card: %v", originalFilename, err)
+		}
+		imagePath, err := w.SaveImage(imageData, originalFilename)
+		if err != nil {
+			return nil, err
+		}
+		item, err := w.AddItemToJob(jobID, imagePath, originalFilename)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.BulkImportItem{item}, nil
+	}
+
+	if len(regions) > maxItems {
+		return nil, fmt.Errorf("found %d cards but only %d more fit in this job", len(regions), maxItems)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	sourcePath, err := w.SaveImage(imageData, originalFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.BulkImportItem, 0, len(regions))
+	for i, region := range regions {
+		crop, err := CropJPEG(img, region)
+		if err != nil {
+			return items, err
+		}
+		cropPath, err := w.SaveImage(crop, ".jpg")
+		if err != nil {
+			return items, err
+		}
+
+		item := &models.BulkImportItem{
+			JobID:            jobID,
+			OriginalFilename: fmt.Sprintf("%s #%d", originalFilename, i+1),
+			ImagePath:        cropPath,
+			SourceImagePath:  sourcePath,
+			BoxX:             region.Min.X,
+			BoxY:             region.Min.Y,
+			BoxWidth:         region.Dx(),
+			BoxHeight:        region.Dy(),
+			Status:           models.BulkImportItemPending,
+			Condition:        models.ConditionNearMint,
+			PrintingType:     models.PrintingNormal,
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		if err := w.db.Create(item).Error; err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+
+	log.Printf("Bulk import: found %d cards in %s", len(items), originalFilename)
+	return items, nil
+}
+
 // GetJob retrieves a job with all its items
 func (w *BulkImportWorker) GetJob(jobID string) (*models.BulkImportJob, error) {
 	var job models.BulkImportJob
@@ -504,10 +586,15 @@ func (w *BulkImportWorker) DeleteJob(jobID string) error {
 	var items []models.BulkImportItem
 	w.db.Where("job_id = ?", jobID).Find(&items)
 
-	// Delete image files
+	// Delete image files (crops of the same photo share its source image)
+	removed := make(map[string]bool)
 	for _, item := range items {
-		if item.ImagePath != "" {
-			imagePath := filepath.Join(w.imageStorageDir, item.ImagePath)
+		for _, path := range []string{item.ImagePath, item.SourceImagePath} {
+			if path == "" || removed[path] {
+				continue
+			}
+			removed[path] = true
+			imagePath := filepath.Join(w.imageStorageDir, path)
 			if err := os.Remove(imagePath); err != nil && !os.IsNotExist(err) {
 				log.Printf("Warning: failed to delete image %s: %v", imagePath, err)
 			}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// phashSize is the side of the grayscale thumbnail transformed for the pHash;
+	// the hash keeps the 8x8 lowest frequencies
+	phashSize = 32
+)
+
+// CardHash is a pair of 64-bit perceptual hashes of a card image. The pHash
+// (DCT based) is robust to compression and lighting, the dHash (gradient based)
+// to small crops; together they separate different artworks well.
+type CardHash struct {
+	PHash uint64 `json:"phash"`
+	DHash uint64 `json:"dhash"`
+}
+
+// Distance is the number of differing bits between two hashes (0-128)
+func (h CardHash) Distance(other CardHash) int {
+	return bits.OnesCount64(h.PHash^other.PHash) + bits.OnesCount64(h.DHash^other.DHash)
+}
+
+// String formats the hash as hex, for logs and scan fingerprints
+func (h CardHash) String() string {
+	return fmt.Sprintf("%016x:%016x", h.PHash, h.DHash)
+}
+
+// ComputeCardHash hashes a region of an image, typically the whole of a
+// reference scan or the detected card in a photo
+func ComputeCardHash(img image.Image, region image.Rectangle) CardHash {
+	return CardHash{
+		PHash: phash(grayGrid(img, region, phashSize, phashSize)),
+		DHash: dhash(grayGrid(img, region, 9, 8)),
+	}
+}
+
+// queryHashes returns the hashes to look up for an uploaded photo: the whole
+// image, plus the card found in it when the photo shows one card on a background
+func queryHashes(img image.Image) []CardHash {
+	bounds := img.Bounds()
+	hashes := []CardHash{ComputeCardHash(img, bounds)}
+
+	// Only a single portrait region is trusted; a lone landscape region is
+	// usually the artwork box of a tightly cropped card
+	regions := DetectCardRegions(img)
+	if len(regions) == 1 && regions[0].Dy() > regions[0].Dx() && regions[0] != bounds {
+		hashes = append(hashes, ComputeCardHash(img, regions[0]))
+	}
+	return hashes
+}
+
+// grayGrid shrinks a region to w x h grayscale values by averaging a grid of
+// up to 4x4 source pixels per cell
+func grayGrid(img image.Image, region image.Rectangle, w, h int) []float64 {
+	grid := make([]float64, w*h)
+	cellW := float64(region.Dx()) / float64(w)
+	cellH := float64(region.Dy()) / float64(h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for sy := 0; sy < 4; sy++ {
+				for sx := 0; sx < 4; sx++ {
+					px := region.Min.X + int((float64(x)+(float64(sx)+0.5)/4)*cellW)
+					py := region.Min.Y + int((float64(y)+(float64(sy)+0.5)/4)*cellH)
+					sum += float64(color.GrayModel.Convert(img.At(px, py)).(color.Gray).Y)
+				}
+			}
+			grid[y*w+x] = sum / 16
+		}
+	}
+	return grid
+}
+
+// phash sets a bit for each of the 8x8 lowest DCT frequencies (except the
+// constant term) that is above their median
+func phash(grid []float64) uint64 {
+	const n, keep = phashSize, 8
+
+	// Separable DCT-II, computing only the kept frequencies
+	rows := make([]float64, keep*n) // rows[u*n+y]: frequency u along row y
+	for y := 0; y < n; y++ {
+		for u := 0; u < keep; u++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += grid[y*n+x] * math.Cos(float64((2*x+1)*u)*math.Pi/(2*n))
+			}
+			rows[u*n+y] = sum
+		}
+	}
+	coeffs := make([]float64, 0, keep*keep)
+	for v := 0; v < keep; v++ {
+		for u := 0; u < keep; u++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[u*n+y] * math.Cos(float64((2*y+1)*v)*math.Pi/(2*n))
+			}
+			coeffs = append(coeffs, sum)
+		}
+	}
+
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, c := range coeffs {
+		if i > 0 && c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// dhash sets a bit for each pixel of a 9x8 grid that is brighter than its right neighbor
+func dhash(grid []float64) uint64 {
+	var hash uint64
+	bit := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if grid[y*9+x] > grid[y*9+x+1] {
+				hash |= 1 << uint(bit)
+			}
+			bit++
+		}
+	}
+	return hash
+}
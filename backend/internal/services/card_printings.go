@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// CachePrintingsByName looks up every printing named name, in one game or both
+// when game is empty, and caches the ones not yet in the cards table. Name-only
+// want list entries match cached printings, so this gives a card that was never
+// searched a price to compare and makes the price worker track it. Cards already
+// cached are left untouched so their prices are not overwritten. It returns the
+// number of printings added.
+func CachePrintingsByName(db *gorm.DB, pokemon *PokemonHybridService, scryfall *ScryfallService, game models.Game, name string) (int, error) {
+	return cachePrintingsByName(db, newImportLookups(pokemon, scryfall), game, name)
+}
+
+func cachePrintingsByName(db *gorm.DB, lookups map[models.Game]importCardLookup, game models.Game, name string) (int, error) {
+	games := []models.Game{models.GamePokemon, models.GameMTG}
+	if game != "" {
+		games = []models.Game{game}
+	}
+
+	var printings []models.Card
+	var lastErr error
+	for _, g := range games {
+		lookup := lookups[g]
+		if lookup == nil {
+			continue
+		}
+		cards, err := lookup.SearchByName(name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		// Search is fuzzy; keep the exact name, as want list matching does
+		for _, card := range cards {
+			if strings.EqualFold(card.Name, name) {
+				printings = append(printings, card)
+			}
+		}
+	}
+	if len(printings) == 0 {
+		return 0, lastErr
+	}
+
+	result := db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&printings)
+	return int(result.RowsAffected), result.Error
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestCachePrintingsByName(t *testing.T) {
+	db := newTestDB(t, &models.Card{})
+
+	price := 3.5
+	if err := db.Create(&models.Card{ID: "bolt-m11", Name: "Lightning Bolt", Game: models.GameMTG, PriceUSD: price}).Error; err != nil {
+		t.Fatalf("failed to seed card: %v", err)
+	}
+	lookups := map[models.Game]importCardLookup{
+		models.GameMTG: &fakeImportLookup{cards: []models.Card{
+			{ID: "bolt-m11", Name: "Lightning Bolt", Game: models.GameMTG},
+			{ID: "bolt-a25", Name: "Lightning Bolt", Game: models.GameMTG},
+			{ID: "bolt-axe", Name: "Lightning Bolt Axe", Game: models.GameMTG},
+		}},
+		models.GamePokemon: &fakeImportLookup{cards: []models.Card{
+			{ID: "pkmn-bolt", Name: "Lightning Bolt", Game: models.GamePokemon},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		game    models.Game
+		added   int
+		cardIDs []string
+	}{
+		{"one game skips near names and cached cards", models.GameMTG, 1, []string{"bolt-a25", "bolt-m11"}},
+		{"no game searches both", "", 1, []string{"bolt-a25", "bolt-m11", "pkmn-bolt"}},
+		{"already cached", "", 0, []string{"bolt-a25", "bolt-m11", "pkmn-bolt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, err := cachePrintingsByName(db, lookups, tt.game, "lightning bolt")
+			if err != nil {
+				t.Fatalf("cachePrintingsByName() error = %v", err)
+			}
+			if added != tt.added {
+				t.Errorf("added = %d, want %d", added, tt.added)
+			}
+			var ids []string
+			db.Model(&models.Card{}).Order("id").Pluck("id", &ids)
+			if len(ids) != len(tt.cardIDs) {
+				t.Fatalf("cached cards = %v, want %v", ids, tt.cardIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.cardIDs[i] {
+					t.Errorf("cached cards = %v, want %v", ids, tt.cardIDs)
+					break
+				}
+			}
+		})
+	}
+
+	var existing models.Card
+	db.First(&existing, "id = ?", "bolt-m11")
+	if existing.PriceUSD != price {
+		t.Errorf("cached price = %v, want %v (existing card must not be overwritten)", existing.PriceUSD, price)
+	}
+}
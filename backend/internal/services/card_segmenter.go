@@ -0,0 +1,464 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Register decoders for uploaded photos
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"sort"
+)
+
+const (
+	// segmentMaxDimension is the working resolution for edge detection; larger
+	// photos are downsampled first, and regions are scaled back afterwards
+	segmentMaxDimension = 800
+
+	// segmentEdgeThreshold is the minimum Sobel magnitude (0-255) counted as an edge
+	segmentEdgeThreshold = 24
+
+	// cardAspectRatio is width/height of a standard card (63x88mm)
+	cardAspectRatio = 63.0 / 88.0
+
+	// segmentAspectTolerance is how far a region's aspect may stray from a card's.
+	// Photos are rarely perfectly square-on, so this is generous.
+	segmentAspectTolerance = 0.18
+
+	// segmentMinAreaFraction is the smallest region, as a fraction of the photo,
+	// that is considered a card (a 4x4 spread of cards is ~6% each)
+	segmentMinAreaFraction = 0.01
+
+	// segmentMinRelativeArea is the smallest region kept, as a percentage of the
+	// largest card found in the same photo
+	segmentMinRelativeArea = 50
+
+	// segmentCropQuality is the JPEG quality used for stored crops
+	segmentCropQuality = 92
+)
+
+// CardLocator finds card bounding boxes in a photo using a vision model.
+// It is used as a fallback when edge detection cannot find the cards.
+type CardLocator interface {
+	LocateCards(ctx context.Context, imageBytes []byte) ([]image.Rectangle, error)
+}
+
+// CardSegmenter splits a photo of several cards (a binder page or a table
+// spread) into one rectangle per card
+type CardSegmenter struct {
+	fallback CardLocator
+}
+
+// NewCardSegmenter creates a segmenter; fallback may be nil to use edge detection only
+func NewCardSegmenter(fallback CardLocator) *CardSegmenter {
+	return &CardSegmenter{fallback: fallback}
+}
+
+// Segment returns the card regions in the photo, in reading order.
+// Edge detection runs first; when it finds fewer than two cards the vision
+// fallback (if any) is asked instead. An empty result means no cards were found.
+func (s *CardSegmenter) Segment(ctx context.Context, imageBytes []byte) ([]image.Rectangle, error) {
+	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	regions := DetectCardRegions(img)
+	if len(regions) >= 2 || s.fallback == nil {
+		return regions, nil
+	}
+
+	located, err := s.fallback.LocateCards(ctx, imageBytes)
+	if err != nil {
+		log.Printf("Card segmentation: vision fallback failed: %v", err)
+		return regions, nil
+	}
+
+	// Clip to the photo and drop degenerate boxes the model may return
+	var clipped []image.Rectangle
+	for _, r := range located {
+		r = r.Canon().Intersect(img.Bounds())
+		if r.Dx() > 1 && r.Dy() > 1 {
+			clipped = append(clipped, r)
+		}
+	}
+	if len(clipped) == 0 {
+		return regions, nil
+	}
+	return sortReadingOrder(clipped), nil
+}
+
+// CropJPEG crops a region out of an image and encodes it as JPEG
+func CropJPEG(img image.Image, region image.Rectangle) ([]byte, error) {
+	region = region.Intersect(img.Bounds())
+	if region.Empty() {
+		return nil, fmt.Errorf("crop region %v is outside the image", region)
+	}
+
+	crop := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(crop, crop.Bounds(), img, region.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, crop, &jpeg.Options{Quality: segmentCropQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode crop: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DetectCardRegions finds card-shaped regions in an image with pure edge
+// detection: a Sobel edge map is dilated so each card's outline and artwork
+// merge into one blob, and blobs with a card's proportions are kept. Blobs
+// that span several touching cards are split along empty rows and columns.
+// Cards are assumed to be roughly axis-aligned (portrait or landscape).
+func DetectCardRegions(img image.Image) []image.Rectangle {
+	bounds := img.Bounds()
+	if bounds.Dx() < 3 || bounds.Dy() < 3 {
+		return nil
+	}
+
+	gray, scale := downsampleGray(img, segmentMaxDimension)
+	edges := sobelEdges(gray)
+
+	// A light dilation closes gaps in card outlines so each card is one blob;
+	// trimming and splitting use the undilated edges, where the narrow gaps
+	// between binder pockets are still open
+	radius := max(1, max(gray.w, gray.h)/300)
+	mask := dilate(edges, radius)
+
+	minArea := int(segmentMinAreaFraction * float64(gray.w*gray.h))
+	var found []image.Rectangle
+	for _, blob := range connectedBoxes(mask) {
+		found = append(found, splitCardBlob(edges, blob, minArea, 0)...)
+	}
+	found = dropNested(found)
+
+	// Cards in one photo are about the same size; much smaller regions are
+	// pieces of a card (artwork, text box) whose outline wasn't found
+	largest := 0
+	for _, r := range found {
+		largest = max(largest, r.Dx()*r.Dy())
+	}
+
+	// Map back to the original resolution
+	regions := make([]image.Rectangle, 0, len(found))
+	for _, r := range found {
+		if r.Dx()*r.Dy()*100 < largest*segmentMinRelativeArea {
+			continue
+		}
+		scaled := image.Rect(
+			int(float64(r.Min.X)*scale), int(float64(r.Min.Y)*scale),
+			int(float64(r.Max.X)*scale), int(float64(r.Max.Y)*scale),
+		).Add(bounds.Min).Intersect(bounds)
+		regions = append(regions, scaled)
+	}
+	return sortReadingOrder(regions)
+}
+
+// isCardShaped reports whether a region has a card's proportions in either orientation
+func isCardShaped(r image.Rectangle) bool {
+	if r.Dx() == 0 || r.Dy() == 0 {
+		return false
+	}
+	aspect := float64(r.Dx()) / float64(r.Dy())
+	if aspect > 1 {
+		aspect = 1 / aspect // Landscape card
+	}
+	return aspect >= cardAspectRatio*(1-segmentAspectTolerance) && aspect <= cardAspectRatio*(1+segmentAspectTolerance)
+}
+
+// splitCardBlob returns the cards within a blob. A blob is first cut along its
+// empty rows or columns, since touching cards (a whole binder page) can form a
+// card-shaped blob too. Otherwise the blob itself is a card if it has a card's
+// proportions.
+func splitCardBlob(edges *grayPlane, r image.Rectangle, minArea, depth int) []image.Rectangle {
+	if r.Dx()*r.Dy() < minArea {
+		return nil
+	}
+	r = trimEmpty(edges, r)
+	if r.Empty() || r.Dx()*r.Dy() < minArea {
+		return nil
+	}
+
+	if depth < 6 {
+		pieces := cutAlongGaps(edges, r, true)
+		if len(pieces) < 2 {
+			pieces = cutAlongGaps(edges, r, false)
+		}
+
+		var cards []image.Rectangle
+		covered := 0
+		if len(pieces) >= 2 {
+			for _, piece := range pieces {
+				for _, card := range splitCardBlob(edges, piece, minArea, depth+1) {
+					cards = append(cards, card)
+					covered += card.Dx() * card.Dy()
+				}
+			}
+		}
+		// A card-shaped blob is only split into several cards that fill it;
+		// otherwise cutting off a card's flat side borders would leave its
+		// artwork box to be mistaken for a card
+		if len(cards) >= 2 && covered*10 >= r.Dx()*r.Dy()*6 || len(cards) > 0 && !isCardShaped(r) {
+			return cards
+		}
+	}
+
+	if isCardShaped(r) {
+		return []image.Rectangle{r}
+	}
+	return nil
+}
+
+// dropNested removes regions lying mostly inside a larger region, such as the
+// artwork box of a card, which can have a landscape card's proportions
+func dropNested(regions []image.Rectangle) []image.Rectangle {
+	var kept []image.Rectangle
+	for i, r := range regions {
+		area := r.Dx() * r.Dy()
+		nested := false
+		for j, other := range regions {
+			// Of two equal regions, the first is kept
+			otherArea := other.Dx() * other.Dy()
+			if i == j || otherArea < area || otherArea == area && j > i {
+				continue
+			}
+			overlap := r.Intersect(other)
+			if overlap.Dx()*overlap.Dy()*10 >= area*8 {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// trimEmpty shrinks a rectangle to the extent of the mask pixels inside it
+func trimEmpty(mask *grayPlane, r image.Rectangle) image.Rectangle {
+	out := image.Rectangle{Min: r.Max, Max: r.Min}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if mask.at(x, y) == 0 {
+				continue
+			}
+			out.Min.X = min(out.Min.X, x)
+			out.Min.Y = min(out.Min.Y, y)
+			out.Max.X = max(out.Max.X, x+1)
+			out.Max.Y = max(out.Max.Y, y+1)
+		}
+	}
+	if out.Min.X >= out.Max.X || out.Min.Y >= out.Max.Y {
+		return image.Rectangle{}
+	}
+	return out
+}
+
+// cutAlongGaps splits a rectangle at columns (vertical) or rows with almost no
+// mask pixels, which is where the gaps between touching cards are
+func cutAlongGaps(mask *grayPlane, r image.Rectangle, vertical bool) []image.Rectangle {
+	length, span := r.Dy(), r.Dx()
+	if !vertical {
+		length, span = r.Dx(), r.Dy()
+	}
+	limit := length / 50 // Allow a little noise in a gap
+
+	var pieces []image.Rectangle
+	start := -1
+	for i := 0; i <= span; i++ {
+		filled := 0
+		if i < span {
+			for j := 0; j < length && filled <= limit; j++ {
+				if vertical && mask.at(r.Min.X+i, r.Min.Y+j) != 0 || !vertical && mask.at(r.Min.X+j, r.Min.Y+i) != 0 {
+					filled++
+				}
+			}
+		}
+		if i < span && filled > limit {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			if vertical {
+				pieces = append(pieces, image.Rect(r.Min.X+start, r.Min.Y, r.Min.X+i, r.Max.Y))
+			} else {
+				pieces = append(pieces, image.Rect(r.Min.X, r.Min.Y+start, r.Max.X, r.Min.Y+i))
+			}
+			start = -1
+		}
+	}
+	return pieces
+}
+
+// sortReadingOrder orders regions top-to-bottom in rows, then left-to-right,
+// so the items of a binder page come out in pocket order
+func sortReadingOrder(regions []image.Rectangle) []image.Rectangle {
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].Min.Y+regions[i].Max.Y < regions[j].Min.Y+regions[j].Max.Y
+	})
+
+	var sorted []image.Rectangle
+	for start := 0; start < len(regions); {
+		// A region joins the row while its center is above the row leader's bottom edge
+		leader := regions[start]
+		end := start + 1
+		for end < len(regions) && (regions[end].Min.Y+regions[end].Max.Y)/2 < leader.Max.Y {
+			end++
+		}
+		row := regions[start:end]
+		sort.Slice(row, func(i, j int) bool { return row[i].Min.X < row[j].Min.X })
+		sorted = append(sorted, row...)
+		start = end
+	}
+	return sorted
+}
+
+// grayPlane is a single-channel image stored row-major
+type grayPlane struct {
+	w, h int
+	pix  []uint8
+}
+
+func newGrayPlane(w, h int) *grayPlane {
+	return &grayPlane{w: w, h: h, pix: make([]uint8, w*h)}
+}
+
+func (p *grayPlane) at(x, y int) uint8 {
+	return p.pix[y*p.w+x]
+}
+
+// downsampleGray converts an image to grayscale at most maxDim pixels on its
+// longer side, averaging a grid of the source pixels behind each output pixel
+// (at most 3x3, so large photos stay fast). It returns
+// the plane and the factor from plane coordinates to image coordinates.
+func downsampleGray(img image.Image, maxDim int) (*grayPlane, float64) {
+	bounds := img.Bounds()
+	scale := 1.0
+	if longest := max(bounds.Dx(), bounds.Dy()); longest > maxDim {
+		scale = float64(longest) / float64(maxDim)
+	}
+	w := max(1, int(float64(bounds.Dx())/scale))
+	h := max(1, int(float64(bounds.Dy())/scale))
+
+	plane := newGrayPlane(w, h)
+	for y := 0; y < h; y++ {
+		y0 := bounds.Min.Y + int(float64(y)*scale)
+		y1 := max(y0+1, bounds.Min.Y+int(float64(y+1)*scale))
+		for x := 0; x < w; x++ {
+			x0 := bounds.Min.X + int(float64(x)*scale)
+			x1 := max(x0+1, bounds.Min.X+int(float64(x+1)*scale))
+
+			stepX, stepY := max(1, (x1-x0)/3), max(1, (y1-y0)/3)
+			var sum, n int
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					sum += int(color.GrayModel.Convert(img.At(sx, sy)).(color.Gray).Y)
+					n++
+				}
+			}
+			plane.pix[y*w+x] = uint8(sum / n)
+		}
+	}
+	return plane, scale
+}
+
+// sobelEdges returns a binary edge map (255 for edges) of a grayscale plane
+func sobelEdges(gray *grayPlane) *grayPlane {
+	edges := newGrayPlane(gray.w, gray.h)
+	for y := 1; y < gray.h-1; y++ {
+		for x := 1; x < gray.w-1; x++ {
+			tl, t, tr := int(gray.at(x-1, y-1)), int(gray.at(x, y-1)), int(gray.at(x+1, y-1))
+			l, r := int(gray.at(x-1, y)), int(gray.at(x+1, y))
+			bl, b, br := int(gray.at(x-1, y+1)), int(gray.at(x, y+1)), int(gray.at(x+1, y+1))
+
+			gx := (tr + 2*r + br) - (tl + 2*l + bl)
+			gy := (bl + 2*b + br) - (tl + 2*t + tr)
+			// |gx|+|gy| peaks at 2040; scale to 0-255
+			if (abs(gx)+abs(gy))/8 >= segmentEdgeThreshold {
+				edges.pix[y*gray.w+x] = 255
+			}
+		}
+	}
+	return edges
+}
+
+// dilate grows every set pixel into a (2r+1)-pixel square, as two separable passes
+func dilate(mask *grayPlane, r int) *grayPlane {
+	horizontal := newGrayPlane(mask.w, mask.h)
+	for y := 0; y < mask.h; y++ {
+		last := -r - 1 // Most recent set pixel at or before x+r
+		for x := -r; x < mask.w; x++ {
+			if ahead := x + r; ahead < mask.w && mask.at(ahead, y) != 0 {
+				last = ahead
+			}
+			if x >= 0 && x-last <= r {
+				horizontal.pix[y*mask.w+x] = 255
+			}
+		}
+	}
+
+	out := newGrayPlane(mask.w, mask.h)
+	for x := 0; x < mask.w; x++ {
+		last := -r - 1
+		for y := -r; y < mask.h; y++ {
+			if ahead := y + r; ahead < mask.h && horizontal.at(x, ahead) != 0 {
+				last = ahead
+			}
+			if y >= 0 && y-last <= r {
+				out.pix[y*mask.w+x] = 255
+			}
+		}
+	}
+	return out
+}
+
+// connectedBoxes returns the bounding box of each 4-connected group of set pixels
+func connectedBoxes(mask *grayPlane) []image.Rectangle {
+	visited := make([]bool, len(mask.pix))
+	var boxes []image.Rectangle
+	var stack []int
+
+	for start := range mask.pix {
+		if mask.pix[start] == 0 || visited[start] {
+			continue
+		}
+		box := image.Rect(start%mask.w, start/mask.w, start%mask.w+1, start/mask.w+1)
+		visited[start] = true
+		stack = append(stack[:0], start)
+
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%mask.w, i/mask.w
+			box = box.Union(image.Rect(x, y, x+1, y+1))
+
+			for _, n := range [4]int{i - 1, i + 1, i - mask.w, i + mask.w} {
+				if n < 0 || n >= len(mask.pix) || visited[n] || mask.pix[n] == 0 {
+					continue
+				}
+				// Don't wrap around row ends
+				if (n == i-1 && x == 0) || (n == i+1 && x == mask.w-1) {
+					continue
+				}
+				visited[n] = true
+				stack = append(stack, n)
+			}
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// drawTestCard paints a card-like rectangle: a dark outline, a flat frame,
+// a noisy artwork box and a few lines of "text"
+func drawTestCard(img *image.RGBA, r image.Rectangle, rng *rand.Rand) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.RGBA{220, 190, 60, 255}
+			if x-r.Min.X < 3 || r.Max.X-x <= 3 || y-r.Min.Y < 3 || r.Max.Y-y <= 3 {
+				c = color.RGBA{30, 30, 30, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	w, h := r.Dx(), r.Dy()
+	art := image.Rect(r.Min.X+w/10, r.Min.Y+h/8, r.Max.X-w/10, r.Min.Y+h/2)
+	for y := art.Min.Y; y < art.Max.Y; y++ {
+		for x := art.Min.X; x < art.Max.X; x++ {
+			v := uint8(rng.Intn(256))
+			img.Set(x, y, color.RGBA{v, 255 - v, v / 2, 255})
+		}
+	}
+	for line := 0; line < 4; line++ {
+		y := r.Min.Y + h*6/10 + line*h/14
+		for x := r.Min.X + w/8; x < r.Max.X-w/8; x++ {
+			if (x/6)%3 != 0 {
+				img.Set(x, y, color.Black)
+				img.Set(x, y+1, color.Black)
+			}
+		}
+	}
+}
+
+func newTestPhoto(w, h int, background color.RGBA, cards []image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = []uint8{background.R, background.G, background.B, background.A}[i%4]
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, card := range cards {
+		drawTestCard(img, card, rng)
+	}
+	return img
+}
+
+// binderPage lays out a 3x3 grid of 250x350 cards with the given gap
+func binderPage(gap int) (int, int, []image.Rectangle) {
+	var cards []image.Rectangle
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			x := gap + col*(250+gap)
+			y := gap + row*(350+gap)
+			cards = append(cards, image.Rect(x, y, x+250, y+350))
+		}
+	}
+	return 3*250 + 4*gap, 3*350 + 4*gap, cards
+}
+
+// regionsMatch reports whether each region is within tolerance pixels of the expected card
+func regionsMatch(got, want []image.Rectangle, tolerance int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		d := []int{got[i].Min.X - want[i].Min.X, got[i].Min.Y - want[i].Min.Y, got[i].Max.X - want[i].Max.X, got[i].Max.Y - want[i].Max.Y}
+		for _, v := range d {
+			if abs(v) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestDetectCardRegions(t *testing.T) {
+	w, h, binder := binderPage(24)
+
+	// A binder page with pocket seams drawn down the middle of each gap
+	seamed := newTestPhoto(w, h, color.RGBA{235, 235, 240, 255}, binder)
+	for i := 1; i < 3; i++ {
+		seamX := 24 + i*(250+24) - 12
+		seamY := 24 + i*(350+24) - 12
+		for y := 0; y < h; y++ {
+			seamed.Set(seamX, y, color.RGBA{150, 150, 160, 255})
+		}
+		for x := 0; x < w; x++ {
+			seamed.Set(x, seamY, color.RGBA{150, 150, 160, 255})
+		}
+	}
+
+	// A table spread: scattered cards on a dark table, one of them sideways
+	spread := []image.Rectangle{
+		image.Rect(500, 80, 800, 500),  // Top right, listed first to check ordering
+		image.Rect(60, 100, 360, 520),  // Top left
+		image.Rect(120, 640, 540, 940), // Landscape card below
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want []image.Rectangle
+	}{
+		{"binder page", newTestPhoto(w, h, color.RGBA{235, 235, 240, 255}, binder), binder},
+		{"binder page with seams", seamed, binder},
+		{"table spread", newTestPhoto(900, 1000, color.RGBA{60, 40, 30, 255}, spread), []image.Rectangle{spread[1], spread[0], spread[2]}},
+		{"single card", newTestPhoto(500, 600, color.RGBA{60, 40, 30, 255}, []image.Rectangle{image.Rect(100, 80, 400, 500)}), []image.Rectangle{image.Rect(100, 80, 400, 500)}},
+		{"empty table", newTestPhoto(600, 600, color.RGBA{60, 40, 30, 255}, nil), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectCardRegions(tt.img)
+			if !regionsMatch(got, tt.want, 8) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubLocator returns fixed card boxes and records whether it was asked
+type stubLocator struct {
+	regions []image.Rectangle
+	called  bool
+}
+
+func (s *stubLocator) LocateCards(ctx context.Context, imageBytes []byte) ([]image.Rectangle, error) {
+	s.called = true
+	return s.regions, nil
+}
+
+func TestCardSegmenterVisionFallback(t *testing.T) {
+	encode := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		return buf.Bytes()
+	}
+	w, h, binder := binderPage(24)
+	page := encode(newTestPhoto(w, h, color.RGBA{235, 235, 240, 255}, binder))
+	empty := encode(newTestPhoto(400, 400, color.RGBA{235, 235, 240, 255}, nil))
+
+	// Edge detection finds the binder cards, so the model is not asked
+	locator := &stubLocator{}
+	regions, err := NewCardSegmenter(locator).Segment(context.Background(), page)
+	if err != nil || len(regions) != 9 || locator.called {
+		t.Errorf("binder page: %d regions, err %v, fallback called %v", len(regions), err, locator.called)
+	}
+
+	// Nothing found: the model's boxes are clipped to the photo and sorted
+	locator = &stubLocator{regions: []image.Rectangle{image.Rect(200, 10, 390, 300), image.Rect(-5, 0, 180, 290), image.Rect(50, 50, 50, 80)}}
+	regions, err = NewCardSegmenter(locator).Segment(context.Background(), empty)
+	want := []image.Rectangle{image.Rect(0, 0, 180, 290), image.Rect(200, 10, 390, 300)}
+	if err != nil || !locator.called || !regionsMatch(regions, want, 0) {
+		t.Errorf("fallback: got %v (err %v), want %v", regions, err, want)
+	}
+
+	if _, err := NewCardSegmenter(nil).Segment(context.Background(), []byte("not an image")); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+}
+
+func TestParseCardLocations(t *testing.T) {
+	text := "```json\n{\"cards\": [{\"box_2d\": [0, 0, 500, 250]}, {\"box_2d\": [100, 500, 900, 1000]}, {\"box_2d\": [1, 2]}]}\n```"
+	got, err := parseCardLocations(text, 2000, 1000)
+	if err != nil {
+		t.Fatalf("parseCardLocations: %v", err)
+	}
+	want := []image.Rectangle{image.Rect(0, 0, 500, 500), image.Rect(1000, 100, 2000, 900)}
+	if !regionsMatch(got, want, 0) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := parseCardLocations("no cards here", 100, 100); err == nil {
+		t.Error("expected an error for non-JSON text")
+	}
+}
+
+func TestBulkImportAddPhotoToJob(t *testing.T) {
+	db := newTestDB(t, &models.BulkImportJob{}, &models.BulkImportItem{})
+	worker := &BulkImportWorker{db: db, segmenter: NewCardSegmenter(nil), imageStorageDir: t.TempDir()}
+	job, err := worker.CreateJob(1)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	w, h, binder := binderPage(24)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestPhoto(w, h, color.RGBA{235, 235, 240, 255}, binder)); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	if _, err := worker.AddPhotoToJob(context.Background(), job.ID, buf.Bytes(), "page.png", 5); err == nil {
+		t.Error("expected an error when the cards exceed the job's remaining capacity")
+	}
+
+	items, err := worker.AddPhotoToJob(context.Background(), job.ID, buf.Bytes(), "page.png", 200)
+	if err != nil || len(items) != 9 {
+		t.Fatalf("AddPhotoToJob: %d items, err %v", len(items), err)
+	}
+
+	middle := items[4]
+	if middle.OriginalFilename != "page.png #5" || middle.SourceImagePath == "" || middle.ImagePath == middle.SourceImagePath {
+		t.Errorf("unexpected item: %+v", middle)
+	}
+	box := image.Rect(middle.BoxX, middle.BoxY, middle.BoxX+middle.BoxWidth, middle.BoxY+middle.BoxHeight)
+	if !regionsMatch([]image.Rectangle{box}, binder[4:5], 8) {
+		t.Errorf("box %v, want %v", box, binder[4])
+	}
+
+	crop, err := os.ReadFile(filepath.Join(worker.imageStorageDir, middle.ImagePath))
+	if err != nil {
+		t.Fatalf("read crop: %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(crop))
+	if err != nil || format != "jpeg" || cfg.Width != middle.BoxWidth || cfg.Height != middle.BoxHeight {
+		t.Errorf("crop is %s %dx%d (err %v), want a %dx%d jpeg", format, cfg.Width, cfg.Height, err, middle.BoxWidth, middle.BoxHeight)
+	}
+
+	// An empty photo is kept whole as a single item
+	buf.Reset()
+	if err := png.Encode(&buf, newTestPhoto(300, 300, color.RGBA{235, 235, 240, 255}, nil)); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	items, err = worker.AddPhotoToJob(context.Background(), job.ID, buf.Bytes(), "blank.png", 200)
+	if err != nil || len(items) != 1 || items[0].SourceImagePath != "" || items[0].OriginalFilename != "blank.png" {
+		t.Errorf("blank photo: %d items, err %v", len(items), err)
+	}
+
+	// Deleting the job removes the shared source photo along with the crops
+	if err := worker.DeleteJob(job.ID); err != nil {
+		t.Fatalf("DeleteJob: %v", err)
+	}
+	if left, _ := os.ReadDir(worker.imageStorageDir); len(left) != 0 {
+		t.Errorf("expected all images removed, %d left", len(left))
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
+)
+
+// ExportFormat identifies a CSV layout for collection exports
+type ExportFormat string
+
+const (
+	ExportFormatGeneric    ExportFormat = "generic"    // Every field we store, using our own enum values
+	ExportFormatTCGplayer  ExportFormat = "tcgplayer"  // TCGplayer app / mass entry
+	ExportFormatCardmarket ExportFormat = "cardmarket" // Cardmarket stock file (semicolon separated)
+	ExportFormatMoxfield   ExportFormat = "moxfield"   // Moxfield collection import (MTG only)
+	ExportFormatDeckbox    ExportFormat = "deckbox"    // Deckbox inventory import (MTG only)
+)
+
+// AllExportFormats returns all supported export formats
+func AllExportFormats() []ExportFormat {
+	return []ExportFormat{
+		ExportFormatGeneric,
+		ExportFormatTCGplayer,
+		ExportFormatCardmarket,
+		ExportFormatMoxfield,
+		ExportFormatDeckbox,
+	}
+}
+
+// ParseExportFormat returns the export format for a query value (case-insensitive)
+func ParseExportFormat(value string) (ExportFormat, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ExportFormatGeneric, true
+	}
+	for _, f := range AllExportFormats() {
+		if string(f) == value {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// SupportsGame reports whether the target site accepts cards from this game.
+// Moxfield and Deckbox only track Magic cards.
+func (f ExportFormat) SupportsGame(game models.Game) bool {
+	switch f {
+	case ExportFormatMoxfield, ExportFormatDeckbox:
+		return game == models.GameMTG
+	default:
+		return true
+	}
+}
+
+// Delimiter returns the field separator used by the target site
+func (f ExportFormat) Delimiter() rune {
+	if f == ExportFormatCardmarket {
+		return ';'
+	}
+	return ','
+}
+
+// Header returns the CSV header row for the format
+func (f ExportFormat) Header() []string {
+	switch f {
+	case ExportFormatTCGplayer:
+		return []string{"Quantity", "Name", "Simple Name", "Set", "Card Number", "Set Code", "Printing", "Condition", "Language", "Rarity", "Product ID", "Price Each"}
+	case ExportFormatCardmarket:
+		return []string{"idProduct", "English Name", "Exp. Name", "Collector Number", "Language", "Condition", "Foil?", "Signed?", "Altered?", "First Edition?", "Reverse Holo?", "Comments", "Amount", "Price"}
+	case ExportFormatMoxfield:
+		return []string{"Count", "Tradelist Count", "Name", "Edition", "Condition", "Language", "Foil", "Tags", "Last Modified", "Collector Number", "Alter", "Proxy", "Purchase Price"}
+	case ExportFormatDeckbox:
+		return []string{"Count", "Tradelist Count", "Name", "Edition", "Card Number", "Condition", "Language", "Foil", "Signed", "Artist Proof", "Altered Art", "Misprint", "Promo", "Textless", "My Price"}
+	default:
+		return []string{"Card ID", "Game", "Name", "Set", "Set Code", "Card Number", "Rarity", "Quantity", "Condition", "Printing", "Language", "Unit Value", "Total Value", "Purchase Price", "Purchase Date", "Acquisition Source", "Notes", "Added At", "Scanned Image", "Grading Company", "Grade", "Cert Number"}
+	}
+}
+
+// Row converts a collection item (with Card loaded) into a CSV row for the format
+func (f ExportFormat) Row(item *models.CollectionItem) []string {
+	card := &item.Card
+	language := item.Language
+	if language == "" {
+		language = models.LanguageEnglish
+	}
+	priceResult, _ := valuation.UnitPrice(item, card)
+	unitValue := priceResult.Price
+
+	switch f {
+	case ExportFormatTCGplayer:
+		return []string{
+			strconv.Itoa(item.Quantity),
+			card.Name,
+			card.Name,
+			card.SetName,
+			card.CardNumber,
+			strings.ToUpper(card.SetCode),
+			MapPrintingToTCGplayer(item.Printing, card.Game),
+			MapConditionToTCGplayer(item.Condition),
+			string(language),
+			card.Rarity,
+			card.TCGPlayerID,
+			formatExportPrice(unitValue),
+		}
+	case ExportFormatCardmarket:
+		return []string{
+			"",
+			card.Name,
+			card.SetName,
+			card.CardNumber,
+			MapLanguageToCardmarket(language),
+			MapConditionToCardmarket(item.Condition),
+			exportFlag(item.Printing == models.PrintingFoil, "X"),
+			"",
+			"",
+			exportFlag(item.Printing == models.Printing1stEdition, "X"),
+			exportFlag(item.Printing == models.PrintingReverseHolo, "X"),
+			item.Notes,
+			strconv.Itoa(item.Quantity),
+			formatExportPrice(unitValue),
+		}
+	case ExportFormatMoxfield:
+		return []string{
+			strconv.Itoa(item.Quantity),
+			"0",
+			card.Name,
+			strings.ToLower(card.SetCode),
+			MapConditionToMoxfield(item.Condition),
+			string(language),
+			exportFlag(item.Printing.IsFoilVariant(), "foil"),
+			"",
+			item.AddedAt.Format("2006-01-02 15:04:05"),
+			card.CardNumber,
+			"False",
+			"False",
+			formatOptionalPrice(item.PurchasePrice),
+		}
+	case ExportFormatDeckbox:
+		return []string{
+			strconv.Itoa(item.Quantity),
+			"0",
+			card.Name,
+			card.SetName,
+			card.CardNumber,
+			MapConditionToDeckbox(item.Condition),
+			string(language),
+			exportFlag(item.Printing.IsFoilVariant(), "foil"),
+			"", "", "", "", "", "",
+			formatOptionalPrice(item.PurchasePrice),
+		}
+	default:
+		purchaseDate := ""
+		if item.PurchaseDate != nil {
+			purchaseDate = item.PurchaseDate.Format("2006-01-02")
+		}
+		return []string{
+			item.CardID,
+			string(card.Game),
+			card.Name,
+			card.SetName,
+			card.SetCode,
+			card.CardNumber,
+			card.Rarity,
+			strconv.Itoa(item.Quantity),
+			string(item.Condition),
+			string(item.Printing),
+			string(language),
+			formatExportPrice(unitValue),
+			formatExportPrice(unitValue * float64(item.Quantity)),
+			formatOptionalPrice(item.PurchasePrice),
+			purchaseDate,
+			item.AcquisitionSource,
+			item.Notes,
+			item.AddedAt.Format(time.RFC3339),
+			item.ScannedImagePath,
+			string(item.GradingCompany),
+			item.Grade,
+			item.CertNumber,
+		}
+	}
+}
+
+// CollectionCSVWriter streams collection items as CSV in one export format
+type CollectionCSVWriter struct {
+	format  ExportFormat
+	writer  *csv.Writer
+	skipped int
+}
+
+// NewCollectionCSVWriter creates a CSV writer and writes the header row
+func NewCollectionCSVWriter(w io.Writer, format ExportFormat) (*CollectionCSVWriter, error) {
+	writer := csv.NewWriter(w)
+	writer.Comma = format.Delimiter()
+	if err := writer.Write(format.Header()); err != nil {
+		return nil, err
+	}
+	return &CollectionCSVWriter{format: format, writer: writer}, nil
+}
+
+// Write appends an item. Items from games the format does not support are skipped.
+func (cw *CollectionCSVWriter) Write(item *models.CollectionItem) error {
+	if !cw.format.SupportsGame(item.Card.Game) {
+		cw.skipped++
+		return nil
+	}
+	return cw.writer.Write(cw.format.Row(item))
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (cw *CollectionCSVWriter) Flush() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// Skipped returns the number of items left out because the format does not support their game
+func (cw *CollectionCSVWriter) Skipped() int {
+	return cw.skipped
+}
+
+// MapConditionToTCGplayer maps the app's collection condition to TCGplayer's
+// condition names, which follow the same five-step scale as JustTCG
+func MapConditionToTCGplayer(condition models.Condition) string {
+	switch models.MapCollectionConditionToPriceCondition(condition) {
+	case models.PriceConditionLP:
+		return "Lightly Played"
+	case models.PriceConditionMP:
+		return "Moderately Played"
+	case models.PriceConditionHP:
+		return "Heavily Played"
+	case models.PriceConditionDMG:
+		return "Damaged"
+	default:
+		return "Near Mint"
+	}
+}
+
+// MapConditionToCardmarket maps the app's collection condition to Cardmarket's
+// grading scale. Our conditions were modelled on it, so the mapping is one-to-one.
+func MapConditionToCardmarket(condition models.Condition) string {
+	switch condition {
+	case models.ConditionMint:
+		return "MT"
+	case models.ConditionExcellent:
+		return "EX"
+	case models.ConditionGood:
+		return "GD"
+	case models.ConditionLightPlay:
+		return "LP"
+	case models.ConditionPlayed:
+		return "PL"
+	case models.ConditionPoor:
+		return "PO"
+	default:
+		return "NM"
+	}
+}
+
+// MapConditionToMoxfield maps the app's collection condition to Moxfield's condition names
+func MapConditionToMoxfield(condition models.Condition) string {
+	switch condition {
+	case models.ConditionMint:
+		return "Mint"
+	case models.ConditionExcellent, models.ConditionLightPlay:
+		return "Lightly Played"
+	case models.ConditionGood:
+		return "Moderately Played"
+	case models.ConditionPlayed:
+		return "Heavily Played"
+	case models.ConditionPoor:
+		return "Damaged"
+	default:
+		return "Near Mint"
+	}
+}
+
+// MapConditionToDeckbox maps the app's collection condition to Deckbox's condition names
+func MapConditionToDeckbox(condition models.Condition) string {
+	switch condition {
+	case models.ConditionMint:
+		return "Mint"
+	case models.ConditionExcellent, models.ConditionLightPlay:
+		return "Good (Lightly Played)"
+	case models.ConditionGood:
+		return "Played"
+	case models.ConditionPlayed:
+		return "Heavily Played"
+	case models.ConditionPoor:
+		return "Poor"
+	default:
+		return "Near Mint"
+	}
+}
+
+// MapPrintingToTCGplayer maps a printing to TCGplayer's printing names.
+// TCGplayer calls Pokemon holos "Holofoil" but Magic foils "Foil".
+func MapPrintingToTCGplayer(printing models.PrintingType, game models.Game) string {
+	switch printing {
+	case "":
+		return string(models.PrintingNormal)
+	case models.PrintingFoil:
+		if game == models.GamePokemon {
+			return "Holofoil"
+		}
+		return "Foil"
+	default:
+		return string(printing)
+	}
+}
+
+// MapLanguageToCardmarket maps a card language to Cardmarket's numeric language ID
+func MapLanguageToCardmarket(language models.CardLanguage) string {
+	switch language {
+	case models.LanguageFrench:
+		return "2"
+	case models.LanguageGerman:
+		return "3"
+	case models.LanguageItalian:
+		return "5"
+	case models.LanguageJapanese:
+		return "7"
+	default:
+		return "1"
+	}
+}
+
+func exportFlag(set bool, value string) string {
+	if set {
+		return value
+	}
+	return ""
+}
+
+func formatExportPrice(price float64) string {
+	return fmt.Sprintf("%.2f", price)
+}
+
+func formatOptionalPrice(price *float64) string {
+	if price == nil {
+		return ""
+	}
+	return formatExportPrice(*price)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestExportConditionMapping(t *testing.T) {
+	tests := []struct {
+		condition  models.Condition
+		tcgplayer  string
+		cardmarket string
+		moxfield   string
+		deckbox    string
+	}{
+		{models.ConditionMint, "Near Mint", "MT", "Mint", "Mint"},
+		{models.ConditionNearMint, "Near Mint", "NM", "Near Mint", "Near Mint"},
+		{models.ConditionExcellent, "Lightly Played", "EX", "Lightly Played", "Good (Lightly Played)"},
+		{models.ConditionLightPlay, "Lightly Played", "LP", "Lightly Played", "Good (Lightly Played)"},
+		{models.ConditionGood, "Moderately Played", "GD", "Moderately Played", "Played"},
+		{models.ConditionPlayed, "Heavily Played", "PL", "Heavily Played", "Heavily Played"},
+		{models.ConditionPoor, "Damaged", "PO", "Damaged", "Poor"},
+		{"", "Near Mint", "NM", "Near Mint", "Near Mint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.condition), func(t *testing.T) {
+			if got := MapConditionToTCGplayer(tt.condition); got != tt.tcgplayer {
+				t.Errorf("MapConditionToTCGplayer() = %q, want %q", got, tt.tcgplayer)
+			}
+			if got := MapConditionToCardmarket(tt.condition); got != tt.cardmarket {
+				t.Errorf("MapConditionToCardmarket() = %q, want %q", got, tt.cardmarket)
+			}
+			if got := MapConditionToMoxfield(tt.condition); got != tt.moxfield {
+				t.Errorf("MapConditionToMoxfield() = %q, want %q", got, tt.moxfield)
+			}
+			if got := MapConditionToDeckbox(tt.condition); got != tt.deckbox {
+				t.Errorf("MapConditionToDeckbox() = %q, want %q", got, tt.deckbox)
+			}
+		})
+	}
+}
+
+func TestMapPrintingToTCGplayer(t *testing.T) {
+	tests := []struct {
+		printing models.PrintingType
+		game     models.Game
+		expected string
+	}{
+		{models.PrintingFoil, models.GameMTG, "Foil"},
+		{models.PrintingFoil, models.GamePokemon, "Holofoil"},
+		{models.PrintingReverseHolo, models.GamePokemon, "Reverse Holofoil"},
+		{models.Printing1stEdition, models.GamePokemon, "1st Edition"},
+		{"", models.GameMTG, "Normal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.game)+"/"+string(tt.printing), func(t *testing.T) {
+			if got := MapPrintingToTCGplayer(tt.printing, tt.game); got != tt.expected {
+				t.Errorf("MapPrintingToTCGplayer() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCollectionCSVWriter(t *testing.T) {
+	mtgItem := models.CollectionItem{
+		CardID:    "mtg-1",
+		Card:      models.Card{ID: "mtg-1", Name: "Lightning Bolt", SetName: "Magic 2011", SetCode: "m11", CardNumber: "149", Game: models.GameMTG, PriceUSD: 2, PriceFoilUSD: 5},
+		Quantity:  3,
+		Condition: models.ConditionExcellent,
+		Printing:  models.PrintingFoil,
+		Language:  models.LanguageGerman,
+		AddedAt:   time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC),
+	}
+	pokemonItem := models.CollectionItem{
+		CardID:    "base1-4",
+		Card:      models.Card{ID: "base1-4", Name: "Charizard", SetName: "Base", SetCode: "base1", CardNumber: "4", Game: models.GamePokemon, PriceUSD: 300},
+		Quantity:  1,
+		Condition: models.ConditionNearMint,
+		Printing:  models.Printing1stEdition,
+	}
+
+	tests := []struct {
+		format      ExportFormat
+		wantRows    int
+		wantSkipped int
+		column      string
+		want        string // Value of column in the first data row
+	}{
+		{ExportFormatGeneric, 2, 0, "Total Value", "15.00"},
+		{ExportFormatTCGplayer, 2, 0, "Printing", "Foil"},
+		{ExportFormatCardmarket, 2, 0, "Language", "3"},
+		{ExportFormatMoxfield, 1, 1, "Edition", "m11"},
+		{ExportFormatDeckbox, 1, 1, "Condition", "Good (Lightly Played)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewCollectionCSVWriter(&buf, tt.format)
+			if err != nil {
+				t.Fatalf("NewCollectionCSVWriter() error = %v", err)
+			}
+			for _, item := range []models.CollectionItem{mtgItem, pokemonItem} {
+				if err := writer.Write(&item); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			reader := csv.NewReader(&buf)
+			reader.Comma = tt.format.Delimiter()
+			records, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("failed to parse output: %v", err)
+			}
+			if len(records)-1 != tt.wantRows {
+				t.Fatalf("got %d data rows, want %d", len(records)-1, tt.wantRows)
+			}
+			if writer.Skipped() != tt.wantSkipped {
+				t.Errorf("Skipped() = %d, want %d", writer.Skipped(), tt.wantSkipped)
+			}
+
+			col := -1
+			for i, name := range records[0] {
+				if name == tt.column {
+					col = i
+				}
+			}
+			if col < 0 {
+				t.Fatalf("column %q not in header %v", tt.column, records[0])
+			}
+			if got := records[1][col]; got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.column, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+const (
+	// maxImportCandidates caps how many candidate cards are stored for an ambiguous row
+	maxImportCandidates = 10
+
+	// scryfallImportRate keeps imports within Scryfall's request guidelines (10 req/s)
+	scryfallImportRate = 10
+)
+
+// importCardLookup is the set of card lookups the importer needs for one game
+type importCardLookup interface {
+	// GetCardByID returns the card with this ID, or nil if it does not exist
+	GetCardByID(id string) (*models.Card, error)
+	// GetCardBySetAndNumber returns the card at set code + collector number, or nil
+	GetCardBySetAndNumber(setCode, number string) (*models.Card, error)
+	// SearchByName returns printings of cards matching the name
+	SearchByName(name string) ([]models.Card, error)
+}
+
+// scryfallImportLookup adapts ScryfallService with a rate limit for long imports
+type scryfallImportLookup struct {
+	service *ScryfallService
+	limiter *rate.Limiter
+}
+
+func (l *scryfallImportLookup) wait() {
+	_ = l.limiter.Wait(context.Background()) // Background context never cancels
+}
+
+func (l *scryfallImportLookup) GetCardByID(id string) (*models.Card, error) {
+	l.wait()
+	return l.service.GetCard(id)
+}
+
+func (l *scryfallImportLookup) GetCardBySetAndNumber(setCode, number string) (*models.Card, error) {
+	l.wait()
+	return l.service.GetCardBySetAndNumber(setCode, number)
+}
+
+func (l *scryfallImportLookup) SearchByName(name string) ([]models.Card, error) {
+	l.wait()
+	result, err := l.service.SearchCardPrintings(name)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return result.Cards, nil
+}
+
+// pokemonImportLookup adapts PokemonHybridService (local data, no rate limit)
+type pokemonImportLookup struct {
+	service *PokemonHybridService
+}
+
+func (l *pokemonImportLookup) GetCardByID(id string) (*models.Card, error) {
+	return l.service.GetCard(id)
+}
+
+func (l *pokemonImportLookup) GetCardBySetAndNumber(setCode, number string) (*models.Card, error) {
+	return l.service.GetCardBySetAndNumber(setCode, number), nil
+}
+
+func (l *pokemonImportLookup) SearchByName(name string) ([]models.Card, error) {
+	result, err := l.service.SearchCards(name)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return result.Cards, nil
+}
+
+// CSVImportService stages collection files from other apps for review.
+// Each row is resolved to a card by set code + number first, then by name search,
+// and marked identified, ambiguous or failed before anything touches the collection.
+type CSVImportService struct {
+	db      *gorm.DB
+	lookups map[models.Game]importCardLookup
+}
+
+// NewCSVImportService creates a new CSV import service
+func NewCSVImportService(db *gorm.DB, pokemon *PokemonHybridService, scryfall *ScryfallService) *CSVImportService {
+	return &CSVImportService{db: db, lookups: newImportLookups(pokemon, scryfall)}
+}
+
+// newImportLookups returns the card lookups for the games whose service is available
+func newImportLookups(pokemon *PokemonHybridService, scryfall *ScryfallService) map[models.Game]importCardLookup {
+	lookups := make(map[models.Game]importCardLookup)
+	if pokemon != nil {
+		lookups[models.GamePokemon] = &pokemonImportLookup{service: pokemon}
+	}
+	if scryfall != nil {
+		lookups[models.GameMTG] = &scryfallImportLookup{
+			service: scryfall,
+			limiter: rate.NewLimiter(rate.Limit(scryfallImportRate), 1),
+		}
+	}
+	return lookups
+}
+
+// CreateJob stores parsed rows as pending items of a new import job
+func (s *CSVImportService) CreateJob(format ImportFormat, filename string, game models.Game, rows []ImportRow) (*models.CSVImportJob, error) {
+	job := &models.CSVImportJob{
+		ID:         uuid.New().String(),
+		Format:     string(format),
+		Filename:   filename,
+		Game:       game,
+		Status:     models.BulkImportStatusPending,
+		TotalItems: len(rows),
+	}
+
+	items := make([]models.CSVImportItem, len(rows))
+	for i, row := range rows {
+		items[i] = models.CSVImportItem{
+			JobID:         job.ID,
+			RowNumber:     row.RowNumber,
+			RawName:       row.Name,
+			RawSetCode:    row.SetCode,
+			RawSetName:    row.SetName,
+			RawNumber:     row.Number,
+			SourceCardID:  row.CardID,
+			Game:          string(row.Game),
+			Quantity:      row.Quantity,
+			Condition:     row.Condition,
+			PrintingType:  row.Printing,
+			Language:      row.Language,
+			PurchasePrice: row.PurchasePrice,
+			PurchaseDate:  row.PurchaseDate,
+			Notes:         row.Notes,
+			Status:        models.CSVImportItemPending,
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			return tx.CreateInBatches(items, 200).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ProcessJob resolves every pending item of a job. It blocks until done, so
+// callers run it in a goroutine. Identical rows are only looked up once.
+func (s *CSVImportService) ProcessJob(jobID string) {
+	var job models.CSVImportJob
+	if err := s.db.First(&job, "id = ?", jobID).Error; err != nil {
+		log.Printf("CSV import: job %s not found: %v", jobID, err)
+		return
+	}
+
+	s.db.Model(&job).Update("status", models.BulkImportStatusProcessing)
+
+	var items []models.CSVImportItem
+	if err := s.db.Where("job_id = ? AND status = ?", jobID, models.CSVImportItemPending).
+		Order("row_number ASC").Find(&items).Error; err != nil {
+		log.Printf("CSV import: failed to load items for job %s: %v", jobID, err)
+		s.db.Model(&job).Update("status", models.BulkImportStatusFailed)
+		return
+	}
+
+	cache := make(map[string]map[string]interface{})
+	counts := make(map[models.CSVImportItemStatus]int)
+	for i := range items {
+		item := &items[i]
+		key := importCacheKey(item)
+		updates, ok := cache[key]
+		if !ok {
+			updates = s.resolveItem(item, job.Game)
+			cache[key] = updates
+		}
+
+		if err := s.db.Model(item).Updates(updates).Error; err != nil {
+			log.Printf("CSV import: failed to update item %d: %v", item.ID, err)
+		}
+		counts[updates["status"].(models.CSVImportItemStatus)]++
+		s.db.Model(&job).UpdateColumn("processed_items", gorm.Expr("processed_items + 1"))
+	}
+
+	s.db.Model(&job).Update("status", models.BulkImportStatusCompleted)
+	log.Printf("CSV import: job %s completed (%d identified, %d ambiguous, %d failed)",
+		jobID, counts[models.CSVImportItemIdentified], counts[models.CSVImportItemAmbiguous], counts[models.CSVImportItemFailed])
+}
+
+// ResumePendingJobs finishes jobs that were interrupted by a restart
+func (s *CSVImportService) ResumePendingJobs() {
+	var jobs []models.CSVImportJob
+	if err := s.db.Where("status IN ?", []models.BulkImportJobStatus{
+		models.BulkImportStatusPending, models.BulkImportStatusProcessing,
+	}).Find(&jobs).Error; err != nil {
+		log.Printf("CSV import: failed to load pending jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		log.Printf("CSV import: resuming job %s", job.ID)
+		s.ProcessJob(job.ID)
+	}
+}
+
+// resolveItem matches an item to a card and returns the column updates to store
+func (s *CSVImportService) resolveItem(item *models.CSVImportItem, gameHint models.Game) map[string]interface{} {
+	method, candidates, needsReview, errMsg := s.resolveCard(item, gameHint)
+
+	updates := map[string]interface{}{
+		"match_method":  method,
+		"card_id":       "",
+		"card_name":     "",
+		"set_code":      "",
+		"set_name":      "",
+		"card_number":   "",
+		"candidates":    "",
+		"error_message": errMsg,
+	}
+
+	switch {
+	case len(candidates) == 0:
+		updates["status"] = models.CSVImportItemFailed
+		if errMsg == "" {
+			updates["error_message"] = "no matching card found"
+		}
+		return updates
+	case len(candidates) == 1 && !needsReview:
+		updates["status"] = models.CSVImportItemIdentified
+	default:
+		updates["status"] = models.CSVImportItemAmbiguous
+		if len(candidates) > maxImportCandidates {
+			candidates = candidates[:maxImportCandidates]
+		}
+		if data, err := json.Marshal(candidates); err == nil {
+			updates["candidates"] = string(data)
+		}
+	}
+
+	// The first candidate is the match (identified) or the suggestion (ambiguous)
+	best := candidates[0]
+	updates["card_id"] = best.ID
+	updates["card_name"] = best.Name
+	updates["set_code"] = best.SetCode
+	updates["set_name"] = best.SetName
+	updates["card_number"] = best.CardNumber
+	updates["game"] = string(best.Game)
+	return updates
+}
+
+// resolveCard finds candidate cards for an item across the games it could belong to.
+// Order of precedence: explicit card ID, set code + number, then name search
+// narrowed by whatever set and number information the row has. needsReview is set
+// when the only candidate is a card whose name does not match the row, or when the
+// row's set or number matched none of the cards with its name.
+func (s *CSVImportService) resolveCard(item *models.CSVImportItem, gameHint models.Game) (method string, candidates []models.Card, needsReview bool, errMsg string) {
+	games := []models.Game{models.GamePokemon, models.GameMTG}
+	if item.Game != "" {
+		games = []models.Game{models.Game(item.Game)}
+	} else if gameHint != "" {
+		games = []models.Game{gameHint}
+	}
+
+	var lastErr error
+
+	// 1. Card ID from the file (our own export or a Scryfall ID)
+	if item.SourceCardID != "" {
+		for _, game := range games {
+			lookup := s.lookups[game]
+			if lookup == nil {
+				continue
+			}
+			card, err := lookup.GetCardByID(item.SourceCardID)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if card != nil {
+				return "id", []models.Card{*card}, false, ""
+			}
+		}
+	}
+
+	// 2. Exact set code + collector number
+	var setMismatches []models.Card
+	if item.RawSetCode != "" && item.RawNumber != "" {
+		for _, game := range games {
+			lookup := s.lookups[game]
+			if lookup == nil {
+				continue
+			}
+			card, err := lookup.GetCardBySetAndNumber(item.RawSetCode, item.RawNumber)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if card == nil {
+				continue
+			}
+			if item.RawName == "" || importNamesMatch(item.RawName, card.Name) {
+				return "set_number", []models.Card{*card}, false, ""
+			}
+			// Same slot but a different name: keep it as a candidate for review
+			setMismatches = append(setMismatches, *card)
+		}
+	}
+
+	// 3. Name search, narrowed by set and number
+	if item.RawName != "" {
+		for _, game := range games {
+			lookup := s.lookups[game]
+			if lookup == nil {
+				continue
+			}
+			cards, err := lookup.SearchByName(item.RawName)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			narrowed, fellBack := narrowImportCandidates(item, cards)
+			candidates = append(candidates, narrowed...)
+			needsReview = needsReview || (fellBack && len(narrowed) > 0)
+		}
+	}
+	if len(candidates) == 0 && len(setMismatches) > 0 {
+		return "set_number", setMismatches, true, ""
+	}
+	candidates = append(candidates, setMismatches...)
+
+	if len(candidates) == 0 && lastErr != nil {
+		return "", nil, false, lastErr.Error()
+	}
+	if len(candidates) == 0 {
+		return "", nil, false, ""
+	}
+	return "name", candidates, needsReview, ""
+}
+
+// narrowImportCandidates keeps cards whose name matches, then narrows by set and
+// number. A filter that would remove every candidate is skipped, so a typo in the
+// set column still leaves the name matches; fellBack reports that this happened,
+// since the row then contradicts its matches and needs review.
+func narrowImportCandidates(item *models.CSVImportItem, cards []models.Card) (named []models.Card, fellBack bool) {
+	filter := func(cards []models.Card, keep func(c *models.Card) bool) []models.Card {
+		var out []models.Card
+		for i := range cards {
+			if keep(&cards[i]) {
+				out = append(out, cards[i])
+			}
+		}
+		if len(out) == 0 {
+			fellBack = true
+			return cards
+		}
+		return out
+	}
+
+	for _, card := range cards {
+		if importNamesMatch(item.RawName, card.Name) {
+			named = append(named, card)
+		}
+	}
+
+	if item.RawSetCode != "" || item.RawSetName != "" {
+		named = filter(named, func(c *models.Card) bool {
+			return (item.RawSetCode != "" && strings.EqualFold(c.SetCode, item.RawSetCode)) ||
+				(item.RawSetName != "" && strings.EqualFold(c.SetName, item.RawSetName))
+		})
+	}
+	if item.RawNumber != "" {
+		named = filter(named, func(c *models.Card) bool {
+			return normalizeImportNumber(c.CardNumber) == item.RawNumber ||
+				strings.TrimLeft(c.CardNumber, "0") == strings.TrimLeft(item.RawNumber, "0")
+		})
+	}
+	return named, fellBack
+}
+
+// importNamesMatch compares names ignoring case and punctuation. Double-faced
+// cards match on either the full name or the front face ("Delver of Secrets").
+func importNamesMatch(a, b string) bool {
+	na, nb := normalizeImportName(a), normalizeImportName(b)
+	if na == nb {
+		return true
+	}
+	frontA, _, _ := strings.Cut(a, "//")
+	frontB, _, _ := strings.Cut(b, "//")
+	return normalizeImportName(frontA) == normalizeImportName(frontB)
+}
+
+func normalizeImportName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, normalizeApostrophes(name))
+}
+
+// importCacheKey identifies rows that resolve to the same card within a job
+func importCacheKey(item *models.CSVImportItem) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%s", item.Game, item.SourceCardID, strings.ToLower(item.RawName),
+		strings.ToLower(item.RawSetCode), strings.ToLower(item.RawSetName), item.RawNumber)
+}
+
+// GetJob retrieves a job with all its items
+func (s *CSVImportService) GetJob(jobID string) (*models.CSVImportJob, error) {
+	var job models.CSVImportJob
+	err := s.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("row_number ASC")
+	}).First(&job, "id = ?", jobID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetItem retrieves a single import item
+func (s *CSVImportService) GetItem(itemID uint) (*models.CSVImportItem, error) {
+	var item models.CSVImportItem
+	if err := s.db.First(&item, itemID).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateItem updates specific fields of an import item
+func (s *CSVImportService) UpdateItem(itemID uint, updates map[string]interface{}) error {
+	return s.db.Model(&models.CSVImportItem{}).Where("id = ?", itemID).Updates(updates).Error
+}
+
+// DeleteJob deletes a job and its items
+func (s *CSVImportService) DeleteJob(jobID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", jobID).Delete(&models.CSVImportItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.CSVImportJob{}, "id = ?", jobID).Error
+	})
+}
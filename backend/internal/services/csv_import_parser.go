@@ -0,0 +1,535 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// ImportFormat identifies the layout of a collection file exported by another app
+type ImportFormat string
+
+const (
+	ImportFormatManaBox      ImportFormat = "manabox"
+	ImportFormatDragonShield ImportFormat = "dragonshield"
+	ImportFormatTCGplayer    ImportFormat = "tcgplayer"
+	ImportFormatDeckbox      ImportFormat = "deckbox"
+	ImportFormatGeneric      ImportFormat = "generic" // Our own generic export (round trip)
+	ImportFormatText         ImportFormat = "text"    // Plain list: "4 Lightning Bolt (M11) 149"
+)
+
+// AllImportFormats returns all supported import formats
+func AllImportFormats() []ImportFormat {
+	return []ImportFormat{
+		ImportFormatManaBox,
+		ImportFormatDragonShield,
+		ImportFormatTCGplayer,
+		ImportFormatDeckbox,
+		ImportFormatGeneric,
+		ImportFormatText,
+	}
+}
+
+// ParseImportFormat returns the import format for a request value (case-insensitive).
+// An empty value means the format should be detected from the file.
+func ParseImportFormat(value string) (ImportFormat, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "auto" {
+		return "", true
+	}
+	for _, f := range AllImportFormats() {
+		if string(f) == value {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// maxImportQuantity is the largest row quantity accepted, the collection's per-item limit
+const maxImportQuantity = 9999
+
+// ImportRow is a single collection entry parsed from an import file
+type ImportRow struct {
+	RowNumber     int
+	Name          string
+	SetCode       string
+	SetName       string
+	Number        string
+	CardID        string // Our card ID or Scryfall ID when the file has one
+	Game          models.Game
+	Quantity      int
+	Condition     models.Condition
+	Printing      models.PrintingType
+	Language      models.CardLanguage
+	PurchasePrice *float64
+	PurchaseDate  *time.Time
+	Notes         string
+}
+
+// ParseImportFile parses an exported collection file. If format is empty it is
+// detected from the CSV header, falling back to the plain text list format.
+// Rows that cannot be parsed are skipped and reported as errors.
+func ParseImportFile(r io.Reader, format ImportFormat) (ImportFormat, []ImportRow, []string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel BOM
+
+	if format == ImportFormatText {
+		rows, errs := parseTextList(data)
+		return format, rows, errs, nil
+	}
+
+	records, headerLine, err := readImportCSV(data)
+	if err != nil || len(records) == 0 {
+		if format != "" {
+			if err == nil {
+				err = fmt.Errorf("file is empty")
+			}
+			return "", nil, nil, err
+		}
+		// Not a CSV we understand; try the plain text list format
+		rows, errs := parseTextList(data)
+		return ImportFormatText, rows, errs, nil
+	}
+
+	cols := newImportColumns(records[0])
+	if format == "" {
+		format = detectImportFormat(cols)
+		if format == "" {
+			rows, errs := parseTextList(data)
+			if len(rows) == 0 {
+				return "", nil, nil, fmt.Errorf("unrecognized file format")
+			}
+			return ImportFormatText, rows, errs, nil
+		}
+	}
+
+	var rows []ImportRow
+	var errs []string
+	for i, record := range records[1:] {
+		lineNumber := headerLine + i + 1
+		if isBlankRecord(record) {
+			continue
+		}
+		row, err := parseImportRecord(format, cols, record)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %v", lineNumber, err))
+			continue
+		}
+		row.RowNumber = lineNumber
+		rows = append(rows, row)
+	}
+
+	return format, rows, errs, nil
+}
+
+// readImportCSV reads all records, skipping Excel's "sep=," hint line that
+// Dragon Shield puts before the header. Returns the 1-based header line number.
+func readImportCSV(data []byte) ([][]string, int, error) {
+	headerLine := 1
+	if firstLine, rest, found := bytes.Cut(data, []byte("\n")); found {
+		trimmed := strings.Trim(strings.TrimSpace(string(firstLine)), `"`)
+		if strings.HasPrefix(strings.ToLower(trimmed), "sep=") {
+			data = rest
+			headerLine = 2
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	// Cardmarket-style files use semicolons; pick whichever separator the header uses more
+	if header, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(records) > 0 && len(records[0]) < 2 {
+		return nil, 0, fmt.Errorf("not a CSV file")
+	}
+	return records, headerLine, nil
+}
+
+// importColumns maps normalized header names to column indexes
+type importColumns map[string]int
+
+func newImportColumns(header []string) importColumns {
+	cols := make(importColumns, len(header))
+	for i, name := range header {
+		key := normalizeHeader(name)
+		if _, exists := cols[key]; !exists {
+			cols[key] = i
+		}
+	}
+	return cols
+}
+
+func (c importColumns) has(names ...string) bool {
+	for _, name := range names {
+		if _, ok := c[normalizeHeader(name)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// get returns the first non-empty value among the named columns
+func (c importColumns) get(record []string, names ...string) string {
+	for _, name := range names {
+		if i, ok := c[normalizeHeader(name)]; ok && i < len(record) {
+			if v := strings.TrimSpace(record[i]); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// detectImportFormat recognizes a format from its distinctive header columns
+func detectImportFormat(cols importColumns) ImportFormat {
+	switch {
+	case cols.has("manabox id") || cols.has("scryfall id", "collector number"):
+		return ImportFormatManaBox
+	case cols.has("folder name", "card name"):
+		return ImportFormatDragonShield
+	case cols.has("card id", "game"):
+		return ImportFormatGeneric
+	case cols.has("simple name") || cols.has("product id", "printing"):
+		return ImportFormatTCGplayer
+	case cols.has("tradelist count", "edition"):
+		return ImportFormatDeckbox
+	default:
+		return ""
+	}
+}
+
+// parseImportRecord converts a CSV record to an ImportRow using the format's columns
+func parseImportRecord(format ImportFormat, cols importColumns, record []string) (ImportRow, error) {
+	var row ImportRow
+	var quantity, condition, printing, language, price, date string
+
+	switch format {
+	case ImportFormatManaBox:
+		row.Name = cols.get(record, "name")
+		row.SetCode = cols.get(record, "set code")
+		row.SetName = cols.get(record, "set name")
+		row.Number = cols.get(record, "collector number")
+		row.CardID = cols.get(record, "scryfall id")
+		row.Game = models.GameMTG
+		quantity = cols.get(record, "quantity")
+		condition = cols.get(record, "condition")
+		printing = cols.get(record, "foil")
+		language = cols.get(record, "language")
+		price = cols.get(record, "purchase price")
+	case ImportFormatDragonShield:
+		row.Name = cols.get(record, "card name")
+		row.SetCode = cols.get(record, "set code")
+		row.SetName = cols.get(record, "set name")
+		row.Number = cols.get(record, "card number")
+		row.Game = models.GameMTG
+		quantity = cols.get(record, "quantity")
+		condition = cols.get(record, "condition")
+		printing = cols.get(record, "printing")
+		language = cols.get(record, "language")
+		price = cols.get(record, "price bought")
+		date = cols.get(record, "date bought")
+		row.Notes = cols.get(record, "folder name")
+	case ImportFormatTCGplayer:
+		row.Name = cols.get(record, "simple name", "name", "product name")
+		row.SetCode = cols.get(record, "set code")
+		row.SetName = cols.get(record, "set", "set name")
+		row.Number = cols.get(record, "card number", "number")
+		quantity = cols.get(record, "quantity", "add to quantity", "total quantity")
+		condition = cols.get(record, "condition")
+		printing = cols.get(record, "printing")
+		language = cols.get(record, "language")
+		// Mass entry condition strings carry the printing: "Near Mint Holofoil"
+		if cond, suffix := splitTCGplayerCondition(condition); suffix != "" {
+			condition = cond
+			if printing == "" {
+				printing = suffix
+			}
+		}
+	case ImportFormatDeckbox:
+		row.Name = cols.get(record, "name")
+		row.SetName = cols.get(record, "edition")
+		row.Number = cols.get(record, "card number")
+		row.Game = models.GameMTG
+		quantity = cols.get(record, "count")
+		condition = cols.get(record, "condition")
+		printing = cols.get(record, "foil")
+		language = cols.get(record, "language")
+		price = cols.get(record, "my price")
+	case ImportFormatGeneric:
+		row.CardID = cols.get(record, "card id")
+		row.Name = cols.get(record, "name")
+		row.SetCode = cols.get(record, "set code")
+		row.SetName = cols.get(record, "set")
+		row.Number = cols.get(record, "card number")
+		row.Game = models.Game(strings.ToLower(cols.get(record, "game")))
+		quantity = cols.get(record, "quantity")
+		condition = cols.get(record, "condition")
+		printing = cols.get(record, "printing")
+		language = cols.get(record, "language")
+		price = cols.get(record, "purchase price")
+		date = cols.get(record, "purchase date")
+		row.Notes = cols.get(record, "notes")
+	default:
+		return row, fmt.Errorf("unsupported format %q", format)
+	}
+
+	if row.Name == "" && row.CardID == "" {
+		return row, fmt.Errorf("missing card name")
+	}
+
+	row.Number = normalizeImportNumber(row.Number)
+	row.Condition = ParseImportCondition(format, condition)
+	row.Printing = ParseImportPrinting(printing)
+	row.Language = models.NormalizeLanguage(language)
+
+	row.Quantity = 1
+	if quantity != "" {
+		qty, err := strconv.Atoi(quantity)
+		if err != nil || qty < 0 {
+			return row, fmt.Errorf("invalid quantity %q", quantity)
+		}
+		if qty == 0 {
+			return row, fmt.Errorf("quantity is zero")
+		}
+		if qty > maxImportQuantity {
+			return row, fmt.Errorf("quantity %d exceeds maximum allowed (%d)", qty, maxImportQuantity)
+		}
+		row.Quantity = qty
+	}
+
+	if price != "" {
+		if p, ok := parseImportPrice(price); ok {
+			row.PurchasePrice = &p
+		}
+	}
+	if date != "" {
+		if d, ok := parseImportDate(date); ok {
+			row.PurchaseDate = &d
+		}
+	}
+
+	return row, nil
+}
+
+// textListLine matches "4 Lightning Bolt", "4x Lightning Bolt (M11) 149" and
+// "1 Charizard (BASE1) 4 *F*". Quantity, set and number are optional.
+var textListLine = regexp.MustCompile(`^(?:(\d+)x?\s+)?(.+?)(?:\s+\(([A-Za-z0-9]+)\)(?:\s+([A-Za-z0-9★/-]+))?)?(\s+\*F\*)?$`)
+
+// parseTextList parses a plain card list, one card per line. Blank lines,
+// comments and section headers ("Deck", "Sideboard:") are ignored.
+func parseTextList(data []byte) ([]ImportRow, []string) {
+	var rows []ImportRow
+	var errs []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") || isTextSectionHeader(line) {
+			continue
+		}
+
+		m := textListLine.FindStringSubmatch(line)
+		if m == nil || strings.TrimSpace(m[2]) == "" {
+			errs = append(errs, fmt.Sprintf("line %d: could not parse %q", lineNumber, line))
+			continue
+		}
+
+		row := ImportRow{
+			RowNumber: lineNumber,
+			Name:      strings.TrimSpace(m[2]),
+			SetCode:   m[3],
+			Number:    normalizeImportNumber(m[4]),
+			Quantity:  1,
+			Condition: models.ConditionNearMint,
+			Printing:  models.PrintingNormal,
+			Language:  models.LanguageEnglish,
+		}
+		if m[1] != "" {
+			qty, err := strconv.Atoi(m[1])
+			if err != nil || qty <= 0 {
+				errs = append(errs, fmt.Sprintf("line %d: invalid quantity %q", lineNumber, m[1]))
+				continue
+			}
+			if qty > maxImportQuantity {
+				errs = append(errs, fmt.Sprintf("line %d: quantity %d exceeds maximum allowed (%d)", lineNumber, qty, maxImportQuantity))
+				continue
+			}
+			row.Quantity = qty
+		}
+		if m[5] != "" {
+			row.Printing = models.PrintingFoil
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, errs
+}
+
+func isTextSectionHeader(line string) bool {
+	switch strings.ToLower(strings.TrimSuffix(line, ":")) {
+	case "deck", "sideboard", "commander", "companion", "maybeboard", "pokemon", "pokémon", "trainer", "energy":
+		return true
+	}
+	return false
+}
+
+// ParseImportCondition maps a condition string from another app to the app's
+// collection condition. Most apps use either the Cardmarket scale (MT..PO) or the
+// TCGplayer scale (NM..DMG); both are accepted. Deckbox's "Played" sits between
+// lightly and heavily played, so it maps to Good rather than Played.
+func ParseImportCondition(format ImportFormat, value string) models.Condition {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, value)
+
+	switch key {
+	case "m", "mt", "mint":
+		return models.ConditionMint
+	case "ex", "excellent":
+		return models.ConditionExcellent
+	case "gd", "good", "mp", "moderatelyplayed":
+		return models.ConditionGood
+	case "lp", "lightlyplayed", "lightplayed", "goodlightlyplayed", "sp", "slightlyplayed":
+		return models.ConditionLightPlay
+	case "pl", "played":
+		if format == ImportFormatDeckbox {
+			return models.ConditionGood
+		}
+		return models.ConditionPlayed
+	case "hp", "heavilyplayed":
+		return models.ConditionPlayed
+	case "po", "pr", "poor", "d", "dmg", "damaged":
+		return models.ConditionPoor
+	default:
+		return models.ConditionNearMint
+	}
+}
+
+// ParseImportPrinting maps a printing or foil column value to a printing type.
+// Foil columns use values like "foil", "etched", "true" or "normal".
+func ParseImportPrinting(value string) models.PrintingType {
+	v := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case v == "" || v == "normal" || v == "nonfoil" || v == "non-foil" || v == "false" || v == "no" || v == "0":
+		return models.PrintingNormal
+	case strings.Contains(v, "reverse"):
+		return models.PrintingReverseHolo
+	case strings.Contains(v, "1st") || strings.Contains(v, "first"):
+		return models.Printing1stEdition
+	case strings.Contains(v, "unlimited"):
+		return models.PrintingUnlimited
+	case strings.Contains(v, "foil") || strings.Contains(v, "holo") || v == "etched" || v == "true" || v == "yes" || v == "x" || v == "1":
+		return models.PrintingFoil
+	default:
+		return models.PrintingNormal
+	}
+}
+
+// tcgplayerPrintingSuffixes are printings TCGplayer appends to condition names,
+// longest first so "Reverse Holofoil" wins over "Holofoil"
+var tcgplayerPrintingSuffixes = []string{
+	"1st edition holofoil",
+	"unlimited holofoil",
+	"reverse holofoil",
+	"1st edition",
+	"holofoil",
+	"unlimited",
+	"foil",
+}
+
+// splitTCGplayerCondition splits "Near Mint Holofoil" into ("Near Mint", "Holofoil")
+func splitTCGplayerCondition(value string) (string, string) {
+	lower := strings.ToLower(value)
+	for _, suffix := range tcgplayerPrintingSuffixes {
+		if strings.HasSuffix(lower, " "+suffix) {
+			cut := len(value) - len(suffix)
+			return strings.TrimSpace(value[:cut]), strings.TrimSpace(value[cut:])
+		}
+	}
+	return value, ""
+}
+
+// normalizeImportNumber strips set totals and prefixes: "4/102" -> "4", "#149" -> "149"
+func normalizeImportNumber(number string) string {
+	number = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(number), "#"))
+	if before, _, found := strings.Cut(number, "/"); found && before != "" {
+		return before
+	}
+	return number
+}
+
+func parseImportPrice(value string) (float64, bool) {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, value)
+	// Treat a lone comma as a decimal separator ("1,50"), otherwise as thousands ("1,234.50")
+	if strings.Count(cleaned, ",") == 1 && !strings.Contains(cleaned, ".") {
+		cleaned = strings.Replace(cleaned, ",", ".", 1)
+	} else {
+		cleaned = strings.ReplaceAll(cleaned, ",", "")
+	}
+	price, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || price < 0 {
+		return 0, false
+	}
+	return price, true
+}
+
+var importDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"01/02/2006",
+	"1/2/2006",
+}
+
+func parseImportDate(value string) (time.Time, bool) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestParseImportFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		format     ImportFormat // Requested format, empty to detect
+		wantFormat ImportFormat
+		want       ImportRow // Expected first row (RowNumber included)
+		wantRows   int
+	}{
+		{
+			name: "manabox",
+			input: "Name,Set code,Set name,Collector number,Foil,Rarity,Quantity,ManaBox ID,Scryfall ID,Purchase price,Misprint,Altered,Condition,Language,Purchase price currency\n" +
+				"Lightning Bolt,m11,Magic 2011,149,foil,common,3,123,abc-123,0.85,false,false,lightly_played,de,USD\n",
+			wantFormat: ImportFormatManaBox,
+			want: ImportRow{RowNumber: 2, Name: "Lightning Bolt", SetCode: "m11", SetName: "Magic 2011", Number: "149", CardID: "abc-123", Game: models.GameMTG,
+				Quantity: 3, Condition: models.ConditionLightPlay, Printing: models.PrintingFoil, Language: models.LanguageGerman},
+			wantRows: 1,
+		},
+		{
+			name: "dragon shield with sep line",
+			input: "\"sep=,\"\n" +
+				"Folder Name,Quantity,Trade Quantity,Card Name,Set Code,Set Name,Card Number,Condition,Printing,Language,Price Bought,Date Bought,LOW,MID,MARKET\n" +
+				"Binder,2,0,Counterspell,7ED,Seventh Edition,67,NearMint,Normal,English,1.50,2025-04-01,1,1,1\n" +
+				",,,,,,,,,,,,,,\n",
+			wantFormat: ImportFormatDragonShield,
+			want: ImportRow{RowNumber: 3, Name: "Counterspell", SetCode: "7ED", SetName: "Seventh Edition", Number: "67", Game: models.GameMTG,
+				Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, Notes: "Binder"},
+			wantRows: 1,
+		},
+		{
+			name: "tcgplayer with printing in condition",
+			input: "Quantity,Name,Simple Name,Set,Card Number,Set Code,Condition,Language,Rarity,Product ID\n" +
+				"1,Charizard - 4/102,Charizard,Base Set,4/102,BS,Lightly Played Holofoil,English,Holo Rare,42382\n",
+			wantFormat: ImportFormatTCGplayer,
+			want: ImportRow{RowNumber: 2, Name: "Charizard", SetCode: "BS", SetName: "Base Set", Number: "4",
+				Quantity: 1, Condition: models.ConditionLightPlay, Printing: models.PrintingFoil, Language: models.LanguageEnglish},
+			wantRows: 1,
+		},
+		{
+			name: "deckbox",
+			input: "Count,Tradelist Count,Name,Edition,Card Number,Condition,Language,Foil,Signed,Artist Proof,Altered Art,Misprint,Promo,Textless,My Price\n" +
+				"4,0,Llanowar Elves,Dominaria,168,Played,English,,,,,,,,$0.25\n",
+			wantFormat: ImportFormatDeckbox,
+			want: ImportRow{RowNumber: 2, Name: "Llanowar Elves", SetName: "Dominaria", Number: "168", Game: models.GameMTG,
+				Quantity: 4, Condition: models.ConditionGood, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+			wantRows: 1,
+		},
+		{
+			name:       "text list",
+			input:      "Deck\n4 Lightning Bolt (M11) 149\n\n// comment\n1x Charizard (BASE1) 4/102 *F*\nCounterspell\n",
+			wantFormat: ImportFormatText,
+			want: ImportRow{RowNumber: 2, Name: "Lightning Bolt", SetCode: "M11", Number: "149",
+				Quantity: 4, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+			wantRows: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, rows, errs, err := ParseImportFile(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("ParseImportFile() error = %v", err)
+			}
+			if len(errs) > 0 {
+				t.Errorf("unexpected row errors: %v", errs)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if len(rows) != tt.wantRows {
+				t.Fatalf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+
+			got := rows[0]
+			// Purchase price/date are compared separately
+			got.PurchasePrice, got.PurchaseDate = nil, nil
+			if got != tt.want {
+				t.Errorf("row = %+v\nwant  %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportFileRowErrors(t *testing.T) {
+	input := "Count,Tradelist Count,Name,Edition,Card Number,Condition,Language,Foil\n" +
+		"x,0,Shock,Dominaria,1,Near Mint,English,\n" +
+		"1,0,,Dominaria,1,Near Mint,English,\n" +
+		"2,0,Shock,Dominaria,1,Near Mint,English,foil\n" +
+		"10000,0,Shock,Dominaria,1,Near Mint,English,\n"
+
+	_, rows, errs, err := ParseImportFile(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatalf("ParseImportFile() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Printing != models.PrintingFoil {
+		t.Errorf("expected the single valid foil row, got %+v", rows)
+	}
+	if len(errs) != 3 || !strings.HasPrefix(errs[0], "line 2:") || !strings.HasPrefix(errs[1], "line 3:") || !strings.HasPrefix(errs[2], "line 5:") {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	// Text lists are capped the same way
+	_, rows, errs, _ = ParseImportFile(strings.NewReader("10000 Shock\n4 Opt\n"), ImportFormatText)
+	if len(rows) != 1 || len(errs) != 1 {
+		t.Errorf("expected the oversized line to be rejected, got %+v, %v", rows, errs)
+	}
+}
+
+func TestParseImportCondition(t *testing.T) {
+	tests := []struct {
+		format   ImportFormat
+		value    string
+		expected models.Condition
+	}{
+		{ImportFormatManaBox, "near_mint", models.ConditionNearMint},
+		{ImportFormatManaBox, "moderately_played", models.ConditionGood},
+		{ImportFormatManaBox, "damaged", models.ConditionPoor},
+		{ImportFormatDragonShield, "Excellent", models.ConditionExcellent},
+		{ImportFormatDragonShield, "Played", models.ConditionPlayed},
+		{ImportFormatDeckbox, "Played", models.ConditionGood},
+		{ImportFormatDeckbox, "Good (Lightly Played)", models.ConditionLightPlay},
+		{ImportFormatTCGplayer, "Heavily Played", models.ConditionPlayed},
+		{ImportFormatGeneric, "MT", models.ConditionMint},
+		{ImportFormatGeneric, "", models.ConditionNearMint},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format)+"/"+tt.value, func(t *testing.T) {
+			if got := ParseImportCondition(tt.format, tt.value); got != tt.expected {
+				t.Errorf("ParseImportCondition() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseImportPrinting(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected models.PrintingType
+	}{
+		{"", models.PrintingNormal},
+		{"normal", models.PrintingNormal},
+		{"foil", models.PrintingFoil},
+		{"etched", models.PrintingFoil},
+		{"Holofoil", models.PrintingFoil},
+		{"Reverse Holofoil", models.PrintingReverseHolo},
+		{"1st Edition Holofoil", models.Printing1stEdition},
+		{"Unlimited", models.PrintingUnlimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := ParseImportPrinting(tt.value); got != tt.expected {
+				t.Errorf("ParseImportPrinting(%q) = %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+// fakeImportLookup serves cards from memory for resolver tests
+type fakeImportLookup struct {
+	cards []models.Card
+}
+
+func (f *fakeImportLookup) GetCardByID(id string) (*models.Card, error) {
+	for i := range f.cards {
+		if f.cards[i].ID == id {
+			return &f.cards[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeImportLookup) GetCardBySetAndNumber(setCode, number string) (*models.Card, error) {
+	for i := range f.cards {
+		if strings.EqualFold(f.cards[i].SetCode, setCode) && f.cards[i].CardNumber == number {
+			return &f.cards[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeImportLookup) SearchByName(name string) ([]models.Card, error) {
+	var out []models.Card
+	for _, c := range f.cards {
+		if strings.Contains(strings.ToLower(c.Name), strings.ToLower(name)) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func TestCSVImportProcessJob(t *testing.T) {
+	db := newTestDB(t, &models.CSVImportJob{}, &models.CSVImportItem{})
+
+	mtg := &fakeImportLookup{cards: []models.Card{
+		{ID: "bolt-m11", Name: "Lightning Bolt", SetCode: "m11", SetName: "Magic 2011", CardNumber: "149", Game: models.GameMTG},
+		{ID: "bolt-a25", Name: "Lightning Bolt", SetCode: "a25", SetName: "Masters 25", CardNumber: "141", Game: models.GameMTG},
+		{ID: "elves-dom", Name: "Llanowar Elves", SetCode: "dom", SetName: "Dominaria", CardNumber: "168", Game: models.GameMTG},
+	}}
+	pokemon := &fakeImportLookup{cards: []models.Card{
+		{ID: "base1-4", Name: "Charizard", SetCode: "base1", SetName: "Base", CardNumber: "4", Game: models.GamePokemon},
+	}}
+	svc := &CSVImportService{db: db, lookups: map[models.Game]importCardLookup{
+		models.GameMTG:     mtg,
+		models.GamePokemon: pokemon,
+	}}
+
+	rows := []ImportRow{
+		{RowNumber: 1, Name: "Lightning Bolt", SetCode: "M11", Number: "149", Quantity: 1},                     // set + number
+		{RowNumber: 2, Name: "Lightning Bolt", Quantity: 2},                                                    // two printings
+		{RowNumber: 3, Name: "Llanowar Elves", SetName: "Dominaria", Game: models.GameMTG, Quantity: 4},        // name + set name
+		{RowNumber: 4, Name: "Charizard", SetCode: "BS", Number: "4", Quantity: 1},                             // unknown set code, name match
+		{RowNumber: 5, Name: "Black Lotus", Quantity: 1},                                                       // not found
+		{RowNumber: 6, CardID: "bolt-a25", Quantity: 1},                                                        // explicit ID
+		{RowNumber: 7, Name: "Lightning Bolt", SetCode: "m11", Number: "149", Quantity: 3},                     // cached lookup
+		{RowNumber: 8, Name: "Counterspell", SetCode: "m11", Number: "149", Game: models.GameMTG, Quantity: 1}, // slot holds another card
+		{RowNumber: 9, Name: "Llanowar Elves", SetCode: "dom", Number: "999", Quantity: 1},                     // number contradicts the only match
+	}
+
+	job, err := svc.CreateJob(ImportFormatText, "cards.txt", "", rows)
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	svc.ProcessJob(job.ID)
+
+	got, err := svc.GetJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if got.Status != models.BulkImportStatusCompleted || got.ProcessedItems != len(rows) {
+		t.Errorf("job status = %s, processed %d, want completed/%d", got.Status, got.ProcessedItems, len(rows))
+	}
+
+	tests := []struct {
+		status models.CSVImportItemStatus
+		method string
+		cardID string
+	}{
+		{models.CSVImportItemIdentified, "set_number", "bolt-m11"},
+		{models.CSVImportItemAmbiguous, "name", "bolt-m11"},
+		{models.CSVImportItemIdentified, "name", "elves-dom"},
+		{models.CSVImportItemAmbiguous, "name", "base1-4"}, // set column matched nothing
+		{models.CSVImportItemFailed, "", ""},
+		{models.CSVImportItemIdentified, "id", "bolt-a25"},
+		{models.CSVImportItemIdentified, "set_number", "bolt-m11"},
+		{models.CSVImportItemAmbiguous, "set_number", "bolt-m11"},
+		{models.CSVImportItemAmbiguous, "name", "elves-dom"},
+	}
+
+	for i, tt := range tests {
+		item := got.Items[i]
+		if item.Status != tt.status || item.MatchMethod != tt.method || item.CardID != tt.cardID {
+			t.Errorf("row %d: got status=%s method=%q card=%q, want %s/%q/%q",
+				item.RowNumber, item.Status, item.MatchMethod, item.CardID, tt.status, tt.method, tt.cardID)
+		}
+	}
+	if got.Items[1].Candidates == "" {
+		t.Error("expected candidates to be stored for the ambiguous row")
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// DeckService resolves decklist cards and compares decks with the collection
+type DeckService struct {
+	lookups map[models.Game]importCardLookup
+}
+
+// NewDeckService creates a deck service. Either card service may be nil, in which
+// case that game's decklists are only matched against cached cards.
+func NewDeckService(pokemon *PokemonHybridService, scryfall *ScryfallService) *DeckService {
+	return &DeckService{lookups: newImportLookups(pokemon, scryfall)}
+}
+
+// ResolveCards sets the card ID of every decklist card without one: a cached card
+// with the same name if there is one, otherwise a lookup by set code + number, then
+// by name. Looked up cards are cached so the price worker keeps their prices current.
+// Cards that cannot be found, or whose set code or number matches none of the
+// printings with their name, keep an empty card ID and are matched by name only.
+func (s *DeckService) ResolveCards(db *gorm.DB, game models.Game, cards []models.DeckCard) {
+	resolved := make(map[string]string)
+	for i := range cards {
+		card := &cards[i]
+		if card.CardID != "" {
+			continue
+		}
+		key := strings.Join([]string{normalizeImportName(card.CardName), strings.ToLower(card.SetCode), card.CollectorNumber}, "\x00")
+		id, ok := resolved[key]
+		if !ok {
+			id = s.resolveCard(db, game, card)
+			resolved[key] = id
+		}
+		card.CardID = id
+	}
+}
+
+func (s *DeckService) resolveCard(db *gorm.DB, game models.Game, deckCard *models.DeckCard) string {
+	item := &models.CSVImportItem{
+		RawName:    deckCard.CardName,
+		RawSetCode: deckCard.SetCode,
+		RawNumber:  deckCard.CollectorNumber,
+	}
+
+	var cached []models.Card
+	db.Where("game = ? AND LOWER(name) = ?", game, strings.ToLower(deckCard.CardName)).Find(&cached)
+	if matches, fellBack := narrowImportCandidates(item, cached); len(matches) > 0 && !fellBack {
+		return matches[0].ID
+	}
+
+	lookup := s.lookups[game]
+	if lookup == nil {
+		return ""
+	}
+	var found *models.Card
+	if deckCard.SetCode != "" && deckCard.CollectorNumber != "" {
+		card, err := lookup.GetCardBySetAndNumber(deckCard.SetCode, deckCard.CollectorNumber)
+		if err == nil && card != nil && importNamesMatch(deckCard.CardName, card.Name) {
+			found = card
+		}
+	}
+	if found == nil {
+		cards, err := lookup.SearchByName(deckCard.CardName)
+		if err != nil {
+			log.Printf("Decks: failed to look up %q: %v", deckCard.CardName, err)
+		}
+		// A printing that contradicts the decklist's set or number is not a match
+		if matches, fellBack := narrowImportCandidates(item, cards); len(matches) > 0 && !fellBack {
+			found = &matches[0]
+		}
+	}
+	if found == nil {
+		return ""
+	}
+	if err := db.Save(found).Error; err != nil {
+		log.Printf("Warning: failed to cache card %s: %v", found.ID, err)
+	}
+	return found.ID
+}
+
+// OwnedDeckCard is the number of copies of a card in the collection
+type OwnedDeckCard struct {
+	CardID     string
+	Name       string
+	SetCode    string
+	CardNumber string
+	Quantity   int
+}
+
+// DeckOwnership compares a deck (with its cards loaded) with the collection
+func DeckOwnership(db *gorm.DB, deck *models.Deck) (models.DeckOwnership, error) {
+	var owned []OwnedDeckCard
+	err := db.Table("collection_items").
+		Select("cards.id as card_id, cards.name, cards.set_code, cards.card_number, SUM(collection_items.quantity) as quantity").
+		Joins("JOIN cards ON cards.id = collection_items.card_id").
+		Where("cards.game = ?", deck.Game).
+		Group("cards.id, cards.name, cards.set_code, cards.card_number").
+		Scan(&owned).Error
+	if err != nil {
+		return models.DeckOwnership{}, err
+	}
+
+	names := make([]string, 0, len(deck.Cards))
+	ids := make([]string, 0, len(deck.Cards))
+	for _, card := range deck.Cards {
+		names = append(names, strings.ToLower(card.CardName))
+		if card.CardID != "" {
+			ids = append(ids, card.CardID)
+		}
+	}
+	var cards []models.Card
+	if len(names) > 0 {
+		err = db.Preload("Prices").
+			Where("game = ? AND (LOWER(name) IN ? OR id IN ?)", deck.Game, names, ids).
+			Find(&cards).Error
+		if err != nil {
+			return models.DeckOwnership{}, err
+		}
+	}
+
+	return BuildDeckOwnership(deck, owned, cards), nil
+}
+
+// BuildDeckOwnership matches a deck's cards with the owned copies (see deckLineKey),
+// so for MTG any printing, condition or language counts. Copies of a card are counted
+// toward its lines in section order (commander, companion, main, sideboard), so a card
+// in both the main deck and the sideboard is only owned once. Missing MTG cards are
+// priced at the cheapest NM English price among the cached cards with their name;
+// Pokemon cards at the price of the card the line matches.
+func BuildDeckOwnership(deck *models.Deck, owned []OwnedDeckCard, cards []models.Card) models.DeckOwnership {
+	resp := models.DeckOwnership{
+		DeckID:  deck.ID,
+		Name:    deck.Name,
+		Game:    deck.Game,
+		Cards:   []models.DeckCardOwnership{},
+		Missing: []models.DeckCardOwnership{},
+	}
+
+	available := make(map[string]int)
+	for _, o := range owned {
+		for _, key := range ownedDeckKeys(deck.Game, o) {
+			available[key] += o.Quantity
+		}
+	}
+
+	byID := make(map[string]*models.Card, len(cards))
+	byName := make(map[string][]*models.Card)
+	for i := range cards {
+		byID[cards[i].ID] = &cards[i]
+		key := deckNameKey(cards[i].Name)
+		byName[key] = append(byName[key], &cards[i])
+	}
+
+	sectionOrder := make(map[models.DeckSection]int)
+	for i, s := range models.AllDeckSections() {
+		sectionOrder[s] = i
+	}
+	lines := append([]models.DeckCard(nil), deck.Cards...)
+	sort.SliceStable(lines, func(i, j int) bool {
+		return sectionOrder[lines[i].Section] < sectionOrder[lines[j].Section]
+	})
+
+	for _, line := range lines {
+		key := deckLineKey(deck.Game, line)
+		result := models.DeckCardOwnership{DeckCard: line}
+		result.Owned = min(line.Quantity, available[key])
+		result.Missing = line.Quantity - result.Owned
+		available[key] -= result.Owned
+
+		candidates := byName[deckNameKey(line.CardName)]
+		if deck.Game == models.GamePokemon {
+			candidates = pokemonDeckCandidates(line, candidates, byID)
+		} else if card, ok := byID[line.CardID]; ok && !containsCard(candidates, card) {
+			candidates = append(candidates, card)
+		}
+		for _, card := range candidates {
+			price, printing := cheapestNMPrice(card)
+			if price > 0 && (result.PriceUSD == 0 || price < result.PriceUSD) {
+				result.PriceUSD = price
+				result.PriceCardID = card.ID
+				result.PricePrinting = printing
+				result.Card = card
+			}
+		}
+		if result.Card == nil {
+			result.Card = byID[line.CardID]
+		}
+		if result.Card != nil {
+			result.Card = withoutPrices(result.Card)
+		}
+
+		resp.TotalCards += line.Quantity
+		resp.OwnedCards += result.Owned
+		resp.MissingCards += result.Missing
+		resp.Cards = append(resp.Cards, result)
+		if result.Missing == 0 {
+			continue
+		}
+		if result.PriceUSD > 0 {
+			resp.CostToComplete += result.PriceUSD * float64(result.Missing)
+		} else {
+			resp.UnpricedMissing += result.Missing
+		}
+		resp.Missing = append(resp.Missing, result)
+	}
+
+	resp.Complete = resp.MissingCards == 0
+	return resp
+}
+
+// deckLineKey is the key a deck line shares with the owned copies that count toward
+// it. MTG cards match on the name, so any printing counts. Pokemon cards with the same
+// name can be different cards altogether, so they match on the resolved card, or the
+// decklist's set and number while unresolved, and only on the name without either.
+func deckLineKey(game models.Game, line models.DeckCard) string {
+	if game == models.GamePokemon {
+		switch {
+		case line.CardID != "":
+			return "id:" + line.CardID
+		case line.SetCode != "" && line.CollectorNumber != "":
+			return deckNumberKey(line.SetCode, line.CollectorNumber)
+		}
+	}
+	return "name:" + deckNameKey(line.CardName)
+}
+
+// ownedDeckKeys returns every key (see deckLineKey) an owned card counts toward
+func ownedDeckKeys(game models.Game, o OwnedDeckCard) []string {
+	keys := []string{"name:" + deckNameKey(o.Name)}
+	if game == models.GamePokemon {
+		keys = append(keys, "id:"+o.CardID)
+		if o.SetCode != "" && o.CardNumber != "" {
+			keys = append(keys, deckNumberKey(o.SetCode, o.CardNumber))
+		}
+	}
+	return keys
+}
+
+func deckNumberKey(setCode, number string) string {
+	return "number:" + strings.ToLower(setCode) + "/" + strings.TrimLeft(number, "0")
+}
+
+// pokemonDeckCandidates returns the cards a Pokemon line is priced from: the resolved
+// card, the same-name cards with the decklist's set and number, or every same-name
+// card for a line with neither
+func pokemonDeckCandidates(line models.DeckCard, named []*models.Card, byID map[string]*models.Card) []*models.Card {
+	if line.CardID != "" {
+		if card, ok := byID[line.CardID]; ok {
+			return []*models.Card{card}
+		}
+		return nil
+	}
+	if line.SetCode == "" || line.CollectorNumber == "" {
+		return named
+	}
+	want := deckNumberKey(line.SetCode, line.CollectorNumber)
+	var matches []*models.Card
+	for _, card := range named {
+		if deckNumberKey(card.SetCode, card.CardNumber) == want {
+			matches = append(matches, card)
+		}
+	}
+	return matches
+}
+
+// deckNameKey is the name a deck card matches the collection on: the front face of
+// a double-faced card, ignoring case and punctuation
+func deckNameKey(name string) string {
+	front, _, _ := strings.Cut(name, "//")
+	return normalizeImportName(front)
+}
+
+// cheapestNMPrice returns the lowest NM English price among the printings of a card
+func cheapestNMPrice(card *models.Card) (float64, models.PrintingType) {
+	var best float64
+	var bestPrinting models.PrintingType
+	printings := append([]models.PrintingType{models.PrintingNormal}, ExpectedPrintings(*card)...)
+	for _, printing := range printings {
+		price := card.GetPrice(models.PriceConditionNM, printing, models.LanguageEnglish)
+		if price > 0 && (best == 0 || price < best) {
+			best, bestPrinting = price, printing
+		}
+	}
+	return best, bestPrinting
+}
+
+func containsCard(cards []*models.Card, card *models.Card) bool {
+	for _, c := range cards {
+		if c.ID == card.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPrices returns a copy of the card without its condition prices, which the
+// ownership response has no use for
+func withoutPrices(card *models.Card) *models.Card {
+	c := *card
+	c.Prices = nil
+	return &c
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestDeckOwnership(t *testing.T) {
+	db := newTestDB(t, &models.Card{}, &models.CardPrice{}, &models.CollectionItem{})
+
+	db.Create(&models.Card{ID: "bolt-sta", Name: "Lightning Bolt", Game: models.GameMTG, PriceUSD: 3})
+	db.Create(&models.Card{ID: "bolt-m11", Name: "Lightning Bolt", Game: models.GameMTG, PriceUSD: 1.5})
+	db.Create(&models.Card{ID: "delver", Name: "Delver of Secrets // Insectile Aberration", Game: models.GameMTG, PriceFoilUSD: 2})
+	db.Create(&models.Card{ID: "opt", Name: "Opt", Game: models.GameMTG})
+	db.Create(&models.Card{ID: "pika", Name: "Lightning Bolt", Game: models.GamePokemon, PriceUSD: 0.1}) // Other game
+	db.Create(&models.CardPrice{CardID: "bolt-m11", Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 1.25})
+
+	// Two Bolts of different printings and conditions, one Japanese Delver
+	db.Create(&models.CollectionItem{CardID: "bolt-sta", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingFoil})
+	db.Create(&models.CollectionItem{CardID: "bolt-m11", Quantity: 1, Condition: models.ConditionPlayed, Printing: models.PrintingNormal})
+	db.Create(&models.CollectionItem{CardID: "delver", Quantity: 1, Condition: models.ConditionNearMint, Language: models.LanguageJapanese})
+	db.Create(&models.CollectionItem{CardID: "pika", Quantity: 4, Condition: models.ConditionNearMint})
+
+	deck := &models.Deck{ID: 1, Name: "Tempo", Game: models.GameMTG, Cards: []models.DeckCard{
+		{Section: models.DeckSectionSideboard, Quantity: 2, CardName: "Lightning Bolt"},
+		{Section: models.DeckSectionMain, Quantity: 3, CardName: "Lightning Bolt", SetCode: "STA", CollectorNumber: "42"},
+		{Section: models.DeckSectionMain, Quantity: 4, CardName: "Delver of Secrets", CardID: "delver"},
+		{Section: models.DeckSectionMain, Quantity: 2, CardName: "Opt"},
+		{Section: models.DeckSectionMain, Quantity: 1, CardName: "Brainstorm"}, // Never cached
+	}}
+
+	resp, err := DeckOwnership(db, deck)
+	if err != nil {
+		t.Fatalf("DeckOwnership: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		section      models.DeckSection
+		wantOwned    int
+		wantMissing  int
+		wantPrice    float64
+		wantPriceFor string
+	}{
+		// Main deck lines claim the owned copies before the sideboard
+		{"Lightning Bolt", models.DeckSectionMain, 2, 1, 1.25, "bolt-m11"},
+		{"Delver of Secrets", models.DeckSectionMain, 1, 3, 2, "delver"},
+		{"Opt", models.DeckSectionMain, 0, 2, 0, ""},
+		{"Brainstorm", models.DeckSectionMain, 0, 1, 0, ""},
+		{"Lightning Bolt", models.DeckSectionSideboard, 0, 2, 1.25, "bolt-m11"},
+	}
+	if len(resp.Cards) != len(tests) {
+		t.Fatalf("got %d lines, want %d", len(resp.Cards), len(tests))
+	}
+	for i, tt := range tests {
+		got := resp.Cards[i]
+		if got.CardName != tt.name || got.Section != tt.section {
+			t.Errorf("line %d = %s (%s), want %s (%s)", i, got.CardName, got.Section, tt.name, tt.section)
+			continue
+		}
+		if got.Owned != tt.wantOwned || got.Missing != tt.wantMissing {
+			t.Errorf("%s (%s): owned %d, missing %d; want %d, %d", tt.name, tt.section, got.Owned, got.Missing, tt.wantOwned, tt.wantMissing)
+		}
+		if !almostEqualFloat(got.PriceUSD, tt.wantPrice) || got.PriceCardID != tt.wantPriceFor {
+			t.Errorf("%s (%s): price %v from %q, want %v from %q", tt.name, tt.section, got.PriceUSD, got.PriceCardID, tt.wantPrice, tt.wantPriceFor)
+		}
+	}
+
+	if resp.TotalCards != 12 || resp.OwnedCards != 3 || resp.MissingCards != 9 || resp.Complete {
+		t.Errorf("totals = %d total, %d owned, %d missing, complete %v", resp.TotalCards, resp.OwnedCards, resp.MissingCards, resp.Complete)
+	}
+	if len(resp.Missing) != 5 || !almostEqualFloat(resp.CostToComplete, 1.25+6+2.5) || resp.UnpricedMissing != 3 {
+		t.Errorf("missing %d lines, cost %v, %d unpriced", len(resp.Missing), resp.CostToComplete, resp.UnpricedMissing)
+	}
+}
+
+func TestDeckOwnershipPokemonMatchesResolvedCard(t *testing.T) {
+	db := newTestDB(t, &models.Card{}, &models.CardPrice{}, &models.CollectionItem{})
+
+	// Same name, different cards
+	db.Create(&models.Card{ID: "pika-svi", Name: "Pikachu", Game: models.GamePokemon, SetCode: "sv1", CardNumber: "62", PriceUSD: 0.5})
+	db.Create(&models.Card{ID: "pika-151", Name: "Pikachu", Game: models.GamePokemon, SetCode: "sv3pt5", CardNumber: "025", PriceUSD: 5})
+	db.Create(&models.Card{ID: "pika-promo", Name: "Pikachu", Game: models.GamePokemon, SetCode: "svp", CardNumber: "27", PriceUSD: 20})
+	db.Create(&models.CollectionItem{CardID: "pika-151", Quantity: 2, Condition: models.ConditionNearMint})
+	db.Create(&models.CollectionItem{CardID: "pika-promo", Quantity: 1, Condition: models.ConditionNearMint})
+
+	deck := &models.Deck{ID: 1, Name: "Pikachu", Game: models.GamePokemon, Cards: []models.DeckCard{
+		{Section: models.DeckSectionMain, Quantity: 4, CardName: "Pikachu", SetCode: "SVI", CollectorNumber: "62", CardID: "pika-svi"},
+		{Section: models.DeckSectionMain, Quantity: 3, CardName: "Pikachu", SetCode: "sv3pt5", CollectorNumber: "25"}, // Unresolved
+		{Section: models.DeckSectionMain, Quantity: 1, CardName: "Pikachu"},                                           // Name only
+	}}
+
+	resp, err := DeckOwnership(db, deck)
+	if err != nil {
+		t.Fatalf("DeckOwnership: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		wantOwned    int
+		wantPrice    float64
+		wantPriceFor string
+	}{
+		{"resolved card owns and prices only itself", 0, 0.5, "pika-svi"},
+		{"set and number match the owned printing", 2, 5, "pika-151"},
+		{"name only falls back to any Pikachu", 1, 0.5, "pika-svi"},
+	}
+	if len(resp.Cards) != len(tests) {
+		t.Fatalf("got %d lines, want %d", len(resp.Cards), len(tests))
+	}
+	for i, tt := range tests {
+		got := resp.Cards[i]
+		if got.Owned != tt.wantOwned || !almostEqualFloat(got.PriceUSD, tt.wantPrice) || got.PriceCardID != tt.wantPriceFor {
+			t.Errorf("%s: owned %d, price %v from %q; want %d, %v from %q", tt.name, got.Owned, got.PriceUSD, got.PriceCardID, tt.wantOwned, tt.wantPrice, tt.wantPriceFor)
+		}
+	}
+}
+
+func TestDeckResolveCardsUsesCachedCards(t *testing.T) {
+	db := newTestDB(t, &models.Card{})
+	db.Create(&models.Card{ID: "bolt-m11", Name: "Lightning Bolt", Game: models.GameMTG, SetCode: "m11", CardNumber: "149"})
+	db.Create(&models.Card{ID: "bolt-sta", Name: "Lightning Bolt", Game: models.GameMTG, SetCode: "sta", CardNumber: "42"})
+
+	cards := []models.DeckCard{
+		{CardName: "Lightning Bolt", SetCode: "STA", CollectorNumber: "42"},
+		{CardName: "lightning bolt", SetCode: "STA", CollectorNumber: "42"},
+		{CardName: "Counterspell"},
+		{CardName: "Opt", CardID: "opt-xln"},
+		{CardName: "Lightning Bolt", SetCode: "XYZ", CollectorNumber: "7"}, // No cached printing matches
+	}
+	NewDeckService(nil, nil).ResolveCards(db, models.GameMTG, cards)
+
+	want := []string{"bolt-sta", "bolt-sta", "", "opt-xln", ""}
+	for i, card := range cards {
+		if card.CardID != want[i] {
+			t.Errorf("%s: card ID %q, want %q", card.CardName, card.CardID, want[i])
+		}
+	}
+}
+
+func TestDeckResolveCardsPTCGOSetCodes(t *testing.T) {
+	// Pokemon TCG Live lists sets by PTCGO code (SVI) while local data uses set IDs (sv1)
+	dataDir := t.TempDir()
+	writeTestData := func(path, data string) {
+		t.Helper()
+		full := filepath.Join(dataDir, "pokemon-tcg-data-master", path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestData("sets/en.json", `[
+		{"id":"sv1","name":"Scarlet & Violet","ptcgoCode":"SVI"},
+		{"id":"sv3pt5","name":"151","ptcgoCode":"MEW"}]`)
+	writeTestData("cards/en/sv1.json", `[{"id":"sv1-62","name":"Pikachu","number":"62"}]`)
+	writeTestData("cards/en/sv3pt5.json", `[{"id":"sv3pt5-25","name":"Pikachu","number":"25"}]`)
+	pokemon, err := NewPokemonHybridService(dataDir)
+	if err != nil {
+		t.Fatalf("NewPokemonHybridService: %v", err)
+	}
+
+	db := newTestDB(t, &models.Card{}, &models.CardPrice{}, &models.CollectionItem{})
+	db.Create(&models.CollectionItem{CardID: "sv1-62", Quantity: 2, Condition: models.ConditionNearMint})
+
+	deck := &models.Deck{ID: 1, Name: "Pikachu", Game: models.GamePokemon, Cards: []models.DeckCard{
+		{Section: models.DeckSectionMain, Quantity: 4, CardName: "Pikachu", SetCode: "SVI", CollectorNumber: "62"},
+		{Section: models.DeckSectionMain, Quantity: 1, CardName: "Pikachu", SetCode: "sv3pt5", CollectorNumber: "25"},
+	}}
+	NewDeckService(pokemon, nil).ResolveCards(db, models.GamePokemon, deck.Cards)
+	if deck.Cards[0].CardID != "sv1-62" || deck.Cards[1].CardID != "sv3pt5-25" {
+		t.Fatalf("resolved to %q and %q, want sv1-62 and sv3pt5-25", deck.Cards[0].CardID, deck.Cards[1].CardID)
+	}
+
+	resp, err := DeckOwnership(db, deck)
+	if err != nil {
+		t.Fatalf("DeckOwnership: %v", err)
+	}
+	if len(resp.Cards) != 2 || resp.Cards[0].Owned != 2 || resp.Cards[1].Owned != 0 {
+		t.Errorf("expected the owned SVI copies to count toward the SVI line only, got %+v", resp.Cards)
+	}
+}
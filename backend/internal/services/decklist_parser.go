@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// Decklist is a deck parsed from an MTG Arena, MTGO or Pokemon TCG Live export
+type Decklist struct {
+	Name   string // From an MTG Arena "About" section
+	Cards  []models.DeckCard
+	Errors []string // Lines that could not be parsed
+}
+
+// pokemonDeckLine matches a Pokemon TCG Live line: "4 Pikachu ex SVI 57". Set codes
+// are upper case there, which tells them apart from the last word of a card name.
+var pokemonDeckLine = regexp.MustCompile(`^(\d+)\s+(.+?)\s+([A-Z][A-Z0-9-]*)\s+([A-Za-z]*\d+[A-Za-z]*)$`)
+
+// deckHeaderSection maps decklist headers to the section of the cards that follow.
+// Pokemon TCG Live groups the main deck by card type ("Pokémon: 12").
+var deckHeaderSection = map[string]models.DeckSection{
+	"deck":      models.DeckSectionMain,
+	"main":      models.DeckSectionMain,
+	"mainboard": models.DeckSectionMain,
+	"sideboard": models.DeckSectionSideboard,
+	"commander": models.DeckSectionCommander,
+	"companion": models.DeckSectionCompanion,
+	"pokemon":   models.DeckSectionMain,
+	"pokémon":   models.DeckSectionMain,
+	"trainer":   models.DeckSectionMain,
+	"trainers":  models.DeckSectionMain,
+	"energy":    models.DeckSectionMain,
+}
+
+// ParseDecklist parses a decklist in the MTG Arena or MTGO text format (for MTG) or
+// the Pokemon TCG Live export format (for Pokemon). Every card line needs a quantity.
+// MTGO lists without section headers start the sideboard after the first blank line
+// (or mark its lines with "SB:"). Maybeboard cards are skipped. Identical lines of
+// the same section are merged.
+func ParseDecklist(game models.Game, text string) Decklist {
+	var list Decklist
+	index := make(map[string]int)
+
+	section := models.DeckSectionMain
+	headers := false  // The list has section headers, so blank lines mean nothing
+	skipping := false // In an "About" or maybeboard section
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(text, "\ufeff")))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if game == models.GameMTG && !headers && len(list.Cards) > 0 {
+				section = models.DeckSectionSideboard
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if header, ok := decklistHeader(line); ok {
+			headers = true
+			skipping = header == "about" || header == "maybeboard"
+			if s, ok := deckHeaderSection[header]; ok {
+				section = s
+			}
+			continue
+		}
+		if skipping {
+			if name, ok := strings.CutPrefix(line, "Name "); ok {
+				list.Name = strings.TrimSpace(name)
+			}
+			continue
+		}
+
+		lineSection := section
+		if len(line) > 3 && strings.EqualFold(line[:3], "SB:") {
+			lineSection = models.DeckSectionSideboard
+			line = strings.TrimSpace(line[3:])
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "* ")) // Older PTCGO exports
+
+		card, ok := parseDeckLine(game, line)
+		if !ok {
+			list.Errors = append(list.Errors, fmt.Sprintf("line %d: could not parse %q", lineNumber, line))
+			continue
+		}
+		card.Section = lineSection
+
+		key := strings.Join([]string{string(card.Section), normalizeImportName(card.CardName), strings.ToLower(card.SetCode), card.CollectorNumber}, "\x00")
+		if i, ok := index[key]; ok {
+			list.Cards[i].Quantity += card.Quantity
+			continue
+		}
+		index[key] = len(list.Cards)
+		list.Cards = append(list.Cards, card)
+	}
+
+	return list
+}
+
+// decklistHeader returns the lower-case header of a section line such as "Sideboard",
+// "About" or "Trainer: 36". Card lines start with their quantity, so never match.
+func decklistHeader(line string) (string, bool) {
+	if line[0] >= '0' && line[0] <= '9' {
+		return "", false
+	}
+	header, count, hasColon := strings.Cut(line, ":")
+	if hasColon {
+		if count = strings.TrimSpace(count); count != "" {
+			if _, err := strconv.Atoi(count); err != nil {
+				return "", false
+			}
+		}
+	}
+	header = strings.ToLower(strings.TrimSpace(header))
+	if _, ok := deckHeaderSection[header]; ok {
+		return header, true
+	}
+	switch header {
+	case "about", "maybeboard", "total cards":
+		return header, true
+	}
+	return "", false
+}
+
+// parseDeckLine parses "4 Lightning Bolt (STA) 42" for MTG, or "4 Pikachu ex SVI 57"
+// for Pokemon; the set and number are optional in both.
+func parseDeckLine(game models.Game, line string) (models.DeckCard, bool) {
+	var qty, name, setCode, number string
+	if m := pokemonDeckLine.FindStringSubmatch(line); game == models.GamePokemon && m != nil {
+		qty, name, setCode, number = m[1], m[2], m[3], m[4]
+	} else if m := textListLine.FindStringSubmatch(line); m != nil {
+		qty, name, setCode, number = m[1], m[2], m[3], m[4]
+	}
+
+	name = strings.TrimSpace(name)
+	quantity, err := strconv.Atoi(qty)
+	if name == "" || err != nil || quantity <= 0 {
+		return models.DeckCard{}, false
+	}
+	return models.DeckCard{
+		Quantity:        quantity,
+		CardName:        name,
+		SetCode:         setCode,
+		CollectorNumber: number,
+	}, true
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestParseDecklist(t *testing.T) {
+	card := func(section models.DeckSection, qty int, name, set, number string) models.DeckCard {
+		return models.DeckCard{Section: section, Quantity: qty, CardName: name, SetCode: set, CollectorNumber: number}
+	}
+	main, side := models.DeckSectionMain, models.DeckSectionSideboard
+
+	tests := []struct {
+		name       string
+		game       models.Game
+		input      string
+		wantName   string
+		want       []models.DeckCard
+		wantErrors int
+	}{
+		{
+			name: "mtg arena",
+			game: models.GameMTG,
+			input: "About\nName Mono Red\n\n" +
+				"Commander\n1 Krenko, Mob Boss (DDT) 52\n\n" +
+				"Deck\n4 Lightning Bolt (STA) 42\n20 Mountain (ELD) 262\n2 Fire // Ice (MH2) 290\n2 Lightning Bolt (STA) 42\n\n" +
+				"Sideboard\n3 Smash to Smithereens (ORI) 163\n\n" +
+				"Maybeboard\n1 Goblin Guide (ZEN) 126\n",
+			wantName: "Mono Red",
+			want: []models.DeckCard{
+				card(models.DeckSectionCommander, 1, "Krenko, Mob Boss", "DDT", "52"),
+				card(main, 6, "Lightning Bolt", "STA", "42"),
+				card(main, 20, "Mountain", "ELD", "262"),
+				card(main, 2, "Fire // Ice", "MH2", "290"),
+				card(side, 3, "Smash to Smithereens", "ORI", "163"),
+			},
+		},
+		{
+			name:  "mtgo sideboard after blank line",
+			game:  models.GameMTG,
+			input: "4 Counterspell\n4x Brainstorm\n\n2 Flusterstorm\nSB: 1 Pyroblast\n",
+			want: []models.DeckCard{
+				card(main, 4, "Counterspell", "", ""),
+				card(main, 4, "Brainstorm", "", ""),
+				card(side, 2, "Flusterstorm", "", ""),
+				card(side, 1, "Pyroblast", "", ""),
+			},
+		},
+		{
+			name: "pokemon tcg live",
+			game: models.GamePokemon,
+			input: "Pokémon: 3\n2 Pikachu ex SVI 57\n1 Pikachu VMAX VIV 44\n\n" +
+				"Trainer: 4\n4 Boss's Orders PAL 172\n\n" +
+				"Energy: 8\n8 Basic {L} Energy SVE 4\n\n" +
+				"Total Cards: 15\n",
+			want: []models.DeckCard{
+				card(main, 2, "Pikachu ex", "SVI", "57"),
+				card(main, 1, "Pikachu VMAX", "VIV", "44"),
+				card(main, 4, "Boss's Orders", "PAL", "172"),
+				card(main, 8, "Basic {L} Energy", "SVE", "4"),
+			},
+		},
+		{
+			name:  "pokemon line without set",
+			game:  models.GamePokemon,
+			input: "* 4 Nest Ball\n2 Pikachu V\n",
+			want: []models.DeckCard{
+				card(main, 4, "Nest Ball", "", ""),
+				card(main, 2, "Pikachu V", "", ""),
+			},
+		},
+		{
+			name:       "unparseable lines",
+			game:       models.GameMTG,
+			input:      "Deck\nLightning Bolt\n0 Shock\n4 Opt\n",
+			want:       []models.DeckCard{card(main, 4, "Opt", "", "")},
+			wantErrors: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := ParseDecklist(tt.game, tt.input)
+			if list.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", list.Name, tt.wantName)
+			}
+			if !reflect.DeepEqual(list.Cards, tt.want) {
+				t.Errorf("Cards = %+v\nwant %+v", list.Cards, tt.want)
+			}
+			if len(list.Errors) != tt.wantErrors {
+				t.Errorf("Errors = %v, want %d", list.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
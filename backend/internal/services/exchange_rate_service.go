@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+const (
+	// exchangeRateRefreshInterval is how often stored exchange rates are topped up
+	exchangeRateRefreshInterval = 12 * time.Hour
+
+	// exchangeRateInitialDays is how far back rates are fetched for a currency with no
+	// stored rates and no snapshots to cover
+	exchangeRateInitialDays = 7
+
+	defaultExchangeRatesURL = "https://api.frankfurter.app"
+)
+
+// defaultExchangeRateCurrencies are refreshed even when they aren't the display currency,
+// so switching to one of them works offline
+var defaultExchangeRateCurrencies = []string{"EUR", "GBP", "CAD", "AUD", "JPY"}
+
+// ErrNoExchangeRates is returned when a currency has no stored rates to convert with
+var ErrNoExchangeRates = errors.New("no exchange rates available")
+
+// RateSource supplies daily USD exchange rates
+type RateSource interface {
+	// Name identifies the source in ExchangeRate.Source
+	Name() string
+	// FetchRates returns the units of each currency per USD for the days from start
+	// through end. Days the source has no rate for (weekends, holidays) are left out.
+	FetchRates(ctx context.Context, currencies []string, start, end time.Time) ([]models.ExchangeRate, error)
+}
+
+// NewRateSourceFromEnv returns a static file source when EXCHANGE_RATES_FILE is set,
+// otherwise the Frankfurter (ECB reference rates) API at EXCHANGE_RATES_URL
+func NewRateSourceFromEnv() RateSource {
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		return NewStaticFileRateSource(path)
+	}
+	baseURL := os.Getenv("EXCHANGE_RATES_URL")
+	if baseURL == "" {
+		baseURL = defaultExchangeRatesURL
+	}
+	return NewFrankfurterRateSource(baseURL)
+}
+
+// ratesDocument is the JSON layout shared by the Frankfurter time series API and
+// rate files: units of each currency per one unit of Base, keyed by day. A file may
+// instead hold a single day's rates with Date set and Rates mapping currency to rate.
+type ratesDocument struct {
+	Base  string                     `json:"base"`
+	Date  string                     `json:"date"`
+	Rates map[string]json.RawMessage `json:"rates"`
+}
+
+// parseRatesDocument converts a rates document into per-USD exchange rates
+func parseRatesDocument(data []byte, source string) ([]models.ExchangeRate, error) {
+	var doc ratesDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid rates document: %w", err)
+	}
+	base := strings.ToUpper(doc.Base)
+	if base == "" {
+		base = models.CurrencyUSD
+	}
+
+	byDay := make(map[string]map[string]float64)
+	if doc.Date != "" {
+		day := make(map[string]float64)
+		for currency, raw := range doc.Rates {
+			var rate float64
+			if err := json.Unmarshal(raw, &rate); err != nil {
+				return nil, fmt.Errorf("invalid rate for %s: %w", currency, err)
+			}
+			day[currency] = rate
+		}
+		byDay[doc.Date] = day
+	} else {
+		for date, raw := range doc.Rates {
+			var day map[string]float64
+			if err := json.Unmarshal(raw, &day); err != nil {
+				return nil, fmt.Errorf("invalid rates for %s: %w", date, err)
+			}
+			byDay[date] = day
+		}
+	}
+
+	var rates []models.ExchangeRate
+	for date, day := range byDay {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", date, err)
+		}
+
+		// Rebase to USD: units of X per USD = (X per base) / (USD per base)
+		usdPerBase := 1.0
+		if base != models.CurrencyUSD {
+			usdPerBase = day[models.CurrencyUSD]
+			if usdPerBase <= 0 {
+				return nil, fmt.Errorf("rates for %s have base %s but no USD rate", date, base)
+			}
+			day[base] = 1
+		}
+		for currency, rate := range day {
+			currency = strings.ToUpper(currency)
+			if currency == models.CurrencyUSD || rate <= 0 {
+				continue
+			}
+			rates = append(rates, models.ExchangeRate{
+				Currency: currency,
+				Date:     parsed,
+				Rate:     rate / usdPerBase,
+				Source:   source,
+			})
+		}
+	}
+	return rates, nil
+}
+
+// filterRates keeps the rates for the given currencies dated from start through end
+func filterRates(rates []models.ExchangeRate, currencies []string, start, end time.Time) []models.ExchangeRate {
+	wanted := make(map[string]bool, len(currencies))
+	for _, c := range currencies {
+		wanted[c] = true
+	}
+	start, end = rateDay(start), rateDay(end)
+
+	var filtered []models.ExchangeRate
+	for _, r := range rates {
+		if wanted[r.Currency] && !r.Date.Before(start) && !r.Date.After(end) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// StaticFileRateSource reads rates from a JSON file for offline use. The file is
+// re-read on every refresh so edits are picked up without a restart.
+type StaticFileRateSource struct {
+	path string
+}
+
+func NewStaticFileRateSource(path string) *StaticFileRateSource {
+	return &StaticFileRateSource{path: path}
+}
+
+func (s *StaticFileRateSource) Name() string { return "file" }
+
+// FetchRates returns the file's rates in the requested range. A file without any
+// rate in the range contributes its latest earlier rate instead, so a single-day file
+// keeps serving as "today's" rate.
+func (s *StaticFileRateSource) FetchRates(_ context.Context, currencies []string, start, end time.Time) ([]models.ExchangeRate, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	all, err := parseRatesDocument(data, s.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+
+	rates := filterRates(all, currencies, start, end)
+	for _, currency := range currencies {
+		if hasCurrency(rates, currency) {
+			continue
+		}
+		var latest *models.ExchangeRate
+		for i := range all {
+			r := &all[i]
+			if r.Currency == currency && r.Date.Before(rateDay(start)) && (latest == nil || r.Date.After(latest.Date)) {
+				latest = r
+			}
+		}
+		if latest != nil {
+			r := *latest
+			r.Date = rateDay(start)
+			rates = append(rates, r)
+		}
+	}
+	return rates, nil
+}
+
+func hasCurrency(rates []models.ExchangeRate, currency string) bool {
+	for _, r := range rates {
+		if r.Currency == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// FrankfurterRateSource fetches European Central Bank reference rates from the
+// Frankfurter API (no key required)
+type FrankfurterRateSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewFrankfurterRateSource(baseURL string) *FrankfurterRateSource {
+	return &FrankfurterRateSource{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *FrankfurterRateSource) Name() string { return "frankfurter" }
+
+func (s *FrankfurterRateSource) FetchRates(ctx context.Context, currencies []string, start, end time.Time) ([]models.ExchangeRate, error) {
+	if len(currencies) == 0 {
+		return nil, nil
+	}
+
+	reqURL := fmt.Sprintf("%s/%s..%s?from=%s&to=%s", s.baseURL,
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+		models.CurrencyUSD, url.QueryEscape(strings.Join(currencies, ",")))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("frankfurter returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	rates, err := parseRatesDocument(body, s.Name())
+	if err != nil {
+		return nil, err
+	}
+	return filterRates(rates, currencies, start, end), nil
+}
+
+// ExchangeRateService keeps the exchange_rates table filled for the display currency
+// and the configured currencies
+type ExchangeRateService struct {
+	db         *gorm.DB
+	source     RateSource
+	currencies []string
+	interval   time.Duration
+	now        func() time.Time
+}
+
+func NewExchangeRateService(db *gorm.DB, source RateSource, currencies []string) *ExchangeRateService {
+	return &ExchangeRateService{
+		db:         db,
+		source:     source,
+		currencies: currencies,
+		interval:   exchangeRateRefreshInterval,
+		now:        time.Now,
+	}
+}
+
+// ExchangeRateCurrenciesFromEnv returns the currencies listed in EXCHANGE_RATE_CURRENCIES
+// (comma-separated), or a default set of common currencies
+func ExchangeRateCurrenciesFromEnv() []string {
+	value := os.Getenv("EXCHANGE_RATE_CURRENCIES")
+	if value == "" {
+		return defaultExchangeRateCurrencies
+	}
+	var currencies []string
+	for _, code := range strings.Split(value, ",") {
+		if currency, ok := NormalizeCurrency(code); ok && currency != models.CurrencyUSD {
+			currencies = append(currencies, currency)
+		}
+	}
+	return currencies
+}
+
+// Start refreshes rates immediately and then every interval until ctx is cancelled
+func (s *ExchangeRateService) Start(ctx context.Context) {
+	log.Printf("Exchange rate service started: refreshing from %s every %v", s.source.Name(), s.interval)
+
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("Exchange rates: refresh failed: %v", err)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Exchange rate service stopping...")
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				log.Printf("Exchange rates: refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh fetches rates for the display currency and the configured currencies
+func (s *ExchangeRateService) Refresh(ctx context.Context) error {
+	currencies := append([]string{}, s.currencies...)
+	if display := DisplayCurrency(s.db); display != models.CurrencyUSD && !containsString(currencies, display) {
+		currencies = append(currencies, display)
+	}
+
+	var errs []error
+	for _, currency := range currencies {
+		if err := s.RefreshCurrency(ctx, currency); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", currency, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RefreshCurrency fetches the rates for one currency since its latest stored rate.
+// A currency without stored rates is backfilled from the first value snapshot so
+// historical values convert at the rate of their own date.
+func (s *ExchangeRateService) RefreshCurrency(ctx context.Context, currency string) error {
+	if currency == models.CurrencyUSD {
+		return nil
+	}
+	end := rateDay(s.now())
+	start, err := s.refreshStart(currency, end)
+	if err != nil {
+		return err
+	}
+
+	rates, err := s.source.FetchRates(ctx, []string{currency}, start, end)
+	if err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+
+	fetchedAt := s.now()
+	for i := range rates {
+		rates[i].Date = rateDay(rates[i].Date)
+		rates[i].FetchedAt = fetchedAt
+	}
+	err = s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "fetched_at"}),
+	}).Create(&rates).Error
+	if err != nil {
+		return err
+	}
+	log.Printf("Exchange rates: stored %d %s rates from %s", len(rates), currency, s.source.Name())
+	return nil
+}
+
+// refreshStart is the first day to fetch: the latest stored day (re-fetched in case it
+// was provisional), else the first snapshot day, else a short recent window
+func (s *ExchangeRateService) refreshStart(currency string, end time.Time) (time.Time, error) {
+	var latest models.ExchangeRate
+	err := s.db.Where("currency = ?", currency).Order("date DESC").First(&latest).Error
+	if err == nil {
+		return rateDay(latest.Date), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, err
+	}
+
+	start := end.AddDate(0, 0, -exchangeRateInitialDays)
+	var first models.CollectionValueSnapshot
+	if err := s.db.Order("snapshot_date ASC").First(&first).Error; err == nil {
+		if day := rateDay(first.SnapshotDate); day.Before(start) {
+			start = day
+		}
+	}
+	return start, nil
+}
+
+// DisplayCurrency returns the saved display currency, else DISPLAY_CURRENCY, else USD
+func DisplayCurrency(db *gorm.DB) string {
+	var setting models.AppSetting
+	if err := db.First(&setting, "key = ?", models.SettingDisplayCurrency).Error; err == nil {
+		if currency, ok := NormalizeCurrency(setting.Value); ok {
+			return currency
+		}
+	}
+	if currency, ok := NormalizeCurrency(os.Getenv("DISPLAY_CURRENCY")); ok {
+		return currency
+	}
+	return models.CurrencyUSD
+}
+
+// NormalizeCurrency upper-cases a currency code and reports whether it looks like an
+// ISO 4217 code (three letters)
+func NormalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return code, false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return code, false
+		}
+	}
+	return code, true
+}
+
+// AvailableCurrencies lists USD and every currency with stored rates
+func AvailableCurrencies(db *gorm.DB) ([]string, error) {
+	var currencies []string
+	if err := db.Model(&models.ExchangeRate{}).Distinct("currency").Pluck("currency", &currencies).Error; err != nil {
+		return nil, err
+	}
+	if !containsString(currencies, models.CurrencyUSD) {
+		currencies = append(currencies, models.CurrencyUSD)
+	}
+	sort.Strings(currencies)
+	return currencies, nil
+}
+
+// CurrencyConverter converts USD values into one currency using stored daily rates
+type CurrencyConverter struct {
+	Currency string
+	rates    []models.ExchangeRate // Sorted by date
+}
+
+// LoadCurrencyConverter loads every stored rate for currency. USD needs no rates;
+// any other currency without stored rates returns ErrNoExchangeRates.
+func LoadCurrencyConverter(db *gorm.DB, currency string) (*CurrencyConverter, error) {
+	conv := &CurrencyConverter{Currency: currency}
+	if currency == models.CurrencyUSD {
+		return conv, nil
+	}
+	if err := db.Where("currency = ?", currency).Order("date ASC").Find(&conv.rates).Error; err != nil {
+		return nil, err
+	}
+	if len(conv.rates) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoExchangeRates, currency)
+	}
+	return conv, nil
+}
+
+// rateAt returns the rate valid on t's date: the latest rate dated on or before that
+// day, or the earliest known rate for days before the first one
+func (c *CurrencyConverter) rateAt(t time.Time) *models.ExchangeRate {
+	if len(c.rates) == 0 {
+		return nil
+	}
+	day := rateDay(t)
+	i := sort.Search(len(c.rates), func(i int) bool { return c.rates[i].Date.After(day) })
+	if i == 0 {
+		return &c.rates[0]
+	}
+	return &c.rates[i-1]
+}
+
+// Rate returns the units of the currency per USD on t's date
+func (c *CurrencyConverter) Rate(t time.Time) float64 {
+	if r := c.rateAt(t); r != nil {
+		return r.Rate
+	}
+	return 1
+}
+
+// RateDate returns the date of the rate used for t, or nil for USD
+func (c *CurrencyConverter) RateDate(t time.Time) *time.Time {
+	if r := c.rateAt(t); r != nil {
+		date := r.Date
+		return &date
+	}
+	return nil
+}
+
+// Convert converts a USD amount at the rate valid on t's date
+func (c *CurrencyConverter) Convert(usd float64, t time.Time) float64 {
+	return usd * c.Rate(t)
+}
+
+// rateDay returns t's calendar date as UTC midnight, the key rates are stored under.
+// Snapshot dates are local midnight, so the date is taken in t's own location.
+func rateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestParseRatesDocument(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want map[string]float64 // "CUR date" -> rate per USD
+	}{
+		{
+			name: "usd time series",
+			doc:  `{"base":"USD","rates":{"2026-01-02":{"EUR":0.9,"GBP":0.8},"2026-01-05":{"EUR":0.92}}}`,
+			want: map[string]float64{"EUR 2026-01-02": 0.9, "GBP 2026-01-02": 0.8, "EUR 2026-01-05": 0.92},
+		},
+		{
+			name: "single day without base",
+			doc:  `{"date":"2026-01-02","rates":{"eur":0.9}}`,
+			want: map[string]float64{"EUR 2026-01-02": 0.9},
+		},
+		{
+			name: "eur base is rebased to usd",
+			doc:  `{"base":"EUR","date":"2026-01-02","rates":{"USD":1.25,"GBP":0.875}}`,
+			want: map[string]float64{"EUR 2026-01-02": 0.8, "GBP 2026-01-02": 0.7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, err := parseRatesDocument([]byte(tt.doc), "test")
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if len(rates) != len(tt.want) {
+				t.Fatalf("expected %d rates, got %d: %+v", len(tt.want), len(rates), rates)
+			}
+			for _, r := range rates {
+				key := r.Currency + " " + r.Date.Format("2006-01-02")
+				if want, ok := tt.want[key]; !ok || !almostEqualFloat(r.Rate, want) {
+					t.Errorf("%s = %v, want %v", key, r.Rate, want)
+				}
+			}
+		})
+	}
+
+	if _, err := parseRatesDocument([]byte(`{"base":"EUR","date":"2026-01-02","rates":{"GBP":0.875}}`), "test"); err == nil {
+		t.Error("expected an error for a non-USD base without a USD rate")
+	}
+}
+
+func TestCurrencyConverterUsesRateOfDate(t *testing.T) {
+	db := newTestDB(t, &models.ExchangeRate{})
+	db.Create(&[]models.ExchangeRate{
+		{Currency: "EUR", Date: day("2026-01-05"), Rate: 0.9},
+		{Currency: "EUR", Date: day("2026-01-02"), Rate: 0.8},
+		{Currency: "GBP", Date: day("2026-01-02"), Rate: 0.7},
+	})
+
+	conv, err := LoadCurrencyConverter(db, "EUR")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	local := time.FixedZone("UTC-8", -8*3600)
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{day("2025-12-30"), 0.8}, // Before the first rate: earliest rate
+		{day("2026-01-02"), 0.8},
+		{day("2026-01-04"), 0.8}, // Weekend: last rate before it
+		{day("2026-01-05"), 0.9},
+		{time.Date(2026, 1, 4, 23, 0, 0, 0, local), 0.8}, // Local date, not the UTC instant
+		{day("2026-03-01"), 0.9},
+	}
+	for _, tt := range tests {
+		if got := conv.Rate(tt.at); got != tt.want {
+			t.Errorf("Rate(%v) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+	if got := conv.Convert(100, day("2026-01-05")); !almostEqualFloat(got, 90) {
+		t.Errorf("Convert = %v, want 90", got)
+	}
+
+	usd, err := LoadCurrencyConverter(db, models.CurrencyUSD)
+	if err != nil || usd.Rate(time.Now()) != 1 {
+		t.Errorf("expected USD to convert at 1, got %v (%v)", usd, err)
+	}
+	if _, err := LoadCurrencyConverter(db, "CHF"); !errors.Is(err, ErrNoExchangeRates) {
+		t.Errorf("expected ErrNoExchangeRates for CHF, got %v", err)
+	}
+}
+
+func TestRefreshCurrencyFromFileBackfillsSnapshots(t *testing.T) {
+	db := newTestDB(t, &models.ExchangeRate{}, &models.AppSetting{}, &models.CollectionValueSnapshot{})
+	db.Create(&models.CollectionValueSnapshot{SnapshotDate: day("2026-01-01"), TotalValue: 100})
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	doc := `{"base":"USD","rates":{"2025-12-31":{"EUR":0.7},"2026-01-01":{"EUR":0.8},"2026-01-10":{"EUR":0.9},"2026-02-01":{"EUR":1.1}}}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewExchangeRateService(db, NewStaticFileRateSource(path), []string{"EUR"})
+	s.now = func() time.Time { return day("2026-01-15") }
+
+	// Running twice must not duplicate rows
+	for i := 0; i < 2; i++ {
+		if err := s.Refresh(context.Background()); err != nil {
+			t.Fatalf("refresh %d failed: %v", i, err)
+		}
+	}
+
+	var rates []models.ExchangeRate
+	db.Order("date ASC").Find(&rates)
+	if len(rates) != 2 || rates[0].Rate != 0.8 || rates[1].Rate != 0.9 || rates[0].Source != "file" {
+		t.Fatalf("expected the Jan 1 and Jan 10 rates only, got %+v", rates)
+	}
+
+	// A day past the file's last rate in range reuses its latest earlier rate
+	s.now = func() time.Time { return day("2026-01-20") }
+	db.Where("date > ?", day("2026-01-01")).Delete(&models.ExchangeRate{})
+	db.Create(&models.ExchangeRate{Currency: "EUR", Date: day("2026-01-12"), Rate: 0.95})
+	if err := s.RefreshCurrency(context.Background(), "EUR"); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	var latest models.ExchangeRate
+	db.Order("date DESC").First(&latest)
+	if !latest.Date.Equal(day("2026-01-12")) || latest.Rate != 0.9 {
+		t.Errorf("expected the Jan 12 rate refreshed to 0.9, got %+v", latest)
+	}
+}
+
+func TestRefreshIncludesDisplayCurrency(t *testing.T) {
+	db := newTestDB(t, &models.ExchangeRate{}, &models.AppSetting{}, &models.CollectionValueSnapshot{})
+	db.Create(&models.AppSetting{Key: models.SettingDisplayCurrency, Value: "jpy"})
+
+	var gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"amount":1.0,"base":"USD","start_date":"2026-01-08","end_date":"2026-01-15","rates":{"2026-01-09":{"JPY":150.5},"2026-01-15":{"JPY":151}}}`))
+	}))
+	defer server.Close()
+
+	s := NewExchangeRateService(db, NewFrankfurterRateSource(server.URL), nil)
+	s.now = func() time.Time { return day("2026-01-15") }
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	if gotPath != "/2026-01-08..2026-01-15" || gotQuery != "from=USD&to=JPY" {
+		t.Errorf("unexpected request %s?%s", gotPath, gotQuery)
+	}
+	var count int64
+	db.Model(&models.ExchangeRate{}).Where("currency = ? AND source = ?", "JPY", "frankfurter").Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 JPY rates, got %d", count)
+	}
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GeminiSession is a recorded identification: the Gemini API exchange of every turn
+// and every card searcher call made by the tools. It is enough to replay the
+// identification offline, with no API key and no card data.
+type GeminiSession struct {
+	Image     string                `json:"image"` // Card photo, relative to the session file
+	Thorough  bool                  `json:"thorough"`
+	Exchanges []GeminiExchange      `json:"exchanges"`
+	Searches  []RecordedSearch      `json:"searches"`
+	Result    *IdentificationResult `json:"result,omitempty"` // Result of the recorded run
+	Error     string                `json:"error,omitempty"`
+
+	mu sync.Mutex
+}
+
+// GeminiExchange is one generateContent call. Inline image data in the request is
+// replaced by its SHA-256 to keep sessions small; the API key is not recorded.
+type GeminiExchange struct {
+	Request  json.RawMessage `json:"request"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// RecordedSearch is one CardSearcher call and its outcome
+type RecordedSearch struct {
+	Game   string          `json:"game"` // "pokemon" or "mtg"
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// LoadGeminiSession reads a recorded session and its card photo
+func LoadGeminiSession(path string) (*GeminiSession, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var session GeminiSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, nil, fmt.Errorf("invalid session %s: %w", path, err)
+	}
+	if session.Image == "" {
+		return nil, nil, fmt.Errorf("session %s has no image", path)
+	}
+	image, err := os.ReadFile(filepath.Join(filepath.Dir(path), session.Image))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &session, image, nil
+}
+
+// save writes the session and its card photo to dir, named after the time and the
+// photo's hash
+func (s *GeminiSession) save(dir string, imageBytes []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(imageBytes)
+	name := time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(sum[:4])
+
+	ext := ".jpg"
+	switch detectMimeType(imageBytes) {
+	case "image/png":
+		ext = ".png"
+	case "image/webp":
+		ext = ".webp"
+	case "image/gif":
+		ext = ".gif"
+	}
+	s.Image = name + ext
+	if err := os.WriteFile(filepath.Join(dir, s.Image), imageBytes, 0644); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name+".json")
+	return path, os.WriteFile(path, data, 0644)
+}
+
+func (s *GeminiSession) addExchange(request []byte, status int, response []byte) {
+	exchange := GeminiExchange{Request: redactInlineData(request), Status: status, Response: response}
+	if !json.Valid(response) {
+		exchange.Response, _ = json.Marshal(string(response))
+	}
+	s.mu.Lock()
+	s.Exchanges = append(s.Exchanges, exchange)
+	s.mu.Unlock()
+}
+
+func (s *GeminiSession) addSearch(game, method string, args []interface{}, result interface{}, err error) {
+	search := RecordedSearch{Game: game, Method: method}
+	search.Args, _ = json.Marshal(args)
+	if err != nil {
+		search.Error = err.Error()
+	} else {
+		search.Result, _ = json.Marshal(result)
+	}
+	s.mu.Lock()
+	s.Searches = append(s.Searches, search)
+	s.mu.Unlock()
+}
+
+// findSearch returns the recorded outcome of a searcher call. Calls are matched by
+// game, method and arguments; the tool caches may skip calls on replay.
+func (s *GeminiSession) findSearch(game, method string, args []interface{}) (RecordedSearch, bool) {
+	key, _ := json.Marshal(args)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, search := range s.Searches {
+		if search.Game == game && search.Method == method && bytes.Equal(compactJSON(search.Args), key) {
+			return search, true
+		}
+	}
+	return RecordedSearch{}, false
+}
+
+// redactInlineData replaces the base64 data of inline images in a request body with
+// "sha256:<hex>" of that data
+func redactInlineData(body []byte) json.RawMessage {
+	var req geminiRequestWithTools
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body
+	}
+	for i := range req.Contents {
+		for j := range req.Contents[i].Parts {
+			if data := req.Contents[i].Parts[j].InlineData; data != nil && !strings.HasPrefix(data.Data, "sha256:") {
+				sum := sha256.Sum256([]byte(data.Data))
+				data.Data = "sha256:" + hex.EncodeToString(sum[:])
+			}
+		}
+	}
+	// Tool declarations are the same in every request
+	req.Tools = nil
+	redacted, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// geminiRequestShape summarizes a request's conversation for replay checks: the role
+// of each content with the functions called or answered and the images shown
+func geminiRequestShape(body []byte) ([]string, error) {
+	var req geminiRequestWithTools
+	if err := json.Unmarshal(redactInlineData(body), &req); err != nil {
+		return nil, err
+	}
+	shape := make([]string, 0, len(req.Contents))
+	for _, content := range req.Contents {
+		entry := content.Role
+		for _, part := range content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				entry += " call:" + part.FunctionCall.Name
+			case part.FunctionResponse != nil:
+				entry += " response:" + part.FunctionResponse.Name
+			case part.InlineData != nil:
+				entry += " image:" + part.InlineData.Data
+			}
+		}
+		shape = append(shape, entry)
+	}
+	return shape, nil
+}
+
+func compactJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+type geminiSessionKey struct{}
+
+// withGeminiSession attaches a session to record to a context
+func withGeminiSession(ctx context.Context, session *GeminiSession) context.Context {
+	return context.WithValue(ctx, geminiSessionKey{}, session)
+}
+
+func geminiSessionFrom(ctx context.Context) *GeminiSession {
+	session, _ := ctx.Value(geminiSessionKey{}).(*GeminiSession)
+	return session
+}
+
+// recordingTransport records the Gemini exchanges of requests whose context carries
+// a session
+type recordingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session := geminiSessionFrom(req.Context())
+	if session == nil || req.GetBody == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	requestBody, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	session.addExchange(requestBody, resp.StatusCode, responseBody)
+	return resp, nil
+}
+
+// replayTransport answers Gemini requests with a session's exchanges, in order. Each
+// request must have the conversation shape of the recorded one, so a change in the
+// identification loop fails the replay instead of silently diverging.
+type replayTransport struct {
+	session *GeminiSession
+	mu      sync.Mutex
+	next    int
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	t.mu.Lock()
+	if t.next >= len(t.session.Exchanges) {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay: no recorded exchange for request %d", t.next+1)
+	}
+	exchange := t.session.Exchanges[t.next]
+	t.next++
+	turn := t.next
+	t.mu.Unlock()
+
+	got, err := geminiRequestShape(body)
+	if err != nil {
+		return nil, fmt.Errorf("replay: invalid request %d: %w", turn, err)
+	}
+	want, err := geminiRequestShape(exchange.Request)
+	if err != nil {
+		return nil, fmt.Errorf("replay: invalid recorded request %d: %w", turn, err)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		return nil, fmt.Errorf("replay: request %d differs from the recording: got %q, want %q", turn, got, want)
+	}
+
+	response := []byte(exchange.Response)
+	var text string
+	if json.Unmarshal(response, &text) == nil {
+		response = []byte(text)
+	}
+	return &http.Response{
+		StatusCode: exchange.Status,
+		Status:     http.StatusText(exchange.Status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(response)),
+		Request:    req,
+	}, nil
+}
+
+// remaining returns the number of recorded exchanges not replayed yet
+func (t *replayTransport) remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.session.Exchanges) - t.next
+}
+
+// recordedSymbolImage keeps a set symbol's image data, which SetSymbolImage leaves
+// out of JSON
+type recordedSymbolImage struct {
+	SetSymbolImage
+	ImageData string `json:"image_data"`
+}
+
+// recordingSearcher records the calls the tools make to a card searcher
+type recordingSearcher struct {
+	game    string
+	inner   CardSearcher
+	session *GeminiSession
+}
+
+func (r *recordingSearcher) SearchByName(ctx context.Context, name string, limit int) ([]CandidateCard, error) {
+	cards, err := r.inner.SearchByName(ctx, name, limit)
+	r.session.addSearch(r.game, "SearchByName", []interface{}{name, limit}, cards, err)
+	return cards, err
+}
+
+func (r *recordingSearcher) SearchByNameWithLanguage(ctx context.Context, name string, language string, limit int) ([]CandidateCard, error) {
+	langSearcher, ok := r.inner.(LanguageFilteredSearcher)
+	if !ok {
+		return r.SearchByName(ctx, name, limit)
+	}
+	cards, err := langSearcher.SearchByNameWithLanguage(ctx, name, language, limit)
+	r.session.addSearch(r.game, "SearchByNameWithLanguage", []interface{}{name, language, limit}, cards, err)
+	return cards, err
+}
+
+func (r *recordingSearcher) SearchInSet(ctx context.Context, setCode, name string, limit int) ([]CandidateCard, error) {
+	cards, err := r.inner.SearchInSet(ctx, setCode, name, limit)
+	r.session.addSearch(r.game, "SearchInSet", []interface{}{setCode, name, limit}, cards, err)
+	return cards, err
+}
+
+func (r *recordingSearcher) GetBySetAndNumber(ctx context.Context, setCode, number string) (*CandidateCard, error) {
+	card, err := r.inner.GetBySetAndNumber(ctx, setCode, number)
+	r.session.addSearch(r.game, "GetBySetAndNumber", []interface{}{setCode, number}, card, err)
+	return card, err
+}
+
+func (r *recordingSearcher) GetCardImage(ctx context.Context, cardID string) (string, error) {
+	image, err := r.inner.GetCardImage(ctx, cardID)
+	r.session.addSearch(r.game, "GetCardImage", []interface{}{cardID}, image, err)
+	return image, err
+}
+
+func (r *recordingSearcher) GetCardDetails(ctx context.Context, cardID string) (*CardDetails, error) {
+	details, err := r.inner.GetCardDetails(ctx, cardID)
+	r.session.addSearch(r.game, "GetCardDetails", []interface{}{cardID}, details, err)
+	return details, err
+}
+
+func (r *recordingSearcher) ListSets(ctx context.Context, query string) ([]SetInfo, error) {
+	sets, err := r.inner.ListSets(ctx, query)
+	r.session.addSearch(r.game, "ListSets", []interface{}{query}, sets, err)
+	return sets, err
+}
+
+func (r *recordingSearcher) GetSetInfo(ctx context.Context, setCode string) (*SetDetails, error) {
+	info, err := r.inner.GetSetInfo(ctx, setCode)
+	r.session.addSearch(r.game, "GetSetInfo", []interface{}{setCode}, info, err)
+	return info, err
+}
+
+func (r *recordingSearcher) GetSetSymbolImages(ctx context.Context, query string, limit int) ([]SetSymbolImage, error) {
+	fetcher, ok := r.inner.(SetSymbolFetcher)
+	if !ok {
+		return nil, fmt.Errorf("set symbol fetching not available for %s", r.game)
+	}
+	symbols, err := fetcher.GetSetSymbolImages(ctx, query, limit)
+	recorded := make([]recordedSymbolImage, len(symbols))
+	for i, sym := range symbols {
+		recorded[i] = recordedSymbolImage{SetSymbolImage: sym, ImageData: sym.ImageData}
+	}
+	r.session.addSearch(r.game, "GetSetSymbolImages", []interface{}{query, limit}, recorded, err)
+	return symbols, err
+}
+
+// replaySearcher answers card searcher calls from a recorded session
+type replaySearcher struct {
+	game    string
+	session *GeminiSession
+}
+
+// replaySearch decodes the recorded result of a call into result
+func (r *replaySearcher) replaySearch(method string, args []interface{}, result interface{}) error {
+	search, ok := r.session.findSearch(r.game, method, args)
+	if !ok {
+		encoded, _ := json.Marshal(args)
+		return fmt.Errorf("replay: %s %s%s was not recorded", r.game, method, encoded)
+	}
+	if search.Error != "" {
+		return fmt.Errorf("%s", search.Error)
+	}
+	return json.Unmarshal(search.Result, result)
+}
+
+func (r *replaySearcher) SearchByName(ctx context.Context, name string, limit int) ([]CandidateCard, error) {
+	var cards []CandidateCard
+	err := r.replaySearch("SearchByName", []interface{}{name, limit}, &cards)
+	return cards, err
+}
+
+func (r *replaySearcher) SearchByNameWithLanguage(ctx context.Context, name string, language string, limit int) ([]CandidateCard, error) {
+	var cards []CandidateCard
+	err := r.replaySearch("SearchByNameWithLanguage", []interface{}{name, language, limit}, &cards)
+	return cards, err
+}
+
+func (r *replaySearcher) SearchInSet(ctx context.Context, setCode, name string, limit int) ([]CandidateCard, error) {
+	var cards []CandidateCard
+	err := r.replaySearch("SearchInSet", []interface{}{setCode, name, limit}, &cards)
+	return cards, err
+}
+
+func (r *replaySearcher) GetBySetAndNumber(ctx context.Context, setCode, number string) (*CandidateCard, error) {
+	var card *CandidateCard
+	err := r.replaySearch("GetBySetAndNumber", []interface{}{setCode, number}, &card)
+	return card, err
+}
+
+func (r *replaySearcher) GetCardImage(ctx context.Context, cardID string) (string, error) {
+	var image string
+	err := r.replaySearch("GetCardImage", []interface{}{cardID}, &image)
+	return image, err
+}
+
+func (r *replaySearcher) GetCardDetails(ctx context.Context, cardID string) (*CardDetails, error) {
+	var details *CardDetails
+	err := r.replaySearch("GetCardDetails", []interface{}{cardID}, &details)
+	return details, err
+}
+
+func (r *replaySearcher) ListSets(ctx context.Context, query string) ([]SetInfo, error) {
+	var sets []SetInfo
+	err := r.replaySearch("ListSets", []interface{}{query}, &sets)
+	return sets, err
+}
+
+func (r *replaySearcher) GetSetInfo(ctx context.Context, setCode string) (*SetDetails, error) {
+	var info *SetDetails
+	err := r.replaySearch("GetSetInfo", []interface{}{setCode}, &info)
+	return info, err
+}
+
+func (r *replaySearcher) GetSetSymbolImages(ctx context.Context, query string, limit int) ([]SetSymbolImage, error) {
+	var recorded []recordedSymbolImage
+	if err := r.replaySearch("GetSetSymbolImages", []interface{}{query, limit}, &recorded); err != nil {
+		return nil, err
+	}
+	symbols := make([]SetSymbolImage, len(recorded))
+	for i, sym := range recorded {
+		symbols[i] = sym.SetSymbolImage
+		symbols[i].ImageData = sym.ImageData
+	}
+	return symbols, nil
+}
+
+// NewGeminiReplayService returns a Gemini service that answers from a recorded
+// session instead of the API, with searchers that replay the session's card data
+func NewGeminiReplayService(session *GeminiSession) (svc *GeminiService, pokemonSearcher, mtgSearcher CardSearcher) {
+	svc = newGeminiService("replay", &http.Client{Transport: &replayTransport{session: session}}, "")
+	return svc, &replaySearcher{game: "pokemon", session: session}, &replaySearcher{game: "mtg", session: session}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGeminiReplayRegression replays recorded identification sessions of the stored
+// card images. Record new sessions with GEMINI_RECORD_DIR set, copy the session file
+// to testdata/gemini_sessions and point its image at the stored card image.
+func TestGeminiReplayRegression(t *testing.T) {
+	tests := []struct {
+		session          string
+		wantCardID       string
+		wantFoil         bool
+		wantFirstEdition bool
+		wantLanguage     string
+	}{
+		{"pikachu_base1_58", "base1-58", false, false, "English"},
+		{"charizard_base1_4", "base1-4", true, false, "English"},
+		{"dark_charizard_base5_4", "base5-4", true, false, "English"},                               // Set found by its symbol
+		{"chienpao_ex_sv2_61", "sv2-61", false, false, "English"},                                   // Thorough mode retries the unverified first answer
+		{"lightning_bolt_lea_161", "ca8a9ab3-02f3-4eb5-8f82-de2a3ba1b3bd", false, false, "English"}, // Prose answer re-prompted for JSON
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.session, func(t *testing.T) {
+			session, image, err := LoadGeminiSession(filepath.Join("testdata", "gemini_sessions", tt.session+".json"))
+			if err != nil {
+				t.Fatalf("load session: %v", err)
+			}
+			svc, pokemonSearcher, mtgSearcher := NewGeminiReplayService(session)
+
+			result, err := svc.IdentifyCardWithOptions(context.Background(), image, pokemonSearcher, mtgSearcher, IdentifyOptions{Thorough: session.Thorough})
+			if err != nil {
+				t.Fatalf("replay failed: %v", err)
+			}
+			if remaining := svc.httpClient.Transport.(*replayTransport).remaining(); remaining != 0 {
+				t.Errorf("%d recorded exchanges were not replayed", remaining)
+			}
+
+			if result.CardID != tt.wantCardID || result.IsFoil != tt.wantFoil || result.IsFirstEdition != tt.wantFirstEdition || result.ObservedLang != tt.wantLanguage {
+				t.Errorf("got card %q (foil %v, 1st edition %v, %s), want %q (foil %v, 1st edition %v, %s)",
+					result.CardID, result.IsFoil, result.IsFirstEdition, result.ObservedLang,
+					tt.wantCardID, tt.wantFoil, tt.wantFirstEdition, tt.wantLanguage)
+			}
+			if session.Result != nil && result.Confidence != session.Result.Confidence {
+				t.Errorf("confidence %v, recorded %v", result.Confidence, session.Result.Confidence)
+			}
+		})
+	}
+}
+
+func TestGeminiReplayRejectsDivergedConversation(t *testing.T) {
+	path := filepath.Join("testdata", "gemini_sessions", "pikachu_base1_58.json")
+	session, image, err := LoadGeminiSession(path)
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+
+	// Another photo than the recorded one
+	other, err := os.ReadFile(filepath.Join("testdata", "pokemon_cards", "gengar_base3_5.png"))
+	if err != nil {
+		t.Fatalf("read image: %v", err)
+	}
+	svc, pokemonSearcher, mtgSearcher := NewGeminiReplayService(session)
+	if _, err := svc.IdentifyCard(context.Background(), other, pokemonSearcher, mtgSearcher); err == nil {
+		t.Errorf("expected replay of a different photo to fail")
+	}
+
+	// A second identification has nothing left to replay
+	svc, pokemonSearcher, mtgSearcher = NewGeminiReplayService(session)
+	if _, err := svc.IdentifyCard(context.Background(), image, pokemonSearcher, mtgSearcher); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if _, err := svc.IdentifyCard(context.Background(), image, pokemonSearcher, mtgSearcher); err == nil {
+		t.Errorf("expected replay past the recorded exchanges to fail")
+	}
+}
+
+func TestGeminiRecordingRoundTrip(t *testing.T) {
+	session, image, err := LoadGeminiSession(filepath.Join("testdata", "gemini_sessions", "charizard_base1_4.json"))
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	_, pokemonSearcher, mtgSearcher := NewGeminiReplayService(session)
+
+	// Record a replay of the session, then replay the new recording
+	dir := t.TempDir()
+	client := &http.Client{Transport: &recordingTransport{base: &replayTransport{session: session}}}
+	recorder := newGeminiService("test", client, dir)
+	if _, err := recorder.IdentifyCard(context.Background(), image, pokemonSearcher, mtgSearcher); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one recorded session, got %v", files)
+	}
+	recorded, recordedImage, err := LoadGeminiSession(files[0])
+	if err != nil {
+		t.Fatalf("load recording: %v", err)
+	}
+	if len(recorded.Exchanges) != len(session.Exchanges) || len(recorded.Searches) == 0 || recorded.Result == nil {
+		t.Fatalf("incomplete recording: %d exchanges, %d searches", len(recorded.Exchanges), len(recorded.Searches))
+	}
+
+	svc, pokemonSearcher, mtgSearcher := NewGeminiReplayService(recorded)
+	result, err := svc.IdentifyCard(context.Background(), recordedImage, pokemonSearcher, mtgSearcher)
+	if err != nil {
+		t.Fatalf("replay of recording failed: %v", err)
+	}
+	if result.CardID != "base1-4" {
+		t.Errorf("replayed card %q, want base1-4", result.CardID)
+	}
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+const (
+	// imageIndexMatchDistance is the largest hash distance (of 128 bits) trusted
+	// to identify a card without the vision model
+	imageIndexMatchDistance = 20
+
+	// imageIndexMatchMargin is how much closer the best match must be than the
+	// next card; reprints sharing artwork are left for the model to tell apart
+	imageIndexMatchMargin = 10
+
+	// imageIndexCandidateDistance is the largest distance offered as a candidate
+	imageIndexCandidateDistance = 36
+
+	// imageIndexCandidateLimit is how many candidates a lookup returns
+	imageIndexCandidateLimit = 5
+
+	imageIndexFormatVersion = 1
+	defaultImageIndexPath   = "./data/image_hash_index.json"
+)
+
+// ImageIndexEntry is the hash of one card's reference image
+type ImageIndexEntry struct {
+	CardID   string   `json:"card_id"`
+	Game     string   `json:"game"`
+	Name     string   `json:"name"`
+	SetCode  string   `json:"set_code"`
+	SetName  string   `json:"set_name"`
+	Number   string   `json:"number"`
+	ImageURL string   `json:"image_url"`
+	Hash     CardHash `json:"hash"`
+}
+
+// ImageIndexMatch is an index entry with its distance from the looked-up image
+type ImageIndexMatch struct {
+	ImageIndexEntry
+	Distance int `json:"distance"`
+}
+
+// Confidence maps the hash distance to 0-1: 1 for identical hashes, 0.5 at
+// the candidate cutoff
+func (m ImageIndexMatch) Confidence() float64 {
+	return 1 - float64(m.Distance)/float64(2*imageIndexCandidateDistance)
+}
+
+// Candidate converts the match to a card candidate for the vision tools
+func (m ImageIndexMatch) Candidate() CandidateCard {
+	return CandidateCard{
+		ID:       m.CardID,
+		Name:     m.Name,
+		SetCode:  m.SetCode,
+		SetName:  m.SetName,
+		Number:   m.Number,
+		ImageURL: m.ImageURL,
+	}
+}
+
+// imageIndexFile is the on-disk format written by cmd/build-image-index
+type imageIndexFile struct {
+	Version int               `json:"version"`
+	BuiltAt time.Time         `json:"built_at"`
+	Entries []ImageIndexEntry `json:"entries"`
+}
+
+// ImageHashIndex holds perceptual hashes of reference card images, so uploaded
+// photos can be matched locally before (or instead of) the vision model
+type ImageHashIndex struct {
+	mu      sync.RWMutex
+	entries []ImageIndexEntry
+	byID    map[string]int
+}
+
+// NewImageHashIndex creates an empty index
+func NewImageHashIndex() *ImageHashIndex {
+	return &ImageHashIndex{byID: make(map[string]int)}
+}
+
+// LoadImageHashIndex reads an index written by Save
+func LoadImageHashIndex(path string) (*ImageHashIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file imageIndexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse image index: %w", err)
+	}
+	if file.Version != imageIndexFormatVersion {
+		return nil, fmt.Errorf("unsupported image index version %d (expected %d), rebuild it with build-image-index", file.Version, imageIndexFormatVersion)
+	}
+
+	idx := NewImageHashIndex()
+	for _, entry := range file.Entries {
+		idx.Add(entry)
+	}
+	return idx, nil
+}
+
+// ImageIndexPathFromEnv returns IMAGE_INDEX_PATH, or the default index location
+func ImageIndexPathFromEnv() string {
+	if path := os.Getenv("IMAGE_INDEX_PATH"); path != "" {
+		return path
+	}
+	return defaultImageIndexPath
+}
+
+// LoadImageHashIndexFromEnv loads the index at IMAGE_INDEX_PATH. It returns nil,
+// disabling hash pre-matching, when the index has not been built.
+func LoadImageHashIndexFromEnv() *ImageHashIndex {
+	path := ImageIndexPathFromEnv()
+	idx, err := LoadImageHashIndex(path)
+	if os.IsNotExist(err) {
+		log.Printf("Image index: %s not found, hash pre-matching disabled (run build-image-index to create it)", path)
+		return nil
+	}
+	if err != nil {
+		log.Printf("Image index: failed to load %s: %v", path, err)
+		return nil
+	}
+	log.Printf("Image index: loaded %d reference images from %s", idx.Len(), path)
+	return idx
+}
+
+// Save writes the index atomically, so a running server never reads a partial file
+func (idx *ImageHashIndex) Save(path string) error {
+	idx.mu.RLock()
+	file := imageIndexFile{
+		Version: imageIndexFormatVersion,
+		BuiltAt: time.Now(),
+		Entries: append([]ImageIndexEntry(nil), idx.entries...),
+	}
+	idx.mu.RUnlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Len returns the number of indexed cards
+func (idx *ImageHashIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Has reports whether a card is already indexed
+func (idx *ImageHashIndex) Has(cardID string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.byID[cardID]
+	return ok
+}
+
+// Add indexes a card, replacing any previous entry for the same card
+func (idx *ImageHashIndex) Add(entry ImageIndexEntry) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if i, ok := idx.byID[entry.CardID]; ok {
+		idx.entries[i] = entry
+		return
+	}
+	idx.byID[entry.CardID] = len(idx.entries)
+	idx.entries = append(idx.entries, entry)
+}
+
+// Lookup returns the closest cards to any of the given hashes, nearest first,
+// up to limit and within the candidate distance
+func (idx *ImageHashIndex) Lookup(hashes []CardHash, limit int) []ImageIndexMatch {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var matches []ImageIndexMatch
+	for _, entry := range idx.entries {
+		best := imageIndexCandidateDistance + 1
+		for _, h := range hashes {
+			best = min(best, h.Distance(entry.Hash))
+		}
+		if best <= imageIndexCandidateDistance {
+			matches = append(matches, ImageIndexMatch{ImageIndexEntry: entry, Distance: best})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// MatchImage hashes an uploaded photo and looks it up
+func (idx *ImageHashIndex) MatchImage(imageBytes []byte, limit int) ([]ImageIndexMatch, error) {
+	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return idx.Lookup(queryHashes(img), limit), nil
+}
+
+// confidentImageMatch returns the best match when it is close enough, and far
+// enough ahead of the runner-up, to identify the card on its own
+func confidentImageMatch(matches []ImageIndexMatch) (ImageIndexMatch, bool) {
+	if len(matches) == 0 || matches[0].Distance > imageIndexMatchDistance {
+		return ImageIndexMatch{}, false
+	}
+	if len(matches) > 1 && matches[1].Distance-matches[0].Distance < imageIndexMatchMargin {
+		return ImageIndexMatch{}, false
+	}
+	return matches[0], true
+}
+
+// ImageIndexSource is a reference image to download and hash into the index
+type ImageIndexSource struct {
+	CardID   string
+	Game     string
+	Name     string
+	SetCode  string
+	SetName  string
+	Number   string
+	ImageURL string
+}
+
+// AddFromURL downloads a source's reference image and indexes its hash.
+// Reference images are full-card scans, so the whole image is hashed.
+func (idx *ImageHashIndex) AddFromURL(ctx context.Context, client *http.Client, src ImageIndexSource) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", src.ImageURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d fetching %s", resp.StatusCode, src.ImageURL)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", src.ImageURL, err)
+	}
+
+	idx.Add(ImageIndexEntry{
+		CardID:   src.CardID,
+		Game:     src.Game,
+		Name:     src.Name,
+		SetCode:  src.SetCode,
+		SetName:  src.SetName,
+		Number:   src.Number,
+		ImageURL: src.ImageURL,
+		Hash:     ComputeCardHash(img, img.Bounds()),
+	})
+	return nil
+}
+
+// CachedMTGImageIndexSources returns the MTG cards cached from Scryfall in the
+// database. MTG has too many printings to index up front, so the index covers
+// the cards this collection has already looked up.
+func CachedMTGImageIndexSources(db *gorm.DB) ([]ImageIndexSource, error) {
+	var cards []models.Card
+	if err := db.Where("game = ? AND image_url != ''", models.GameMTG).Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	sources := make([]ImageIndexSource, 0, len(cards))
+	for _, card := range cards {
+		sources = append(sources, ImageIndexSource{
+			CardID:   card.ID,
+			Game:     string(models.GameMTG),
+			Name:     card.Name,
+			SetCode:  card.SetCode,
+			SetName:  card.SetName,
+			Number:   card.CardNumber,
+			ImageURL: card.ImageURL,
+		})
+	}
+	return sources, nil
+}
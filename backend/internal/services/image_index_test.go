@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestCardImage decodes one of the stored reference card scans
+func loadTestCardImage(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", path))
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return img
+}
+
+// photographCard simulates a phone photo of a scan: shrunk, brightened, placed
+// on a table and JPEG-compressed
+func photographCard(t *testing.T, card image.Image) []byte {
+	t.Helper()
+	b := card.Bounds()
+	w, h := b.Dx()*7/10, b.Dy()*7/10
+	photo := image.NewRGBA(image.Rect(0, 0, w+200, h+160))
+	draw.Draw(photo, photo.Bounds(), &image.Uniform{color.RGBA{90, 70, 50, 255}}, image.Point{}, draw.Src)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := card.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h).RGBA()
+			photo.Set(90+x, 70+y, color.RGBA{uint8(min(255, r>>8+20)), uint8(min(255, g>>8+15)), uint8(min(255, bl>>8+5)), 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, photo, &jpeg.Options{Quality: 70}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+var testIndexCards = []struct {
+	id, path string
+}{
+	{"base1-58", "pokemon_cards/pikachu_base1_58.png"},
+	{"base1-4", "pokemon_cards/charizard_base1_4.png"},
+	{"base3-5", "pokemon_cards/gengar_base3_5.png"},
+	{"neo1-9", "pokemon_cards/lugia_neo1_9.png"},
+	{"sv2-61", "pokemon_cards/chienpao_ex_sv2_61.png"},
+	{"ltr-246", "mtg_cards/the_one_ring_ltr_246.jpg"},
+}
+
+func newTestImageIndex(t *testing.T) *ImageHashIndex {
+	t.Helper()
+	idx := NewImageHashIndex()
+	for _, c := range testIndexCards {
+		img := loadTestCardImage(t, c.path)
+		idx.Add(ImageIndexEntry{CardID: c.id, Game: "pokemon", Name: c.id, Hash: ComputeCardHash(img, img.Bounds())})
+	}
+	return idx
+}
+
+func TestImageHashIndexMatchesPhotos(t *testing.T) {
+	idx := newTestImageIndex(t)
+
+	// The index survives a save and load
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadImageHashIndex(path)
+	if err != nil || loaded.Len() != len(testIndexCards) || !loaded.Has("base1-4") {
+		t.Fatalf("LoadImageHashIndex: %d entries, err %v", loaded.Len(), err)
+	}
+
+	for _, c := range testIndexCards {
+		t.Run(c.id, func(t *testing.T) {
+			matches, err := loaded.MatchImage(photographCard(t, loadTestCardImage(t, c.path)), imageIndexCandidateLimit)
+			if err != nil {
+				t.Fatalf("MatchImage: %v", err)
+			}
+			best, ok := confidentImageMatch(matches)
+			if !ok || best.CardID != c.id {
+				t.Errorf("expected a confident match for %s, got %+v", c.id, matches)
+			}
+		})
+	}
+
+	// A card that isn't indexed matches nothing
+	unknown := photographCard(t, loadTestCardImage(t, "pokemon_cards/scyther_base2_10.png"))
+	if matches, _ := loaded.MatchImage(unknown, imageIndexCandidateLimit); len(matches) > 0 {
+		if _, ok := confidentImageMatch(matches); ok {
+			t.Errorf("unexpected confident match for an unindexed card: %+v", matches)
+		}
+	}
+}
+
+func TestImageHashIndexAddFromURL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	idx := NewImageHashIndex()
+	src := ImageIndexSource{CardID: "base1-4", Game: "pokemon", Name: "Charizard", SetCode: "base1", ImageURL: server.URL + "/pokemon_cards/charizard_base1_4.png"}
+	if err := idx.AddFromURL(context.Background(), server.Client(), src); err != nil {
+		t.Fatalf("AddFromURL: %v", err)
+	}
+
+	img := loadTestCardImage(t, "pokemon_cards/charizard_base1_4.png")
+	matches := idx.Lookup([]CardHash{ComputeCardHash(img, img.Bounds())}, 1)
+	if len(matches) != 1 || matches[0].Distance != 0 || matches[0].SetCode != "base1" {
+		t.Errorf("unexpected matches: %+v", matches)
+	}
+
+	src.ImageURL = server.URL + "/pokemon_cards/missing.png"
+	if err := idx.AddFromURL(context.Background(), server.Client(), src); err == nil {
+		t.Error("expected an error for a missing image")
+	}
+}
+
+// stubVisionIdentifier records the options it was called with
+type stubVisionIdentifier struct {
+	enabled bool
+	calls   []IdentifyOptions
+}
+
+func (s *stubVisionIdentifier) Name() string    { return "stub" }
+func (s *stubVisionIdentifier) IsEnabled() bool { return s.enabled }
+
+func (s *stubVisionIdentifier) IdentifyCard(ctx context.Context, imageBytes []byte, pokemon, mtg CardSearcher) (*IdentificationResult, error) {
+	return s.IdentifyCardWithOptions(ctx, imageBytes, pokemon, mtg, IdentifyOptions{})
+}
+
+func (s *stubVisionIdentifier) IdentifyCardWithOptions(ctx context.Context, imageBytes []byte, pokemon, mtg CardSearcher, opts IdentifyOptions) (*IdentificationResult, error) {
+	s.calls = append(s.calls, opts)
+	return &IdentificationResult{CardID: "from-model", Confidence: 0.9}, nil
+}
+
+func TestImageIndexIdentifier(t *testing.T) {
+	pikachu := photographCard(t, loadTestCardImage(t, "pokemon_cards/pikachu_base1_58.png"))
+	scyther := photographCard(t, loadTestCardImage(t, "pokemon_cards/scyther_base2_10.png"))
+
+	// A reprint with the same artwork makes the Pikachu match ambiguous
+	reprinted := newTestImageIndex(t)
+	img := loadTestCardImage(t, "pokemon_cards/pikachu_base1_58.png")
+	reprinted.Add(ImageIndexEntry{CardID: "base4-87", Name: "Pikachu", Hash: ComputeCardHash(img, img.Bounds())})
+
+	tests := []struct {
+		name           string
+		index          *ImageHashIndex
+		image          []byte
+		modelEnabled   bool
+		wantCardID     string
+		wantModelCalls int
+		wantCandidates []string // Candidates passed to the model
+	}{
+		{"confident match skips the model", newTestImageIndex(t), pikachu, true, "base1-58", 0, nil},
+		{"confident match without a model", newTestImageIndex(t), pikachu, false, "base1-58", 0, nil},
+		{"reprints are verified by the model", reprinted, pikachu, true, "from-model", 1, []string{"base1-58", "base4-87"}},
+		{"unknown card goes to the model", newTestImageIndex(t), scyther, true, "from-model", 1, nil},
+		{"unknown card without a model", newTestImageIndex(t), scyther, false, "", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &stubVisionIdentifier{enabled: tt.modelEnabled}
+			identifier := NewImageIndexIdentifier(tt.index, model)
+			if !identifier.IsEnabled() {
+				t.Fatal("expected the identifier to be enabled with a non-empty index")
+			}
+
+			result, err := identifier.IdentifyCardWithOptions(context.Background(), tt.image, nil, nil, IdentifyOptions{Thorough: true})
+			if err != nil {
+				t.Fatalf("IdentifyCardWithOptions: %v", err)
+			}
+			if result.CardID != tt.wantCardID {
+				t.Errorf("CardID = %q, want %q (%s)", result.CardID, tt.wantCardID, result.Reasoning)
+			}
+			if len(model.calls) != tt.wantModelCalls {
+				t.Fatalf("model called %d times, want %d", len(model.calls), tt.wantModelCalls)
+			}
+			if tt.wantModelCalls == 0 {
+				return
+			}
+
+			opts := model.calls[0]
+			if !opts.Thorough {
+				t.Error("expected the caller's options to be passed through")
+			}
+			if len(opts.Candidates) < len(tt.wantCandidates) {
+				t.Fatalf("candidates = %+v, want %v first", opts.Candidates, tt.wantCandidates)
+			}
+			got := map[string]bool{}
+			for _, c := range opts.Candidates[:len(tt.wantCandidates)] {
+				got[c.ID] = true
+			}
+			for _, id := range tt.wantCandidates {
+				if !got[id] {
+					t.Errorf("expected %s among the first candidates, got %+v", id, opts.Candidates)
+				}
+			}
+		})
+	}
+
+	if NewImageIndexIdentifier(NewImageHashIndex(), &stubVisionIdentifier{}).IsEnabled() {
+		t.Error("expected an empty index without a model to be disabled")
+	}
+}
+
+func TestBuildCandidatesPrompt(t *testing.T) {
+	if buildCandidatesPrompt(nil) != "" {
+		t.Error("expected no prompt without candidates")
+	}
+	prompt := buildCandidatesPrompt([]CandidateCard{{ID: "base1-58", Name: "Pikachu", SetName: "Base", Number: "58"}})
+	if !bytes.Contains([]byte(prompt), []byte("- base1-58: Pikachu (Base #58)")) {
+		t.Errorf("unexpected prompt: %s", prompt)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/metrics"
+)
+
+// ImageIndexIdentifier looks uploaded cards up in the image hash index before
+// calling a vision provider. A confident match is returned without calling the
+// model; closer-than-cutoff matches are passed to the model as candidates to
+// verify. Without a configured provider, the index alone still identifies
+// cards with known artwork.
+type ImageIndexIdentifier struct {
+	index *ImageHashIndex
+	next  VisionIdentifier
+}
+
+// NewImageIndexIdentifier wraps a vision provider with hash pre-matching
+func NewImageIndexIdentifier(index *ImageHashIndex, next VisionIdentifier) *ImageIndexIdentifier {
+	return &ImageIndexIdentifier{index: index, next: next}
+}
+
+// Name returns the wrapped provider's name
+func (i *ImageIndexIdentifier) Name() string {
+	return i.next.Name()
+}
+
+// IsEnabled returns true when either the provider or a non-empty index is available
+func (i *ImageIndexIdentifier) IsEnabled() bool {
+	return i.next.IsEnabled() || i.index.Len() > 0
+}
+
+// IdentifyCard identifies a card in standard mode
+func (i *ImageIndexIdentifier) IdentifyCard(
+	ctx context.Context,
+	imageBytes []byte,
+	pokemonSearcher CardSearcher,
+	mtgSearcher CardSearcher,
+) (*IdentificationResult, error) {
+	return i.IdentifyCardWithOptions(ctx, imageBytes, pokemonSearcher, mtgSearcher, IdentifyOptions{})
+}
+
+// IdentifyCardWithOptions matches the image against the index, then falls
+// through to the provider unless the match is confident
+func (i *ImageIndexIdentifier) IdentifyCardWithOptions(
+	ctx context.Context,
+	imageBytes []byte,
+	pokemonSearcher CardSearcher,
+	mtgSearcher CardSearcher,
+	opts IdentifyOptions,
+) (*IdentificationResult, error) {
+	matches, err := i.index.MatchImage(imageBytes, imageIndexCandidateLimit)
+	switch {
+	case err != nil:
+		log.Printf("Image index: lookup failed: %v", err)
+		metrics.ImageIndexLookupsTotal.WithLabelValues("error").Inc()
+	case len(matches) == 0:
+		metrics.ImageIndexLookupsTotal.WithLabelValues("miss").Inc()
+	default:
+		if best, ok := confidentImageMatch(matches); ok {
+			metrics.ImageIndexLookupsTotal.WithLabelValues("match").Inc()
+			log.Printf("Image index: matched %s (distance %d), skipping %s", best.CardID, best.Distance, i.next.Name())
+			return imageMatchResult(best, matches, "Matched a reference image"), nil
+		}
+		metrics.ImageIndexLookupsTotal.WithLabelValues("candidates").Inc()
+	}
+
+	if !i.next.IsEnabled() {
+		// No model to verify with: offer the closest match for review
+		if len(matches) == 0 {
+			return &IdentificationResult{Reasoning: "No similar reference image found, and no vision provider is configured"}, nil
+		}
+		return imageMatchResult(matches[0], matches, "Closest reference image, not verified (no vision provider configured)"), nil
+	}
+
+	for _, m := range matches {
+		opts.Candidates = append(opts.Candidates, m.Candidate())
+	}
+	return i.next.IdentifyCardWithOptions(ctx, imageBytes, pokemonSearcher, mtgSearcher, opts)
+}
+
+// LocateCards delegates multi-card detection to the wrapped provider
+func (i *ImageIndexIdentifier) LocateCards(ctx context.Context, imageBytes []byte) ([]image.Rectangle, error) {
+	locator, ok := i.next.(CardLocator)
+	if !ok || !i.next.IsEnabled() {
+		return nil, fmt.Errorf("%s cannot locate cards", i.next.Name())
+	}
+	return locator.LocateCards(ctx, imageBytes)
+}
+
+// imageMatchResult builds an identification from an index match. The hash only
+// identifies artwork, so foil, 1st edition and language are left to review.
+func imageMatchResult(best ImageIndexMatch, matches []ImageIndexMatch, reason string) *IdentificationResult {
+	result := &IdentificationResult{
+		CardID:          best.CardID,
+		CardName:        best.Name,
+		CanonicalNameEN: best.Name,
+		SetCode:         best.SetCode,
+		SetName:         best.SetName,
+		Number:          best.Number,
+		Game:            best.Game,
+		Confidence:      best.Confidence(),
+		Reasoning:       fmt.Sprintf("%s (hash distance %d)", reason, best.Distance),
+	}
+	for _, m := range matches {
+		result.Candidates = append(result.Candidates, m.Candidate())
+	}
+	return result
+}
@@ -316,6 +316,54 @@ func (s *JustTCGService) GetCardPrices(cardName, setCode string, game models.Gam
 	return prices, nil
 }
 
+// Name implements PriceProvider
+func (s *JustTCGService) Name() string {
+	return PriceProviderJustTCG
+}
+
+// SupportedGames implements PriceProvider
+func (s *JustTCGService) SupportedGames() []models.Game {
+	return []models.Game{models.GameMTG, models.GamePokemon}
+}
+
+// MaxBatchSize implements PriceProvider
+func (s *JustTCGService) MaxBatchSize() int {
+	return justTCGBatchSize
+}
+
+// Quota implements PriceProvider
+func (s *JustTCGService) Quota() ProviderQuota {
+	return ProviderQuota{
+		Limited:    true,
+		DailyLimit: s.GetDailyLimit(),
+		Remaining:  s.GetRequestsRemaining(),
+		ResetsAt:   s.GetResetTime(),
+	}
+}
+
+// FetchPrices implements PriceProvider. MTG cards are looked up by Scryfall ID (our card ID);
+// Pokemon cards need a TCGPlayerID and are skipped without one.
+func (s *JustTCGService) FetchPrices(cards []models.Card) (*BatchPriceResult, error) {
+	lookups := make([]CardLookup, 0, len(cards))
+	for _, card := range cards {
+		lookup := CardLookup{
+			CardID:  card.ID,
+			Name:    card.Name,
+			Set:     card.SetCode,
+			SetName: card.SetName,
+			Game:    "pokemon",
+		}
+		if card.Game == models.GameMTG {
+			lookup.Game = "magic-the-gathering"
+			lookup.ScryfallID = card.ID
+		} else {
+			lookup.TCGPlayerID = card.TCGPlayerID
+		}
+		lookups = append(lookups, lookup)
+	}
+	return s.BatchGetPrices(lookups)
+}
+
 // BatchGetPrices fetches prices for multiple cards using the batch POST endpoint.
 // All cards must have either TCGPlayerID or ScryfallID - cards without are skipped.
 // The price worker is responsible for syncing sets to discover TCGPlayerIDs before calling this.
@@ -535,7 +583,7 @@ func (s *JustTCGService) convertVariantsToPrices(variants []JustTCGVariant) []mo
 			Printing:       printing,
 			Language:       language,
 			PriceUSD:       v.Price,
-			Source:         "justtcg",
+			Source:         PriceProviderJustTCG,
 			PriceUpdatedAt: &now,
 		})
 	}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// justTCGSealedCondition is the variant condition JustTCG lists sealed products under
+const justTCGSealedCondition = "Sealed"
+
+// justTCGGame returns JustTCG's game ID for a game
+func justTCGGame(game models.Game) string {
+	if game == models.GameMTG {
+		return "magic-the-gathering"
+	}
+	return "pokemon"
+}
+
+// SearchSealedProducts searches JustTCG for sealed products by name. The results are
+// unsaved catalog entries with their current price filled in.
+func (s *JustTCGService) SearchSealedProducts(query string, game models.Game) ([]models.SealedProduct, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	params := url.Values{}
+	params.Set("game", justTCGGame(game))
+	params.Set("q", query)
+	params.Set("condition", justTCGSealedCondition)
+	params.Set("limit", "50")
+	params.Set("include_price_history", "false")
+	params.Set("include_statistics", "")
+
+	apiResp, err := s.doSealedRequest(http.MethodGet, fmt.Sprintf("%s/cards?%s", s.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var products []models.SealedProduct
+	for _, card := range apiResp.Data {
+		price, ok := sealedVariantPrice(card.Variants)
+		if !ok {
+			continue // Singles match the query too
+		}
+		products = append(products, models.SealedProduct{
+			Name:           card.Name,
+			Game:           game,
+			ProductType:    models.InferSealedProductType(card.Name),
+			SetCode:        card.Set,
+			SetName:        card.SetName,
+			TCGPlayerID:    card.TCGPlayerID,
+			PriceUSD:       price,
+			PriceSource:    PriceProviderJustTCG,
+			PriceUpdatedAt: &now,
+		})
+	}
+	return products, nil
+}
+
+// FetchSealedPrices fetches the sealed price of up to justTCGBatchSize products in one
+// batch POST, keyed by product ID. Products without a TCGPlayerID are skipped.
+func (s *JustTCGService) FetchSealedPrices(products []models.SealedProduct) (map[uint]float64, error) {
+	if len(products) > justTCGBatchSize {
+		return nil, fmt.Errorf("batch size %d exceeds max %d", len(products), justTCGBatchSize)
+	}
+
+	prices := make(map[uint]float64)
+	byTCGPlayerID := make(map[string][]uint)
+	var body []batchPostRequest
+	for _, p := range products {
+		if p.TCGPlayerID == "" {
+			log.Printf("JustTCG: skipping sealed product %d - no TCGPlayerID", p.ID)
+			continue
+		}
+		if _, seen := byTCGPlayerID[p.TCGPlayerID]; !seen {
+			body = append(body, batchPostRequest{TCGPlayerID: p.TCGPlayerID, Condition: justTCGSealedCondition})
+		}
+		byTCGPlayerID[p.TCGPlayerID] = append(byTCGPlayerID[p.TCGPlayerID], p.ID)
+	}
+	if len(body) == 0 {
+		return prices, nil
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sealed batch request: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("include_price_history", "false")
+	params.Set("include_statistics", "")
+
+	apiResp, err := s.doSealedRequest(http.MethodPost, fmt.Sprintf("%s/cards?%s", s.baseURL, params.Encode()), bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match by TCGPlayerID; JustTCG skips products it can't find
+	for _, card := range apiResp.Data {
+		price, ok := sealedVariantPrice(card.Variants)
+		if !ok {
+			continue
+		}
+		for _, id := range byTCGPlayerID[card.TCGPlayerID] {
+			prices[id] = price
+		}
+	}
+
+	log.Printf("JustTCG: fetched sealed prices for %d/%d products (remaining: %d daily)",
+		len(prices), len(products), apiResp.Metadata.APIDailyRequestsRemaining)
+	return prices, nil
+}
+
+// doSealedRequest sends one rate-limited request and decodes the response
+func (s *JustTCGService) doSealedRequest(method, reqURL string, body []byte) (*JustTCGResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit wait failed: %w", err)
+	}
+	if !s.checkDailyLimit() {
+		return nil, fmt.Errorf("JustTCG daily rate limit exceeded")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.setHeaders(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sealed products: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JustTCG API error: status %d", resp.StatusCode)
+	}
+
+	var apiResp JustTCGResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if apiResp.Error != "" {
+		return nil, fmt.Errorf("JustTCG API error: %s", apiResp.Error)
+	}
+
+	s.updateRemaining(apiResp.Metadata.APIDailyRequestsRemaining)
+	return &apiResp, nil
+}
+
+// sealedVariantPrice returns the price of the sealed variant, preferring English
+func sealedVariantPrice(variants []JustTCGVariant) (float64, bool) {
+	var price float64
+	found := false
+	for _, v := range variants {
+		if !strings.EqualFold(v.Condition, justTCGSealedCondition) || v.Price <= 0 {
+			continue
+		}
+		if models.NormalizeLanguage(v.Language) == models.LanguageEnglish {
+			return v.Price, true
+		}
+		if !found {
+			price, found = v.Price, true
+		}
+	}
+	return price, found
+}
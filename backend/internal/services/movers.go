@@ -0,0 +1,176 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
+)
+
+// moversPeriods are the supported top movers periods
+var moversPeriods = map[string]time.Duration{
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// MoversPeriodStart returns the start of a movers period ("7d" or "30d"), or false for
+// an unsupported period
+func MoversPeriodStart(period string, now time.Time) (time.Time, bool) {
+	d, ok := moversPeriods[period]
+	if !ok {
+		return time.Time{}, false
+	}
+	return now.Add(-d), true
+}
+
+// CalculateMovers compares the value of each collection card printing now with its
+// value at since (see HistoricalPrices), using the same condition, printing and
+// language fallbacks as the current value. Graded slabs have no price history and are
+// left out.
+func CalculateMovers(db *gorm.DB, since time.Time, limit int) (*models.MoversResponse, error) {
+	var items []models.CollectionItem
+	if err := db.Preload("Card.Prices").Where("COALESCE(grading_company, '') = ''").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	cardIDs := make([]string, 0, len(items))
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if !seen[item.CardID] {
+			seen[item.CardID] = true
+			cardIDs = append(cardIDs, item.CardID)
+		}
+	}
+
+	baselines, err := HistoricalPrices(db, cardIDs, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group copies of the same printing so a card split across stacks ranks once
+	type moverKey struct {
+		cardID    string
+		condition models.Condition
+		printing  models.PrintingType
+		language  models.CardLanguage
+	}
+	movers := make(map[moverKey]*models.PriceMover)
+	var order []moverKey
+	for i := range items {
+		item := &items[i]
+		baseline, ok := baselines[item.CardID]
+		if !ok {
+			continue
+		}
+
+		then := historicalCard(item.Card, baseline)
+		unitThen, _ := valuation.UnitPrice(item, &then)
+		unitNow, _ := valuation.UnitPrice(item, &item.Card)
+
+		key := moverKey{item.CardID, item.Condition, item.Printing, item.Language}
+		m, exists := movers[key]
+		if !exists {
+			m = &models.PriceMover{
+				CardID:        item.CardID,
+				CardName:      item.Card.Name,
+				SetCode:       item.Card.SetCode,
+				SetName:       item.Card.SetName,
+				ImageURL:      item.Card.ImageURL,
+				Game:          item.Card.Game,
+				Condition:     item.Condition,
+				Printing:      item.Printing,
+				Language:      item.Language,
+				UnitPriceThen: unitThen.Price,
+				UnitPriceNow:  unitNow.Price,
+			}
+			movers[key] = m
+			order = append(order, key)
+		}
+		m.Quantity += item.Quantity
+		m.ValueThen += unitThen.Price * float64(item.Quantity)
+		m.ValueNow += unitNow.Price * float64(item.Quantity)
+	}
+
+	resp := &models.MoversResponse{Since: since}
+	byGame := map[models.Game][]models.PriceMover{}
+	for _, key := range order {
+		m := movers[key]
+		m.Change = m.ValueNow - m.ValueThen
+		if m.ValueThen > 0 {
+			m.ChangePercent = m.Change / m.ValueThen * 100
+		}
+		byGame[m.Game] = append(byGame[m.Game], *m)
+	}
+	resp.MTG = rankMovers(byGame[models.GameMTG], limit)
+	resp.Pokemon = rankMovers(byGame[models.GamePokemon], limit)
+	return resp, nil
+}
+
+// historicalCard returns a copy of card priced with the observations of another time.
+// Base prices are rebuilt from the NM observations the way the price worker sets them.
+// Graded prices have no history, so the current ones are kept.
+func historicalCard(card models.Card, prices []models.CardPrice) models.Card {
+	then := card
+	then.Prices = prices
+	then.PriceUSD, then.PriceFoilUSD = 0, 0
+	for _, p := range prices {
+		if p.Condition != models.PriceConditionNM || (p.Language != "" && p.Language != models.LanguageEnglish) {
+			continue
+		}
+		if p.Printing.IsFoilVariant() {
+			then.PriceFoilUSD = p.PriceUSD
+		} else {
+			then.PriceUSD = p.PriceUSD
+		}
+	}
+	return then
+}
+
+// rankMovers totals a game's movers and keeps the top limit of each ranking
+func rankMovers(movers []models.PriceMover, limit int) models.GameMovers {
+	result := models.GameMovers{
+		TrackedCards:   len(movers),
+		Gainers:        []models.PriceMover{},
+		Losers:         []models.PriceMover{},
+		PercentGainers: []models.PriceMover{},
+		PercentLosers:  []models.PriceMover{},
+	}
+	for _, m := range movers {
+		result.ValueThen += m.ValueThen
+		result.ValueNow += m.ValueNow
+	}
+	result.Change = result.ValueNow - result.ValueThen
+	if result.ValueThen > 0 {
+		result.ChangePercent = result.Change / result.ValueThen * 100
+	}
+
+	top := func(keep func(m models.PriceMover) bool, less func(a, b models.PriceMover) bool) []models.PriceMover {
+		ranked := []models.PriceMover{}
+		for _, m := range movers {
+			if keep(m) {
+				ranked = append(ranked, m)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool { return less(ranked[i], ranked[j]) })
+		if len(ranked) > limit {
+			ranked = ranked[:limit]
+		}
+		return ranked
+	}
+	result.Gainers = top(
+		func(m models.PriceMover) bool { return m.Change > 0 },
+		func(a, b models.PriceMover) bool { return a.Change > b.Change })
+	result.Losers = top(
+		func(m models.PriceMover) bool { return m.Change < 0 },
+		func(a, b models.PriceMover) bool { return a.Change < b.Change })
+	result.PercentGainers = top(
+		func(m models.PriceMover) bool { return m.Change > 0 && m.ValueThen > 0 },
+		func(a, b models.PriceMover) bool { return a.ChangePercent > b.ChangePercent })
+	result.PercentLosers = top(
+		func(m models.PriceMover) bool { return m.Change < 0 && m.ValueThen > 0 },
+		func(a, b models.PriceMover) bool { return a.ChangePercent < b.ChangePercent })
+	return result
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestCalculateMovers(t *testing.T) {
+	db := newTestDB(t, &models.Card{}, &models.CardPrice{}, &models.CardPriceHistory{}, &models.CollectionItem{})
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+
+	nm := func(cardID string, printing models.PrintingType, price float64) models.CardPrice {
+		return models.CardPrice{CardID: cardID, Condition: models.PriceConditionNM, Printing: printing, Language: models.LanguageEnglish, PriceUSD: price}
+	}
+	record := func(p models.CardPrice, daysAgo int) {
+		db.Create(&models.CardPriceHistory{
+			CardID: p.CardID, Condition: p.Condition, Printing: p.Printing, Language: p.Language,
+			PriceUSD: p.PriceUSD, RecordedAt: now.AddDate(0, 0, -daysAgo),
+		})
+	}
+
+	db.Create(&models.Card{ID: "m1", Name: "Riser", Game: models.GameMTG, PriceUSD: 3})
+	db.Create(&models.Card{ID: "m2", Name: "Faller", Game: models.GameMTG, PriceUSD: 12})
+	db.Create(&models.Card{ID: "m3", Name: "Newcomer", Game: models.GameMTG, PriceUSD: 2.5})
+	db.Create(&models.Card{ID: "m4", Name: "Unrecorded", Game: models.GameMTG, PriceUSD: 50})
+	db.Create(&models.Card{ID: "p1", Name: "Pikachu", Game: models.GamePokemon, PriceUSD: 5})
+
+	db.Create([]models.CardPrice{
+		nm("m1", models.PrintingNormal, 3),
+		nm("m2", models.PrintingNormal, 12),
+		{CardID: "m2", Condition: models.PriceConditionLP, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 6},
+		nm("m3", models.PrintingNormal, 2.5),
+		nm("p1", models.PrintingNormal, 5),
+	})
+
+	record(nm("m1", models.PrintingNormal, 0.5), 20)
+	record(nm("m1", models.PrintingNormal, 1), 10) // Latest before the period
+	record(nm("m1", models.PrintingNormal, 3), 1)
+	record(nm("m2", models.PrintingNormal, 15), 10)
+	record(models.CardPrice{CardID: "m2", Condition: models.PriceConditionLP, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 10}, 10)
+	record(nm("m3", models.PrintingNormal, 2), 3) // First priced inside the period
+	record(nm("m3", models.PrintingNormal, 2.5), 1)
+	record(nm("p1", models.PrintingNormal, 4), 10)
+
+	db.Create([]models.CollectionItem{
+		// Two stacks of the same printing rank as one mover
+		{CardID: "m1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+		{CardID: "m1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+		{CardID: "m2", Quantity: 1, Condition: models.ConditionLightPlay, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+		{CardID: "m3", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+		{CardID: "m4", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+		// Japanese copy valued from English prices then and now
+		{CardID: "p1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageJapanese},
+		// Slabs have no price history
+		{CardID: "p1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, GradingCompany: models.GradingPSA, Grade: "10"},
+	})
+
+	got, err := CalculateMovers(db, since, 10)
+	if err != nil {
+		t.Fatalf("CalculateMovers: %v", err)
+	}
+
+	ids := func(movers []models.PriceMover) []string {
+		var out []string
+		for _, m := range movers {
+			out = append(out, m.CardID)
+		}
+		return out
+	}
+	equal := func(a, b []string) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	mtg := got.MTG
+	if mtg.TrackedCards != 3 {
+		t.Errorf("MTG tracked cards = %d, want 3", mtg.TrackedCards)
+	}
+	if g := ids(mtg.Gainers); !equal(g, []string{"m1", "m3"}) {
+		t.Errorf("MTG gainers = %v, want [m1 m3]", g)
+	}
+	if l := ids(mtg.Losers); !equal(l, []string{"m2"}) {
+		t.Errorf("MTG losers = %v, want [m2]", l)
+	}
+	if len(mtg.Gainers) > 0 {
+		m1 := mtg.Gainers[0]
+		if m1.Quantity != 2 || !almostEqualFloat(m1.ValueThen, 2) || !almostEqualFloat(m1.ValueNow, 6) || !almostEqualFloat(m1.ChangePercent, 200) {
+			t.Errorf("m1 = %+v, want 2 copies from 2.00 to 6.00 (+200%%)", m1)
+		}
+	}
+	if len(mtg.Losers) > 0 {
+		// LP copy moves with the LP price, not the NM price
+		if m2 := mtg.Losers[0]; !almostEqualFloat(m2.Change, -4) || !almostEqualFloat(m2.ChangePercent, -40) {
+			t.Errorf("m2 change = %v (%v%%), want -4 (-40%%)", m2.Change, m2.ChangePercent)
+		}
+	}
+	if !almostEqualFloat(mtg.Change, 0.5) {
+		t.Errorf("MTG change = %v, want 0.5", mtg.Change)
+	}
+
+	pokemon := got.Pokemon
+	if pokemon.TrackedCards != 1 || len(pokemon.Gainers) != 1 || !almostEqualFloat(pokemon.Gainers[0].Change, 1) {
+		t.Errorf("Pokemon movers = %+v, want only the raw Japanese copy up 1.00", pokemon)
+	}
+
+	limited, err := CalculateMovers(db, since, 1)
+	if err != nil {
+		t.Fatalf("CalculateMovers: %v", err)
+	}
+	if g := ids(limited.MTG.PercentGainers); !equal(g, []string{"m1"}) || limited.MTG.TrackedCards != 3 {
+		t.Errorf("limited percent gainers = %v of %d, want [m1] of 3", g, limited.MTG.TrackedCards)
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// Alert is a fired price alert as delivered to notifiers
+type Alert struct {
+	RuleID    uint                 `json:"rule_id"`
+	RuleName  string               `json:"rule_name"`
+	Type      models.AlertRuleType `json:"type"`
+	CardID    string               `json:"card_id,omitempty"`
+	CardName  string               `json:"card_name,omitempty"`
+	Value     float64              `json:"value"`
+	Threshold float64              `json:"threshold"`
+	Message   string               `json:"message"`
+	FiredAt   time.Time            `json:"fired_at"`
+}
+
+// Notifier delivers fired alerts to the user
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NewNotifiersFromEnv returns the notifiers configured through the environment.
+// Alerts are always logged; ALERT_WEBHOOK_URL adds a webhook and SMTP_HOST with
+// ALERT_EMAIL_TO adds email delivery.
+func NewNotifiersFromEnv() []Notifier {
+	notifiers := []Notifier{NewLogNotifier()}
+
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(url))
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	to := os.Getenv("ALERT_EMAIL_TO")
+	if host != "" && to != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		from := os.Getenv("ALERT_EMAIL_FROM")
+		if from == "" {
+			from = "tcg-tracker@localhost"
+		}
+		var auth smtp.Auth
+		if user := os.Getenv("SMTP_USERNAME"); user != "" {
+			auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		notifiers = append(notifiers, NewSMTPNotifier(net.JoinHostPort(host, port), auth, from, strings.Split(to, ",")))
+	}
+
+	return notifiers
+}
+
+// LogNotifier writes alerts to the server log
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Name() string { return "log" }
+
+func (n *LogNotifier) Notify(_ context.Context, alert Alert) error {
+	log.Printf("Price alert %d (%s): %s", alert.RuleID, alert.RuleName, alert.Message)
+	return nil
+}
+
+// WebhookNotifier POSTs each alert as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier emails each alert
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+
+	// sendMail is sendMailContext, replaceable in tests
+	sendMail func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// smtpTimeout bounds a whole SMTP exchange when the context has no earlier deadline
+const smtpTimeout = 30 * time.Second
+
+func NewSMTPNotifier(addr string, auth smtp.Auth, from string, to []string) *SMTPNotifier {
+	recipients := make([]string, 0, len(to))
+	for _, r := range to {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return &SMTPNotifier{
+		addr:     addr,
+		auth:     auth,
+		from:     from,
+		to:       recipients,
+		sendMail: sendMailContext,
+	}
+}
+
+func (n *SMTPNotifier) Name() string { return "smtp" }
+
+func (n *SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	subject := "TCG Tracker price alert"
+	if alert.RuleName != "" {
+		subject += ": " + alert.RuleName
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(subject)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.FiredAt.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(alert.Message + "\r\n")
+
+	return n.sendMail(ctx, n.addr, n.auth, n.from, n.to, msg.Bytes())
+}
+
+// headerValue folds a user-supplied string onto one line so it cannot end a
+// mail header early and inject headers or body text of its own
+func headerValue(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+}
+
+// sendMailContext is smtp.SendMail with the connection bound to ctx: the dial
+// and every later read and write fail once ctx is done or smtpTimeout passes
+func sendMailContext(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	// A cancel before the deadline closes the connection to unblock the exchange
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if err := c.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
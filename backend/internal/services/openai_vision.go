@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpenAIVisionBaseURL = "https://api.openai.com/v1"
+
+	// Self-hosted models are often slower than hosted APIs, so both modes get the
+	// thorough timeout per request
+	openAIVisionTimeout         = 90 * time.Second
+	openAIVisionTimeoutThorough = 180 * time.Second
+)
+
+// OpenAIVisionService identifies cards with any OpenAI-compatible chat completions
+// API that supports images and tool calls: OpenAI itself, or a self-hosted model
+// server such as vLLM or Ollama
+type OpenAIVisionService struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+	runner     *visionRunner
+}
+
+// NewOpenAIVisionService creates an OpenAI-compatible identifier. The API key may be
+// empty for servers without authentication; thoroughModel defaults to model.
+func NewOpenAIVisionService(baseURL, apiKey, model, thoroughModel string) *OpenAIVisionService {
+	if baseURL == "" {
+		baseURL = defaultOpenAIVisionBaseURL
+	}
+	if thoroughModel == "" {
+		thoroughModel = model
+	}
+
+	svc := &OpenAIVisionService{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: openAIVisionTimeoutThorough},
+	}
+	svc.runner = &visionRunner{
+		provider:        "OpenAI",
+		client:          svc,
+		model:           model,
+		thoroughModel:   thoroughModel,
+		timeout:         openAIVisionTimeout,
+		thoroughTimeout: openAIVisionTimeoutThorough,
+		caches:          newVisionToolCaches(),
+	}
+	return svc
+}
+
+// NewOpenAIVisionServiceFromEnv creates an OpenAI-compatible identifier from
+// VISION_OPENAI_BASE_URL, VISION_OPENAI_API_KEY (or VISION_OPENAI_API_KEY_FILE),
+// VISION_OPENAI_MODEL and VISION_OPENAI_MODEL_THOROUGH
+func NewOpenAIVisionServiceFromEnv() *OpenAIVisionService {
+	apiKey := os.Getenv("VISION_OPENAI_API_KEY")
+	if apiKey == "" {
+		if keyPath := os.Getenv("VISION_OPENAI_API_KEY_FILE"); keyPath != "" {
+			if data, err := os.ReadFile(keyPath); err == nil {
+				apiKey = strings.TrimSpace(string(data))
+			}
+		}
+	}
+
+	svc := NewOpenAIVisionService(
+		os.Getenv("VISION_OPENAI_BASE_URL"),
+		apiKey,
+		os.Getenv("VISION_OPENAI_MODEL"),
+		os.Getenv("VISION_OPENAI_MODEL_THOROUGH"),
+	)
+
+	if svc.IsEnabled() {
+		log.Printf("OpenAI vision service: enabled (base_url=%s, model=%s, thorough_model=%s)", svc.baseURL, svc.model, svc.runner.thoroughModel)
+	} else {
+		log.Printf("OpenAI vision service: disabled (no VISION_OPENAI_MODEL)")
+	}
+	return svc
+}
+
+// Name returns the provider name
+func (s *OpenAIVisionService) Name() string {
+	return "openai"
+}
+
+// IsEnabled returns whether a model is configured
+func (s *OpenAIVisionService) IsEnabled() bool {
+	return s.model != ""
+}
+
+// IdentifyCard identifies a card in standard mode, optimized for speed
+func (s *OpenAIVisionService) IdentifyCard(
+	ctx context.Context,
+	imageBytes []byte,
+	pokemonSearcher CardSearcher,
+	mtgSearcher CardSearcher,
+) (*IdentificationResult, error) {
+	return s.IdentifyCardWithOptions(ctx, imageBytes, pokemonSearcher, mtgSearcher, IdentifyOptions{})
+}
+
+// IdentifyCardWithOptions identifies a card with the given options (e.g., thorough mode for bulk import)
+func (s *OpenAIVisionService) IdentifyCardWithOptions(
+	ctx context.Context,
+	imageBytes []byte,
+	pokemonSearcher CardSearcher,
+	mtgSearcher CardSearcher,
+	opts IdentifyOptions,
+) (*IdentificationResult, error) {
+	if !s.IsEnabled() {
+		return nil, fmt.Errorf("OpenAI vision service not enabled (no VISION_OPENAI_MODEL)")
+	}
+	return s.runner.identify(ctx, imageBytes, pokemonSearcher, mtgSearcher, opts)
+}
+
+// LocateCards asks the model for the bounding box of each card in a multi-card photo
+func (s *OpenAIVisionService) LocateCards(ctx context.Context, imageBytes []byte) ([]image.Rectangle, error) {
+	if !s.IsEnabled() {
+		return nil, fmt.Errorf("OpenAI vision service not enabled (no VISION_OPENAI_MODEL)")
+	}
+	return s.runner.locate(ctx, imageBytes)
+}
+
+// chat sends the conversation to the chat completions endpoint
+func (s *OpenAIVisionService) chat(ctx context.Context, model string, messages []visionMessage, tools []visionToolSpec) (*visionReply, error) {
+	req := openAIChatRequest{
+		Model:       model,
+		Temperature: 0.1,
+		MaxTokens:   2048,
+	}
+	for _, msg := range messages {
+		m, err := openAIMessageFor(msg)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, m)
+	}
+	for _, tool := range tools {
+		req.Tools = append(req.Tools, openAITool{
+			Type:     "function",
+			Function: openAIFunction{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters},
+		})
+	}
+
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/chat/completions", bytes.NewReader(reqJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var apiResp openAIChatResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if apiResp.Error != nil {
+		return nil, fmt.Errorf("API error: %s", apiResp.Error.Message)
+	}
+	if len(apiResp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+
+	message := apiResp.Choices[0].Message
+	reply := &visionReply{}
+	if message.Content != nil {
+		reply.Text = *message.Content
+	}
+	for i, call := range message.ToolCalls {
+		// Arguments are a JSON-encoded string; some servers send an object instead
+		args := map[string]interface{}{}
+		var encoded string
+		if err := json.Unmarshal(call.Function.Arguments, &encoded); err == nil {
+			if encoded != "" {
+				if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+					log.Printf("OpenAI: invalid arguments for %s: %v", call.Function.Name, err)
+				}
+			}
+		} else if err := json.Unmarshal(call.Function.Arguments, &args); err != nil {
+			log.Printf("OpenAI: invalid arguments for %s: %v", call.Function.Name, err)
+		}
+
+		id := call.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", i)
+		}
+		reply.ToolCalls = append(reply.ToolCalls, visionToolCall{ID: id, Name: call.Function.Name, Args: args})
+	}
+	return reply, nil
+}
+
+// openAIMessageFor converts a conversation message to a chat completions message
+func openAIMessageFor(msg visionMessage) (openAIMessage, error) {
+	switch msg.Role {
+	case visionRoleAssistant:
+		m := openAIMessage{Role: "assistant"}
+		if text := visionText(msg.Parts); text != "" {
+			m.Content = text
+		}
+		for _, call := range msg.ToolCalls {
+			args, err := json.Marshal(call.Args)
+			if err != nil {
+				return m, fmt.Errorf("failed to marshal tool arguments: %w", err)
+			}
+			encoded, _ := json.Marshal(string(args))
+			m.ToolCalls = append(m.ToolCalls, openAIToolCall{
+				ID:       call.ID,
+				Type:     "function",
+				Function: openAIFunctionCall{Name: call.Name, Arguments: encoded},
+			})
+		}
+		return m, nil
+	case visionRoleTool:
+		response, err := json.Marshal(msg.ToolResult.Response)
+		if err != nil {
+			return openAIMessage{}, fmt.Errorf("failed to marshal tool response: %w", err)
+		}
+		return openAIMessage{Role: "tool", ToolCallID: msg.ToolResult.Call.ID, Content: string(response)}, nil
+	default:
+		var parts []openAIContentPart
+		for _, part := range msg.Parts {
+			if part.Image != nil {
+				parts = append(parts, openAIContentPart{
+					Type:     "image_url",
+					ImageURL: &openAIImageURL{URL: "data:" + part.Image.MimeType + ";base64," + part.Image.Data},
+				})
+			} else {
+				parts = append(parts, openAIContentPart{Type: "text", Text: part.Text})
+			}
+		}
+		return openAIMessage{Role: "user", Content: parts}, nil
+	}
+}
+
+// visionText joins the text parts of a message
+func visionText(parts []visionPart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Image == nil && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// OpenAI chat completions API types
+
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"` // string, []openAIContentPart, or nil
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"` // data: URL with the base64 image
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"` // JSON-encoded string of the arguments
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   *string          `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
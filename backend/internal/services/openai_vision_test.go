@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubCardSearcher serves a fixed set of candidates to the identification tools
+type stubCardSearcher struct {
+	cards []CandidateCard
+}
+
+func (s *stubCardSearcher) SearchByName(ctx context.Context, name string, limit int) ([]CandidateCard, error) {
+	var found []CandidateCard
+	for _, card := range s.cards {
+		if strings.EqualFold(card.Name, name) {
+			found = append(found, card)
+		}
+	}
+	return found, nil
+}
+
+func (s *stubCardSearcher) SearchInSet(ctx context.Context, setCode, name string, limit int) ([]CandidateCard, error) {
+	return nil, nil
+}
+
+func (s *stubCardSearcher) GetBySetAndNumber(ctx context.Context, setCode, number string) (*CandidateCard, error) {
+	return nil, nil
+}
+
+func (s *stubCardSearcher) GetCardImage(ctx context.Context, cardID string) (string, error) {
+	return "aW1hZ2U=", nil
+}
+
+func (s *stubCardSearcher) GetCardDetails(ctx context.Context, cardID string) (*CardDetails, error) {
+	return nil, fmt.Errorf("not found")
+}
+
+func (s *stubCardSearcher) ListSets(ctx context.Context, query string) ([]SetInfo, error) {
+	return nil, nil
+}
+
+func (s *stubCardSearcher) GetSetInfo(ctx context.Context, setCode string) (*SetDetails, error) {
+	return nil, fmt.Errorf("not found")
+}
+
+func TestOpenAIVisionIdentifiesWithTools(t *testing.T) {
+	var requests []openAIChatRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		requests = append(requests, req)
+
+		if len(requests) == 1 {
+			// Arguments are a JSON string, as OpenAI sends them; the second call has no ID
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[
+				{"id":"call_a","type":"function","function":{"name":"search_pokemon_cards","arguments":"{\"name\":\"Pikachu\"}"}},
+				{"type":"function","function":{"name":"view_card_image","arguments":{"card_id":"base1-58","game":"pokemon"}}}
+			]}}]}`))
+			return
+		}
+		answer := `{"card_id":"base1-58","card_name":"Pikachu","canonical_name_en":"Pikachu","game":"pokemon","confidence":0.9}`
+		encoded, _ := json.Marshal("```json\n" + answer + "\n```")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(encoded) + `}}]}`))
+	}))
+	defer server.Close()
+
+	pokemon := &stubCardSearcher{cards: []CandidateCard{{ID: "base1-58", Name: "Pikachu", SetCode: "base1", Number: "58"}}}
+	svc := NewOpenAIVisionService(server.URL+"/v1/", "secret", "local-vision", "")
+	result, err := svc.IdentifyCard(context.Background(), []byte("\x89PNG\r\n\x1a\nfake"), pokemon, &stubCardSearcher{})
+	if err != nil {
+		t.Fatalf("IdentifyCard: %v", err)
+	}
+
+	if result.CardID != "base1-58" || result.TurnsUsed != 2 || len(result.SearchTerms) != 1 || result.SearchTerms[0] != "Pikachu" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+
+	first := requests[0]
+	if first.Model != "local-vision" || len(first.Tools) != len(identificationTools) || len(first.Messages) != 1 {
+		t.Errorf("unexpected first request: model %s, %d tools, %d messages", first.Model, len(first.Tools), len(first.Messages))
+	}
+	parts, _ := json.Marshal(first.Messages[0].Content)
+	if !strings.Contains(string(parts), `"url":"data:image/png;base64,`) {
+		t.Errorf("expected the photo as a data URL, got %s", parts)
+	}
+
+	// The assistant's calls, both tool results in order, then the card image
+	second := requests[1].Messages
+	wantRoles := []string{"user", "assistant", "tool", "tool", "user"}
+	if len(second) != len(wantRoles) {
+		t.Fatalf("expected %d messages, got %d", len(wantRoles), len(second))
+	}
+	for i, role := range wantRoles {
+		if second[i].Role != role {
+			t.Errorf("message %d: role %s, want %s", i, second[i].Role, role)
+		}
+	}
+	if len(second[1].ToolCalls) != 2 || second[2].ToolCallID != "call_a" || second[3].ToolCallID != "call_1" {
+		t.Errorf("tool call IDs not echoed: %+v", second[1:4])
+	}
+	if content, _ := second[2].Content.(string); !strings.Contains(content, `"id":"base1-58"`) {
+		t.Errorf("expected search results in the tool message, got %v", second[2].Content)
+	}
+	image, _ := json.Marshal(second[4].Content)
+	if !strings.Contains(string(image), "Here is the image for card base1-58") || !strings.Contains(string(image), "aW1hZ2U=") {
+		t.Errorf("expected the card image after the tool results, got %s", image)
+	}
+}
+
+func TestNewVisionIdentifierFromEnv(t *testing.T) {
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY_FILE", "")
+	t.Setenv("VISION_OPENAI_MODEL", "llava")
+
+	tests := []struct {
+		provider    string
+		wantName    string
+		wantEnabled bool
+	}{
+		{"", "gemini", false},
+		{"Gemini", "gemini", false},
+		{"openai", "openai", true},
+		{"unknown", "gemini", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("VISION_PROVIDER", tt.provider)
+		got := NewVisionIdentifierFromEnv()
+		if got.Name() != tt.wantName || got.IsEnabled() != tt.wantEnabled {
+			t.Errorf("VISION_PROVIDER=%q: got %s (enabled %v), want %s (enabled %v)", tt.provider, got.Name(), got.IsEnabled(), tt.wantName, tt.wantEnabled)
+		}
+	}
+}
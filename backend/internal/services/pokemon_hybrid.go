@@ -25,10 +25,11 @@ const pokemonDataURL = "https://github.com/PokemonTCG/pokemon-tcg-data/archive/r
 
 type PokemonHybridService struct {
 	sets      map[string]LocalSet
-	cardIndex map[string][]int // name -> card indices for fast lookup
-	wordIndex map[string][]int // word -> card indices for full-text search
-	idIndex   map[string]int   // card ID -> card index for O(1) lookup
-	setIndex  map[string][]int // set ID -> card indices for O(1) set lookups
+	cardIndex map[string][]int  // name -> card indices for fast lookup
+	wordIndex map[string][]int  // word -> card indices for full-text search
+	idIndex   map[string]int    // card ID -> card index for O(1) lookup
+	setIndex  map[string][]int  // set ID -> card indices for O(1) set lookups
+	ptcgoSets map[string]string // lowercase PTCGO set code -> set ID
 	cards     []LocalPokemonCard
 	mu        sync.RWMutex
 }
@@ -104,6 +105,7 @@ type LocalSet struct {
 	Series      string         `json:"series"`
 	ReleaseDate string         `json:"releaseDate"`
 	Total       int            `json:"total"`
+	PtcgoCode   string         `json:"ptcgoCode"` // e.g. "SVI" for sv1, used by PTCGO and TCG Live decklists
 	Images      LocalSetImages `json:"images"`
 }
 
@@ -368,6 +370,7 @@ func NewPokemonHybridService(dataDir string) (*PokemonHybridService, error) {
 		wordIndex: make(map[string][]int),
 		idIndex:   make(map[string]int),
 		setIndex:  make(map[string][]int),
+		ptcgoSets: make(map[string]string),
 	}
 
 	if err := service.loadData(dataDir); err != nil {
@@ -402,6 +405,9 @@ func (s *PokemonHybridService) loadData(dataDir string) error {
 
 	for _, set := range sets {
 		s.sets[set.ID] = set
+		if set.PtcgoCode != "" {
+			s.ptcgoSets[strings.ToLower(set.PtcgoCode)] = set.ID
+		}
 	}
 
 	// Load all English card files
@@ -1003,11 +1009,55 @@ func (s *PokemonHybridService) GetSetCount() int {
 	return len(s.sets)
 }
 
-// GetCardBySetAndNumber finds a specific card by set code and card number
+// ImageIndexSources returns a reference image for every card with one, for
+// cmd/build-image-index. Small images are enough for perceptual hashing.
+func (s *PokemonHybridService) ImageIndexSources() []ImageIndexSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sources := make([]ImageIndexSource, 0, len(s.cards))
+	for _, card := range s.cards {
+		imageURL := card.Images.Small
+		if imageURL == "" {
+			imageURL = card.Images.Large
+		}
+		if imageURL == "" {
+			continue
+		}
+		sources = append(sources, ImageIndexSource{
+			CardID:   card.ID,
+			Game:     string(models.GamePokemon),
+			Name:     card.Name,
+			SetCode:  card.SetID,
+			SetName:  s.sets[card.SetID].Name,
+			Number:   card.Number,
+			ImageURL: imageURL,
+		})
+	}
+	return sources
+}
+
+// setIDForCode returns the set ID for a set code that is either a set ID or a PTCGO
+// code (e.g. "SVI" for sv1). Unknown codes are returned unchanged. Callers hold s.mu.
+func (s *PokemonHybridService) setIDForCode(code string) string {
+	lower := strings.ToLower(code)
+	if _, ok := s.setIndex[lower]; ok {
+		return code
+	}
+	if id, ok := s.ptcgoSets[lower]; ok {
+		return id
+	}
+	return code
+}
+
+// GetCardBySetAndNumber finds a specific card by set code (set ID or PTCGO code) and
+// card number
 func (s *PokemonHybridService) GetCardBySetAndNumber(setCode, cardNumber string) *models.Card {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	setCode = s.setIDForCode(setCode)
+
 	// Normalize card number (remove leading zeros)
 	normalizedNum := strings.TrimLeft(cardNumber, "0")
 	if normalizedNum == "" {
@@ -2150,7 +2200,7 @@ func (s *PokemonHybridService) GetSetCards(setCode, nameFilter string) (*models.
 	// Use setIndex for O(1) lookup instead of iterating all cards
 	cardIndices, exists := s.setIndex[setCodeLower]
 	if !exists {
-		return nil, fmt.Errorf("set not found: %s", setCode)
+		return nil, fmt.Errorf("%w: %s", ErrSetNotFound, setCode)
 	}
 
 	var cards []models.Card
@@ -2201,7 +2251,7 @@ func (s *PokemonHybridService) SearchInSet(ctx context.Context, setCode, name st
 	// Check if set exists
 	set, exists := s.sets[setCodeLower]
 	if !exists {
-		return nil, fmt.Errorf("set not found: %s", setCode)
+		return nil, fmt.Errorf("%w: %s", ErrSetNotFound, setCode)
 	}
 
 	var candidates []CandidateCard
@@ -2263,7 +2313,7 @@ func (s *PokemonHybridService) GetSetInfo(ctx context.Context, setCode string) (
 	setCodeLower := strings.ToLower(strings.TrimSpace(setCode))
 	set, exists := s.sets[setCodeLower]
 	if !exists {
-		return nil, fmt.Errorf("set not found: %s", setCode)
+		return nil, fmt.Errorf("%w: %s", ErrSetNotFound, setCode)
 	}
 
 	// Generate a symbol description based on set series and name
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// historyQueryChunk bounds IN (...) lists to stay under SQLite's bound parameter limit
+const historyQueryChunk = 500
+
+// historyPricesSQL selects, for each card/condition/printing/language, the observation
+// closest to a point in time on one side of it: MAX with <= for the latest at or before,
+// MIN with > for the earliest after.
+const historyPricesSQL = `
+	SELECT h.card_id, h.condition, h.printing, h.language, h.price_usd
+	FROM card_price_history h
+	WHERE h.card_id IN ?
+	AND h.recorded_at = (
+		SELECT %s(h2.recorded_at) FROM card_price_history h2
+		WHERE h2.card_id = h.card_id
+		AND h2.condition = h.condition
+		AND h2.printing = h.printing
+		AND h2.language = h.language
+		AND h2.recorded_at %s ?
+	)
+`
+
+// HistoricalPrices returns the recorded prices of cards at a point in time: the latest
+// observation of each condition/printing/language at or before at. Cards first priced
+// after at get their first observations instead. Cards with no history are left out.
+func HistoricalPrices(db *gorm.DB, cardIDs []string, at time.Time) (map[string][]models.CardPrice, error) {
+	prices := make(map[string][]models.CardPrice)
+	for start := 0; start < len(cardIDs); start += historyQueryChunk {
+		chunk := cardIDs[start:min(start+historyQueryChunk, len(cardIDs))]
+
+		var before, after []models.CardPrice
+		if err := db.Raw(fmt.Sprintf(historyPricesSQL, "MAX", "<="), chunk, at).Scan(&before).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Raw(fmt.Sprintf(historyPricesSQL, "MIN", ">"), chunk, at).Scan(&after).Error; err != nil {
+			return nil, err
+		}
+
+		for _, p := range before {
+			prices[p.CardID] = append(prices[p.CardID], p)
+		}
+		firstSeen := make(map[string][]models.CardPrice)
+		for _, p := range after {
+			if _, ok := prices[p.CardID]; !ok {
+				firstSeen[p.CardID] = append(firstSeen[p.CardID], p)
+			}
+		}
+		for id, first := range firstSeen {
+			prices[id] = first
+		}
+	}
+	return prices, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// Price provider names, used in CardPrice.Source and the precedence config
+const (
+	PriceProviderJustTCG  = "justtcg"
+	PriceProviderScryfall = "scryfall"
+)
+
+// PriceProvider is a source of card prices that can be queried in batches
+type PriceProvider interface {
+	// Name identifies the provider in precedence config and CardPrice.Source
+	Name() string
+	// SupportedGames lists the games the provider can price
+	SupportedGames() []models.Game
+	// MaxBatchSize is the most cards one FetchPrices call accepts
+	MaxBatchSize() int
+	// Quota reports the provider's remaining request budget
+	Quota() ProviderQuota
+	// FetchPrices looks up prices for the cards. Cards the provider can't price are
+	// left out of the result rather than failing the batch.
+	FetchPrices(cards []models.Card) (*BatchPriceResult, error)
+}
+
+// ProviderQuota is a provider's request budget. Unlimited providers report Limited=false.
+type ProviderQuota struct {
+	Limited    bool      `json:"limited"`
+	DailyLimit int       `json:"daily_limit,omitempty"`
+	Remaining  int       `json:"remaining,omitempty"`
+	ResetsAt   time.Time `json:"resets_at,omitempty"`
+}
+
+// Exhausted reports whether the provider has no requests left until ResetsAt
+func (q ProviderQuota) Exhausted() bool {
+	return q.Limited && q.Remaining <= 0
+}
+
+// ProviderStatus is a provider's quota and the games it prices, for the price status endpoint
+type ProviderStatus struct {
+	Name  string        `json:"name"`
+	Games []models.Game `json:"games"`
+	ProviderQuota
+}
+
+// PricePolicy is the order providers are tried in for each game. A card that the
+// first provider can't price (or when its quota is exhausted) falls through to the next.
+type PricePolicy map[models.Game][]string
+
+// DefaultPricePolicy prefers JustTCG's condition-level prices and falls back to
+// Scryfall's market prices for MTG
+func DefaultPricePolicy() PricePolicy {
+	return PricePolicy{
+		models.GameMTG:     {PriceProviderJustTCG, PriceProviderScryfall},
+		models.GamePokemon: {PriceProviderJustTCG},
+	}
+}
+
+// NewPricePolicyFromEnv returns the default policy with per-game overrides from
+// PRICE_PROVIDERS_MTG and PRICE_PROVIDERS_POKEMON (comma-separated provider names)
+func NewPricePolicyFromEnv() PricePolicy {
+	policy := DefaultPricePolicy()
+	for game, env := range map[models.Game]string{
+		models.GameMTG:     "PRICE_PROVIDERS_MTG",
+		models.GamePokemon: "PRICE_PROVIDERS_POKEMON",
+	} {
+		if value := os.Getenv(env); value != "" {
+			policy[game] = parseProviderList(value)
+		}
+	}
+	return policy
+}
+
+func parseProviderList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func supportsGame(p PriceProvider, game models.Game) bool {
+	for _, g := range p.SupportedGames() {
+		if g == game {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchFromProviders prices cards by trying each game's providers in policy order.
+// Cards a provider returns no prices for are passed to the next provider. An error is
+// returned only when no card could be priced and at least one provider failed.
+func fetchFromProviders(providers map[string]PriceProvider, policy PricePolicy, cards []models.Card) (*BatchPriceResult, error) {
+	result := &BatchPriceResult{
+		Prices:            make(map[string][]models.CardPrice),
+		DiscoveredTCGPIDs: make(map[string]string),
+	}
+
+	byGame := make(map[models.Game][]models.Card)
+	for _, card := range cards {
+		byGame[card.Game] = append(byGame[card.Game], card)
+	}
+
+	var lastErr error
+	for game, pending := range byGame {
+		for _, name := range policy[game] {
+			if len(pending) == 0 {
+				break
+			}
+			provider, ok := providers[name]
+			if !ok || !supportsGame(provider, game) {
+				continue
+			}
+			if provider.Quota().Exhausted() {
+				log.Printf("Prices: %s quota exhausted, trying next provider for %d %s cards", name, len(pending), game)
+				continue
+			}
+
+			var unpriced []models.Card
+			for start := 0; start < len(pending); start += provider.MaxBatchSize() {
+				chunk := pending[start:min(start+provider.MaxBatchSize(), len(pending))]
+				batch, err := provider.FetchPrices(chunk)
+				if err != nil {
+					log.Printf("Prices: %s failed for %d %s cards: %v", name, len(chunk), game, err)
+					lastErr = fmt.Errorf("%s: %w", name, err)
+					unpriced = append(unpriced, chunk...)
+					continue
+				}
+				for cardID, id := range batch.DiscoveredTCGPIDs {
+					result.DiscoveredTCGPIDs[cardID] = id
+				}
+				for _, card := range chunk {
+					if prices := batch.Prices[card.ID]; len(prices) > 0 {
+						result.Prices[card.ID] = prices
+					} else {
+						unpriced = append(unpriced, card)
+					}
+				}
+			}
+			pending = unpriced
+		}
+	}
+
+	if len(result.Prices) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
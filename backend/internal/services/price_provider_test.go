@@ -0,0 +1,206 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// fakePriceProvider prices the cards in its prices map and records each call
+type fakePriceProvider struct {
+	name      string
+	games     []models.Game
+	batchSize int
+	exhausted bool
+	err       error
+	prices    map[string]float64
+	calls     [][]string
+}
+
+func (p *fakePriceProvider) Name() string                  { return p.name }
+func (p *fakePriceProvider) SupportedGames() []models.Game { return p.games }
+func (p *fakePriceProvider) MaxBatchSize() int             { return p.batchSize }
+
+func (p *fakePriceProvider) Quota() ProviderQuota {
+	if p.exhausted {
+		return ProviderQuota{Limited: true, DailyLimit: 100}
+	}
+	return ProviderQuota{Limited: true, DailyLimit: 100, Remaining: 50}
+}
+
+func (p *fakePriceProvider) FetchPrices(cards []models.Card) (*BatchPriceResult, error) {
+	var ids []string
+	for _, c := range cards {
+		ids = append(ids, c.ID)
+	}
+	p.calls = append(p.calls, ids)
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	result := &BatchPriceResult{Prices: make(map[string][]models.CardPrice)}
+	for _, c := range cards {
+		if price, ok := p.prices[c.ID]; ok {
+			result.Prices[c.ID] = []models.CardPrice{{Condition: models.PriceConditionNM, Printing: models.PrintingNormal, PriceUSD: price, Source: p.name}}
+		}
+	}
+	return result, nil
+}
+
+func TestFetchFromProvidersFallback(t *testing.T) {
+	cards := []models.Card{
+		{ID: "mtg-1", Game: models.GameMTG},
+		{ID: "mtg-2", Game: models.GameMTG},
+		{ID: "mtg-3", Game: models.GameMTG},
+		{ID: "pkm-1", Game: models.GamePokemon},
+	}
+	policy := DefaultPricePolicy()
+
+	tests := []struct {
+		name    string
+		primary fakePriceProvider
+		want    map[string]string // card ID -> source
+		wantErr bool
+	}{
+		{
+			name:    "primary prices everything it can",
+			primary: fakePriceProvider{prices: map[string]float64{"mtg-1": 1, "mtg-2": 2, "pkm-1": 3}},
+			want:    map[string]string{"mtg-1": "justtcg", "mtg-2": "justtcg", "mtg-3": "scryfall", "pkm-1": "justtcg"},
+		},
+		{
+			name:    "quota exhausted falls back for MTG only",
+			primary: fakePriceProvider{exhausted: true, prices: map[string]float64{"mtg-1": 1, "pkm-1": 3}},
+			want:    map[string]string{"mtg-1": "scryfall", "mtg-2": "scryfall", "mtg-3": "scryfall"},
+		},
+		{
+			name:    "errors fall back",
+			primary: fakePriceProvider{err: errors.New("daily rate limit exceeded")},
+			want:    map[string]string{"mtg-1": "scryfall", "mtg-2": "scryfall", "mtg-3": "scryfall"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			justTCG := tt.primary
+			justTCG.name = PriceProviderJustTCG
+			justTCG.games = []models.Game{models.GameMTG, models.GamePokemon}
+			justTCG.batchSize = 100
+			scryfall := &fakePriceProvider{
+				name: PriceProviderScryfall, games: []models.Game{models.GameMTG}, batchSize: 2,
+				prices: map[string]float64{"mtg-1": 10, "mtg-2": 20, "mtg-3": 30, "pkm-1": 40},
+			}
+			providers := map[string]PriceProvider{PriceProviderJustTCG: &justTCG, PriceProviderScryfall: scryfall}
+
+			result, err := fetchFromProviders(providers, policy, cards)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Prices) != len(tt.want) {
+				t.Errorf("priced %d cards, want %d", len(result.Prices), len(tt.want))
+			}
+			for id, source := range tt.want {
+				if prices := result.Prices[id]; len(prices) == 0 || prices[0].Source != source {
+					t.Errorf("%s: got %+v, want source %s", id, prices, source)
+				}
+			}
+			// Scryfall never sees Pokemon cards and gets at most 2 cards per call
+			for _, call := range scryfall.calls {
+				if len(call) > 2 {
+					t.Errorf("scryfall call exceeded batch size: %v", call)
+				}
+				for _, id := range call {
+					if id == "pkm-1" {
+						t.Error("scryfall asked to price a Pokemon card")
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("all providers fail", func(t *testing.T) {
+		failing := &fakePriceProvider{name: PriceProviderJustTCG, games: []models.Game{models.GamePokemon}, batchSize: 10, err: errors.New("down")}
+		_, err := fetchFromProviders(map[string]PriceProvider{PriceProviderJustTCG: failing}, policy, cards[3:])
+		if err == nil {
+			t.Error("expected an error when nothing could be priced")
+		}
+	})
+}
+
+func TestNewPricePolicyFromEnv(t *testing.T) {
+	t.Setenv("PRICE_PROVIDERS_MTG", " Scryfall , justtcg,")
+	policy := NewPricePolicyFromEnv()
+
+	if got := policy[models.GameMTG]; len(got) != 2 || got[0] != PriceProviderScryfall || got[1] != PriceProviderJustTCG {
+		t.Errorf("MTG policy = %v", got)
+	}
+	if got := policy[models.GamePokemon]; len(got) != 1 || got[0] != PriceProviderJustTCG {
+		t.Errorf("Pokemon policy = %v, want default", got)
+	}
+}
+
+func TestPriceServiceCanFetchPrices(t *testing.T) {
+	justTCG := &fakePriceProvider{name: PriceProviderJustTCG, games: []models.Game{models.GameMTG, models.GamePokemon}, batchSize: 100, exhausted: true}
+	scryfall := &fakePriceProvider{name: PriceProviderScryfall, games: []models.Game{models.GameMTG}, batchSize: 75}
+	svc := NewPriceService(nil, nil, justTCG, scryfall)
+	svc.policy = DefaultPricePolicy()
+
+	if !svc.CanFetchPrices(models.GameMTG) {
+		t.Error("MTG should still be priceable through Scryfall")
+	}
+	if svc.CanFetchPrices(models.GamePokemon) {
+		t.Error("Pokemon should not be priceable with JustTCG exhausted")
+	}
+	if statuses := svc.GetProviderStatuses(); len(statuses) != 2 || statuses[0].Name != PriceProviderJustTCG || !statuses[0].Exhausted() {
+		t.Errorf("unexpected provider statuses: %+v", statuses)
+	}
+}
+
+func TestScryfallFetchPrices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/cards/collection" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req scryfallCollectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Identifiers) != 3 {
+			t.Errorf("unexpected body %+v (%v)", req, err)
+		}
+		_, _ = w.Write([]byte(`{"object":"list","not_found":[{"id":"gone"}],"data":[
+			{"id":"bolt","name":"Lightning Bolt","prices":{"usd":"2.50","usd_foil":"11.00"}},
+			{"id":"foil-only","name":"Promo","prices":{"usd":null,"usd_foil":"4.25"}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	svc := NewScryfallService()
+	svc.baseURL = srv.URL
+
+	result, err := svc.FetchPrices([]models.Card{{ID: "bolt"}, {ID: "foil-only"}, {ID: "gone"}})
+	if err != nil {
+		t.Fatalf("FetchPrices() error: %v", err)
+	}
+
+	bolt := result.Prices["bolt"]
+	if len(bolt) != 2 || bolt[0].Printing != models.PrintingNormal || bolt[0].PriceUSD != 2.5 ||
+		bolt[1].Printing != models.PrintingFoil || bolt[1].PriceUSD != 11 {
+		t.Errorf("unexpected bolt prices: %+v", bolt)
+	}
+	for _, p := range bolt {
+		if p.Source != PriceProviderScryfall || p.Condition != models.PriceConditionNM || p.Language != models.LanguageEnglish {
+			t.Errorf("unexpected price metadata: %+v", p)
+		}
+		if p.PriceUpdatedAt == nil || time.Since(*p.PriceUpdatedAt) > time.Minute {
+			t.Errorf("expected fresh PriceUpdatedAt, got %v", p.PriceUpdatedAt)
+		}
+	}
+	if promo := result.Prices["foil-only"]; len(promo) != 1 || promo[0].Printing != models.PrintingFoil {
+		t.Errorf("unexpected foil-only prices: %+v", promo)
+	}
+	if _, ok := result.Prices["gone"]; ok {
+		t.Error("expected no prices for a card Scryfall did not return")
+	}
+}
@@ -15,18 +15,65 @@ const (
 	PriceStalenessThreshold = 24 * time.Hour
 )
 
-// PriceService provides unified price fetching from JustTCG
+// PriceService provides unified price fetching from the configured price providers
 type PriceService struct {
 	justTCG *JustTCGService
 	db      *gorm.DB
+
+	// Price providers by name, tried per game in policy order
+	providers     map[string]PriceProvider
+	providerNames []string
+	policy        PricePolicy
+}
+
+// NewPriceService creates a new price service. JustTCG is the only provider unless
+// others are given; the per-game order comes from NewPricePolicyFromEnv.
+func NewPriceService(justTCG *JustTCGService, db *gorm.DB, providers ...PriceProvider) *PriceService {
+	if len(providers) == 0 && justTCG != nil {
+		providers = []PriceProvider{justTCG}
+	}
+
+	s := &PriceService{
+		justTCG:   justTCG,
+		db:        db,
+		providers: make(map[string]PriceProvider, len(providers)),
+		policy:    NewPricePolicyFromEnv(),
+	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+		s.providerNames = append(s.providerNames, p.Name())
+	}
+	return s
 }
 
-// NewPriceService creates a new price service
-func NewPriceService(justTCG *JustTCGService, db *gorm.DB) *PriceService {
-	return &PriceService{
-		justTCG: justTCG,
-		db:      db,
+// FetchPrices looks up current prices for the cards from the configured providers,
+// falling back through each game's provider order
+func (s *PriceService) FetchPrices(cards []models.Card) (*BatchPriceResult, error) {
+	return fetchFromProviders(s.providers, s.policy, cards)
+}
+
+// CanFetchPrices reports whether any provider for the game is configured and has quota left
+func (s *PriceService) CanFetchPrices(game models.Game) bool {
+	for _, name := range s.policy[game] {
+		if p, ok := s.providers[name]; ok && supportsGame(p, game) && !p.Quota().Exhausted() {
+			return true
+		}
 	}
+	return false
+}
+
+// GetProviderStatuses returns the quota of every configured provider
+func (s *PriceService) GetProviderStatuses() []ProviderStatus {
+	statuses := make([]ProviderStatus, 0, len(s.providerNames))
+	for _, name := range s.providerNames {
+		p := s.providers[name]
+		statuses = append(statuses, ProviderStatus{
+			Name:          name,
+			Games:         p.SupportedGames(),
+			ProviderQuota: p.Quota(),
+		})
+	}
+	return statuses
 }
 
 // GetPrice returns the cached price for a specific card, condition, and printing type
@@ -67,6 +114,31 @@ func (s *PriceService) GetPrice(card *models.Card, condition models.PriceConditi
 	return 0, "", nil
 }
 
+// GetGradedPrices returns all cached graded prices for a card, by company and grade
+func (s *PriceService) GetGradedPrices(cardID string) ([]models.GradedPrice, error) {
+	var prices []models.GradedPrice
+	err := s.db.Where("card_id = ?", cardID).Order("grading_company, grade").Find(&prices).Error
+	return prices, err
+}
+
+// SaveGradedPrice stores the price of a slab (upsert on card, company and grade)
+func (s *PriceService) SaveGradedPrice(price *models.GradedPrice) error {
+	if price.PriceUpdatedAt == nil {
+		now := time.Now()
+		price.PriceUpdatedAt = &now
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "card_id"}, {Name: "grading_company"}, {Name: "grade"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price_usd", "source", "price_updated_at", "updated_at"}),
+	}).Create(price).Error
+}
+
+// DeleteGradedPrice removes the price of a slab
+func (s *PriceService) DeleteGradedPrice(cardID string, company models.GradingCompany, grade string) error {
+	return s.db.Where("card_id = ? AND grading_company = ? AND grade = ?", cardID, company, grade).
+		Delete(&models.GradedPrice{}).Error
+}
+
 // GetAllConditionPrices returns all cached prices for a card (no live API calls)
 // Returns cached prices, stale prices, or base prices from the card record
 // Use NeedsRefresh to check if the card should be queued for background update
@@ -196,6 +268,95 @@ func (s *PriceService) saveCardPrices(cardID string, prices []models.CardPrice)
 	}
 }
 
+// RecordPriceHistory appends a dated observation for each price to card_price_history.
+// Unlike saveCardPrices this never overwrites, so the trend survives each refresh.
+func (s *PriceService) RecordPriceHistory(cardID string, prices []models.CardPrice) {
+	if len(prices) == 0 {
+		return
+	}
+
+	now := time.Now()
+	history := make([]models.CardPriceHistory, 0, len(prices))
+	for _, p := range prices {
+		recordedAt := now
+		if p.PriceUpdatedAt != nil {
+			recordedAt = *p.PriceUpdatedAt
+		}
+		language := p.Language
+		if language == "" {
+			language = models.LanguageEnglish
+		}
+		printing := p.Printing
+		if printing == "" {
+			printing = models.PrintingNormal
+		}
+		history = append(history, models.CardPriceHistory{
+			CardID:     cardID,
+			Condition:  p.Condition,
+			Printing:   printing,
+			Language:   language,
+			PriceUSD:   p.PriceUSD,
+			Source:     p.Source,
+			RecordedAt: recordedAt,
+		})
+	}
+
+	if err := s.db.Create(&history).Error; err != nil {
+		log.Printf("Failed to record price history for card %s: %v", cardID, err)
+	}
+}
+
+// PriceHistoryFilter narrows a price history query. Empty fields match all values.
+type PriceHistoryFilter struct {
+	Condition models.PriceCondition
+	Printing  models.PrintingType
+	Language  models.CardLanguage
+}
+
+// GetPriceHistory returns the recorded price observations for a card within the period,
+// grouped into one series per condition/printing/language and ordered oldest first
+func (s *PriceService) GetPriceHistory(cardID string, filter PriceHistoryFilter, period string) ([]models.PriceHistorySeries, error) {
+	query := s.db.Where("card_id = ?", cardID)
+	if filter.Condition != "" {
+		query = query.Where("condition = ?", filter.Condition)
+	}
+	if filter.Printing != "" {
+		query = query.Where("printing = ?", filter.Printing)
+	}
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+	if startDate := periodStartDate(period, time.Now()); !startDate.IsZero() {
+		query = query.Where("recorded_at >= ?", startDate)
+	}
+
+	var rows []models.CardPriceHistory
+	if err := query.Order("condition, printing, language, recorded_at ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	series := []models.PriceHistorySeries{}
+	for _, row := range rows {
+		n := len(series)
+		if n == 0 || series[n-1].Condition != row.Condition ||
+			series[n-1].Printing != row.Printing || series[n-1].Language != row.Language {
+			series = append(series, models.PriceHistorySeries{
+				Condition: row.Condition,
+				Printing:  row.Printing,
+				Language:  row.Language,
+			})
+			n++
+		}
+		series[n-1].Points = append(series[n-1].Points, models.PriceHistoryPoint{
+			RecordedAt: row.RecordedAt,
+			PriceUSD:   row.PriceUSD,
+			Source:     row.Source,
+		})
+	}
+
+	return series, nil
+}
+
 // isFresh checks if a price update time is within the staleness threshold
 func (s *PriceService) isFresh(updatedAt *time.Time) bool {
 	if updatedAt == nil {
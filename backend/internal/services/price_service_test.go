@@ -3,6 +3,12 @@ package services
 import (
 	"testing"
 	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
 )
 
 func TestIsFresh(t *testing.T) {
@@ -47,3 +53,145 @@ func TestGetJustTCGRequestsRemaining(t *testing.T) {
 		t.Errorf("Expected 100 remaining, got %d", remaining)
 	}
 }
+
+// newTestDB opens a fresh in-memory SQLite database migrated with the given models
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	// Each connection to ":memory:" is a separate database, so pin the pool to one
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func newTestPriceDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return newTestDB(t, &models.CardPrice{}, &models.CardPriceHistory{})
+}
+
+func TestRecordAndGetPriceHistory(t *testing.T) {
+	db := newTestPriceDB(t)
+	svc := NewPriceService(nil, db)
+
+	day1 := time.Now().Add(-48 * time.Hour)
+	day2 := time.Now().Add(-24 * time.Hour)
+	old := time.Now().AddDate(0, -2, 0)
+
+	svc.RecordPriceHistory("card-1", []models.CardPrice{
+		{Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 9, Source: "justtcg", PriceUpdatedAt: &old},
+	})
+	svc.RecordPriceHistory("card-1", []models.CardPrice{
+		{Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 10, Source: "justtcg", PriceUpdatedAt: &day1},
+		{Condition: models.PriceConditionNM, Printing: models.PrintingFoil, PriceUSD: 25, Source: "justtcg", PriceUpdatedAt: &day1},
+	})
+	svc.RecordPriceHistory("card-1", []models.CardPrice{
+		{Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 12, Source: "justtcg", PriceUpdatedAt: &day2},
+	})
+	svc.RecordPriceHistory("card-2", []models.CardPrice{
+		{Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: 99, Source: "justtcg", PriceUpdatedAt: &day2},
+	})
+
+	// Appending never overwrites: all four card-1 observations are kept
+	var count int64
+	db.Model(&models.CardPriceHistory{}).Where("card_id = ?", "card-1").Count(&count)
+	if count != 4 {
+		t.Fatalf("expected 4 history rows for card-1, got %d", count)
+	}
+
+	// Month period excludes the 2-month-old observation and splits by printing
+	series, err := svc.GetPriceHistory("card-1", PriceHistoryFilter{}, "month")
+	if err != nil {
+		t.Fatalf("GetPriceHistory failed: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(series))
+	}
+	for _, s := range series {
+		if s.Language != models.LanguageEnglish {
+			t.Errorf("expected empty language to default to English, got %q", s.Language)
+		}
+		if s.Printing == models.PrintingNormal {
+			if len(s.Points) != 2 {
+				t.Fatalf("expected 2 Normal points, got %d", len(s.Points))
+			}
+			if s.Points[0].PriceUSD != 10 || s.Points[1].PriceUSD != 12 {
+				t.Errorf("expected points ordered oldest first (10, 12), got (%v, %v)", s.Points[0].PriceUSD, s.Points[1].PriceUSD)
+			}
+		}
+	}
+
+	// Filters narrow to a single series; "all" includes the old observation
+	series, err = svc.GetPriceHistory("card-1", PriceHistoryFilter{
+		Condition: models.PriceConditionNM,
+		Printing:  models.PrintingNormal,
+		Language:  models.LanguageEnglish,
+	}, "all")
+	if err != nil {
+		t.Fatalf("GetPriceHistory failed: %v", err)
+	}
+	if len(series) != 1 || len(series[0].Points) != 3 {
+		t.Fatalf("expected 1 series with 3 points, got %+v", series)
+	}
+}
+
+func TestPeriodStartDate(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period   string
+		expected time.Time
+	}{
+		{"week", time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)},
+		{"3month", time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"year", time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)},
+		{"all", time.Time{}},
+		{"bogus", time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			if got := periodStartDate(tt.period, now); !got.Equal(tt.expected) {
+				t.Errorf("periodStartDate(%q) = %v, want %v", tt.period, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveGradedPrice(t *testing.T) {
+	db := newTestDB(t, &models.CardPrice{}, &models.GradedPrice{})
+	svc := NewPriceService(nil, db)
+	card := &models.Card{ID: "pkm-1"}
+
+	old := time.Now().Add(-2 * PriceStalenessThreshold)
+	for _, p := range []models.GradedPrice{
+		{CardID: card.ID, GradingCompany: models.GradingPSA, Grade: "10", PriceUSD: 900, Source: models.GradedPriceSourceManual, PriceUpdatedAt: &old},
+		{CardID: card.ID, GradingCompany: models.GradingBGS, Grade: "9.5", PriceUSD: 400, Source: "market", PriceUpdatedAt: &old},
+	} {
+		if err := svc.SaveGradedPrice(&p); err != nil {
+			t.Fatalf("SaveGradedPrice failed: %v", err)
+		}
+	}
+	// Saving again updates instead of adding a row
+	if err := svc.SaveGradedPrice(&models.GradedPrice{CardID: card.ID, GradingCompany: models.GradingPSA, Grade: "10", PriceUSD: 1000, Source: models.GradedPriceSourceManual}); err != nil {
+		t.Fatalf("SaveGradedPrice failed: %v", err)
+	}
+
+	prices, _ := svc.GetGradedPrices(card.ID)
+	if len(prices) != 2 {
+		t.Fatalf("expected 2 graded prices, got %d", len(prices))
+	}
+	// Ordered by company, so PSA is second
+	if prices[1].PriceUSD != 1000 {
+		t.Errorf("PSA 10 price = %v, want the updated 1000", prices[1].PriceUSD)
+	}
+}
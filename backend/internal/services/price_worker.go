@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"gorm.io/gorm"
+
 	"github.com/codyseavey/tcg-tracker/backend/internal/database"
 	"github.com/codyseavey/tcg-tracker/backend/internal/metrics"
 	"github.com/codyseavey/tcg-tracker/backend/internal/models"
@@ -32,6 +34,7 @@ type PriceWorker struct {
 	priceService   *PriceService
 	justTCG        *JustTCGService
 	pokemonService *PokemonHybridService
+	alerts         *AlertService // Optional; evaluated after each batch
 	updateInterval time.Duration
 	mu             sync.RWMutex
 
@@ -63,15 +66,19 @@ type PriceStatus struct {
 	Remaining  int       `json:"remaining"`
 	ResetsAt   time.Time `json:"resets_at,omitempty"`
 
+	// Quota for every configured price provider
+	Providers []ProviderStatus `json:"providers"`
+
 	// Cards that can't receive price updates (missing TCGPlayerID)
 	UnmatchedCards []UnmatchedCard `json:"unmatched_cards,omitempty"`
 }
 
-func NewPriceWorker(priceService *PriceService, pokemonService *PokemonHybridService, justTCG *JustTCGService) *PriceWorker {
+func NewPriceWorker(priceService *PriceService, pokemonService *PokemonHybridService, justTCG *JustTCGService, alerts *AlertService) *PriceWorker {
 	return &PriceWorker{
 		priceService:   priceService,
 		justTCG:        justTCG,
 		pokemonService: pokemonService,
+		alerts:         alerts,
 		batchSize:      defaultBatchSize,
 		updateInterval: 15 * time.Minute,
 	}
@@ -122,13 +129,16 @@ func (w *PriceWorker) resetDailyStatsIfNeeded() {
 	}
 }
 
-// hasJustTCGQuota checks if we have JustTCG API quota remaining
-// Returns false only if JustTCG is configured and quota is exhausted
-func (w *PriceWorker) hasJustTCGQuota() bool {
-	if w.justTCG == nil {
-		return false // No JustTCG configured
+// priceableGames returns the games that have a price provider with quota remaining.
+// MTG keeps updating through Scryfall when JustTCG's quota is exhausted.
+func (w *PriceWorker) priceableGames() []models.Game {
+	var games []models.Game
+	for _, game := range []models.Game{models.GameMTG, models.GamePokemon} {
+		if w.priceService.CanFetchPrices(game) {
+			games = append(games, game)
+		}
 	}
-	return w.priceService.GetJustTCGRequestsRemaining() > 0
+	return games
 }
 
 // Start begins the background price update worker
@@ -141,6 +151,7 @@ func (w *PriceWorker) Start(ctx context.Context) {
 	} else {
 		log.Printf("Price worker: initial batch updated %d cards", updated)
 	}
+	w.logSealedUpdate()
 
 	ticker := time.NewTicker(w.updateInterval)
 	defer ticker.Stop()
@@ -156,22 +167,24 @@ func (w *PriceWorker) Start(ctx context.Context) {
 			} else if updated > 0 {
 				log.Printf("Price worker: batch updated %d cards", updated)
 			}
+			w.logSealedUpdate()
 		}
 	}
 }
 
 // UpdateBatch updates a batch of cards with priority ordering:
 // 1. User-requested refreshes
-// 2. Collection cards without prices
-// 3. Collection cards with oldest prices
+// 2. Collection and want list cards without prices
+// 3. Collection and want list cards with oldest prices
 func (w *PriceWorker) UpdateBatch() (updated int, err error) {
 	// Reset daily stats at midnight
 	w.resetDailyStatsIfNeeded()
 
-	// Check JustTCG quota - skip batch if exhausted
-	if !w.hasJustTCGQuota() {
+	// Only pick cards from games a provider can still price - skip batch if none
+	games := w.priceableGames()
+	if len(games) == 0 {
 		resetTime := w.priceService.GetJustTCGResetTime()
-		log.Printf("Price worker: JustTCG quota exhausted, skipping until %s", resetTime.Format("15:04"))
+		log.Printf("Price worker: price provider quota exhausted, skipping until %s", resetTime.Format("15:04"))
 		return 0, nil
 	}
 
@@ -193,30 +206,23 @@ func (w *PriceWorker) UpdateBatch() (updated int, err error) {
 	if len(urgentIDs) > 0 {
 		var urgentCards []models.Card
 		db.Where("id IN ?", urgentIDs).Find(&urgentCards)
-		cardsToUpdate = append(cardsToUpdate, urgentCards...)
 		for _, c := range urgentCards {
+			if !containsGame(games, c.Game) {
+				// No provider can price this game right now; keep it queued
+				w.QueueRefresh(c.ID)
+				continue
+			}
+			cardsToUpdate = append(cardsToUpdate, c)
 			cardIDs = append(cardIDs, c.ID)
 		}
-		log.Printf("Price worker: processing %d urgent refresh requests", len(urgentCards))
+		log.Printf("Price worker: processing %d urgent refresh requests", len(cardsToUpdate))
 	}
 
 	remaining := w.batchSize - len(cardsToUpdate)
 
-	// Priority 2: Collection cards without prices
+	// Priority 2: Tracked cards without prices
 	if remaining > 0 {
-		var noPriceCards []models.Card
-		query := `
-			SELECT DISTINCT c.* FROM cards c
-			INNER JOIN collection_items ci ON ci.card_id = c.id
-			LEFT JOIN card_prices cp ON cp.card_id = c.id
-			WHERE cp.id IS NULL
-		`
-		if len(cardIDs) > 0 {
-			db.Raw(query+" AND c.id NOT IN (?) LIMIT ?", cardIDs, remaining).Scan(&noPriceCards)
-		} else {
-			db.Raw(query+" LIMIT ?", remaining).Scan(&noPriceCards)
-		}
-
+		noPriceCards := trackedCardsWithoutPrices(db, games, cardIDs, remaining)
 		cardsToUpdate = append(cardsToUpdate, noPriceCards...)
 		for _, c := range noPriceCards {
 			cardIDs = append(cardIDs, c.ID)
@@ -224,21 +230,9 @@ func (w *PriceWorker) UpdateBatch() (updated int, err error) {
 		remaining -= len(noPriceCards)
 	}
 
-	// Priority 3: Collection cards with oldest prices
+	// Priority 3: Tracked cards with oldest prices
 	if remaining > 0 {
-		var oldestCards []models.Card
-		query := `
-			SELECT DISTINCT c.* FROM cards c
-			INNER JOIN collection_items ci ON ci.card_id = c.id
-		`
-		if len(cardIDs) > 0 {
-			db.Raw(query+" WHERE c.id NOT IN (?) ORDER BY c.price_updated_at ASC NULLS FIRST LIMIT ?",
-				cardIDs, remaining).Scan(&oldestCards)
-		} else {
-			db.Raw(query+" ORDER BY c.price_updated_at ASC NULLS FIRST LIMIT ?",
-				remaining).Scan(&oldestCards)
-		}
-		cardsToUpdate = append(cardsToUpdate, oldestCards...)
+		cardsToUpdate = append(cardsToUpdate, trackedCardsByOldestPrice(db, games, cardIDs, remaining)...)
 	}
 
 	if len(cardsToUpdate) == 0 {
@@ -251,6 +245,84 @@ func (w *PriceWorker) UpdateBatch() (updated int, err error) {
 	return w.batchUpdatePrices(cardsToUpdate)
 }
 
+// UpdateSealedPrices refreshes the JustTCG prices of sealed products in the collection
+// that are missing or stale. A batch costs one request and only runs while quota remains.
+func (w *PriceWorker) UpdateSealedPrices() (int, error) {
+	if w.justTCG == nil || w.justTCG.GetRequestsRemaining() == 0 {
+		return 0, nil
+	}
+
+	db := database.GetDB()
+	products := StaleSealedProducts(db, time.Now(), w.batchSize)
+	if len(products) == 0 {
+		return 0, nil
+	}
+	return RefreshSealedPrices(db, w.justTCG, products)
+}
+
+// logSealedUpdate runs UpdateSealedPrices and logs the outcome
+func (w *PriceWorker) logSealedUpdate() {
+	if updated, err := w.UpdateSealedPrices(); err != nil {
+		log.Printf("Price worker: sealed price update failed: %v", err)
+	} else if updated > 0 {
+		log.Printf("Price worker: updated prices for %d sealed products", updated)
+	}
+}
+
+// trackedCardsCondition matches the cards whose prices are kept fresh: cards in the
+// collection, want list cards by ID, and cached printings of any-printing want list entries
+const trackedCardsCondition = `(
+	c.id IN (SELECT card_id FROM collection_items)
+	OR c.id IN (SELECT card_id FROM want_list_entries WHERE card_id != '')
+	OR EXISTS (
+		SELECT 1 FROM want_list_entries w
+		WHERE w.any_printing AND LOWER(w.card_name) = LOWER(c.name)
+		AND (w.game = '' OR w.game = c.game)
+	)
+)`
+
+// trackedCardsWithoutPrices returns up to limit tracked cards of the given games that
+// have no condition prices yet
+func trackedCardsWithoutPrices(db *gorm.DB, games []models.Game, exclude []string, limit int) []models.Card {
+	var cards []models.Card
+	query := `
+		SELECT c.* FROM cards c
+		WHERE NOT EXISTS (SELECT 1 FROM card_prices cp WHERE cp.card_id = c.id)
+		AND c.game IN (?) AND ` + trackedCardsCondition
+	if len(exclude) > 0 {
+		db.Raw(query+" AND c.id NOT IN (?) LIMIT ?", games, exclude, limit).Scan(&cards)
+	} else {
+		db.Raw(query+" LIMIT ?", games, limit).Scan(&cards)
+	}
+	return cards
+}
+
+// trackedCardsByOldestPrice returns up to limit tracked cards of the given games,
+// least recently priced first
+func trackedCardsByOldestPrice(db *gorm.DB, games []models.Game, exclude []string, limit int) []models.Card {
+	var cards []models.Card
+	query := `
+		SELECT c.* FROM cards c
+		WHERE c.game IN (?) AND ` + trackedCardsCondition
+	if len(exclude) > 0 {
+		db.Raw(query+" AND c.id NOT IN (?) ORDER BY c.price_updated_at ASC NULLS FIRST LIMIT ?",
+			games, exclude, limit).Scan(&cards)
+	} else {
+		db.Raw(query+" ORDER BY c.price_updated_at ASC NULLS FIRST LIMIT ?",
+			games, limit).Scan(&cards)
+	}
+	return cards
+}
+
+func containsGame(games []models.Game, game models.Game) bool {
+	for _, g := range games {
+		if g == game {
+			return true
+		}
+	}
+	return false
+}
+
 // batchUpdatePrices uses the batch API to update all cards at once
 // For Pokemon cards without TCGPlayerIDs, it syncs the set first to discover IDs
 // This ensures all cards can use efficient batch POST (no individual GETs)
@@ -276,7 +348,7 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 	permanentlyFailedSets := make(map[string]string) // setName -> reason
 	var newUnmatchedCards []UnmatchedCard
 
-	if len(setsToSync) > 0 {
+	if len(setsToSync) > 0 && w.justTCG != nil {
 		log.Printf("Price worker: syncing %d sets to discover TCGPlayerIDs", len(setsToSync))
 
 		for setName, cardIndices := range setsToSync {
@@ -376,23 +448,15 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 		}
 	}
 
-	// Build lookup requests (now all Pokemon cards should have TCGPlayerIDs)
-	lookups := make([]CardLookup, 0, len(cards))
+	// Collect the cards to price (now all Pokemon cards should have TCGPlayerIDs)
+	lookupCards := make([]models.Card, 0, len(cards))
 	cardMap := make(map[string]*models.Card)
 
 	for i := range cards {
 		card := &cards[i]
-		gameStr := "pokemon"
-		scryfallID := ""
-		tcgPlayerID := ""
-
-		if card.Game == models.GameMTG {
-			gameStr = "magic-the-gathering"
-			scryfallID = card.ID
-		} else {
-			tcgPlayerID = card.TCGPlayerID
+		if card.Game == models.GamePokemon {
 			// Skip Pokemon cards without TCGPlayerID
-			if tcgPlayerID == "" {
+			if card.TCGPlayerID == "" {
 				// Only mark as permanently unmatched if we actually tried to sync the set
 				// Cards from sets that were skipped due to quota/API errors will retry next batch
 				setName := card.SetName
@@ -413,15 +477,7 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 			}
 		}
 
-		lookups = append(lookups, CardLookup{
-			CardID:      card.ID,
-			ScryfallID:  scryfallID,
-			TCGPlayerID: tcgPlayerID,
-			Name:        card.Name,
-			Set:         card.SetCode,
-			SetName:     card.SetName,
-			Game:        gameStr,
-		})
+		lookupCards = append(lookupCards, *card)
 		cardMap[card.ID] = card
 	}
 
@@ -447,13 +503,13 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 		log.Printf("These cards will not receive price updates until their TCGPlayerID is discovered. Check set mappings in tcgplayer_sync.go")
 	}
 
-	if len(lookups) == 0 {
+	if len(lookupCards) == 0 {
 		log.Printf("Price worker: no cards with valid IDs to update")
 		return 0, nil
 	}
 
-	// Single batch POST request (all cards now have external IDs)
-	result, err := w.justTCG.BatchGetPrices(lookups)
+	// Batch lookup through the price providers (falls back per game, e.g. JustTCG -> Scryfall)
+	result, err := w.priceService.FetchPrices(lookupCards)
 	if err != nil {
 		log.Printf("Price worker: batch request failed: %v", err)
 		return 0, err
@@ -461,6 +517,7 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 
 	// Save results
 	updated := 0
+	updatedIDs := make([]string, 0, len(result.Prices))
 	now := time.Now()
 	for cardID, prices := range result.Prices {
 		if len(prices) == 0 {
@@ -478,6 +535,7 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 			prices[i].CardID = card.ID
 		}
 		w.priceService.SaveCardPrices(card.ID, prices)
+		w.priceService.RecordPriceHistory(card.ID, prices)
 
 		// Update base card prices for backward compatibility
 		for _, p := range prices {
@@ -496,6 +554,7 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 
 		db.Save(card)
 		updated++
+		updatedIDs = append(updatedIDs, card.ID)
 	}
 
 	w.mu.Lock()
@@ -518,6 +577,15 @@ func (w *PriceWorker) batchUpdatePrices(cards []models.Card) (int, error) {
 
 	log.Printf("Price worker: batch updated %d card prices (discovered %d TCGPlayerIDs)",
 		updated, len(result.DiscoveredTCGPIDs))
+
+	// Check price alerts against the new prices
+	if w.alerts != nil && updated > 0 {
+		if fired, err := w.alerts.Evaluate(updatedIDs); err != nil {
+			log.Printf("Price worker: failed to evaluate price alerts: %v", err)
+		} else if fired > 0 {
+			log.Printf("Price worker: fired %d price alerts", fired)
+		}
+	}
 	return updated, nil
 }
 
@@ -535,6 +603,7 @@ func (w *PriceWorker) GetStatus() PriceStatus {
 		DailyLimit:        w.priceService.GetJustTCGDailyLimit(),
 		Remaining:         w.priceService.GetJustTCGRequestsRemaining(),
 		ResetsAt:          w.priceService.GetJustTCGResetTime(),
+		Providers:         w.priceService.GetProviderStatuses(),
 		UnmatchedCards:    w.unmatchedCards,
 	}
 }
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestTrackedCardsIncludeWantList(t *testing.T) {
+	db := newTestDB(t, &models.Card{}, &models.CardPrice{}, &models.CollectionItem{}, &models.WantListEntry{})
+
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now()
+	cards := []models.Card{
+		{ID: "owned", Name: "Bolt", Game: models.GameMTG, PriceUpdatedAt: &recent},
+		{ID: "wanted", Name: "Sol Ring", Game: models.GameMTG, PriceUpdatedAt: &old},
+		{ID: "reprint-a", Name: "Pikachu", Game: models.GamePokemon},
+		{ID: "reprint-b", Name: "PIKACHU", Game: models.GamePokemon},
+		{ID: "other-game", Name: "Pikachu", Game: models.GameMTG},
+		{ID: "untracked", Name: "Mox", Game: models.GameMTG},
+	}
+	for i := range cards {
+		db.Create(&cards[i])
+	}
+	db.Create(&models.CollectionItem{CardID: "owned", Quantity: 1})
+	db.Create(&models.WantListEntry{CardID: "wanted", CardName: "Sol Ring", Game: models.GameMTG})
+	db.Create(&models.WantListEntry{CardName: "pikachu", Game: models.GamePokemon, AnyPrinting: true})
+	db.Create(&models.CardPrice{CardID: "wanted", Condition: models.PriceConditionNM, Printing: models.PrintingNormal, PriceUSD: 1})
+
+	allGames := []models.Game{models.GameMTG, models.GamePokemon}
+	ids := func(cards []models.Card) []string {
+		out := make([]string, len(cards))
+		for i, c := range cards {
+			out[i] = c.ID
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	tests := []struct {
+		name string
+		got  []models.Card
+		want []string
+	}{
+		{"without prices", trackedCardsWithoutPrices(db, allGames, nil, 10), []string{"owned", "reprint-a", "reprint-b"}},
+		{"without prices excluding", trackedCardsWithoutPrices(db, allGames, []string{"owned"}, 10), []string{"reprint-a", "reprint-b"}},
+		{"by oldest price", trackedCardsByOldestPrice(db, allGames, nil, 10), []string{"owned", "reprint-a", "reprint-b", "wanted"}},
+		{"only priceable games", trackedCardsByOldestPrice(db, []models.Game{models.GamePokemon}, nil, 10), []string{"reprint-a", "reprint-b"}},
+		{"oldest first", trackedCardsByOldestPrice(db, allGames, []string{"reprint-a", "reprint-b"}, 1), []string{"wanted"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(tt.got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
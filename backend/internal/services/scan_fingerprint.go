@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// duplicateUploadDistance is the largest fingerprint distance at which two scans of
+// the same card ID are reported as the same photo uploaded twice. It allows for the
+// photo being re-encoded or resized; a new photo, even of the same physical card,
+// is framed and lit differently and lands well above it (30+ in the tests).
+//
+// This detects duplicate uploads, not the same card scanned twice: every copy of a
+// printing shares its artwork, and the hash can't tell copies apart by wear.
+const duplicateUploadDistance = 8
+
+// unfingerprintedScans matches scanned items saved before fingerprints were stored
+const unfingerprintedScans = "scanned_image_path IS NOT NULL AND scanned_image_path != '' AND (scan_fingerprint IS NULL OR scan_fingerprint = '')"
+
+// ScanFingerprint hashes a scanned image as a whole for duplicate upload detection
+func ScanFingerprint(imageBytes []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return ComputeCardHash(img, img.Bounds()).String(), nil
+}
+
+// parseScanFingerprint parses a fingerprint formatted by ScanFingerprint
+func parseScanFingerprint(s string) (CardHash, error) {
+	p, d, ok := strings.Cut(s, ":")
+	if !ok {
+		return CardHash{}, fmt.Errorf("invalid scan fingerprint %q", s)
+	}
+	phash, err := strconv.ParseUint(p, 16, 64)
+	if err != nil {
+		return CardHash{}, fmt.Errorf("invalid scan fingerprint %q: %w", s, err)
+	}
+	dhash, err := strconv.ParseUint(d, 16, 64)
+	if err != nil {
+		return CardHash{}, fmt.Errorf("invalid scan fingerprint %q: %w", s, err)
+	}
+	return CardHash{PHash: phash, DHash: dhash}, nil
+}
+
+// FindDuplicateUploads returns the scanned items of a card whose scan looks like the
+// same photo as the given fingerprint, closest first
+func FindDuplicateUploads(db *gorm.DB, cardID, fingerprint string) ([]models.DuplicateUpload, error) {
+	hash, err := parseScanFingerprint(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.CollectionItem
+	if err := db.Where("card_id = ? AND scan_fingerprint IS NOT NULL AND scan_fingerprint != ''", cardID).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var duplicates []models.DuplicateUpload
+	for _, item := range items {
+		other, err := parseScanFingerprint(item.ScanFingerprint)
+		if err != nil {
+			continue
+		}
+		if d := hash.Distance(other); d <= duplicateUploadDistance {
+			duplicates = append(duplicates, models.DuplicateUpload{ItemID: item.ID, ScannedImagePath: item.ScannedImagePath, Distance: d})
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].Distance < duplicates[j].Distance })
+	return duplicates, nil
+}
+
+// CountUnfingerprintedScans returns the number of scanned items saved before
+// fingerprints were stored, which duplicate upload detection can't see until backfilled
+func CountUnfingerprintedScans(db *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&models.CollectionItem{}).Where(unfingerprintedScans).Count(&count).Error
+	return count, err
+}
+
+// BackfillScanFingerprints fingerprints scanned items saved before fingerprints
+// were stored, reading their images from the scanned images directory. Items
+// whose image is missing or unreadable are skipped.
+func BackfillScanFingerprints(db *gorm.DB, imageDir string) (int, error) {
+	var items []models.CollectionItem
+	if err := db.Where(unfingerprintedScans).Find(&items).Error; err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for _, item := range items {
+		data, err := os.ReadFile(filepath.Join(imageDir, item.ScannedImagePath))
+		if err != nil {
+			log.Printf("Duplicate uploads: cannot read scan of item %d: %v", item.ID, err)
+			continue
+		}
+		fingerprint, err := ScanFingerprint(data)
+		if err != nil {
+			log.Printf("Duplicate uploads: cannot fingerprint item %d: %v", item.ID, err)
+			continue
+		}
+		if err := db.Model(&models.CollectionItem{}).Where("id = ?", item.ID).
+			Update("scan_fingerprint", fingerprint).Error; err != nil {
+			return filled, err
+		}
+		filled++
+	}
+	return filled, nil
+}
+
+// FindDuplicateUploadGroups groups the fingerprinted scans of each card that look
+// like the same photo, linking chains of close scans
+func FindDuplicateUploadGroups(db *gorm.DB) ([]models.DuplicateUploadGroup, error) {
+	var items []models.CollectionItem
+	if err := db.Preload("Card").Where("scan_fingerprint IS NOT NULL AND scan_fingerprint != ''").
+		Order("card_id, id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var groups []models.DuplicateUploadGroup
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && items[end].CardID == items[start].CardID {
+			end++
+		}
+		groups = append(groups, duplicateGroupsOf(items[start:end])...)
+		start = end
+	}
+	return groups, nil
+}
+
+// duplicateGroupsOf clusters the scans of one card
+func duplicateGroupsOf(items []models.CollectionItem) []models.DuplicateUploadGroup {
+	hashes := make([]CardHash, len(items))
+	valid := make([]bool, len(items))
+	for i, item := range items {
+		hash, err := parseScanFingerprint(item.ScanFingerprint)
+		hashes[i], valid[i] = hash, err == nil // Invalid fingerprints match nothing
+	}
+
+	// Union-find over the pairs within the duplicate distance
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if valid[i] && valid[j] && hashes[i].Distance(hashes[j]) <= duplicateUploadDistance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range items {
+		root := find(i)
+		if len(members[root]) == 0 {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	var groups []models.DuplicateUploadGroup
+	for _, root := range roots {
+		if len(members[root]) < 2 {
+			continue
+		}
+		first := members[root][0]
+		group := models.DuplicateUploadGroup{CardID: items[first].CardID, CardName: items[first].Card.Name}
+		for _, i := range members[root] {
+			group.Scans = append(group.Scans, models.DuplicateUpload{
+				ItemID:           items[i].ID,
+				ScannedImagePath: items[i].ScannedImagePath,
+				Distance:         hashes[first].Distance(hashes[i]),
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
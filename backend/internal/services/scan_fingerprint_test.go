@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestScanFingerprint(t *testing.T) {
+	fingerprint := func(data []byte) CardHash {
+		t.Helper()
+		s, err := ScanFingerprint(data)
+		if err != nil {
+			t.Fatalf("ScanFingerprint: %v", err)
+		}
+		hash, err := parseScanFingerprint(s)
+		if err != nil {
+			t.Fatalf("parseScanFingerprint(%q): %v", s, err)
+		}
+		return hash
+	}
+
+	// The same photo uploaded again matches; a new photo (here of another copy of
+	// the same printing) doesn't, nor does a different card
+	for _, c := range testIndexCards {
+		t.Run(c.id, func(t *testing.T) {
+			card := loadTestCardImage(t, c.path)
+			photo := photographCard(t, card)
+			if d := fingerprint(photo).Distance(fingerprint(reupload(t, photo))); d > duplicateUploadDistance {
+				t.Errorf("re-upload distance %d, want <= %d", d, duplicateUploadDistance)
+			}
+			if d := fingerprint(photo).Distance(fingerprint(photographCopy(t, card))); d <= duplicateUploadDistance {
+				t.Errorf("another copy at distance %d, want > %d", d, duplicateUploadDistance)
+			}
+		})
+	}
+	pikachu := fingerprint(photographCard(t, loadTestCardImage(t, "pokemon_cards/pikachu_base1_58.png")))
+	lugia := fingerprint(photographCard(t, loadTestCardImage(t, "pokemon_cards/lugia_neo1_9.png")))
+	if d := pikachu.Distance(lugia); d <= duplicateUploadDistance {
+		t.Errorf("different cards at distance %d", d)
+	}
+
+	if _, err := ScanFingerprint([]byte("not an image")); err == nil {
+		t.Error("expected an error for an undecodable image")
+	}
+	for _, bad := range []string{"", "abc", "zz:00", "00:00,12", "00ff:00ff,ffff:ffff"} {
+		if _, err := parseScanFingerprint(bad); err == nil {
+			t.Errorf("parseScanFingerprint(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestDuplicateUploads(t *testing.T) {
+	db := newTestDB(t, &models.Card{}, &models.CollectionItem{})
+	imageDir := t.TempDir()
+
+	// Older scans were saved without a fingerprint
+	save := func(name string, data []byte) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(imageDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	pikachu := loadTestCardImage(t, "pokemon_cards/pikachu_base1_58.png")
+	pikachuPhoto := photographCard(t, pikachu)
+	lugia := loadTestCardImage(t, "pokemon_cards/lugia_neo1_9.png")
+
+	db.Create(&models.Card{ID: "base1-58", Name: "Pikachu", Game: models.GamePokemon})
+	db.Create(&models.Card{ID: "neo1-9", Name: "Lugia", Game: models.GamePokemon})
+	items := []models.CollectionItem{
+		{CardID: "base1-58", Quantity: 1, ScannedImagePath: save("a.jpg", pikachuPhoto)},
+		{CardID: "base1-58", Quantity: 1, ScannedImagePath: save("b.jpg", reupload(t, pikachuPhoto))},
+		{CardID: "base1-58", Quantity: 1, ScannedImagePath: save("c.jpg", photographCopy(t, pikachu))}, // Another copy
+		{CardID: "base1-58", Quantity: 1, ScannedImagePath: "missing.jpg"},
+		{CardID: "base1-58", Quantity: 3}, // Unscanned stack
+		{CardID: "neo1-9", Quantity: 1, ScannedImagePath: save("d.jpg", photographCard(t, lugia))},
+		// Same photo as a Pikachu scan, but a different card ID
+		{CardID: "neo1-9", Quantity: 1, ScannedImagePath: save("e.jpg", pikachuPhoto)},
+	}
+	for i := range items {
+		db.Create(&items[i])
+	}
+
+	if count, err := CountUnfingerprintedScans(db); err != nil || count != 6 {
+		t.Errorf("CountUnfingerprintedScans = %d, %v; want 6", count, err)
+	}
+	filled, err := BackfillScanFingerprints(db, imageDir)
+	if err != nil || filled != 5 {
+		t.Fatalf("BackfillScanFingerprints = %d, %v; want 5", filled, err)
+	}
+	if filled, _ := BackfillScanFingerprints(db, imageDir); filled != 0 {
+		t.Errorf("expected a second backfill to fill nothing, got %d", filled)
+	}
+
+	groups, err := FindDuplicateUploadGroups(db)
+	if err != nil {
+		t.Fatalf("FindDuplicateUploadGroups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].CardID != "base1-58" || groups[0].CardName != "Pikachu" || len(groups[0].Scans) != 2 {
+		t.Fatalf("expected one group of two Pikachu scans, got %+v", groups)
+	}
+	if groups[0].Scans[0].ItemID != items[0].ID || groups[0].Scans[0].Distance != 0 || groups[0].Scans[1].ItemID != items[1].ID {
+		t.Errorf("unexpected group scans: %+v", groups[0].Scans)
+	}
+
+	// A new upload of the Pikachu photo is checked against the card's scans only
+	fingerprint, _ := ScanFingerprint(pikachuPhoto)
+	duplicates, err := FindDuplicateUploads(db, "base1-58", fingerprint)
+	if err != nil || len(duplicates) != 2 || duplicates[0].ItemID != items[0].ID || duplicates[0].Distance != 0 {
+		t.Errorf("FindDuplicateUploads = %+v, %v", duplicates, err)
+	}
+	// The other copy's photo matches only itself, not the first copy's photos
+	fingerprint, _ = ScanFingerprint(photographCopy(t, pikachu))
+	if duplicates, _ := FindDuplicateUploads(db, "base1-58", fingerprint); len(duplicates) != 1 || duplicates[0].ItemID != items[2].ID {
+		t.Errorf("expected only the other copy's scan, got %+v", duplicates)
+	}
+	if duplicates, _ := FindDuplicateUploads(db, "sv2-61", fingerprint); len(duplicates) != 0 {
+		t.Errorf("expected no duplicates for an unscanned card, got %+v", duplicates)
+	}
+}
+
+// photographCopy simulates a photo of another copy of a card: framed differently
+// on a lighter table than photographCard, and a little darker
+func photographCopy(t *testing.T, card image.Image) []byte {
+	t.Helper()
+	b := card.Bounds()
+	w, h := b.Dx()*8/10, b.Dy()*8/10
+	photo := image.NewRGBA(image.Rect(0, 0, w+160, h+220))
+	draw.Draw(photo, photo.Bounds(), &image.Uniform{color.RGBA{200, 200, 205, 255}}, image.Point{}, draw.Src)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := card.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h).RGBA()
+			photo.Set(40+x, 100+y, color.RGBA{uint8(max(0, int(r>>8)-10)), uint8(max(0, int(g>>8)-10)), uint8(max(0, int(bl>>8)-10)), 255})
+		}
+	}
+	return encodeTestJPEG(t, photo, 80)
+}
+
+// reupload simulates the same photo saved again with heavier compression
+func reupload(t *testing.T, data []byte) []byte {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return encodeTestJPEG(t, img, 50)
+}
+
+func encodeTestJPEG(t *testing.T, img image.Image, quality int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return buf.Bytes()
+}
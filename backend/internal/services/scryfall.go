@@ -9,12 +9,15 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/codyseavey/tcg-tracker/backend/internal/models"
 )
 
@@ -32,12 +35,25 @@ type scryfallSet struct {
 }
 
 type ScryfallService struct {
-	client *http.Client
+	client  *http.Client
+	baseURL string
+
+	// Spaces out price collection and set page requests (Scryfall asks for 50-100ms between requests)
+	priceLimiter *rate.Limiter
 
 	// Cache for sets list (refreshed every 24 hours)
 	setsCacheMu   sync.RWMutex
 	setsCache     []scryfallSet
 	setsCacheTime time.Time
+
+	// Cache for full set card lists by lowercase set code (refreshed every 24 hours)
+	setCardsCacheMu sync.Mutex
+	setCardsCache   map[string]cachedSetCards
+}
+
+type cachedSetCards struct {
+	cards     []models.Card
+	fetchedAt time.Time
 }
 
 // setsCacheTTL is how long the sets cache is valid (24 hours)
@@ -48,6 +64,8 @@ func NewScryfallService() *ScryfallService {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		baseURL:      scryfallBaseURL,
+		priceLimiter: rate.NewLimiter(rate.Every(100*time.Millisecond), 1),
 	}
 }
 
@@ -56,6 +74,7 @@ type scryfallSearchResponse struct {
 	Object     string         `json:"object"`
 	TotalCards int            `json:"total_cards"`
 	HasMore    bool           `json:"has_more"`
+	NextPage   string         `json:"next_page"`
 }
 
 type scryfallCard struct {
@@ -860,6 +879,90 @@ func (s *ScryfallService) GetSetCards(setCode, nameFilter string) (*models.CardS
 	return result, nil
 }
 
+// GetAllSetCards returns every print in a set sorted by collector number, following
+// Scryfall's result pages (GetSetCards only returns the first). Lists are cached for
+// setsCacheTTL, so set completion doesn't refetch each owned set on every request.
+// Returns ErrSetNotFound when Scryfall has no cards for the set code.
+func (s *ScryfallService) GetAllSetCards(ctx context.Context, setCode string) ([]models.Card, error) {
+	key := strings.ToLower(strings.TrimSpace(setCode))
+
+	s.setCardsCacheMu.Lock()
+	cached, ok := s.setCardsCache[key]
+	s.setCardsCacheMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < setsCacheTTL {
+		return slices.Clone(cached.cards), nil
+	}
+
+	var cards []models.Card
+	// Search collapses cards to one print by default; a set list needs every print
+	reqURL := fmt.Sprintf("%s/cards/search?q=%s", s.baseURL, url.QueryEscape("set:"+key+" unique:prints"))
+	for page := 0; reqURL != ""; page++ {
+		if page > 0 {
+			if err := s.priceLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("rate limit wait failed: %w", err)
+			}
+		}
+		searchResp, err := s.fetchSearchPage(ctx, reqURL)
+		if err != nil {
+			return nil, err
+		}
+		if searchResp == nil {
+			return nil, fmt.Errorf("%w: %s", ErrSetNotFound, setCode)
+		}
+		for _, sc := range searchResp.Data {
+			cards = append(cards, s.convertToCard(sc))
+		}
+		reqURL = ""
+		if searchResp.HasMore {
+			reqURL = searchResp.NextPage
+		}
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		numI, _ := strconv.Atoi(strings.TrimLeft(cards[i].CardNumber, "0"))
+		numJ, _ := strconv.Atoi(strings.TrimLeft(cards[j].CardNumber, "0"))
+		return numI < numJ
+	})
+
+	s.setCardsCacheMu.Lock()
+	if s.setCardsCache == nil {
+		s.setCardsCache = make(map[string]cachedSetCards)
+	}
+	s.setCardsCache[key] = cachedSetCards{cards: cards, fetchedAt: time.Now()}
+	s.setCardsCacheMu.Unlock()
+
+	return slices.Clone(cards), nil
+}
+
+// fetchSearchPage fetches one page of search results. A 404 (no matching cards)
+// returns nil without an error.
+func (s *ScryfallService) fetchSearchPage(ctx context.Context, reqURL string) (*scryfallSearchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search scryfall: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scryfall API returned status %d", resp.StatusCode)
+	}
+
+	var searchResp scryfallSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode scryfall response: %w", err)
+	}
+	return &searchResp, nil
+}
+
 // SearchInSet implements CardSearcher interface for Gemini function calling.
 // Searches for cards within a specific MTG set, optionally filtered by name.
 func (s *ScryfallService) SearchInSet(ctx context.Context, setCode, name string, limit int) ([]CandidateCard, error) {
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// scryfallCollectionLimit is the most identifiers /cards/collection accepts per request
+const scryfallCollectionLimit = 75
+
+type scryfallCollectionRequest struct {
+	Identifiers []scryfallIdentifier `json:"identifiers"`
+}
+
+type scryfallIdentifier struct {
+	ID string `json:"id"`
+}
+
+type scryfallCollectionResponse struct {
+	Data []scryfallCard `json:"data"`
+}
+
+// Name implements PriceProvider
+func (s *ScryfallService) Name() string {
+	return PriceProviderScryfall
+}
+
+// SupportedGames implements PriceProvider
+func (s *ScryfallService) SupportedGames() []models.Game {
+	return []models.Game{models.GameMTG}
+}
+
+// MaxBatchSize implements PriceProvider
+func (s *ScryfallService) MaxBatchSize() int {
+	return scryfallCollectionLimit
+}
+
+// Quota implements PriceProvider. Scryfall has no daily quota, only a request rate.
+func (s *ScryfallService) Quota() ProviderQuota {
+	return ProviderQuota{}
+}
+
+// FetchPrices implements PriceProvider using Scryfall's daily market prices. Scryfall only
+// publishes one non-foil and one foil price, so they are stored as Near Mint English prices;
+// other conditions fall back to them.
+func (s *ScryfallService) FetchPrices(cards []models.Card) (*BatchPriceResult, error) {
+	result := &BatchPriceResult{
+		Prices:            make(map[string][]models.CardPrice),
+		DiscoveredTCGPIDs: make(map[string]string),
+	}
+	if len(cards) == 0 {
+		return result, nil
+	}
+	if len(cards) > scryfallCollectionLimit {
+		return nil, fmt.Errorf("batch size %d exceeds max %d", len(cards), scryfallCollectionLimit)
+	}
+
+	body := scryfallCollectionRequest{Identifiers: make([]scryfallIdentifier, 0, len(cards))}
+	for _, card := range cards {
+		body.Identifiers = append(body.Identifiers, scryfallIdentifier{ID: card.ID})
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal collection request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.priceLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit wait failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/cards/collection", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scryfall collection request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scryfall API returned status %d", resp.StatusCode)
+	}
+
+	var collection scryfallCollectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("failed to decode scryfall response: %w", err)
+	}
+
+	now := time.Now()
+	for _, sc := range collection.Data {
+		if prices := scryfallCardPrices(sc.Prices, now); len(prices) > 0 {
+			result.Prices[sc.ID] = prices
+		}
+	}
+	return result, nil
+}
+
+// scryfallCardPrices converts Scryfall's usd/usd_foil prices to Near Mint English CardPrices
+func scryfallCardPrices(p scryfallPrices, updatedAt time.Time) []models.CardPrice {
+	var prices []models.CardPrice
+	for _, v := range []struct {
+		value    string
+		printing models.PrintingType
+	}{
+		{p.USD, models.PrintingNormal},
+		{p.USDFoil, models.PrintingFoil},
+	} {
+		price, err := strconv.ParseFloat(v.value, 64)
+		if err != nil || price <= 0 {
+			continue
+		}
+		prices = append(prices, models.CardPrice{
+			Condition:      models.PriceConditionNM,
+			Printing:       v.printing,
+			Language:       models.LanguageEnglish,
+			PriceUSD:       price,
+			Source:         PriceProviderScryfall,
+			PriceUpdatedAt: &updatedAt,
+		})
+	}
+	return prices
+}
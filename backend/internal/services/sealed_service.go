@@ -0,0 +1,65 @@
+package services
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// sealedPriceMaxAge is how old a JustTCG sealed price may get before the price worker refreshes it.
+// Sealed prices move slowly, so once a day is plenty.
+const sealedPriceMaxAge = 24 * time.Hour
+
+// StaleSealedProducts returns up to limit sealed products in the collection whose
+// JustTCG price is missing or older than sealedPriceMaxAge, least recently looked up
+// first. A product JustTCG had no price for waits sealedPriceMaxAge before it is
+// looked up again, so misses don't crowd out the rest. Manually priced products and
+// products without a TCGPlayerID are skipped.
+func StaleSealedProducts(db *gorm.DB, now time.Time, limit int) []models.SealedProduct {
+	// Products priced before lookups were recorded count from their last price
+	const lastChecked = "COALESCE(price_checked_at, price_updated_at)"
+
+	var products []models.SealedProduct
+	db.Where("id IN (SELECT sealed_product_id FROM sealed_collection_items)").
+		Where("tcg_player_id != '' AND COALESCE(price_source, '') != ?", models.SealedPriceSourceManual).
+		Where(lastChecked+" IS NULL OR "+lastChecked+" < ?", now.Add(-sealedPriceMaxAge)).
+		Order(lastChecked + " IS NOT NULL, " + lastChecked + " ASC").
+		Limit(limit).
+		Find(&products)
+	return products
+}
+
+// RefreshSealedPrices fetches the JustTCG price of each product and saves the ones
+// found. Every product looked up is marked checked, priced or not. It returns the
+// number of products updated.
+func RefreshSealedPrices(db *gorm.DB, justTCG *JustTCGService, products []models.SealedProduct) (int, error) {
+	prices, err := justTCG.FetchSealedPrices(products)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	if err := db.Model(&models.SealedProduct{}).Where("id IN ?", ids).Update("price_checked_at", now).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for id, price := range prices {
+		result := db.Model(&models.SealedProduct{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"price_usd":        price,
+			"price_source":     PriceProviderJustTCG,
+			"price_updated_at": now,
+		})
+		if result.Error != nil {
+			return updated, result.Error
+		}
+		updated += int(result.RowsAffected)
+	}
+	return updated, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
+)
+
+func TestRefreshSealedPrices(t *testing.T) {
+	db := newTestDB(t, &models.SealedProduct{}, &models.SealedCollectionItem{})
+
+	now := time.Now()
+	fresh := now.Add(-time.Hour)
+	old := now.Add(-48 * time.Hour)
+	products := []models.SealedProduct{
+		{Name: "151 Elite Trainer Box", Game: models.GamePokemon, TCGPlayerID: "100", PriceUSD: 50, PriceSource: PriceProviderJustTCG, PriceUpdatedAt: &old},
+		{Name: "151 Booster Bundle", Game: models.GamePokemon, TCGPlayerID: "200"},                                                             // Never priced
+		{Name: "Evolving Skies Booster Box", Game: models.GamePokemon, TCGPlayerID: "300", PriceUpdatedAt: &fresh},                             // Fresh
+		{Name: "Obsidian Flames ETB", Game: models.GamePokemon, TCGPlayerID: "400", PriceSource: models.SealedPriceSourceManual, PriceUSD: 45}, // Manual
+		{Name: "Lost Origin ETB", Game: models.GamePokemon},                                                                                    // No TCGPlayerID
+		{Name: "Paldea Evolved Booster Box", Game: models.GamePokemon, TCGPlayerID: "600"},                                                     // Not in the collection
+	}
+	db.Create(&products)
+	for _, p := range products[:5] {
+		db.Create(&models.SealedCollectionItem{SealedProductID: p.ID, Quantity: 2})
+	}
+
+	stale := StaleSealedProducts(db, now, 10)
+	if len(stale) != 2 || stale[0].TCGPlayerID != "200" || stale[1].TCGPlayerID != "100" {
+		t.Fatalf("expected the unpriced then the old product, got %+v", stale)
+	}
+
+	var requested []batchPostRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&requested)
+		_, _ = w.Write([]byte(`{"data":[
+			{"tcgplayerId":"100","variants":[
+				{"condition":"Sealed","language":"Japanese","price":40},
+				{"condition":"Sealed","language":"English","price":62.5}
+			]},
+			{"tcgplayerId":"200","variants":[{"condition":"Near Mint","price":3}]}
+		],"_metadata":{"apiDailyRequestsRemaining":99}}`))
+	}))
+	defer server.Close()
+
+	justTCG := NewJustTCGService("", 100)
+	justTCG.baseURL = server.URL
+
+	updated, err := RefreshSealedPrices(db, justTCG, stale)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("expected 1 product updated (200 has no sealed variant), got %d", updated)
+	}
+	if len(requested) != 2 || requested[0].Condition != justTCGSealedCondition {
+		t.Errorf("unexpected request body %+v", requested)
+	}
+
+	// The product JustTCG had no price for waits a day like the priced ones
+	if again := StaleSealedProducts(db, now, 10); len(again) != 0 {
+		t.Errorf("expected no products due right after a refresh, got %+v", again)
+	}
+	later := StaleSealedProducts(db, now.Add(sealedPriceMaxAge+time.Hour), 10)
+	if len(later) != 3 || later[0].TCGPlayerID != "300" {
+		t.Errorf("expected the fresh product first a day later, got %+v", later)
+	}
+
+	var etb models.SealedProduct
+	db.First(&etb, products[0].ID)
+	if etb.PriceUSD != 62.5 || etb.PriceUpdatedAt == nil || !etb.PriceUpdatedAt.After(old) {
+		t.Errorf("expected the English sealed price saved, got %+v", etb)
+	}
+
+	totals := valuation.Sealed(db)
+	// 2 x (62.5 + 0 + 0 + 45 + 0)
+	if totals.Items != 10 || !almostEqualFloat(totals.Value, 215) {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// ErrSetNotFound is returned when a card service has no cards for a set code
+var ErrSetNotFound = errors.New("set not found")
+
+// OwnedSetCard is the number of copies of one card printing in the collection
+type OwnedSetCard struct {
+	CardID   string
+	SetCode  string
+	SetName  string
+	Printing models.PrintingType
+	Quantity int
+}
+
+// CollectionSet is a set with at least one card in the collection
+type CollectionSet struct {
+	Game    models.Game
+	SetCode string
+	SetName string
+}
+
+// CollectionSets returns every set the collection has cards from, by game and set code
+func CollectionSets(db *gorm.DB, game string) ([]CollectionSet, error) {
+	var sets []CollectionSet
+	query := db.Table("collection_items").
+		Select("cards.game, cards.set_code, MAX(cards.set_name) as set_name").
+		Joins("JOIN cards ON cards.id = collection_items.card_id").
+		Where("cards.set_code != ''").
+		Group("cards.game, cards.set_code").
+		Order("cards.game, cards.set_code")
+	if game != "" {
+		query = query.Where("cards.game = ?", game)
+	}
+	err := query.Scan(&sets).Error
+	return sets, err
+}
+
+// LoadOwnedSetCards returns the collection's copies of cards in a set, grouped by card and printing
+func LoadOwnedSetCards(db *gorm.DB, game models.Game, setCode string) ([]OwnedSetCard, error) {
+	var owned []OwnedSetCard
+	err := db.Table("collection_items").
+		Select("collection_items.card_id, cards.set_code, cards.set_name, collection_items.printing, SUM(collection_items.quantity) as quantity").
+		Joins("JOIN cards ON cards.id = collection_items.card_id").
+		Where("cards.game = ? AND LOWER(cards.set_code) = ?", game, strings.ToLower(setCode)).
+		Group("collection_items.card_id, cards.set_code, cards.set_name, collection_items.printing").
+		Scan(&owned).Error
+	return owned, err
+}
+
+// AttachCachedPrices loads the cached condition prices of the cards, and fills in base
+// prices for cards whose set list came without them
+func AttachCachedPrices(db *gorm.DB, cards []models.Card) {
+	if len(cards) == 0 {
+		return
+	}
+	ids := make([]string, len(cards))
+	for i, card := range cards {
+		ids[i] = card.ID
+	}
+
+	var prices []models.CardPrice
+	db.Where("card_id IN ?", ids).Find(&prices)
+	byCard := make(map[string][]models.CardPrice)
+	for _, p := range prices {
+		byCard[p.CardID] = append(byCard[p.CardID], p)
+	}
+
+	var cached []models.Card
+	db.Select("id, price_usd, price_foil_usd").Where("id IN ?", ids).Find(&cached)
+	base := make(map[string]models.Card, len(cached))
+	for _, c := range cached {
+		base[c.ID] = c
+	}
+
+	for i := range cards {
+		cards[i].Prices = byCard[cards[i].ID]
+		if b, ok := base[cards[i].ID]; ok {
+			if cards[i].PriceUSD == 0 {
+				cards[i].PriceUSD = b.PriceUSD
+			}
+			if cards[i].PriceFoilUSD == 0 {
+				cards[i].PriceFoilUSD = b.PriceFoilUSD
+			}
+		}
+	}
+}
+
+// ExpectedPrintings returns the printings a collector needs of a card for a master set.
+// Printings seen in cached prices are authoritative. Otherwise MTG uses Scryfall's
+// finishes, and Pokemon guesses from rarity: commons to rares come in normal and reverse
+// holo, holo rares in holo and reverse holo, and higher rarities in holo only.
+func ExpectedPrintings(card models.Card) []models.PrintingType {
+	var printings []models.PrintingType
+	add := func(p models.PrintingType) {
+		if !containsPrinting(printings, p) {
+			printings = append(printings, p)
+		}
+	}
+
+	for _, p := range card.Prices {
+		if p.Language == "" || p.Language == models.LanguageEnglish {
+			add(p.Printing)
+		}
+	}
+	if len(printings) > 0 {
+		return printings
+	}
+
+	if card.Game == models.GameMTG {
+		for _, finish := range card.Finishes {
+			if finish == "nonfoil" {
+				add(models.PrintingNormal)
+			} else {
+				add(models.PrintingFoil) // foil and etched
+			}
+		}
+		if len(printings) == 0 {
+			add(models.PrintingNormal)
+		}
+		return printings
+	}
+
+	switch strings.ToLower(strings.TrimSpace(card.Rarity)) {
+	case "common", "uncommon", "rare":
+		return []models.PrintingType{models.PrintingNormal, models.PrintingReverseHolo}
+	case "rare holo":
+		return []models.PrintingType{models.PrintingFoil, models.PrintingReverseHolo}
+	case "", "promo":
+		return []models.PrintingType{models.PrintingNormal}
+	default:
+		return []models.PrintingType{models.PrintingFoil}
+	}
+}
+
+// BuildSetCompletion compares a set's card list with the owned copies. With
+// countPrintings each expected printing of a card counts on its own. partial marks a
+// card list that is known to be truncated; total is then the set size reported by the
+// source, and owned cards missing from the list still count as owned. Cards must have
+// their cached prices attached for the cost to complete.
+func BuildSetCompletion(game models.Game, setCode string, cards []models.Card, total int, partial bool, owned []OwnedSetCard, countPrintings bool) models.SetMissingResponse {
+	resp := models.SetMissingResponse{
+		SetCompletion: models.SetCompletion{
+			Game:           game,
+			SetCode:        setCode,
+			CountPrintings: countPrintings,
+			Partial:        partial,
+		},
+		Missing: []models.MissingSetCard{},
+	}
+	if len(cards) > 0 {
+		resp.SetName = cards[0].SetName
+	}
+
+	ownedPrintings := make(map[string]map[models.PrintingType]bool)
+	for _, o := range owned {
+		if ownedPrintings[o.CardID] == nil {
+			ownedPrintings[o.CardID] = make(map[models.PrintingType]bool)
+		}
+		ownedPrintings[o.CardID][o.Printing] = true
+		resp.OwnedCopies += o.Quantity
+		if resp.SetName == "" {
+			resp.SetName = o.SetName
+		}
+	}
+
+	addMissing := func(card models.Card, printing models.PrintingType) {
+		price := card.GetPrice(models.PriceConditionNM, printing, models.LanguageEnglish)
+		if price > 0 {
+			resp.CostToComplete += price
+		} else {
+			resp.UnpricedMissing++
+		}
+		card.Prices = nil // PriceUSD has the one price that matters
+		resp.Missing = append(resp.Missing, models.MissingSetCard{Card: card, Printing: printing, PriceUSD: price})
+	}
+
+	listed := make(map[string]bool, len(cards))
+	listedSlots := 0
+	for _, card := range cards {
+		listed[card.ID] = true
+		have := ownedPrintings[card.ID]
+
+		if !countPrintings {
+			listedSlots++
+			if len(have) > 0 {
+				resp.Owned++
+				continue
+			}
+			printing := ExpectedPrintings(card)[0]
+			addMissing(card, printing)
+			continue
+		}
+
+		expected := ExpectedPrintings(card)
+		for p := range have {
+			// A printing we own is part of the set even if we didn't expect it
+			if !containsPrinting(expected, p) {
+				expected = append(expected, p)
+			}
+		}
+		for _, printing := range expected {
+			listedSlots++
+			if have[printing] {
+				resp.Owned++
+			} else {
+				addMissing(card, printing)
+			}
+		}
+	}
+
+	// Owned cards the truncated list didn't include
+	if partial {
+		for cardID, have := range ownedPrintings {
+			if listed[cardID] {
+				continue
+			}
+			if countPrintings {
+				resp.Owned += len(have)
+				listedSlots += len(have)
+			} else {
+				resp.Owned++
+				listedSlots++
+			}
+		}
+	}
+
+	resp.Total = listedSlots
+	if partial && !countPrintings && total > resp.Total {
+		resp.Total = total
+	}
+	if resp.Total > 0 {
+		resp.Percent = float64(resp.Owned) / float64(resp.Total) * 100
+	}
+	return resp
+}
+
+// containsPrinting reports whether printings includes p
+func containsPrinting(printings []models.PrintingType, p models.PrintingType) bool {
+	for _, existing := range printings {
+		if existing == p {
+			return true
+		}
+	}
+	return false
+}
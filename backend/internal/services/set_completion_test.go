@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestBuildSetCompletion(t *testing.T) {
+	db := newTestDB(t, &models.Card{}, &models.CardPrice{})
+	db.Create(&models.Card{ID: "p4", Name: "Mew ex", Game: models.GamePokemon, PriceFoilUSD: 20})
+	db.Create(&models.CardPrice{CardID: "p3", Condition: models.PriceConditionNM, Printing: models.PrintingFoil, Language: models.LanguageEnglish, PriceUSD: 5})
+
+	// Set lists come from the card services without cached prices
+	pokemonSet := func() []models.Card {
+		cards := []models.Card{
+			{ID: "p1", Name: "Bulbasaur", SetName: "151", Game: models.GamePokemon, Rarity: "Common", PriceUSD: 0.25},
+			{ID: "p2", Name: "Ivysaur", SetName: "151", Game: models.GamePokemon, Rarity: "Uncommon"},
+			{ID: "p3", Name: "Venusaur", SetName: "151", Game: models.GamePokemon, Rarity: "Rare Holo"},
+			{ID: "p4", Name: "Mew ex", SetName: "151", Game: models.GamePokemon, Rarity: "Double Rare"},
+		}
+		AttachCachedPrices(db, cards)
+		return cards
+	}
+	pokemonOwned := []OwnedSetCard{
+		{CardID: "p1", Printing: models.PrintingNormal, Quantity: 3},
+		{CardID: "p2", Printing: models.PrintingReverseHolo, Quantity: 1},
+	}
+
+	tests := []struct {
+		name           string
+		game           models.Game
+		cards          []models.Card
+		total          int
+		partial        bool
+		owned          []OwnedSetCard
+		countPrintings bool
+
+		wantOwned, wantTotal, wantUnpriced, wantCopies int
+		wantCost                                       float64
+		wantMissing                                    []string // "cardID printing"
+	}{
+		{
+			name: "cards", game: models.GamePokemon, cards: pokemonSet(), total: 4, owned: pokemonOwned,
+			wantOwned: 2, wantTotal: 4, wantCopies: 4, wantCost: 25,
+			// p3's cached price shows it only comes in holo
+			wantMissing: []string{"p3 Foil", "p4 Foil"},
+		},
+		{
+			name: "printings", game: models.GamePokemon, cards: pokemonSet(), total: 4, owned: pokemonOwned, countPrintings: true,
+			wantOwned: 2, wantTotal: 6, wantUnpriced: 1, wantCopies: 4, wantCost: 25.25,
+			wantMissing: []string{"p1 Reverse Holofoil", "p2 Normal", "p3 Foil", "p4 Foil"},
+		},
+		{
+			name: "unexpected owned printing counts toward the set", game: models.GamePokemon, cards: pokemonSet()[3:], total: 1, countPrintings: true,
+			owned:     []OwnedSetCard{{CardID: "p4", Printing: models.PrintingNormal, Quantity: 1}},
+			wantOwned: 1, wantTotal: 2, wantCopies: 1, wantCost: 20,
+			wantMissing: []string{"p4 Foil"},
+		},
+		{
+			name: "truncated list uses the reported total", game: models.GameMTG, total: 300, partial: true,
+			cards: []models.Card{
+				{ID: "m1", Name: "Bolt", Game: models.GameMTG, Finishes: []string{"nonfoil", "foil"}, PriceUSD: 1},
+				{ID: "m2", Name: "Shock", Game: models.GameMTG, Finishes: []string{"nonfoil"}, PriceUSD: 0.1},
+			},
+			owned: []OwnedSetCard{
+				{CardID: "m1", Printing: models.PrintingFoil, Quantity: 1},
+				{CardID: "m250", Printing: models.PrintingNormal, Quantity: 2}, // Past the first page
+			},
+			wantOwned: 2, wantTotal: 300, wantCopies: 3, wantCost: 0.1,
+			wantMissing: []string{"m2 Normal"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildSetCompletion(tt.game, "set", tt.cards, tt.total, tt.partial, tt.owned, tt.countPrintings)
+			if got.Owned != tt.wantOwned || got.Total != tt.wantTotal || got.OwnedCopies != tt.wantCopies || got.UnpricedMissing != tt.wantUnpriced {
+				t.Errorf("owned %d/%d, copies %d, unpriced %d; want %d/%d, %d, %d",
+					got.Owned, got.Total, got.OwnedCopies, got.UnpricedMissing, tt.wantOwned, tt.wantTotal, tt.wantCopies, tt.wantUnpriced)
+			}
+			if !almostEqualFloat(got.CostToComplete, tt.wantCost) {
+				t.Errorf("cost to complete = %v, want %v", got.CostToComplete, tt.wantCost)
+			}
+			if len(got.Missing) != len(tt.wantMissing) {
+				t.Fatalf("missing %+v, want %v", got.Missing, tt.wantMissing)
+			}
+			for i, m := range got.Missing {
+				if key := m.Card.ID + " " + string(m.Printing); key != tt.wantMissing[i] {
+					t.Errorf("missing[%d] = %s, want %s", i, key, tt.wantMissing[i])
+				}
+			}
+			if got.Partial != tt.partial || !almostEqualFloat(got.Percent, float64(tt.wantOwned)/float64(tt.wantTotal)*100) {
+				t.Errorf("partial = %v, percent = %v", got.Partial, got.Percent)
+			}
+		})
+	}
+}
+
+func TestScryfallGetAllSetCards(t *testing.T) {
+	var requests atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Query().Get("q") {
+		case "set:dom unique:prints":
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = w.Write([]byte(`{"object":"list","total_cards":4,"has_more":false,"data":[
+					{"id":"c3","name":"Llanowar Elves","set":"dom","collector_number":"168"},
+					{"id":"c4","name":"Opt","set":"dom","collector_number":"270"}]}`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"object":"list","total_cards":4,"has_more":true,"next_page":"%s/cards/search?q=set%%3Adom+unique%%3Aprints&page=2","data":[
+				{"id":"c2","name":"Shivan Fire","set":"dom","collector_number":"142"},
+				{"id":"c1","name":"Opt","set":"dom","collector_number":"60"}]}`, srv.URL)
+		case "set:xyz unique:prints":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewScryfallService()
+	svc.baseURL = srv.URL
+
+	cards, err := svc.GetAllSetCards(context.Background(), "DOM")
+	if err != nil {
+		t.Fatalf("GetAllSetCards() error: %v", err)
+	}
+	// Every print is listed, including a second print of the same card
+	if len(cards) != 4 || cards[0].ID != "c1" || cards[1].ID != "c2" || cards[2].ID != "c3" || cards[3].ID != "c4" {
+		t.Errorf("expected all prints from both pages sorted by collector number, got %+v", cards)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 page requests, got %d", requests.Load())
+	}
+
+	// Cached: no more requests, and callers can't modify the cached list
+	cards[0].Name = "changed"
+	again, err := svc.GetAllSetCards(context.Background(), "dom")
+	if err != nil || len(again) != 4 || again[0].Name != "Opt" {
+		t.Errorf("unexpected cached cards %+v (%v)", again, err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected the cached list, got %d requests", requests.Load())
+	}
+
+	if _, err := svc.GetAllSetCards(context.Background(), "xyz"); !errors.Is(err, ErrSetNotFound) {
+		t.Errorf("expected ErrSetNotFound for an unknown set, got %v", err)
+	}
+	if _, err := svc.GetAllSetCards(context.Background(), "down"); err == nil || errors.Is(err, ErrSetNotFound) {
+		t.Errorf("expected an upstream error, got %v", err)
+	}
+}
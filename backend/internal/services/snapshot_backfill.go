@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/database"
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
+)
+
+// ErrBackfillRange is returned when a backfill's start date is after its end date
+var ErrBackfillRange = errors.New("backfill start date is after its end date")
+
+// backfillHolding is a stack of cards that was in the collection from one time until
+// another (zero while still in the collection)
+type backfillHolding struct {
+	item  models.CollectionItem // Card and its prices loaded
+	from  time.Time
+	until time.Time
+}
+
+// held reports whether the holding was in the collection at the end of a day
+func (h backfillHolding) held(endOfDay time.Time) bool {
+	return h.from.Before(endOfDay) && (h.until.IsZero() || !h.until.Before(endOfDay))
+}
+
+// Backfill reconstructs value snapshots for days between from and to (inclusive) that
+// have none, e.g. while the server was down. A day's collection is every item added
+// before its end, plus sold cards that were still held, valued at the price history
+// of that day (see HistoricalPrices; cards without any history use today's prices).
+// Sealed product and graded prices have no history and use today's prices.
+//
+// Backfilled snapshots are marked Derived and get breakdown rows valued the same way,
+// with today's tags and storage locations. Running it again recomputes derived
+// snapshots and their breakdowns and never touches snapshots recorded on the day. A zero from starts at
+// the first item's AddedAt; a zero to (or one from today on) ends yesterday.
+func (s *SnapshotService) Backfill(from, to time.Time) (*models.SnapshotBackfillResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db := database.GetDB()
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	holdings, err := loadBackfillHoldings(db)
+	if err != nil {
+		return nil, err
+	}
+	var sealedItems []models.SealedCollectionItem
+	if err := db.Preload("SealedProduct").Find(&sealedItems).Error; err != nil {
+		return nil, err
+	}
+	tags, err := valuation.LoadItemTags(db)
+	if err != nil {
+		return nil, err
+	}
+
+	explicitFrom := !from.IsZero()
+	if !explicitFrom {
+		for _, h := range holdings {
+			if from.IsZero() || h.from.Before(from) {
+				from = h.from
+			}
+		}
+		for _, item := range sealedItems {
+			if from.IsZero() || item.AddedAt.Before(from) {
+				from = item.AddedAt
+			}
+		}
+	}
+	if to.IsZero() || !to.Before(today) {
+		to = today.AddDate(0, 0, -1)
+	}
+	result := &models.SnapshotBackfillResult{}
+	if from.IsZero() {
+		return result, nil // Nothing was ever added
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, now.Location())
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, now.Location())
+	result.From, result.To = from.Format("2006-01-02"), to.Format("2006-01-02")
+	if from.After(to) {
+		if explicitFrom {
+			return nil, ErrBackfillRange
+		}
+		return result, nil // Collection started today; nothing to backfill
+	}
+
+	var existing []models.CollectionValueSnapshot
+	if err := db.Where("snapshot_date >= ? AND snapshot_date < ?", from, to.AddDate(0, 0, 1)).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]models.CollectionValueSnapshot, len(existing))
+	for _, snap := range existing {
+		byDate[snap.SnapshotDate.In(now.Location()).Format("2006-01-02")] = snap
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		prior, exists := byDate[day.Format("2006-01-02")]
+		if exists && !prior.Derived {
+			result.Recorded++
+			continue
+		}
+
+		snapshot, items, err := backfillSnapshot(db, day, holdings, sealedItems)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot.TotalCards == 0 && snapshot.SealedItems == 0 {
+			result.Empty++
+			continue
+		}
+		sealed := valuation.SealedTotals{Items: snapshot.SealedItems, Value: snapshot.SealedValue}
+		breakdowns, err := CalculateBreakdowns(db, day, items, tags, sealed)
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if exists {
+				if err := tx.Delete(&prior).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(snapshot).Error; err != nil {
+				return err
+			}
+			return replaceBreakdowns(tx, day, breakdowns)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+	}
+
+	log.Printf("Snapshot service: backfilled %s to %s (%d created, %d updated, %d recorded, %d empty)",
+		result.From, result.To, result.Created, result.Updated, result.Recorded, result.Empty)
+	return result, nil
+}
+
+// loadBackfillHoldings returns the collection's items, plus sold cards from the date
+// their stack was added (or purchased, if the stack is gone) until they were sold
+func loadBackfillHoldings(db *gorm.DB) ([]backfillHolding, error) {
+	var items []models.CollectionItem
+	if err := db.Preload("Card.Prices").Preload("Card.GradedPrices").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	holdings := make([]backfillHolding, 0, len(items))
+	addedAt := make(map[uint]time.Time, len(items))
+	cards := make(map[string]models.Card)
+	for _, item := range items {
+		holdings = append(holdings, backfillHolding{item: item, from: item.AddedAt})
+		addedAt[item.ID] = item.AddedAt
+		cards[item.CardID] = item.Card
+	}
+
+	var sales []models.CollectionSale
+	if err := db.Find(&sales).Error; err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, sale := range sales {
+		if _, ok := cards[sale.CardID]; !ok {
+			missing = append(missing, sale.CardID)
+		}
+	}
+	for start := 0; start < len(missing); start += historyQueryChunk {
+		var found []models.Card
+		chunk := missing[start:min(start+historyQueryChunk, len(missing))]
+		if err := db.Preload("Prices").Preload("GradedPrices").Where("id IN ?", chunk).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, card := range found {
+			cards[card.ID] = card
+		}
+	}
+
+	for _, sale := range sales {
+		from, ok := addedAt[sale.CollectionItemID]
+		if !ok {
+			if sale.PurchaseDate == nil {
+				continue // No way to tell when the cards were added
+			}
+			from = *sale.PurchaseDate
+		}
+		card, ok := cards[sale.CardID]
+		if !ok {
+			continue
+		}
+		holdings = append(holdings, backfillHolding{
+			item: models.CollectionItem{
+				CardID:    sale.CardID,
+				Card:      card,
+				Quantity:  sale.Quantity,
+				Condition: sale.Condition,
+				Printing:  sale.Printing,
+				Language:  sale.Language,
+			},
+			from:  from,
+			until: sale.SoldAt,
+		})
+	}
+	return holdings, nil
+}
+
+// backfillSnapshot values the holdings held at the end of day with that day's prices.
+// It also returns the held items with their value that day, for the breakdowns.
+func backfillSnapshot(db *gorm.DB, day time.Time, holdings []backfillHolding, sealedItems []models.SealedCollectionItem) (*models.CollectionValueSnapshot, []models.CollectionItem, error) {
+	endOfDay := day.AddDate(0, 0, 1)
+
+	var held []backfillHolding
+	var cardIDs []string
+	seen := make(map[string]bool)
+	for _, h := range holdings {
+		if !h.held(endOfDay) {
+			continue
+		}
+		held = append(held, h)
+		if !seen[h.item.CardID] {
+			seen[h.item.CardID] = true
+			cardIDs = append(cardIDs, h.item.CardID)
+		}
+	}
+
+	prices, err := HistoricalPrices(db, cardIDs, endOfDay)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot := &models.CollectionValueSnapshot{
+		SnapshotDate: day,
+		UniqueCards:  len(cardIDs),
+		Derived:      true,
+		CreatedAt:    time.Now(),
+	}
+	items := make([]models.CollectionItem, 0, len(held))
+	for _, h := range held {
+		card := h.item.Card
+		if dayPrices, ok := prices[h.item.CardID]; ok {
+			card = historicalCard(card, dayPrices)
+		}
+		unit, _ := valuation.UnitPrice(&h.item, &card)
+		value := unit.Price * float64(h.item.Quantity)
+
+		item := h.item
+		item.Card = card
+		item.ItemValue = value
+		items = append(items, item)
+
+		snapshot.TotalCards += h.item.Quantity
+		switch card.Game {
+		case models.GameMTG:
+			snapshot.MTGCards += h.item.Quantity
+			snapshot.MTGValue += value
+		case models.GamePokemon:
+			snapshot.PokemonCards += h.item.Quantity
+			snapshot.PokemonValue += value
+		}
+	}
+
+	for _, item := range sealedItems {
+		if !item.AddedAt.Before(endOfDay) {
+			continue
+		}
+		snapshot.SealedItems += item.Quantity
+		snapshot.SealedValue += item.SealedProduct.PriceUSD * float64(item.Quantity)
+	}
+
+	snapshot.TotalValue = snapshot.MTGValue + snapshot.PokemonValue + snapshot.SealedValue
+	return snapshot, items, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestSnapshotBackfill(t *testing.T) {
+	db := newTestDB(t,
+		&models.Card{}, &models.CardPrice{}, &models.GradedPrice{}, &models.CardPriceHistory{},
+		&models.CollectionItem{}, &models.CollectionSale{},
+		&models.SealedProduct{}, &models.SealedCollectionItem{},
+		&models.StorageLocation{}, &models.Tag{}, &models.CollectionItemTag{},
+		&models.CollectionValueSnapshot{}, &models.CollectionValueBreakdown{},
+	)
+	useTestDB(t, db)
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	at := func(days, hours int) time.Time {
+		return today.AddDate(0, 0, -days).Add(time.Duration(hours) * time.Hour)
+	}
+	record := func(cardID string, price float64, recordedAt time.Time) {
+		db.Create(&models.CardPriceHistory{CardID: cardID, Condition: models.PriceConditionNM, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PriceUSD: price, RecordedAt: recordedAt})
+	}
+
+	db.Create(&models.Card{ID: "m1", Name: "Bolt", Game: models.GameMTG, PriceUSD: 4})
+	db.Create(&models.Card{ID: "m2", Name: "Sold", Game: models.GameMTG, PriceUSD: 8})
+	db.Create(&models.Card{ID: "p1", Name: "Pikachu", Game: models.GamePokemon, PriceUSD: 10})
+	record("m1", 1, at(10, 0))
+	record("m1", 3, at(3, 12))
+	record("m2", 5, at(20, 0))
+	// p1 has no history and is valued at today's price
+
+	db.Create(&models.CollectionItem{CardID: "m1", Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, AddedAt: at(5, 10)})
+	db.Create(&models.CollectionItem{CardID: "p1", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, AddedAt: at(2, 0)})
+	purchased := at(6, 0)
+	db.Create(&models.CollectionSale{CollectionItemID: 99, CardID: "m2", Game: models.GameMTG, Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, PurchaseDate: &purchased, SoldAt: at(4, 12)})
+	box := models.SealedProduct{Name: "Booster Box", Game: "mtg", ProductType: models.SealedBoosterBox, PriceUSD: 20}
+	db.Create(&box)
+	db.Create(&models.SealedCollectionItem{SealedProductID: box.ID, Quantity: 1, AddedAt: at(1, 1)})
+
+	// Recorded on the day, so never replaced
+	db.Create(&models.CollectionValueSnapshot{SnapshotDate: at(3, 0), TotalValue: 999})
+
+	s := NewSnapshotService()
+	wantValues := map[int]float64{
+		6: 5,      // Only the sold card, bought that day
+		5: 2 + 5,  // Bolt added at its old price
+		4: 2,      // Sold card gone
+		3: 999,    // Recorded
+		2: 6 + 10, // Bolt's new price, Pikachu at today's price
+		1: 6 + 10 + 20,
+	}
+	tests := []struct {
+		name                     string
+		wantCreated, wantUpdated int
+	}{
+		{"first run", 5, 0},
+		{"second run recomputes derived snapshots", 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.Backfill(time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatalf("Backfill: %v", err)
+			}
+			if result.Created != tt.wantCreated || result.Updated != tt.wantUpdated || result.Recorded != 1 {
+				t.Errorf("result = %+v, want %d created, %d updated, 1 recorded", result, tt.wantCreated, tt.wantUpdated)
+			}
+			if result.From != at(6, 0).Format("2006-01-02") || result.To != at(1, 0).Format("2006-01-02") {
+				t.Errorf("range = %s to %s", result.From, result.To)
+			}
+
+			var snapshots []models.CollectionValueSnapshot
+			db.Order("snapshot_date ASC").Find(&snapshots)
+			if len(snapshots) != len(wantValues) {
+				t.Fatalf("got %d snapshots, want %d", len(snapshots), len(wantValues))
+			}
+			for i, snap := range snapshots {
+				daysAgo := 6 - i
+				if !almostEqualFloat(snap.TotalValue, wantValues[daysAgo]) {
+					t.Errorf("%d days ago: total value = %v, want %v", daysAgo, snap.TotalValue, wantValues[daysAgo])
+				}
+				if snap.Derived != (daysAgo != 3) {
+					t.Errorf("%d days ago: derived = %v", daysAgo, snap.Derived)
+				}
+			}
+
+			// Derived days get one set of breakdown rows adding up to their total, with a
+			// sealed row once the box was added; the recorded day is left alone
+			var rows []models.CollectionValueBreakdown
+			db.Where("dimension = ?", models.BreakdownLanguage).Order("snapshot_date ASC").Find(&rows)
+			if len(rows) != 6 {
+				t.Fatalf("got %d language breakdown rows, want one per derived day plus sealed: %+v", len(rows), rows)
+			}
+			sums := make(map[int]float64)
+			for _, row := range rows {
+				daysAgo := int(today.Sub(row.SnapshotDate).Hours()+12) / 24
+				sums[daysAgo] += row.Value
+				if row.Key == models.BreakdownSealedKey && (daysAgo != 1 || !almostEqualFloat(row.Value, 20)) {
+					t.Errorf("%d days ago: unexpected sealed row %+v", daysAgo, row)
+				}
+			}
+			for daysAgo, sum := range sums {
+				if daysAgo == 3 || !almostEqualFloat(sum, wantValues[daysAgo]) {
+					t.Errorf("%d days ago: breakdown sums to %v, want %v", daysAgo, sum, wantValues[daysAgo])
+				}
+			}
+		})
+	}
+
+	if _, err := s.Backfill(at(1, 0), at(3, 0)); !errors.Is(err, ErrBackfillRange) {
+		t.Errorf("expected ErrBackfillRange for a reversed range, got %v", err)
+	}
+}
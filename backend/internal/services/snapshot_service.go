@@ -6,8 +6,11 @@ import (
 	"sync"
 	"time"
 
+	"gorm.io/gorm"
+
 	"github.com/codyseavey/tcg-tracker/backend/internal/database"
 	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
 )
 
 // SnapshotService handles collection value snapshots
@@ -16,6 +19,7 @@ type SnapshotService struct {
 	lastSnapshot  time.Time
 	snapshotHour  int // Hour of day to take snapshot (0-23)
 	checkInterval time.Duration
+	cardResolver  valuation.CardResolver // Cards missing from the cards table; may be nil
 }
 
 // NewSnapshotService creates a new snapshot service
@@ -26,6 +30,13 @@ func NewSnapshotService() *SnapshotService {
 	}
 }
 
+// SetCardResolver sets how to load collection cards that are missing from the cards
+// table, so snapshots value them like the collection endpoints do. Call it before
+// Start.
+func (s *SnapshotService) SetCardResolver(resolve valuation.CardResolver) {
+	s.cardResolver = resolve
+}
+
 // Start begins the background snapshot worker
 func (s *SnapshotService) Start(ctx context.Context) {
 	log.Println("Snapshot service started: will record daily collection value")
@@ -80,7 +91,7 @@ func (s *SnapshotService) hasSnapshotForDate(date time.Time) bool {
 	return count > 0
 }
 
-// TakeSnapshot records the current collection value
+// TakeSnapshot records the current collection value and its breakdowns
 func (s *SnapshotService) TakeSnapshot() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -89,8 +100,18 @@ func (s *SnapshotService) TakeSnapshot() error {
 	now := time.Now()
 	snapshotDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	// Calculate current stats (reusing the same logic as GetStats handler)
-	stats := s.calculateStats()
+	// Value the collection once for both the totals and the breakdowns, the same way
+	// as the GetStats handler
+	items, err := valuation.LoadItems(db, s.cardResolver)
+	if err != nil {
+		return err
+	}
+	tags, err := valuation.LoadItemTags(db)
+	if err != nil {
+		return err
+	}
+	sealed := valuation.Sealed(db)
+	stats := valuation.Summarize(items, tags, sealed).Stats()
 
 	snapshot := models.CollectionValueSnapshot{
 		SnapshotDate: snapshotDate,
@@ -101,6 +122,8 @@ func (s *SnapshotService) TakeSnapshot() error {
 		PokemonCards: stats.PokemonCards,
 		MTGValue:     stats.MTGValue,
 		PokemonValue: stats.PokemonValue,
+		SealedItems:  stats.SealedItems,
+		SealedValue:  stats.SealedValue,
 		CreatedAt:    now,
 	}
 
@@ -114,6 +137,8 @@ func (s *SnapshotService) TakeSnapshot() error {
 			PokemonCards: snapshot.PokemonCards,
 			MTGValue:     snapshot.MTGValue,
 			PokemonValue: snapshot.PokemonValue,
+			SealedItems:  snapshot.SealedItems,
+			SealedValue:  snapshot.SealedValue,
 		}).
 		FirstOrCreate(&snapshot)
 
@@ -121,6 +146,10 @@ func (s *SnapshotService) TakeSnapshot() error {
 		return result.Error
 	}
 
+	if err := recordBreakdowns(db, snapshotDate, items, tags, sealed); err != nil {
+		return err
+	}
+
 	s.lastSnapshot = now
 	log.Printf("Snapshot service: recorded value snapshot for %s (total: $%.2f, cards: %d)",
 		snapshotDate.Format("2006-01-02"), stats.TotalValue, stats.TotalCards)
@@ -128,92 +157,49 @@ func (s *SnapshotService) TakeSnapshot() error {
 	return nil
 }
 
-// calculateStats computes current collection statistics
-func (s *SnapshotService) calculateStats() models.CollectionStats {
-	db := database.GetDB()
-	var stats models.CollectionStats
-
-	// Total and unique cards
-	db.Model(&models.CollectionItem{}).Select("COALESCE(SUM(quantity), 0)").Scan(&stats.TotalCards)
-	var uniqueCount int64
-	db.Model(&models.CollectionItem{}).Distinct("card_id").Count(&uniqueCount)
-	stats.UniqueCards = int(uniqueCount)
-
-	// Calculate values and counts by game using condition-appropriate prices
-	type gameStats struct {
-		Game       string
-		Count      int
-		TotalValue float64
+// recordBreakdowns replaces the breakdown rows of a snapshot date with the current ones
+func recordBreakdowns(db *gorm.DB, snapshotDate time.Time, items []models.CollectionItem, tags []valuation.ItemTag, sealed valuation.SealedTotals) error {
+	breakdowns, err := CalculateBreakdowns(db, snapshotDate, items, tags, sealed)
+	if err != nil {
+		return err
 	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceBreakdowns(tx, snapshotDate, breakdowns)
+	})
+}
 
-	var gameResults []gameStats
-	db.Table("collection_items").
-		Select(`
-			cards.game,
-			SUM(collection_items.quantity) as count,
-			SUM(
-				COALESCE(
-					(SELECT cp.price_usd FROM card_prices cp
-					 WHERE cp.card_id = cards.id
-					 AND cp.condition = (
-						CASE collection_items.condition
-							WHEN 'M' THEN 'NM'
-							WHEN 'NM' THEN 'NM'
-							WHEN 'EX' THEN 'LP'
-							WHEN 'LP' THEN 'LP'
-							WHEN 'GD' THEN 'MP'
-							WHEN 'PL' THEN 'HP'
-							WHEN 'PR' THEN 'DMG'
-							ELSE 'NM'
-						END
-					 )
-					 AND cp.printing = collection_items.printing
-					 AND cp.language = COALESCE(NULLIF(collection_items.language, ''), 'English')
-					 LIMIT 1),
-					(SELECT cp.price_usd FROM card_prices cp
-					 WHERE cp.card_id = cards.id
-					 AND cp.condition = (
-						CASE collection_items.condition
-							WHEN 'M' THEN 'NM'
-							WHEN 'NM' THEN 'NM'
-							WHEN 'EX' THEN 'LP'
-							WHEN 'LP' THEN 'LP'
-							WHEN 'GD' THEN 'MP'
-							WHEN 'PL' THEN 'HP'
-							WHEN 'PR' THEN 'DMG'
-							ELSE 'NM'
-						END
-					 )
-					 AND cp.printing = collection_items.printing
-					 AND cp.language = 'English'
-					 AND COALESCE(NULLIF(collection_items.language, ''), 'English') != 'English'
-					 LIMIT 1),
-					CASE
-						WHEN collection_items.printing IN ('Foil', '1st Edition', 'Reverse Holofoil')
-						THEN cards.price_foil_usd
-						ELSE cards.price_usd
-					END
-				) * collection_items.quantity
-			) as total_value
-		`).
-		Joins("JOIN cards ON cards.id = collection_items.card_id").
-		Group("cards.game").
-		Scan(&gameResults)
-
-	for _, gr := range gameResults {
-		switch gr.Game {
-		case "mtg":
-			stats.MTGCards = gr.Count
-			stats.MTGValue = gr.TotalValue
-		case "pokemon":
-			stats.PokemonCards = gr.Count
-			stats.PokemonValue = gr.TotalValue
-		}
+// replaceBreakdowns deletes the breakdown rows of a snapshot date and stores breakdowns
+func replaceBreakdowns(tx *gorm.DB, snapshotDate time.Time, breakdowns []models.CollectionValueBreakdown) error {
+	if err := tx.Where("DATE(snapshot_date) = DATE(?)", snapshotDate).Delete(&models.CollectionValueBreakdown{}).Error; err != nil {
+		return err
+	}
+	if len(breakdowns) == 0 {
+		return nil
 	}
+	return tx.CreateInBatches(breakdowns, 100).Error
+}
 
-	stats.TotalValue = stats.MTGValue + stats.PokemonValue
+// calculateStats computes current collection statistics.
+// Sold cards are moved out of collection_items into collection_sales, so they are not counted.
+func (s *SnapshotService) calculateStats() models.CollectionStats {
+	return s.StatsFrom(database.GetDB())
+}
 
-	return stats
+// CurrentStats returns the collection statistics a snapshot taken now would record
+func (s *SnapshotService) CurrentStats() models.CollectionStats {
+	return s.calculateStats()
+}
+
+// StatsFrom computes the collection statistics from db, which may be a
+// transaction so the stats describe the same rows as other reads in it.
+// Tags are not loaded, so the stats have no per-tag values (ByTag).
+func (s *SnapshotService) StatsFrom(db *gorm.DB) models.CollectionStats {
+	items, err := valuation.LoadItems(db, s.cardResolver)
+	if err != nil {
+		log.Printf("Snapshot service: failed to value collection: %v", err)
+		return models.CollectionStats{}
+	}
+	return valuation.Summarize(items, nil, valuation.Sealed(db)).Stats()
 }
 
 // GetHistory retrieves value snapshots for a given period
@@ -221,23 +207,7 @@ func (s *SnapshotService) GetHistory(period string) ([]models.CollectionValueSna
 	db := database.GetDB()
 	var snapshots []models.CollectionValueSnapshot
 
-	now := time.Now()
-	var startDate time.Time
-
-	switch period {
-	case "week":
-		startDate = now.AddDate(0, 0, -7)
-	case "month":
-		startDate = now.AddDate(0, -1, 0)
-	case "3month":
-		startDate = now.AddDate(0, -3, 0)
-	case "year":
-		startDate = now.AddDate(-1, 0, 0)
-	case "all":
-		startDate = time.Time{} // No filter
-	default:
-		startDate = now.AddDate(0, -1, 0) // Default to 1 month
-	}
+	startDate := periodStartDate(period, time.Now())
 
 	query := db.Order("snapshot_date ASC")
 	if !startDate.IsZero() {
@@ -251,6 +221,44 @@ func (s *SnapshotService) GetHistory(period string) ([]models.CollectionValueSna
 	return snapshots, nil
 }
 
+// GetBreakdownHistory retrieves the breakdown rows of one dimension for a given period
+func (s *SnapshotService) GetBreakdownHistory(dimension, period string) ([]models.CollectionValueBreakdown, error) {
+	db := database.GetDB()
+	var rows []models.CollectionValueBreakdown
+
+	startDate := periodStartDate(period, time.Now())
+
+	query := db.Where("dimension = ?", dimension).Order("snapshot_date ASC, key ASC")
+	if !startDate.IsZero() {
+		query = query.Where("snapshot_date >= ?", startDate)
+	}
+
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// periodStartDate converts a history period ("week", "month", "3month", "year", "all")
+// into the earliest date to include. A zero time means no lower bound.
+func periodStartDate(period string, now time.Time) time.Time {
+	switch period {
+	case "week":
+		return now.AddDate(0, 0, -7)
+	case "month":
+		return now.AddDate(0, -1, 0)
+	case "3month":
+		return now.AddDate(0, -3, 0)
+	case "year":
+		return now.AddDate(-1, 0, 0)
+	case "all":
+		return time.Time{} // No filter
+	default:
+		return now.AddDate(0, -1, 0) // Default to 1 month
+	}
+}
+
 // GetLastSnapshot returns the most recent snapshot
 func (s *SnapshotService) GetLastSnapshot() *models.CollectionValueSnapshot {
 	db := database.GetDB()
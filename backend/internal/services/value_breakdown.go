@@ -0,0 +1,190 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+	"github.com/codyseavey/tcg-tracker/backend/internal/valuation"
+)
+
+// CalculateBreakdowns returns the value of valued collection items (see
+// valuation.LoadItems) per set, rarity, language, top-level storage location and tag.
+// Sealed product gets its own row in every dimension but tag, so those dimensions add
+// up to the snapshot's total value. Every row carries snapshotDate.
+func CalculateBreakdowns(db *gorm.DB, snapshotDate time.Time, items []models.CollectionItem, tags []valuation.ItemTag, sealed valuation.SealedTotals) ([]models.CollectionValueBreakdown, error) {
+	var locations []models.StorageLocation
+	if err := db.Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	roots := topLevelLocations(locations)
+
+	tagsByItem := make(map[uint][]valuation.ItemTag)
+	for _, t := range tags {
+		tagsByItem[t.CollectionItemID] = append(tagsByItem[t.CollectionItemID], t)
+	}
+
+	rows := make(map[string]*models.CollectionValueBreakdown)
+	addValue := func(dimension, key, label string, cards int, value float64) {
+		id := dimension + "\x00" + key
+		row, ok := rows[id]
+		if !ok {
+			row = &models.CollectionValueBreakdown{
+				SnapshotDate: snapshotDate,
+				Dimension:    dimension,
+				Key:          key,
+				Label:        label,
+			}
+			rows[id] = row
+		}
+		row.Cards += cards
+		row.Value += value
+	}
+	add := func(dimension, key, label string, item *models.CollectionItem) {
+		addValue(dimension, key, label, item.Quantity, item.ItemValue)
+	}
+
+	for i := range items {
+		item := &items[i]
+		card := item.Card
+
+		// Set codes and rarities are only unique within a game
+		setKey, setLabel := "", "Unknown set"
+		if card.SetCode != "" {
+			setKey = string(card.Game) + "/" + strings.ToLower(card.SetCode)
+			setLabel = card.SetName
+			if setLabel == "" {
+				setLabel = card.SetCode
+			}
+		}
+		add(models.BreakdownSet, setKey, setLabel, item)
+
+		rarityKey, rarityLabel := "", "Unknown rarity"
+		if card.Rarity != "" {
+			rarityKey = string(card.Game) + "/" + strings.ToLower(card.Rarity)
+			rarityLabel = card.Rarity
+		}
+		add(models.BreakdownRarity, rarityKey, rarityLabel, item)
+
+		language := string(item.Language)
+		if language == "" {
+			language = string(models.LanguageEnglish)
+		}
+		add(models.BreakdownLanguage, language, language, item)
+
+		locationKey, locationLabel := "", "Unassigned"
+		if item.StorageLocationID != nil {
+			if root, ok := roots[*item.StorageLocationID]; ok {
+				locationKey, locationLabel = strconv.FormatUint(uint64(root.ID), 10), root.Name
+			}
+		}
+		add(models.BreakdownLocation, locationKey, locationLabel, item)
+
+		for _, tag := range tagsByItem[item.ID] {
+			add(models.BreakdownTag, strconv.FormatUint(uint64(tag.TagID), 10), tag.Name, item)
+		}
+	}
+
+	// Sealed items are not tagged, so they have no tag row
+	if sealed.Items > 0 {
+		for _, dimension := range []string{models.BreakdownSet, models.BreakdownRarity, models.BreakdownLanguage, models.BreakdownLocation} {
+			addValue(dimension, models.BreakdownSealedKey, "Sealed product", sealed.Items, sealed.Value)
+		}
+	}
+
+	dimensionOrder := make(map[string]int)
+	for i, d := range models.AllBreakdownDimensions() {
+		dimensionOrder[d] = i
+	}
+	result := make([]models.CollectionValueBreakdown, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Dimension != result[j].Dimension {
+			return dimensionOrder[result[i].Dimension] < dimensionOrder[result[j].Dimension]
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// topLevelLocations maps each storage location ID to the top-level location containing it
+func topLevelLocations(locations []models.StorageLocation) map[uint]models.StorageLocation {
+	byID := make(map[uint]models.StorageLocation, len(locations))
+	for _, loc := range locations {
+		byID[loc.ID] = loc
+	}
+	roots := make(map[uint]models.StorageLocation, len(locations))
+	for _, loc := range locations {
+		root := loc
+		// Bounded walk in case of a parent cycle
+		for i := 0; i < len(locations) && root.ParentID != nil; i++ {
+			parent, ok := byID[*root.ParentID]
+			if !ok {
+				break
+			}
+			root = parent
+		}
+		roots[loc.ID] = root
+	}
+	return roots
+}
+
+// BuildBreakdownSeries turns breakdown rows into one series per key, largest change
+// between the first and last snapshot dates first
+func BuildBreakdownSeries(rows []models.CollectionValueBreakdown, first, last time.Time) []models.ValueBreakdownSeries {
+	byKey := make(map[string]*models.ValueBreakdownSeries)
+	var order []string
+	for _, row := range rows {
+		series, ok := byKey[row.Key]
+		if !ok {
+			series = &models.ValueBreakdownSeries{Key: row.Key, Points: []models.ValueBreakdownPoint{}}
+			byKey[row.Key] = series
+			order = append(order, row.Key)
+		}
+		series.Label = row.Label // Latest label wins, e.g. after a location rename
+		series.Points = append(series.Points, models.ValueBreakdownPoint{
+			SnapshotDate: row.SnapshotDate,
+			Cards:        row.Cards,
+			Value:        row.Value,
+		})
+	}
+
+	result := make([]models.ValueBreakdownSeries, 0, len(order))
+	for _, key := range order {
+		series := byKey[key]
+		sort.SliceStable(series.Points, func(i, j int) bool {
+			return series.Points[i].SnapshotDate.Before(series.Points[j].SnapshotDate)
+		})
+		series.Change = BreakdownValueAt(series.Points, last, false) - BreakdownValueAt(series.Points, first, false)
+		result = append(result, *series)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ci, cj := math.Abs(result[i].Change), math.Abs(result[j].Change)
+		if ci != cj {
+			return ci > cj
+		}
+		return strings.ToLower(result[i].Label) < strings.ToLower(result[j].Label)
+	})
+	return result
+}
+
+// BreakdownValueAt returns a series' value (or display value) on a snapshot date, 0 if
+// the series has no point on it
+func BreakdownValueAt(points []models.ValueBreakdownPoint, date time.Time, display bool) float64 {
+	for _, p := range points {
+		if p.SnapshotDate.Equal(date) {
+			if display {
+				return p.DisplayValue
+			}
+			return p.Value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func TestSnapshotBreakdowns(t *testing.T) {
+	db := newTestDB(t,
+		&models.Card{}, &models.CardPrice{}, &models.GradedPrice{}, &models.CollectionItem{},
+		&models.StorageLocation{}, &models.Tag{}, &models.CollectionItemTag{},
+		&models.SealedProduct{}, &models.SealedCollectionItem{},
+		&models.CollectionValueSnapshot{}, &models.CollectionValueBreakdown{},
+	)
+	useTestDB(t, db)
+
+	binder := models.StorageLocation{Name: "Binder A", Type: models.StorageLocationBinder}
+	db.Create(&binder)
+	page := models.StorageLocation{Name: "Page 3", Type: models.StorageLocationPage, ParentID: &binder.ID}
+	db.Create(&page)
+	trade := models.Tag{Name: "for trade"}
+	db.Create(&trade)
+
+	db.Create(&models.Card{ID: "m1", Name: "Bolt", Game: models.GameMTG, SetCode: "MH3", SetName: "Modern Horizons 3", Rarity: "uncommon", PriceUSD: 2})
+	db.Create(&models.Card{ID: "m2", Name: "Ragavan", Game: models.GameMTG, SetCode: "MH3", SetName: "Modern Horizons 3", Rarity: "mythic", PriceUSD: 50})
+	db.Create(&models.Card{ID: "p1", Name: "Pikachu", Game: models.GamePokemon, SetCode: "sv1", SetName: "Scarlet & Violet", Rarity: "Common", PriceUSD: 1})
+
+	items := []models.CollectionItem{
+		{CardID: "m1", Quantity: 4, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish, StorageLocationID: &page.ID},
+		{CardID: "m2", Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+		{CardID: "p1", Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, Language: models.LanguageJapanese, StorageLocationID: &binder.ID},
+	}
+	db.Create(&items)
+	db.Create(&models.CollectionItemTag{CollectionItemID: items[1].ID, TagID: trade.ID})
+	box := models.SealedProduct{Name: "MH3 Booster Box", Game: "mtg", ProductType: models.SealedBoosterBox, PriceUSD: 250}
+	db.Create(&box)
+	db.Create(&models.SealedCollectionItem{SealedProductID: box.ID, Quantity: 2})
+
+	s := NewSnapshotService()
+	// Taking the day's snapshot again replaces its breakdown rows
+	for i := 0; i < 2; i++ {
+		if err := s.TakeSnapshot(); err != nil {
+			t.Fatalf("TakeSnapshot: %v", err)
+		}
+	}
+
+	var rows []models.CollectionValueBreakdown
+	db.Find(&rows)
+	got := make(map[string]models.CollectionValueBreakdown)
+	for _, r := range rows {
+		got[r.Dimension+" "+r.Key] = r
+	}
+
+	tests := []struct {
+		key   string
+		label string
+		cards int
+		value float64
+	}{
+		{"set mtg/mh3", "Modern Horizons 3", 5, 58},
+		{"set pokemon/sv1", "Scarlet & Violet", 3, 3},
+		{"rarity mtg/mythic", "mythic", 1, 50},
+		{"rarity pokemon/common", "Common", 3, 3},
+		{"language English", "English", 5, 58},
+		{"language Japanese", "Japanese", 3, 3},
+		{"location " + strconv.FormatUint(uint64(binder.ID), 10), "Binder A", 7, 11}, // Page 3 rolls up into its binder
+		{"location ", "Unassigned", 1, 50},
+		{"tag " + strconv.FormatUint(uint64(trade.ID), 10), "for trade", 1, 50},
+		{"set sealed", "Sealed product", 2, 500},
+		{"location sealed", "Sealed product", 2, 500},
+	}
+	for _, tt := range tests {
+		r, ok := got[tt.key]
+		if !ok {
+			t.Errorf("missing breakdown %q", tt.key)
+			continue
+		}
+		if r.Label != tt.label || r.Cards != tt.cards || !almostEqualFloat(r.Value, tt.value) {
+			t.Errorf("%s = %q %d cards $%.2f, want %q %d cards $%.2f", tt.key, r.Label, r.Cards, r.Value, tt.label, tt.cards, tt.value)
+		}
+	}
+	if len(rows) != 14 { // 2 sets, 3 rarities, 2 languages, 2 locations, 1 tag, sealed in all but tag
+		t.Errorf("got %d breakdown rows, want 14", len(rows))
+	}
+
+	// Each dimension except tags adds up to the snapshot's total value
+	snapshot := s.GetLastSnapshot()
+	for _, dimension := range []string{models.BreakdownSet, models.BreakdownRarity, models.BreakdownLanguage, models.BreakdownLocation} {
+		var sum float64
+		for _, r := range rows {
+			if r.Dimension == dimension {
+				sum += r.Value
+			}
+		}
+		if !almostEqualFloat(sum, snapshot.TotalValue) {
+			t.Errorf("%s breakdown sums to %v, snapshot has %v", dimension, sum, snapshot.TotalValue)
+		}
+	}
+}
+
+func TestBuildBreakdownSeries(t *testing.T) {
+	jan1, jan2, jan3 := day("2026-01-01"), day("2026-01-02"), day("2026-01-03")
+	rows := []models.CollectionValueBreakdown{
+		{SnapshotDate: jan1, Key: "mtg/mh3", Label: "MH3", Value: 100},
+		{SnapshotDate: jan1, Key: "pokemon/sv1", Label: "Scarlet & Violet", Value: 10},
+		{SnapshotDate: jan2, Key: "mtg/mh3", Label: "MH3", Value: 90},
+		{SnapshotDate: jan2, Key: "pokemon/sv1", Label: "Scarlet & Violet", Value: 30},
+		{SnapshotDate: jan3, Key: "mtg/mh3", Label: "Modern Horizons 3", Value: 95},
+		{SnapshotDate: jan3, Key: "pokemon/sv2", Label: "Paldea Evolved", Value: 2}, // New set, sv1 sold
+	}
+
+	series := BuildBreakdownSeries(rows, jan1, jan3)
+
+	want := []struct {
+		key    string
+		label  string
+		change float64
+		points int
+	}{
+		{"pokemon/sv1", "Scarlet & Violet", -10, 2},
+		{"mtg/mh3", "Modern Horizons 3", -5, 3},
+		{"pokemon/sv2", "Paldea Evolved", 2, 1},
+	}
+	if len(series) != len(want) {
+		t.Fatalf("got %d series, want %d", len(series), len(want))
+	}
+	for i, w := range want {
+		s := series[i]
+		if s.Key != w.key || s.Label != w.label || !almostEqualFloat(s.Change, w.change) || len(s.Points) != w.points {
+			t.Errorf("series[%d] = %s %q change %v with %d points, want %s %q change %v with %d points",
+				i, s.Key, s.Label, s.Change, len(s.Points), w.key, w.label, w.change, w.points)
+		}
+	}
+}
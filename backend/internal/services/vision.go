@@ -0,0 +1,828 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	maxTurns              = 15  // Max conversation turns before giving up
+	maxTurnsThorough      = 25  // More turns for difficult cards
+	minConfidenceThorough = 0.8 // Retry if confidence below this
+)
+
+// VisionIdentifier identifies a trading card from a photo with a vision model that
+// calls the card search tools. Implemented by GeminiService and OpenAIVisionService.
+type VisionIdentifier interface {
+	// Name returns the provider name for logs and error messages
+	Name() string
+	// IsEnabled returns whether the provider is configured
+	IsEnabled() bool
+	// IdentifyCard identifies a card in standard mode, optimized for speed (real-time scanning)
+	IdentifyCard(ctx context.Context, imageBytes []byte, pokemonSearcher, mtgSearcher CardSearcher) (*IdentificationResult, error)
+	// IdentifyCardWithOptions identifies a card, e.g. in thorough mode for bulk import
+	IdentifyCardWithOptions(ctx context.Context, imageBytes []byte, pokemonSearcher, mtgSearcher CardSearcher, opts IdentifyOptions) (*IdentificationResult, error)
+}
+
+// NewVisionIdentifierFromEnv returns the identification provider selected by
+// VISION_PROVIDER: "gemini" (default) or "openai" for an OpenAI-compatible
+// chat completions API, such as a self-hosted vLLM or Ollama server.
+func NewVisionIdentifierFromEnv() VisionIdentifier {
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("VISION_PROVIDER")))
+	switch provider {
+	case "", "gemini":
+		return NewGeminiService()
+	case "openai":
+		return NewOpenAIVisionServiceFromEnv()
+	default:
+		log.Printf("Vision: unknown VISION_PROVIDER %q, using gemini", provider)
+		return NewGeminiService()
+	}
+}
+
+// CandidateCard represents a potential match for visual comparison
+// Includes rich metadata to help the model filter candidates without image viewing
+type CandidateCard struct {
+	// Core identification
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	SetCode  string `json:"set_code"`
+	SetName  string `json:"set_name"`
+	Number   string `json:"number"`
+	ImageURL string `json:"image_url"`
+
+	// Common to both games
+	Rarity      string `json:"rarity,omitempty"`       // "Rare Holo", "mythic", etc.
+	Artist      string `json:"artist,omitempty"`       // Artist name for art verification
+	ReleaseDate string `json:"release_date,omitempty"` // Set release date (YYYY-MM-DD)
+
+	// Pokemon-specific fields
+	Subtypes       []string `json:"subtypes,omitempty"`        // ["V", "VMAX", "ex", "GX"]
+	HP             string   `json:"hp,omitempty"`              // "320" - visible on card
+	Types          []string `json:"types,omitempty"`           // ["Fire", "Water"] - energy types
+	RegulationMark string   `json:"regulation_mark,omitempty"` // "D", "E", "F", "G" - bottom-left of modern cards
+
+	// MTG-specific fields
+	TypeLine     string   `json:"type_line,omitempty"`     // "Creature — Goblin Wizard"
+	ManaCost     string   `json:"mana_cost,omitempty"`     // "{2}{R}{R}" - top-right corner
+	BorderColor  string   `json:"border_color,omitempty"`  // "black", "borderless", "white"
+	FrameEffects []string `json:"frame_effects,omitempty"` // ["showcase", "extendedart"]
+	PromoTypes   []string `json:"promo_types,omitempty"`   // ["prerelease", "buyabox"]
+}
+
+// IdentificationResult is the final result returned to the client
+type IdentificationResult struct {
+	CardID          string          `json:"card_id"`                     // Matched card ID (empty if no match)
+	CardName        string          `json:"card_name"`                   // Card name (may be non-English if that's what was on the card)
+	CanonicalNameEN string          `json:"canonical_name_en"`           // English name for lookup/display (always English)
+	SetCode         string          `json:"set_code"`                    // Set code
+	SetName         string          `json:"set_name"`                    // Set name
+	Number          string          `json:"card_number"`                 // Card number
+	Game            string          `json:"game"`                        // "pokemon" or "mtg"
+	ObservedLang    string          `json:"observed_language,omitempty"` // Language observed on card (e.g., "Japanese", "English")
+	IsFoil          bool            `json:"is_foil"`                     // Whether the card appears to be foil/holo
+	IsFirstEdition  bool            `json:"is_first_edition"`            // Whether the card has a 1st Edition stamp (Pokemon)
+	Confidence      float64         `json:"confidence"`                  // 0-1 confidence score
+	Reasoning       string          `json:"reasoning"`                   // The model's explanation
+	TurnsUsed       int             `json:"turns_used"`                  // Number of API turns used
+	Candidates      []CandidateCard `json:"candidates,omitempty"`        // Alternative candidates if low confidence
+	SearchTerms     []string        `json:"search_terms,omitempty"`      // Card names the model searched for (fallback for handler)
+}
+
+// IdentifyOptions configures the identification behavior
+type IdentifyOptions struct {
+	// Thorough mode: use more capable model, more turns, auto-retry on low confidence
+	// Recommended for background processing (bulk import) where accuracy > speed
+	Thorough bool
+
+	// Candidates are likely matches found before the model runs (e.g. by the
+	// image hash index); the model is asked to verify them first
+	Candidates []CandidateCard
+}
+
+// CardSearcher is the interface for searching cards (implemented by Pokemon/Scryfall services)
+type CardSearcher interface {
+	// SearchByName searches for cards by name, returns up to limit results
+	SearchByName(ctx context.Context, name string, limit int) ([]CandidateCard, error)
+	// SearchInSet searches for cards within a specific set, optionally filtered by name
+	SearchInSet(ctx context.Context, setCode, name string, limit int) ([]CandidateCard, error)
+	// GetBySetAndNumber gets a specific card by set code and collector number
+	GetBySetAndNumber(ctx context.Context, setCode, number string) (*CandidateCard, error)
+	// GetCardImage downloads a card image by ID, returns base64-encoded image
+	GetCardImage(ctx context.Context, cardID string) (string, error)
+	// GetCardDetails returns full card details for verification (attacks, abilities, text)
+	GetCardDetails(ctx context.Context, cardID string) (*CardDetails, error)
+	// ListSets returns sets matching a query
+	ListSets(ctx context.Context, query string) ([]SetInfo, error)
+	// GetSetInfo returns detailed information about a specific set
+	GetSetInfo(ctx context.Context, setCode string) (*SetDetails, error)
+}
+
+// LanguageFilteredSearcher is an optional interface for searchers that support language filtering.
+// Currently only Pokemon cards support this (Japanese vs English card databases).
+type LanguageFilteredSearcher interface {
+	// SearchByNameWithLanguage searches for cards by name with optional language filtering.
+	// Language parameter: "japanese", "english", or "" for no filter.
+	SearchByNameWithLanguage(ctx context.Context, name string, language string, limit int) ([]CandidateCard, error)
+}
+
+// CardDetails contains full card information for text verification
+type CardDetails struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	SetCode  string `json:"set_code"`
+	SetName  string `json:"set_name"`
+	Number   string `json:"number"`
+	Rarity   string `json:"rarity,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+
+	// Pokemon-specific
+	HP             string        `json:"hp,omitempty"`              // "320"
+	Types          []string      `json:"types,omitempty"`           // ["Fire"]
+	Subtypes       []string      `json:"subtypes,omitempty"`        // ["V", "VMAX"]
+	Attacks        []AttackInfo  `json:"attacks,omitempty"`         // Attack details
+	Abilities      []AbilityInfo `json:"abilities,omitempty"`       // Ability details
+	Weaknesses     []string      `json:"weaknesses,omitempty"`      // ["Water x2"]
+	Resistances    []string      `json:"resistances,omitempty"`     // ["Fighting -30"]
+	RetreatCost    int           `json:"retreat_cost,omitempty"`    // Number of energy to retreat
+	RegulationMark string        `json:"regulation_mark,omitempty"` // "F", "G"
+	EvolvesFrom    string        `json:"evolves_from,omitempty"`    // What this Pokemon evolves from
+
+	// MTG-specific
+	TypeLine   string `json:"type_line,omitempty"`   // "Creature — Goblin Wizard"
+	ManaCost   string `json:"mana_cost,omitempty"`   // "{2}{R}{R}"
+	OracleText string `json:"oracle_text,omitempty"` // Rules text
+	Power      string `json:"power,omitempty"`       // Creature power
+	Toughness  string `json:"toughness,omitempty"`   // Creature toughness
+	Loyalty    string `json:"loyalty,omitempty"`     // Planeswalker loyalty
+	FlavorText string `json:"flavor_text,omitempty"` // Flavor text
+}
+
+// AttackInfo describes a Pokemon card attack
+type AttackInfo struct {
+	Name   string `json:"name"`             // Attack name
+	Cost   string `json:"cost,omitempty"`   // Energy cost, e.g., "Fire Fire Colorless"
+	Damage string `json:"damage,omitempty"` // Damage dealt, e.g., "120+"
+	Text   string `json:"text,omitempty"`   // Attack effect text
+}
+
+// AbilityInfo describes a Pokemon card ability
+type AbilityInfo struct {
+	Name string `json:"name"`           // Ability name
+	Type string `json:"type,omitempty"` // Ability type (e.g., "Ability", "Poke-Body")
+	Text string `json:"text,omitempty"` // Ability effect text
+}
+
+// SetInfo contains information about a card set
+type SetInfo struct {
+	ID          string `json:"id"`                     // Set code (e.g., "swsh4", "MH2")
+	Name        string `json:"name"`                   // Full name (e.g., "Vivid Voltage")
+	Series      string `json:"series,omitempty"`       // Series name (e.g., "Sword & Shield")
+	ReleaseDate string `json:"release_date,omitempty"` // YYYY-MM-DD
+	TotalCards  int    `json:"total_cards,omitempty"`  // Number of cards in set
+	SymbolURL   string `json:"symbol_url,omitempty"`   // URL to set symbol image (Pokemon PNG, MTG SVG)
+	LogoURL     string `json:"logo_url,omitempty"`     // URL to set logo image (Pokemon only)
+}
+
+// SetDetails contains detailed information about a specific set
+type SetDetails struct {
+	ID                string `json:"id"`                           // Set code
+	Name              string `json:"name"`                         // Full name
+	Series            string `json:"series,omitempty"`             // Series name
+	ReleaseDate       string `json:"release_date,omitempty"`       // YYYY-MM-DD
+	TotalCards        int    `json:"total_cards,omitempty"`        // Number of cards
+	SymbolDescription string `json:"symbol_description,omitempty"` // Description of set symbol for visual matching
+	SetType           string `json:"set_type,omitempty"`           // expansion, promo, masters, etc. (MTG)
+}
+
+// SetSymbolImage contains a set's symbol image data for visual comparison by the model
+type SetSymbolImage struct {
+	SetID       string `json:"set_id"`
+	SetName     string `json:"name"`
+	Series      string `json:"series,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	ImageData   string `json:"-"` // base64 encoded, not serialized to JSON response
+}
+
+// SetSymbolFetcher is an optional interface for fetching set symbol images.
+// Used by the identification model to visually compare set symbols on scanned cards.
+type SetSymbolFetcher interface {
+	// GetSetSymbolImages fetches symbol images for sets matching the query
+	GetSetSymbolImages(ctx context.Context, query string, limit int) ([]SetSymbolImage, error)
+}
+
+// detectMimeType returns the MIME type for image bytes
+func detectMimeType(data []byte) string {
+	// http.DetectContentType uses the first 512 bytes
+	contentType := http.DetectContentType(data)
+	// It returns things like "image/jpeg", "image/png", "image/gif", "image/webp"
+	// For non-image types or unknown, default to jpeg (most common for photos)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "image/jpeg"
+	}
+	return contentType
+}
+
+// visionRole is the author of a message in a provider-neutral conversation
+type visionRole string
+
+const (
+	visionRoleUser      visionRole = "user"
+	visionRoleAssistant visionRole = "assistant"
+	visionRoleTool      visionRole = "tool"
+)
+
+// visionPart is a piece of text or an image in a message
+type visionPart struct {
+	Text  string
+	Image *visionImage
+}
+
+// visionMessage is one message of an identification conversation
+type visionMessage struct {
+	Role       visionRole
+	Parts      []visionPart      // User and assistant content
+	ToolCalls  []visionToolCall  // Assistant: tools the model asked for
+	ToolResult *visionToolResult // Tool: the result of one call
+
+	// native is the provider's own copy of an assistant message, sent back unchanged
+	// on later turns (Gemini requires its thought signatures to be returned)
+	native interface{}
+}
+
+// visionReply is a model's answer to a conversation: either tool calls or final text
+type visionReply struct {
+	Text      string
+	ToolCalls []visionToolCall
+	native    interface{}
+}
+
+// visionChat sends a conversation to a provider's chat API with the tools declared
+type visionChat interface {
+	chat(ctx context.Context, model string, messages []visionMessage, tools []visionToolSpec) (*visionReply, error)
+}
+
+// visionRunner runs the tool-calling identification loop against a provider.
+// Providers only translate messages to and from their wire format.
+type visionRunner struct {
+	provider        string // For logs, e.g. "Gemini"
+	client          visionChat
+	model           string
+	thoroughModel   string
+	timeout         time.Duration
+	thoroughTimeout time.Duration
+	caches          *visionToolCaches
+
+	// observe, if set, is called after each identification attempt (for metrics)
+	observe func(turns int, elapsed time.Duration, result *IdentificationResult)
+}
+
+// identify runs an identification, retrying once in thorough mode when the first
+// attempt has low confidence or did not verify the artwork
+func (r *visionRunner) identify(
+	ctx context.Context,
+	imageBytes []byte,
+	pokemonSearcher CardSearcher,
+	mtgSearcher CardSearcher,
+	opts IdentifyOptions,
+) (*IdentificationResult, error) {
+	// Configure based on mode
+	model := r.model
+	timeout := r.timeout
+	maxIterations := maxTurns
+	if opts.Thorough {
+		model = r.thoroughModel
+		timeout = r.thoroughTimeout
+		maxIterations = maxTurnsThorough
+		log.Printf("%s thorough mode: using model=%s, timeout=%v, maxTurns=%d", r.provider, model, timeout, maxIterations)
+	}
+
+	// Create context with appropriate timeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tools := newToolRegistry(pokemonSearcher, mtgSearcher, r.caches)
+
+	// Run identification (may retry in thorough mode)
+	hint := buildCandidatesPrompt(opts.Candidates)
+	result, viewCardImageCalled, searchTerms, err := r.run(ctx, imageBytes, tools, model, maxIterations, hint)
+	if err != nil {
+		return nil, err
+	}
+
+	// In thorough mode, retry if confidence is low or artwork wasn't verified
+	if opts.Thorough && result != nil {
+		needsRetry := false
+		retryReason := ""
+
+		if result.CardID != "" && !viewCardImageCalled {
+			needsRetry = true
+			retryReason = "artwork not verified (view_card_image not called)"
+		} else if result.CardID != "" && result.Confidence < minConfidenceThorough {
+			needsRetry = true
+			retryReason = fmt.Sprintf("low confidence (%.2f < %.2f)", result.Confidence, minConfidenceThorough)
+		} else if result.CardID == "" && len(searchTerms) > 0 {
+			needsRetry = true
+			retryReason = "no match found but search terms available"
+		}
+
+		if needsRetry {
+			log.Printf("%s thorough mode: retrying because %s", r.provider, retryReason)
+
+			// Build a more specific prompt for retry
+			retryPrompt := buildRetryPrompt(result, viewCardImageCalled, searchTerms) + hint
+			result2, _, searchTerms2, err := r.run(ctx, imageBytes, tools, model, maxIterations, retryPrompt)
+			if err != nil {
+				log.Printf("%s thorough mode: retry failed: %v", r.provider, err)
+				// Return original result on retry failure
+			} else if result2 != nil && (result2.CardID != "" || result.CardID == "") {
+				// Use retry result if it found something (or original also found nothing)
+				if result2.Confidence > result.Confidence || (result2.CardID != "" && result.CardID == "") {
+					log.Printf("%s thorough mode: retry improved result (confidence: %.2f -> %.2f)", r.provider, result.Confidence, result2.Confidence)
+					result = result2
+					result.SearchTerms = append(searchTerms, searchTerms2...)
+				}
+			}
+		}
+	}
+
+	if result != nil {
+		result.SearchTerms = searchTerms
+	}
+
+	return result, nil
+}
+
+// buildCandidatesPrompt lists cards found before the model ran, for it to verify first
+func buildCandidatesPrompt(candidates []CandidateCard) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n=== REFERENCE IMAGE MATCHES ===\n")
+	sb.WriteString("A local image lookup found these cards with similar artwork, closest first. ")
+	sb.WriteString("They are likely but NOT certain (reprints often share artwork): ")
+	sb.WriteString("verify with view_card_image and the card details before answering.\n")
+	for _, c := range candidates {
+		sb.WriteString(fmt.Sprintf("- %s: %s (%s #%s)\n", c.ID, c.Name, c.SetName, c.Number))
+	}
+	return sb.String()
+}
+
+// buildRetryPrompt creates a more specific prompt for retry attempts
+func buildRetryPrompt(prevResult *IdentificationResult, viewCalled bool, searchTerms []string) string {
+	var hints []string
+
+	if prevResult.CardID != "" && !viewCalled {
+		hints = append(hints, "IMPORTANT: You MUST call view_card_image to verify the artwork matches before returning a result.")
+	}
+
+	if prevResult.CardID != "" && prevResult.Confidence < minConfidenceThorough {
+		hints = append(hints, fmt.Sprintf("Previous attempt had low confidence (%.2f). Please be more thorough:", prevResult.Confidence))
+		hints = append(hints, "- Compare multiple candidate images if available")
+		hints = append(hints, "- Check additional details like HP, attacks, set symbol")
+		hints = append(hints, "- Use get_card_details to verify card text matches")
+	}
+
+	if prevResult.CardID == "" && len(searchTerms) > 0 {
+		hints = append(hints, "Previous searches didn't find a match. Try:")
+		hints = append(hints, "- Alternative spellings or translations of the card name")
+		hints = append(hints, "- Searching by set if you can identify the set symbol")
+		hints = append(hints, "- Using list_pokemon_sets or list_mtg_sets to find the set code")
+	}
+
+	if len(hints) == 0 {
+		return ""
+	}
+
+	return "\n\n=== RETRY GUIDANCE ===\n" + strings.Join(hints, "\n")
+}
+
+// run performs one identification conversation with an optional additional prompt.
+// Returns the result, whether view_card_image was called and the card names searched.
+func (r *visionRunner) run(
+	ctx context.Context,
+	imageBytes []byte,
+	tools *toolRegistry,
+	model string,
+	maxIterations int,
+	additionalPrompt string,
+) (*IdentificationResult, bool, []string, error) {
+	startTime := time.Now()
+
+	// Build initial message with the card image
+	imageB64 := base64.StdEncoding.EncodeToString(imageBytes)
+	mimeType := detectMimeType(imageBytes)
+
+	promptText := systemPrompt
+	if additionalPrompt != "" {
+		promptText += additionalPrompt
+	}
+
+	// Conversation history
+	messages := []visionMessage{
+		{
+			Role: visionRoleUser,
+			Parts: []visionPart{
+				{Image: &visionImage{MimeType: mimeType, Data: imageB64}},
+				{Text: promptText},
+			},
+		},
+	}
+
+	var result *IdentificationResult
+	turnsUsed := 0
+	viewCardImageCalled := false // Track if the model ever called view_card_image
+	var searchTerms []string     // Track card names the model searched for
+	searchTermsSeen := make(map[string]bool)
+
+	// Conversation loop - the model calls tools until it has an answer
+	for turn := 0; turn < maxIterations; turn++ {
+		turnsUsed++
+
+		reply, err := r.client.chat(ctx, model, messages, tools.Specs())
+		if err != nil {
+			return nil, viewCardImageCalled, searchTerms, fmt.Errorf("turn %d failed: %w", turn+1, err)
+		}
+
+		// Check if the model wants to call tools
+		if len(reply.ToolCalls) > 0 {
+			// Log each tool call with its arguments
+			for _, call := range reply.ToolCalls {
+				argsJSON, _ := json.Marshal(call.Args)
+				log.Printf("%s turn %d: calling %s(%s)", r.provider, turn+1, call.Name, string(argsJSON))
+				if call.Name == "view_card_image" {
+					viewCardImageCalled = true
+				}
+				// Track search terms for fallback
+				if call.Name == "search_pokemon_cards" || call.Name == "search_mtg_cards" {
+					if name, ok := call.Args["name"].(string); ok && name != "" {
+						if !searchTermsSeen[name] {
+							searchTermsSeen[name] = true
+							searchTerms = append(searchTerms, name)
+						}
+					}
+				}
+			}
+
+			callResults := tools.Execute(ctx, reply.ToolCalls)
+
+			// Add the model's response to history
+			assistant := visionMessage{Role: visionRoleAssistant, ToolCalls: reply.ToolCalls, native: reply.native}
+			if reply.Text != "" {
+				assistant.Parts = []visionPart{{Text: reply.Text}}
+			}
+			messages = append(messages, assistant)
+
+			// Tool responses must directly follow the calls (OpenAI requires it), so
+			// images the tools loaded are shown afterwards, one message per image
+			for i := range callResults {
+				messages = append(messages, visionMessage{Role: visionRoleTool, ToolResult: &callResults[i]})
+			}
+			for _, result := range callResults {
+				for _, img := range result.Images {
+					image := img.Image
+					messages = append(messages, visionMessage{
+						Role:  visionRoleUser,
+						Parts: []visionPart{{Text: img.Caption}, {Image: &image}},
+					})
+				}
+			}
+
+			log.Printf("%s turn %d: executed %d function calls", r.provider, turn+1, len(reply.ToolCalls))
+			continue
+		}
+
+		// The model returned a final answer (text response)
+		if reply.Text != "" {
+			result, err = parseIdentificationResult(reply.Text)
+			if err != nil {
+				log.Printf("%s turn %d: failed to parse result: %v", r.provider, turn+1, err)
+				// Ask the model to try again with proper format
+				messages = append(messages, visionMessage{
+					Role:   visionRoleAssistant,
+					Parts:  []visionPart{{Text: reply.Text}},
+					native: reply.native,
+				})
+				messages = append(messages, visionMessage{
+					Role:  visionRoleUser,
+					Parts: []visionPart{{Text: "Please provide the result as valid JSON matching the expected schema."}},
+				})
+				continue
+			}
+
+			result.TurnsUsed = turnsUsed
+
+			// Log warning if the model returned a card_id without calling view_card_image
+			if result.CardID != "" && !viewCardImageCalled {
+				log.Printf("WARNING: %s returned card_id '%s' without calling view_card_image - artwork not verified!", r.provider, result.CardID)
+			}
+			break
+		}
+
+		// No tool calls and no text - something went wrong
+		log.Printf("%s turn %d: empty response", r.provider, turn+1)
+		break
+	}
+
+	if r.observe != nil {
+		r.observe(turnsUsed, time.Since(startTime), result)
+	}
+
+	if result == nil {
+		log.Printf("%s identification failed: no result after %d turns, view_card_image_called=%v, search_terms=%v", r.provider, turnsUsed, viewCardImageCalled, searchTerms)
+		return &IdentificationResult{
+			Game:        "pokemon", // Assume Pokemon since that's most common for Japanese cards
+			Confidence:  0,
+			Reasoning:   "Failed to identify card after max turns",
+			TurnsUsed:   turnsUsed,
+			SearchTerms: searchTerms,
+		}, viewCardImageCalled, searchTerms, nil
+	}
+
+	// Add search terms to successful result too (for fallback candidates)
+	result.SearchTerms = searchTerms
+
+	// Log summary of identification session
+	log.Printf("%s identification complete: card_id=%q, canonical_name=%q, view_card_image_called=%v, turns=%d",
+		r.provider, result.CardID, result.CanonicalNameEN, viewCardImageCalled, turnsUsed)
+
+	return result, viewCardImageCalled, searchTerms, nil
+}
+
+// locate asks the model for the bounding box of every card in a photo.
+// It is a single turn without tools, used when edge detection fails.
+func (r *visionRunner) locate(ctx context.Context, imageBytes []byte) ([]image.Rectangle, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	messages := []visionMessage{{
+		Role: visionRoleUser,
+		Parts: []visionPart{
+			{Image: &visionImage{MimeType: detectMimeType(imageBytes), Data: base64.StdEncoding.EncodeToString(imageBytes)}},
+			{Text: locatePrompt},
+		},
+	}}
+	reply, err := r.client.chat(ctx, r.model, messages, nil)
+	if err != nil {
+		return nil, fmt.Errorf("card location failed: %w", err)
+	}
+
+	regions, err := parseCardLocations(reply.Text, cfg.Width, cfg.Height)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%s located %d cards", r.provider, len(regions))
+	return regions, nil
+}
+
+// parseCardLocations converts the model's boxes, normalized to 0-1000 as
+// [ymin, xmin, ymax, xmax], to pixel rectangles in a width x height photo
+func parseCardLocations(text string, width, height int) ([]image.Rectangle, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+
+	var parsed struct {
+		Cards []struct {
+			Box []float64 `json:"box_2d"`
+		} `json:"cards"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w (text: %s)", err, text)
+	}
+
+	var regions []image.Rectangle
+	for _, card := range parsed.Cards {
+		if len(card.Box) != 4 {
+			continue
+		}
+		regions = append(regions, image.Rect(
+			int(card.Box[1]*float64(width)/1000), int(card.Box[0]*float64(height)/1000),
+			int(card.Box[3]*float64(width)/1000), int(card.Box[2]*float64(height)/1000),
+		))
+	}
+	return regions, nil
+}
+
+// locatePrompt asks the model for card bounding boxes in a multi-card photo
+const locatePrompt = `This photo shows one or more trading cards, for example a binder page or cards spread on a table.
+
+Find every trading card that is fully or mostly visible. Ignore card backs, empty binder pockets and other objects.
+
+Respond with ONLY this JSON, listing the cards in reading order (top to bottom, left to right):
+{"cards": [{"box_2d": [ymin, xmin, ymax, xmax]}]}
+
+Coordinates are normalized to 0-1000 relative to the image height (y) and width (x).`
+
+// parseIdentificationResult parses the model's final JSON answer, with or without a markdown code block
+func parseIdentificationResult(text string) (*IdentificationResult, error) {
+	// Try to extract JSON from the response
+	text = strings.TrimSpace(text)
+
+	// Handle markdown code blocks
+	if strings.HasPrefix(text, "```json") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimSuffix(text, "```")
+		text = strings.TrimSpace(text)
+	} else if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(text, "```")
+		text = strings.TrimSpace(text)
+	}
+
+	var result IdentificationResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w (text: %s)", err, text)
+	}
+
+	return &result, nil
+}
+
+// systemPrompt instructs the model how to identify a card with the tools
+const systemPrompt = `You are a trading card identification expert. I'm showing you a photo of a trading card (Pokemon TCG or Magic: The Gathering).
+
+YOUR TASK: Identify the EXACT card printing shown in the image.
+
+=== POKEMON CARD VISUAL GUIDE ===
++----------------------------------+
+|  [NAME]               [HP] [TYPE]|  <- HP top-right (e.g., "HP 320"), type symbol far right
+|  +----------------------------+  |
+|  |                            |  |
+|  |         ARTWORK            |  |
+|  |                            |  |
+|  +----------------------------[S]|  <- [S] = SET SYMBOL at bottom-right of art box
+|[1]                               |  <- [1] = 1ST EDITION stamp left of art (if present)
+|  Attack Name           Damage    |
+|  --------------------------------|
+|[R]        [Artist]     [###/###] |  <- [R] = REGULATION MARK (D,E,F,G,H) bottom-left
+|                        [RARITY]  |  <- COLLECTOR NUMBER + RARITY bottom-right
++----------------------------------+
+
+KEY POKEMON IDENTIFIERS:
+- Collector number: Bottom-right, format "025/185" or just "025"
+- Set symbol: Small icon at bottom-right of artwork box (matches the set)
+- 1st Edition stamp: Black "1" in shadow, LEFT side below artwork (WotC era: Base-Neo)
+- Regulation mark: Single letter (D,E,F,G,H) at bottom-left (modern cards 2019+)
+- Rarity: ● common, ◆ uncommon, ★ rare, ★H holo rare, ★★★ ultra rare
+- Subtypes in name: "V", "VMAX", "VSTAR", "ex", "GX", "EX" indicate card variant
+- Language hints: HP=English, KP=German, PV=French, PS=Spanish/Italian
+
+=== MTG CARD VISUAL GUIDE ===
++----------------------------------+
+|  [NAME]               [MANA COST]|  <- Mana symbols top-right corner
+|  +----------------------------+  |
+|  |                            |  |
+|  |         ARTWORK            |  |
+|  |                            |  |
+|  +----------------------------+  |
+|  [TYPE LINE]             [SET S] |  <- SET SYMBOL middle-right (color=rarity)
+|  --------------------------------|
+|  Rules text...                   |
+|  --------------------------------|
+|  [COLLECTOR#]           [P/T]    |  <- Power/Toughness bottom-right (creatures)
++----------------------------------+
+
+KEY MTG IDENTIFIERS:
+- Set symbol color: GOLD=mythic, ORANGE=rare, SILVER=uncommon, BLACK=common
+- Border: Black=standard, White=pre-8th edition, Borderless=premium, Silver=Un-sets
+- Frame effects: "Showcase" (special art frame), "Extended art", "Borderless"
+- Collector number: Bottom-left, numbers beyond set size (285/280) = bonus/variant
+- Type line: "Creature — Goblin Wizard" visible below artwork
+
+=== EFFICIENT WORKFLOW (3-4 turns target) ===
+
+TURN 1 - ANALYZE & SEARCH:
+1. Determine game (Pokemon or MTG) from card layout
+2. Read the card name (in any language)
+3. Note: HP/subtypes (Pokemon), mana cost/type (MTG), collector number if visible
+4. Search using English name: search_pokemon_cards or search_mtg_cards
+
+TURN 2 - FILTER CANDIDATES:
+Search results now include RICH DATA - use it to filter WITHOUT viewing images:
+- Pokemon: hp, subtypes, types, rarity, artist, release_date, regulation_mark
+- MTG: type_line, mana_cost, rarity, border_color, frame_effects, artist
+
+Example filtering:
+- Scanned card shows HP 320, "VMAX" in name → filter for hp="320", subtypes contains "VMAX"
+- Scanned card shows regulation mark "G" → filter for regulation_mark="G"
+- If unsure, use get_card_details to verify HP/attacks/abilities match
+
+TURN 3 - VERIFY ARTWORK:
+Call view_card_image for the 1-2 best candidates after filtering.
+Compare these specific features:
+1. CHARACTER POSE: Body position, facing direction, action
+2. BACKGROUND: Sky, landscape, patterns, energy effects, colors
+3. ART STYLE: 3D CGI vs hand-drawn vs watercolor
+4. COMPOSITION: Full body vs close-up, centered vs off-center
+
+TURN 4 - RETURN RESULT:
+Return the matching card_id with confidence score.
+
+=== TOOLS REFERENCE ===
+
+SEARCH TOOLS (return rich metadata for filtering):
+- search_pokemon_cards(name, language?, limit?): Returns id, name, set, number, rarity, hp, types, subtypes, artist, release_date. Use language="japanese" for Japanese-exclusive cards (jp-* IDs), language="english" for standard English cards, or omit for all.
+- search_mtg_cards: Returns id, name, set, number, rarity, type_line, mana_cost, border_color, frame_effects, artist
+- search_cards_in_set(set_code, name?, game): Search within a specific set (more targeted, use after identifying set)
+
+LOOKUP TOOLS (for exact matches):
+- get_pokemon_card(set_code, number): Get specific card by set+number
+- get_mtg_card(set_code, number): Get specific MTG card by set+number
+- list_pokemon_sets(query): Find set codes by name/series (e.g., "Vivid Voltage", "Sword & Shield")
+- list_mtg_sets(query): Find MTG set codes by name/type (e.g., "Modern Horizons", "masters")
+- get_set_info(set_code, game): Get detailed set info including symbol description (helps match set symbols visually)
+
+VERIFICATION TOOLS:
+- get_card_details: Get full card data (attacks, abilities, oracle text) to verify text matches
+- view_card_image: REQUIRED before returning card_id - compare actual artwork
+- view_multiple_card_images(card_ids, game): View up to 5 images at once (more efficient than multiple single calls)
+- view_set_symbols(query, game, limit?): View up to 10 set symbol images for visual comparison with the scanned card's set symbol
+
+=== IMPORTANT RULES ===
+
+1. USE METADATA FIRST: Filter candidates by hp/subtypes/type_line before viewing images
+2. VERIFY ARTWORK: You MUST call view_card_image at least once before returning a card_id
+3. ONE MATCH RULE: Only return card_id for a card you VIEWED and VERIFIED
+4. NO MATCH: Return card_id="" with candidates list if no artwork matches
+
+=== SPECIAL CASES ===
+
+JAPANESE CARDS:
+- Read Japanese text (ポケモン = Pokemon, etc.)
+- Most Japanese cards share artwork with English → search with language="english" first
+- If artwork doesn't match ANY English version → search with language="japanese" for Japanese-exclusive cards
+- Japanese-exclusive sets: "Leaders' Stadium", "Gym" sets (IDs prefixed with "jp-")
+
+1ST EDITION DETECTION (Pokemon):
+- Look for black "1" stamp LEFT of artwork, below the art box
+- Only exists on WotC-era sets: Base Set, Jungle, Fossil, Team Rocket, Gym Heroes/Challenge, Neo series
+- Set is_first_edition: true if stamp is present
+
+FOIL/HOLO DETECTION:
+- Look for holographic sheen, rainbow gradients, sparkle patterns
+- Check artwork area AND card border for holo effects
+- Set is_foil: true if any holographic elements visible
+
+LANGUAGE DETECTION:
+- Japanese: Japanese characters (カタカナ, ひらがな, 漢字)
+- German: "KP" for HP, German text
+- French: "PV" for HP, French text
+- Spanish: "PS" for HP
+- Set observed_language to the detected language
+
+SET SYMBOL MATCHING:
+- If you can clearly see the set symbol on the card but cannot read the set name text, use view_set_symbols
+- Search by era (e.g., "neo", "sword & shield") or partial set name (e.g., "vivid", "base")
+- Compare the scanned symbol's shape, color, and style against the returned symbol images
+- Set symbols are most distinctive in older sets (Base Set: pokeball, Jungle: flower, Fossil: shell, Neo: temple gate)
+- Modern sets often share similar symbols within a series - use other clues (card number, release date) to narrow down
+
+=== RESPONSE FORMAT ===
+
+When you have VERIFIED artwork match:
+{
+  "card_id": "the-verified-card-id",
+  "card_name": "Name as printed on card (may be non-English)",
+  "canonical_name_en": "English name",
+  "set_code": "swsh4",
+  "set_name": "Vivid Voltage",
+  "card_number": "025",
+  "game": "pokemon",
+  "observed_language": "Japanese",
+  "is_foil": false,
+  "is_first_edition": false,
+  "confidence": 0.95,
+  "reasoning": "Matched by: HP 320 matches, VMAX subtype matches, artwork comparison shows same pose/background"
+}
+
+If NO match found after verification:
+{
+  "card_id": "",
+  "card_name": "Name on card",
+  "canonical_name_en": "English translation",
+  "game": "pokemon",
+  "observed_language": "Japanese",
+  "is_foil": false,
+  "is_first_edition": false,
+  "confidence": 0.0,
+  "reasoning": "Viewed swsh4-25 and sv4-25 but neither artwork matched the scanned card",
+  "candidates": [{"id": "swsh4-25", "name": "Charizard VMAX"}, ...]
+}`
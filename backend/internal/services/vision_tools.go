@@ -0,0 +1,788 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// visionToolSpec declares a tool the identification model may call. Parameters is a
+// JSON schema object, which Gemini and OpenAI-compatible APIs both accept.
+type visionToolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// visionToolCall is a tool call requested by the model
+type visionToolCall struct {
+	ID   string // Provider's call ID, echoed back with the result (empty for Gemini)
+	Name string
+	Args map[string]interface{}
+}
+
+// visionImage is an image shown to the model, base64-encoded
+type visionImage struct {
+	MimeType string
+	Data     string
+}
+
+// visionToolResult is the outcome of a tool call
+type visionToolResult struct {
+	Call     visionToolCall
+	Response interface{} // JSON-compatible value returned to the model as the tool's output
+	// Images to show the model after the tool responses, with a caption for each
+	Images []captionedImage
+}
+
+// captionedImage is an image injected into the conversation with a short caption
+type captionedImage struct {
+	Caption string
+	Image   visionImage
+}
+
+// visionToolCaches keeps downloaded card images and set symbols across identifications
+type visionToolCaches struct {
+	imageCache  *lru.Cache[string, string] // game:cardID -> base64 image, max 50 entries
+	symbolCache *lru.Cache[string, string] // symbol:game:setID -> base64 symbol image, max 100 entries
+}
+
+// newVisionToolCaches creates the image and symbol caches shared by a provider's identifications
+func newVisionToolCaches() *visionToolCaches {
+	// Create LRU cache for card images (max 50 images, ~50MB)
+	imageCache, err := lru.New[string, string](50)
+	if err != nil {
+		log.Printf("Failed to create image cache: %v", err)
+	}
+
+	// Create LRU cache for set symbols (max 100, ~500KB - symbols are small)
+	symbolCache, err := lru.New[string, string](100)
+	if err != nil {
+		log.Printf("Failed to create symbol cache: %v", err)
+	}
+
+	return &visionToolCaches{imageCache: imageCache, symbolCache: symbolCache}
+}
+
+// toolRegistry runs the identification tools against the Pokemon and MTG card
+// searchers. It is independent of the model provider: providers translate
+// identificationTools and the calls/results to their own wire format.
+type toolRegistry struct {
+	pokemonSearcher CardSearcher
+	mtgSearcher     CardSearcher
+	imageCache      *lru.Cache[string, string]
+	symbolCache     *lru.Cache[string, string]
+}
+
+// newToolRegistry creates a tool registry for one identification
+func newToolRegistry(pokemonSearcher, mtgSearcher CardSearcher, caches *visionToolCaches) *toolRegistry {
+	return &toolRegistry{
+		pokemonSearcher: pokemonSearcher,
+		mtgSearcher:     mtgSearcher,
+		imageCache:      caches.imageCache,
+		symbolCache:     caches.symbolCache,
+	}
+}
+
+// Specs returns the declarations of the identification tools
+func (r *toolRegistry) Specs() []visionToolSpec {
+	return identificationTools
+}
+
+// Execute runs the model's tool calls in parallel and returns their results in order.
+// This significantly reduces latency when the model calls multiple tools in one turn
+// (e.g., searching both Pokemon and MTG, or viewing multiple card images).
+func (r *toolRegistry) Execute(ctx context.Context, calls []visionToolCall) []visionToolResult {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	// For a single call, skip the goroutine overhead
+	if len(calls) == 1 {
+		return []visionToolResult{r.executeOne(ctx, calls[0])}
+	}
+
+	results := make([]visionToolResult, len(calls))
+	var wg sync.WaitGroup
+
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call visionToolCall) {
+			defer wg.Done()
+			results[i] = r.executeOne(ctx, call)
+		}(i, call)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// executeOne runs a single tool call
+func (r *toolRegistry) executeOne(ctx context.Context, call visionToolCall) visionToolResult {
+	pokemonSearcher, mtgSearcher := r.pokemonSearcher, r.mtgSearcher
+
+	var resultJSON []byte
+	var err error
+	var images []captionedImage
+
+	switch call.Name {
+	case "search_pokemon_cards":
+		resultJSON, err = r.handleSearchCards(ctx, call.Args, pokemonSearcher)
+	case "search_mtg_cards":
+		resultJSON, err = r.handleSearchCards(ctx, call.Args, mtgSearcher)
+	case "get_pokemon_card":
+		resultJSON, err = r.handleGetCard(ctx, call.Args, pokemonSearcher)
+	case "get_mtg_card":
+		resultJSON, err = r.handleGetCard(ctx, call.Args, mtgSearcher)
+	case "view_card_image":
+		// Special handling - returns image data to inject into conversation
+		var imageData, imageCardID string
+		imageData, imageCardID, err = r.handleViewCardImage(ctx, call.Args, pokemonSearcher, mtgSearcher)
+		if err == nil {
+			resultJSON = []byte(fmt.Sprintf(`{"card_id": "%s", "status": "image_loaded"}`, imageCardID))
+			images = append(images, cardImageMessage(imageCardID, imageData))
+		}
+	case "get_card_details":
+		resultJSON, err = r.handleGetCardDetails(ctx, call.Args, pokemonSearcher, mtgSearcher)
+	case "list_pokemon_sets":
+		resultJSON, err = r.handleListSets(ctx, call.Args, pokemonSearcher)
+	case "list_mtg_sets":
+		resultJSON, err = r.handleListSets(ctx, call.Args, mtgSearcher)
+	case "view_multiple_card_images":
+		// Special handling - returns multiple images to inject into conversation
+		batch, batchErr := r.handleViewMultipleCardImages(ctx, call.Args, pokemonSearcher, mtgSearcher)
+		if batchErr == nil && len(batch) > 0 {
+			// Build response with all card IDs
+			cardIDs := make([]string, len(batch))
+			for i, img := range batch {
+				cardIDs[i] = img.cardID
+				images = append(images, cardImageMessage(img.cardID, img.imageData))
+			}
+			resultJSON, _ = json.Marshal(map[string]interface{}{
+				"card_ids": cardIDs,
+				"count":    len(batch),
+				"status":   "images_loaded",
+			})
+		} else {
+			err = batchErr
+		}
+	case "search_cards_in_set":
+		game, _ := call.Args["game"].(string)
+		if game == "mtg" {
+			resultJSON, err = r.handleSearchCardsInSet(ctx, call.Args, mtgSearcher)
+		} else {
+			resultJSON, err = r.handleSearchCardsInSet(ctx, call.Args, pokemonSearcher)
+		}
+	case "get_set_info":
+		game, _ := call.Args["game"].(string)
+		if game == "mtg" {
+			resultJSON, err = r.handleGetSetInfo(ctx, call.Args, mtgSearcher)
+		} else {
+			resultJSON, err = r.handleGetSetInfo(ctx, call.Args, pokemonSearcher)
+		}
+	case "view_set_symbols":
+		symbols, symbolErr := r.handleViewSetSymbols(ctx, call.Args, pokemonSearcher, mtgSearcher)
+		if symbolErr == nil && len(symbols) > 0 {
+			// Build JSON response with set info (without image data)
+			setInfos := make([]map[string]interface{}, len(symbols))
+			for i, sym := range symbols {
+				setInfos[i] = map[string]interface{}{
+					"set_id":       sym.SetID,
+					"name":         sym.SetName,
+					"series":       sym.Series,
+					"release_date": sym.ReleaseDate,
+				}
+				images = append(images, captionedImage{
+					Caption: fmt.Sprintf("Set symbol for %s (%s):", sym.SetName, sym.SetID),
+					Image:   visionImage{MimeType: "image/png", Data: sym.ImageData},
+				})
+			}
+			resultJSON, _ = json.Marshal(map[string]interface{}{
+				"sets":   setInfos,
+				"count":  len(symbols),
+				"status": "symbols_loaded",
+			})
+		} else {
+			err = symbolErr
+		}
+	default:
+		err = fmt.Errorf("unknown function: %s", call.Name)
+	}
+
+	result := visionToolResult{Call: call, Images: images}
+	if err != nil {
+		result.Response = map[string]interface{}{"error": err.Error()}
+	} else {
+		var response interface{}
+		if err := json.Unmarshal(resultJSON, &response); err != nil {
+			result.Response = map[string]interface{}{"error": "invalid response format"}
+		} else {
+			result.Response = response
+		}
+	}
+	return result
+}
+
+// cardImage holds a downloaded card image
+type cardImage struct {
+	cardID    string
+	imageData string // base64-encoded
+}
+
+// cardImageMessage captions a card image for injection into the conversation
+func cardImageMessage(cardID, imageData string) captionedImage {
+	return captionedImage{
+		Caption: fmt.Sprintf("Here is the image for card %s:", cardID),
+		Image:   visionImage{MimeType: "image/jpeg", Data: imageData},
+	}
+}
+
+func (r *toolRegistry) handleSearchCards(ctx context.Context, args map[string]interface{}, searcher CardSearcher) ([]byte, error) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return json.Marshal(map[string]interface{}{"error": "name is required"})
+	}
+
+	language, _ := args["language"].(string) // Optional: "japanese", "english", or ""
+
+	limit := 10
+	if l, ok := args["limit"].(float64); ok {
+		limit = int(l)
+		if limit > 20 {
+			limit = 20
+		}
+	}
+
+	var cards []CandidateCard
+	var err error
+
+	// Use language-filtered search if supported and language is specified
+	if language != "" {
+		if langSearcher, ok := searcher.(LanguageFilteredSearcher); ok {
+			cards, err = langSearcher.SearchByNameWithLanguage(ctx, name, language, limit)
+		} else {
+			// Searcher doesn't support language filtering, fall back to regular search
+			cards, err = searcher.SearchByName(ctx, name, limit)
+		}
+	} else {
+		cards, err = searcher.SearchByName(ctx, name, limit)
+	}
+
+	if err != nil {
+		return json.Marshal(map[string]interface{}{"error": err.Error()})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"cards": cards,
+		"count": len(cards),
+	})
+}
+
+func (r *toolRegistry) handleGetCard(ctx context.Context, args map[string]interface{}, searcher CardSearcher) ([]byte, error) {
+	setCode, _ := args["set_code"].(string)
+	number, _ := args["number"].(string)
+
+	if setCode == "" || number == "" {
+		return json.Marshal(map[string]interface{}{"error": "set_code and number are required"})
+	}
+
+	card, err := searcher.GetBySetAndNumber(ctx, setCode, number)
+	if err != nil {
+		return json.Marshal(map[string]interface{}{"error": err.Error()})
+	}
+	if card == nil {
+		return json.Marshal(map[string]interface{}{"error": "card not found"})
+	}
+
+	return json.Marshal(card)
+}
+
+// handleViewCardImage returns (imageBase64, cardID, error)
+// The image data is returned separately so it can be injected into the conversation
+// Uses LRU cache to avoid re-downloading the same image
+func (r *toolRegistry) handleViewCardImage(ctx context.Context, args map[string]interface{}, pokemonSearcher, mtgSearcher CardSearcher) (string, string, error) {
+	cardID, _ := args["card_id"].(string)
+	game, _ := args["game"].(string)
+
+	if cardID == "" {
+		return "", "", fmt.Errorf("card_id is required")
+	}
+
+	// Check cache first
+	cacheKey := game + ":" + cardID
+	if r.imageCache != nil {
+		if cached, ok := r.imageCache.Get(cacheKey); ok {
+			log.Printf("Image cache hit: %s", cardID)
+			return cached, cardID, nil
+		}
+	}
+
+	var searcher CardSearcher
+	if game == "mtg" {
+		searcher = mtgSearcher
+	} else {
+		searcher = pokemonSearcher
+	}
+
+	imageB64, err := searcher.GetCardImage(ctx, cardID)
+	if err != nil {
+		return "", cardID, err
+	}
+
+	// Cache the result
+	if r.imageCache != nil {
+		r.imageCache.Add(cacheKey, imageB64)
+		log.Printf("Image cached: %s", cardID)
+	}
+
+	return imageB64, cardID, nil
+}
+
+// handleGetCardDetails returns full card details for text verification
+func (r *toolRegistry) handleGetCardDetails(ctx context.Context, args map[string]interface{}, pokemonSearcher, mtgSearcher CardSearcher) ([]byte, error) {
+	cardID, _ := args["card_id"].(string)
+	game, _ := args["game"].(string)
+
+	if cardID == "" {
+		return json.Marshal(map[string]interface{}{"error": "card_id is required"})
+	}
+
+	var searcher CardSearcher
+	if game == "mtg" {
+		searcher = mtgSearcher
+	} else {
+		searcher = pokemonSearcher
+	}
+
+	details, err := searcher.GetCardDetails(ctx, cardID)
+	if err != nil {
+		return json.Marshal(map[string]interface{}{"error": err.Error()})
+	}
+
+	return json.Marshal(details)
+}
+
+// handleListSets returns sets matching a query
+func (r *toolRegistry) handleListSets(ctx context.Context, args map[string]interface{}, searcher CardSearcher) ([]byte, error) {
+	query, _ := args["query"].(string)
+
+	if query == "" {
+		return json.Marshal(map[string]interface{}{"error": "query is required"})
+	}
+
+	sets, err := searcher.ListSets(ctx, query)
+	if err != nil {
+		return json.Marshal(map[string]interface{}{"error": err.Error()})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"sets":  sets,
+		"count": len(sets),
+	})
+}
+
+// handleViewMultipleCardImages fetches multiple card images at once
+// Returns images to be injected into the conversation
+func (r *toolRegistry) handleViewMultipleCardImages(ctx context.Context, args map[string]interface{}, pokemonSearcher, mtgSearcher CardSearcher) ([]cardImage, error) {
+	cardIDsRaw, _ := args["card_ids"].([]interface{})
+	game, _ := args["game"].(string)
+
+	if len(cardIDsRaw) == 0 {
+		return nil, fmt.Errorf("card_ids array is required")
+	}
+	if len(cardIDsRaw) > 5 {
+		return nil, fmt.Errorf("maximum 5 card_ids allowed per call")
+	}
+
+	var searcher CardSearcher
+	if game == "mtg" {
+		searcher = mtgSearcher
+	} else {
+		searcher = pokemonSearcher
+	}
+
+	var results []cardImage
+	for _, idRaw := range cardIDsRaw {
+		cardID, ok := idRaw.(string)
+		if !ok || cardID == "" {
+			continue
+		}
+
+		// Check cache first
+		cacheKey := game + ":" + cardID
+		var imageB64 string
+		if r.imageCache != nil {
+			if cached, ok := r.imageCache.Get(cacheKey); ok {
+				log.Printf("Image cache hit (batch): %s", cardID)
+				imageB64 = cached
+			}
+		}
+
+		if imageB64 == "" {
+			var err error
+			imageB64, err = searcher.GetCardImage(ctx, cardID)
+			if err != nil {
+				log.Printf("Failed to fetch image for %s: %v", cardID, err)
+				continue
+			}
+			// Cache the result
+			if r.imageCache != nil {
+				r.imageCache.Add(cacheKey, imageB64)
+				log.Printf("Image cached (batch): %s", cardID)
+			}
+		}
+
+		results = append(results, cardImage{cardID: cardID, imageData: imageB64})
+	}
+
+	return results, nil
+}
+
+// handleSearchCardsInSet searches for cards within a specific set
+func (r *toolRegistry) handleSearchCardsInSet(ctx context.Context, args map[string]interface{}, searcher CardSearcher) ([]byte, error) {
+	setCode, _ := args["set_code"].(string)
+	name, _ := args["name"].(string) // optional
+	game, _ := args["game"].(string)
+
+	if setCode == "" {
+		return json.Marshal(map[string]interface{}{"error": "set_code is required"})
+	}
+
+	limit := 20
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+		if limit > 50 {
+			limit = 50
+		}
+	}
+
+	cards, err := searcher.SearchInSet(ctx, setCode, name, limit)
+	if err != nil {
+		return json.Marshal(map[string]interface{}{
+			"error":      err.Error(),
+			"set_code":   setCode,
+			"suggestion": fmt.Sprintf("Try list_%s_sets to verify the set code exists", game),
+		})
+	}
+
+	if len(cards) == 0 {
+		return json.Marshal(map[string]interface{}{
+			"cards":      cards,
+			"count":      0,
+			"set_code":   setCode,
+			"suggestion": "No cards found. Try a different search term or verify the set code.",
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"cards":    cards,
+		"count":    len(cards),
+		"set_code": setCode,
+	})
+}
+
+// handleGetSetInfo returns detailed information about a specific set
+func (r *toolRegistry) handleGetSetInfo(ctx context.Context, args map[string]interface{}, searcher CardSearcher) ([]byte, error) {
+	setCode, _ := args["set_code"].(string)
+
+	if setCode == "" {
+		return json.Marshal(map[string]interface{}{"error": "set_code is required"})
+	}
+
+	setInfo, err := searcher.GetSetInfo(ctx, setCode)
+	if err != nil {
+		return json.Marshal(map[string]interface{}{
+			"error":      err.Error(),
+			"set_code":   setCode,
+			"suggestion": "Use list_pokemon_sets or list_mtg_sets to find valid set codes",
+		})
+	}
+
+	return json.Marshal(setInfo)
+}
+
+// handleViewSetSymbols fetches set symbol images for visual comparison
+func (r *toolRegistry) handleViewSetSymbols(ctx context.Context, args map[string]interface{}, pokemonSearcher, mtgSearcher CardSearcher) ([]SetSymbolImage, error) {
+	query, _ := args["query"].(string)
+	game, _ := args["game"].(string)
+
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	limit := 10
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+		if limit > 10 {
+			limit = 10
+		}
+	}
+
+	// Determine which searcher to use
+	var fetcher SetSymbolFetcher
+	if game == "mtg" {
+		fetcher, _ = mtgSearcher.(SetSymbolFetcher)
+	} else {
+		fetcher, _ = pokemonSearcher.(SetSymbolFetcher)
+	}
+
+	if fetcher == nil {
+		return nil, fmt.Errorf("set symbol fetching not available for %s", game)
+	}
+
+	// Fetch symbols (service handles downloading)
+	symbols, err := fetcher.GetSetSymbolImages(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check cache and update for each symbol
+	for i, sym := range symbols {
+		cacheKey := fmt.Sprintf("symbol:%s:%s", game, sym.SetID)
+		if cached, ok := r.symbolCache.Get(cacheKey); ok {
+			// Use cached version
+			symbols[i].ImageData = cached
+		} else if sym.ImageData != "" {
+			// Cache the newly fetched symbol
+			r.symbolCache.Add(cacheKey, sym.ImageData)
+		}
+	}
+
+	return symbols, nil
+}
+
+// identificationTools declares the tools the model can call while identifying a card
+var identificationTools = []visionToolSpec{
+	{
+		Name:        "search_pokemon_cards",
+		Description: "Search for Pokemon TCG cards by name. Returns RICH DATA for each card: id, name, set_code, set_name, number, rarity, hp, types (energy), subtypes (V/VMAX/ex/GX), artist, release_date. Use this metadata to FILTER candidates before calling view_card_image. For example, if scanned card shows HP 320 and 'VMAX', filter results by hp='320' and subtypes containing 'VMAX'. Use 'language' parameter to filter results when the scanned card's language is known.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Card name to search for in ENGLISH (e.g., 'Charizard', 'Pikachu V', 'Professor's Research')",
+				},
+				"language": map[string]interface{}{
+					"type":        "string",
+					"description": "Filter by card language: 'japanese' (Japanese-exclusive cards with jp- prefixed IDs), 'english' (standard English cards), or omit for all cards. Use 'japanese' when the scanned card is Japanese and has different artwork from English versions.",
+					"enum":        []string{"japanese", "english"},
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of results (default 10, max 20)",
+				},
+			},
+			"required": []string{"name"},
+		},
+	},
+	{
+		Name:        "search_mtg_cards",
+		Description: "Search for MTG cards by name. Returns RICH DATA for each card: id, name, set_code, set_name, number, rarity, type_line, mana_cost, border_color, frame_effects (showcase/borderless/extendedart), promo_types, artist, release_date. Use this metadata to FILTER candidates - e.g., if scanned card is borderless, filter for border_color='borderless'.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Card name to search for (e.g., 'Lightning Bolt', 'Black Lotus')",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of results (default 10, max 20)",
+				},
+			},
+			"required": []string{"name"},
+		},
+	},
+	{
+		Name:        "get_pokemon_card",
+		Description: "Get a specific Pokemon card by set code and collector number. Use this when you can read the set code and number from the card.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"set_code": map[string]interface{}{
+					"type":        "string",
+					"description": "Set code (e.g., 'swsh4', 'sv4', 'base1', 'neo1', 'mew')",
+				},
+				"number": map[string]interface{}{
+					"type":        "string",
+					"description": "Collector number (e.g., '25', '025', 'TG15')",
+				},
+			},
+			"required": []string{"set_code", "number"},
+		},
+	},
+	{
+		Name:        "get_mtg_card",
+		Description: "Get a specific MTG card by set code and collector number. Use this when you can read the set code and number from the card.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"set_code": map[string]interface{}{
+					"type":        "string",
+					"description": "Three-letter set code (e.g., '2XM', 'MH2', 'ONE')",
+				},
+				"number": map[string]interface{}{
+					"type":        "string",
+					"description": "Collector number",
+				},
+			},
+			"required": []string{"set_code", "number"},
+		},
+	},
+	{
+		Name:        "view_card_image",
+		Description: "REQUIRED before returning any card_id. Downloads and shows you the official card image for visual comparison. Compare: 1) Character pose and position, 2) Background elements and colors, 3) Art style (3D CGI vs hand-drawn), 4) Overall composition. Only call this for 1-2 top candidates AFTER filtering by metadata.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"card_id": map[string]interface{}{
+					"type":        "string",
+					"description": "The card ID from search results",
+				},
+				"game": map[string]interface{}{
+					"type":        "string",
+					"description": "Game type: 'pokemon' or 'mtg'",
+				},
+			},
+			"required": []string{"card_id", "game"},
+		},
+	},
+
+	{
+		Name:        "get_card_details",
+		Description: "Get full details for a specific card including HP, attacks, abilities (Pokemon) or oracle text, power/toughness (MTG). Use this to VERIFY that readable text on the scanned card matches a candidate. For example, if you can read HP '320' and attack 'Max Blaze' on the scanned card, use this to verify the candidate has the same values.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"card_id": map[string]interface{}{
+					"type":        "string",
+					"description": "The card ID from search results to get details for",
+				},
+				"game": map[string]interface{}{
+					"type":        "string",
+					"description": "Game type: 'pokemon' or 'mtg'",
+				},
+			},
+			"required": []string{"card_id", "game"},
+		},
+	},
+	{
+		Name:        "list_pokemon_sets",
+		Description: "Search for Pokemon TCG sets by name, series, or set code. Use this when you need to find a set code from a set name or symbol, or to narrow down which era a card is from. Returns set codes, names, series, release dates, and card counts.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Search query: set name (e.g., 'Vivid Voltage'), series (e.g., 'Sword & Shield'), or set code (e.g., 'swsh4')",
+				},
+			},
+			"required": []string{"query"},
+		},
+	},
+	{
+		Name:        "list_mtg_sets",
+		Description: "Search for MTG sets by name, set type, or set code. Use this to find set codes or to understand what sets exist in a particular category. Returns set codes, names, types (expansion/masters/promo), release dates, and card counts.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Search query: set name (e.g., 'Modern Horizons'), set type (e.g., 'masters'), or set code (e.g., 'MH2')",
+				},
+			},
+			"required": []string{"query"},
+		},
+	},
+	{
+		Name:        "view_multiple_card_images",
+		Description: "View up to 5 card images at once for efficient comparison. More efficient than multiple view_card_image calls. Use after filtering candidates by metadata (HP, subtypes, rarity). Maximum 5 cards per call.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"card_ids": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Array of card IDs to view (max 5)",
+				},
+				"game": map[string]interface{}{
+					"type":        "string",
+					"description": "Game type: 'pokemon' or 'mtg'",
+				},
+			},
+			"required": []string{"card_ids", "game"},
+		},
+	},
+	{
+		Name:        "search_cards_in_set",
+		Description: "Search for cards within a specific set. More targeted than general search - use when you've identified the set from the set symbol or other indicators. Can optionally filter by card name.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"set_code": map[string]interface{}{
+					"type":        "string",
+					"description": "Set code (e.g., 'swsh4' for Pokemon, 'MH2' for MTG)",
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: filter by card name within the set",
+				},
+				"game": map[string]interface{}{
+					"type":        "string",
+					"description": "Game type: 'pokemon' or 'mtg'",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum results (default 20, max 50)",
+				},
+			},
+			"required": []string{"set_code", "game"},
+		},
+	},
+	{
+		Name:        "get_set_info",
+		Description: "Get detailed information about a specific set by its code. Returns set name, series, release date, total cards, and a description of the set symbol to help with visual matching.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"set_code": map[string]interface{}{
+					"type":        "string",
+					"description": "Set code (e.g., 'swsh4' for Pokemon, 'MH2' for MTG)",
+				},
+				"game": map[string]interface{}{
+					"type":        "string",
+					"description": "Game type: 'pokemon' or 'mtg'",
+				},
+			},
+			"required": []string{"set_code", "game"},
+		},
+	},
+	{
+		Name:        "view_set_symbols",
+		Description: "View set symbol images for visual comparison with the scanned card's set symbol. Use when you can clearly see the set symbol on the card but cannot read the set name text. Search for sets by name, series, or era (e.g., 'neo', 'Vivid Voltage', 'sword & shield', 'base set'). Returns up to 10 set symbols as images for direct visual comparison.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Search query for sets - can be set name, series name, or era (e.g., 'neo', 'sword & shield', 'vivid voltage', 'double masters')",
+				},
+				"game": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"pokemon", "mtg"},
+					"description": "Game type: 'pokemon' or 'mtg'",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum symbols to return (default 10, max 10)",
+				},
+			},
+			"required": []string{"query", "game"},
+		},
+	},
+}
@@ -0,0 +1,211 @@
+// Package valuation values the collection. Every total the API reports (stats, grouped
+// and per-item values, snapshots and their breakdowns) goes through it, so they all
+// use the same price resolution as Card.GetPriceWithSource: graded price for slabs,
+// then condition, printing and language fallbacks, then the card's base prices.
+package valuation
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+// CardResolver loads a card that is missing from the cards table, e.g. a Japanese
+// Pokemon card only present in the local JSON data
+type CardResolver func(id string) (*models.Card, error)
+
+// UnitPrice returns the value of one copy of an item with the given card: its graded
+// price for a slab with one, otherwise the condition-appropriate price. gradedFallback
+// is true for a slab valued at its raw price.
+func UnitPrice(item *models.CollectionItem, card *models.Card) (result models.PriceResult, gradedFallback bool) {
+	return item.UnitPrice(card)
+}
+
+// Item values an item with its card, filling ItemValue, PriceLanguage, PriceFallback,
+// GradedPriceFallback and the gain/loss fields. It returns the unit price.
+func Item(item *models.CollectionItem, card *models.Card) models.PriceResult {
+	priceResult, gradedFallback := UnitPrice(item, card)
+	item.ItemValue = priceResult.Price * float64(item.Quantity)
+	item.PriceLanguage = priceResult.PriceLanguage
+	item.PriceFallback = priceResult.IsFallback
+	item.GradedPriceFallback = gradedFallback
+	item.CalculateGainLoss()
+	return priceResult
+}
+
+// ResolveCard fills in the card of an item whose card is not in the cards table.
+// resolve may be nil.
+func ResolveCard(item *models.CollectionItem, resolve CardResolver) {
+	if resolve == nil || item.Card.Name != "" || item.Card.ImageURL != "" {
+		return
+	}
+	if card, err := resolve(item.CardID); err == nil && card != nil {
+		item.Card = *card
+	}
+}
+
+// LoadItems loads every collection item with its card and prices, and values it.
+// resolve may be nil.
+func LoadItems(db *gorm.DB, resolve CardResolver) ([]models.CollectionItem, error) {
+	var items []models.CollectionItem
+	if err := db.Preload("Card.Prices").Preload("Card.GradedPrices").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for i := range items {
+		ResolveCard(&items[i], resolve)
+		Item(&items[i], &items[i].Card)
+	}
+	return items, nil
+}
+
+// ItemTag is a tag on a collection item
+type ItemTag struct {
+	CollectionItemID uint
+	TagID            uint
+	Name             string
+}
+
+// LoadItemTags returns every tag link with the tag's name
+func LoadItemTags(db *gorm.DB) ([]ItemTag, error) {
+	var tags []ItemTag
+	err := db.Table("collection_item_tags").
+		Select("collection_item_tags.collection_item_id, collection_item_tags.tag_id, tags.name").
+		Joins("JOIN tags ON tags.id = collection_item_tags.tag_id").
+		Scan(&tags).Error
+	return tags, err
+}
+
+// Totals is the value and cost basis of a group of valued items
+type Totals struct {
+	Cards          int     // Sum of quantities
+	Value          float64 // Sum of item values
+	CostBasisCards int     // Cards with a known purchase price
+	CostBasis      float64 // Sum of purchase price * quantity
+	CostBasisValue float64 // Current value of the cards with a known purchase price
+}
+
+// Add counts a valued item
+func (t *Totals) Add(item *models.CollectionItem) {
+	t.Cards += item.Quantity
+	t.Value += item.ItemValue
+	if item.CostBasis != nil {
+		t.CostBasisCards += item.Quantity
+		t.CostBasis += *item.CostBasis
+		t.CostBasisValue += item.ItemValue
+	}
+}
+
+// SealedTotals is the value of the sealed collection at current product prices
+type SealedTotals struct {
+	Items          int     // Sum of quantities
+	Value          float64 // Sum of product price * quantity
+	CostBasisItems int     // Items with a known purchase price
+	CostBasis      float64 // Sum of purchase price * quantity
+	CostBasisValue float64 // Current value of the items with a known purchase price
+}
+
+// Sealed sums the sealed collection. Sealed products are valued as a whole, so unlike
+// singles there is no condition or printing fallback.
+func Sealed(db *gorm.DB) SealedTotals {
+	var totals SealedTotals
+	db.Raw(`
+		SELECT
+			COALESCE(SUM(i.quantity), 0) as items,
+			COALESCE(SUM(p.price_usd * i.quantity), 0) as value,
+			COALESCE(SUM(CASE WHEN i.purchase_price IS NOT NULL THEN i.quantity ELSE 0 END), 0) as cost_basis_items,
+			COALESCE(SUM(CASE WHEN i.purchase_price IS NOT NULL THEN i.purchase_price * i.quantity ELSE 0 END), 0) as cost_basis,
+			COALESCE(SUM(CASE WHEN i.purchase_price IS NOT NULL THEN p.price_usd * i.quantity ELSE 0 END), 0) as cost_basis_value
+		FROM sealed_collection_items i
+		JOIN sealed_products p ON p.id = i.sealed_product_id
+	`).Scan(&totals)
+	return totals
+}
+
+// Summary totals the whole collection
+type Summary struct {
+	Cards       Totals // All singles, including cards of an unknown game
+	UniqueCards int
+	MTG         Totals
+	Pokemon     Totals
+	Sealed      SealedTotals
+	ByTag       []models.TagStats // Sorted by name ignoring case
+}
+
+// Summarize totals valued items by game and tag, with the sealed bucket
+func Summarize(items []models.CollectionItem, tags []ItemTag, sealed SealedTotals) Summary {
+	summary := Summary{Sealed: sealed}
+	unique := make(map[string]bool)
+	byID := make(map[uint]*models.CollectionItem, len(items))
+	for i := range items {
+		item := &items[i]
+		byID[item.ID] = item
+		unique[item.CardID] = true
+		summary.Cards.Add(item)
+		switch item.Card.Game {
+		case models.GameMTG:
+			summary.MTG.Add(item)
+		case models.GamePokemon:
+			summary.Pokemon.Add(item)
+		}
+	}
+	summary.UniqueCards = len(unique)
+
+	byTag := make(map[uint]*models.TagStats)
+	for _, tag := range tags {
+		item, ok := byID[tag.CollectionItemID]
+		if !ok {
+			continue
+		}
+		stats, ok := byTag[tag.TagID]
+		if !ok {
+			stats = &models.TagStats{TagID: tag.TagID, Name: tag.Name}
+			byTag[tag.TagID] = stats
+		}
+		stats.Cards += item.Quantity
+		stats.TotalValue += item.ItemValue
+	}
+	for _, stats := range byTag {
+		summary.ByTag = append(summary.ByTag, *stats)
+	}
+	sort.Slice(summary.ByTag, func(i, j int) bool {
+		return strings.ToLower(summary.ByTag[i].Name) < strings.ToLower(summary.ByTag[j].Name)
+	})
+	return summary
+}
+
+// Collection loads, values and totals the whole collection. resolve may be nil.
+func Collection(db *gorm.DB, resolve CardResolver) (Summary, error) {
+	items, err := LoadItems(db, resolve)
+	if err != nil {
+		return Summary{}, err
+	}
+	tags, err := LoadItemTags(db)
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summarize(items, tags, Sealed(db)), nil
+}
+
+// Stats returns the summary as collection statistics. Sealed items count toward
+// value and cost basis but not toward the card counts.
+func (s Summary) Stats() models.CollectionStats {
+	return models.CollectionStats{
+		TotalCards:     s.Cards.Cards,
+		UniqueCards:    s.UniqueCards,
+		TotalValue:     s.MTG.Value + s.Pokemon.Value + s.Sealed.Value,
+		MTGCards:       s.MTG.Cards,
+		PokemonCards:   s.Pokemon.Cards,
+		MTGValue:       s.MTG.Value,
+		PokemonValue:   s.Pokemon.Value,
+		SealedItems:    s.Sealed.Items,
+		SealedValue:    s.Sealed.Value,
+		CostBasisCards: s.Cards.CostBasisCards + s.Sealed.CostBasisItems,
+		TotalCostBasis: s.Cards.CostBasis + s.Sealed.CostBasis,
+		CostBasisValue: s.Cards.CostBasisValue + s.Sealed.CostBasisValue,
+		UnrealizedGain: s.Cards.CostBasisValue + s.Sealed.CostBasisValue - s.Cards.CostBasis - s.Sealed.CostBasis,
+		ByTag:          s.ByTag,
+	}
+}
@@ -0,0 +1,141 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codyseavey/tcg-tracker/backend/internal/models"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.0001
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// wotcCard is a WotC-era card priced only for its Unlimited print run
+func wotcCard() models.Card {
+	return models.Card{
+		ID:           "base1-4",
+		Name:         "Charizard",
+		Game:         models.GamePokemon,
+		PriceUSD:     30,
+		PriceFoilUSD: 0,
+		Prices: []models.CardPrice{
+			{CardID: "base1-4", Condition: models.PriceConditionNM, Printing: models.PrintingUnlimited, Language: models.LanguageEnglish, PriceUSD: 50},
+			{CardID: "base1-4", Condition: models.PriceConditionLP, Printing: models.PrintingUnlimited, Language: models.LanguageEnglish, PriceUSD: 40},
+		},
+		GradedPrices: []models.GradedPrice{
+			{CardID: "base1-4", GradingCompany: models.GradingPSA, Grade: "10", PriceUSD: 500},
+		},
+	}
+}
+
+func TestItem(t *testing.T) {
+	tests := []struct {
+		name               string
+		item               models.CollectionItem
+		wantValue          float64
+		wantLanguage       models.CardLanguage
+		wantFallback       bool
+		wantGradedFallback bool
+		wantGain           *float64
+	}{
+		{
+			name:         "1st Edition falls back to Unlimited, not the foil base price",
+			item:         models.CollectionItem{Quantity: 2, Condition: models.ConditionNearMint, Printing: models.Printing1stEdition, Language: models.LanguageEnglish},
+			wantValue:    100,
+			wantLanguage: models.LanguageEnglish,
+		},
+		{
+			name:         "Normal falls back to Unlimited in the same condition",
+			item:         models.CollectionItem{Quantity: 1, Condition: models.ConditionExcellent, Printing: models.PrintingNormal, Language: models.LanguageEnglish},
+			wantValue:    40,
+			wantLanguage: models.LanguageEnglish,
+		},
+		{
+			name:         "Japanese copy uses English prices",
+			item:         models.CollectionItem{Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingUnlimited, Language: models.LanguageJapanese},
+			wantValue:    50,
+			wantLanguage: models.LanguageEnglish,
+			wantFallback: true,
+		},
+		{
+			name:         "foil without a foil price uses the non-foil base price",
+			item:         models.CollectionItem{Quantity: 3, Condition: models.ConditionNearMint, Printing: models.PrintingReverseHolo, Language: models.LanguageEnglish},
+			wantValue:    90,
+			wantLanguage: models.LanguageEnglish,
+		},
+		{
+			name:         "slab at its graded price",
+			item:         models.CollectionItem{Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingUnlimited, Language: models.LanguageEnglish, GradingCompany: models.GradingPSA, Grade: "10", PurchasePrice: floatPtr(400)},
+			wantValue:    500,
+			wantLanguage: models.LanguageEnglish,
+			wantGain:     floatPtr(100),
+		},
+		{
+			name:               "slab without a graded price at its raw price",
+			item:               models.CollectionItem{Quantity: 1, Condition: models.ConditionNearMint, Printing: models.PrintingUnlimited, Language: models.LanguageEnglish, GradingCompany: models.GradingBGS, Grade: "9.5"},
+			wantValue:          50,
+			wantLanguage:       models.LanguageEnglish,
+			wantGradedFallback: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := wotcCard()
+			item := tt.item
+			Item(&item, &card)
+			if !almostEqual(item.ItemValue, tt.wantValue) {
+				t.Errorf("ItemValue = %v, want %v", item.ItemValue, tt.wantValue)
+			}
+			if item.PriceLanguage != tt.wantLanguage || item.PriceFallback != tt.wantFallback || item.GradedPriceFallback != tt.wantGradedFallback {
+				t.Errorf("price language %s, fallback %v, graded fallback %v; want %s, %v, %v",
+					item.PriceLanguage, item.PriceFallback, item.GradedPriceFallback, tt.wantLanguage, tt.wantFallback, tt.wantGradedFallback)
+			}
+			if (item.UnrealizedGain == nil) != (tt.wantGain == nil) || (tt.wantGain != nil && !almostEqual(*item.UnrealizedGain, *tt.wantGain)) {
+				t.Errorf("UnrealizedGain = %v, want %v", item.UnrealizedGain, tt.wantGain)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	mtg := models.Card{ID: "m1", Game: models.GameMTG, PriceUSD: 10}
+	pokemon := wotcCard()
+	items := []models.CollectionItem{
+		{ID: 1, CardID: "m1", Card: mtg, Quantity: 2, Condition: models.ConditionNearMint, Printing: models.PrintingNormal, PurchasePrice: floatPtr(8)},
+		{ID: 2, CardID: "base1-4", Card: pokemon, Quantity: 1, Condition: models.ConditionNearMint, Printing: models.Printing1stEdition},
+		{ID: 3, CardID: "base1-4", Card: pokemon, Quantity: 1, Printing: models.PrintingUnlimited, GradingCompany: models.GradingPSA, Grade: "10"},
+	}
+	for i := range items {
+		Item(&items[i], &items[i].Card)
+	}
+	tags := []ItemTag{
+		{CollectionItemID: 1, TagID: 7, Name: "trade"},
+		{CollectionItemID: 3, TagID: 7, Name: "trade"},
+		{CollectionItemID: 3, TagID: 2, Name: "Grails"},
+		{CollectionItemID: 99, TagID: 2, Name: "Grails"}, // Item gone
+	}
+	sealed := SealedTotals{Items: 1, Value: 100, CostBasisItems: 1, CostBasis: 90, CostBasisValue: 100}
+
+	stats := Summarize(items, tags, sealed).Stats()
+
+	if stats.TotalCards != 4 || stats.UniqueCards != 2 || stats.MTGCards != 2 || stats.PokemonCards != 2 {
+		t.Errorf("counts = %d total, %d unique, %d mtg, %d pokemon", stats.TotalCards, stats.UniqueCards, stats.MTGCards, stats.PokemonCards)
+	}
+	if !almostEqual(stats.MTGValue, 20) || !almostEqual(stats.PokemonValue, 550) || !almostEqual(stats.TotalValue, 670) {
+		t.Errorf("values = %v mtg, %v pokemon, %v total", stats.MTGValue, stats.PokemonValue, stats.TotalValue)
+	}
+	// Cost basis of the MTG stack and the sealed item: 16 + 90 against 20 + 100
+	if stats.CostBasisCards != 3 || !almostEqual(stats.TotalCostBasis, 106) || !almostEqual(stats.UnrealizedGain, 14) {
+		t.Errorf("cost basis = %d cards, %v cost, %v gain", stats.CostBasisCards, stats.TotalCostBasis, stats.UnrealizedGain)
+	}
+	if len(stats.ByTag) != 2 || stats.ByTag[0].Name != "Grails" || !almostEqual(stats.ByTag[0].TotalValue, 500) ||
+		stats.ByTag[1].Cards != 3 || !almostEqual(stats.ByTag[1].TotalValue, 520) {
+		t.Errorf("by tag = %+v", stats.ByTag)
+	}
+}